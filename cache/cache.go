@@ -0,0 +1,77 @@
+// Package cache provides runtime support for caches generated with
+// merovius.de/go-misc/cmd/go-cache.
+//
+// A Store is a storage backend of a cache, like Redis or bigcache. Caches
+// generated with -store look up values in their Store before calling their
+// loader and store the loaded values in it, so that several processes can
+// share them.
+//
+// The API is still not finalized, I reserve the right to change things for now.
+package cache // import "merovius.de/go-misc/cache"
+
+import (
+	"sync"
+	"time"
+)
+
+// Store stores values of type V by keys of type K. Implementations must be
+// safe for concurrent use.
+type Store[K comparable, V any] interface {
+	// Get returns the value stored for k. ok is false, if none is stored or
+	// it has expired.
+	Get(k K) (v V, ok bool, err error)
+	// Set stores v for k. It expires after ttl, if it is positive.
+	Set(k K, v V, ttl time.Duration) error
+	// Delete deletes the value stored for k, if any.
+	Delete(k K) error
+}
+
+// Map is a Store keeping values in memory. The zero value is an empty Map.
+// Expired values are dropped when they are looked up.
+type Map[K comparable, V any] struct {
+	mu sync.Mutex
+	m  map[K]mapEntry[V]
+}
+
+type mapEntry[V any] struct {
+	v   V
+	exp time.Time
+}
+
+// Get implements Store.
+func (m *Map[K, V]) Get(k K) (v V, ok bool, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.m[k]
+	if !ok {
+		return v, false, nil
+	}
+	if !e.exp.IsZero() && !time.Now().Before(e.exp) {
+		delete(m.m, k)
+		return v, false, nil
+	}
+	return e.v, true, nil
+}
+
+// Set implements Store.
+func (m *Map[K, V]) Set(k K, v V, ttl time.Duration) error {
+	e := mapEntry[V]{v: v}
+	if ttl > 0 {
+		e.exp = time.Now().Add(ttl)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.m == nil {
+		m.m = make(map[K]mapEntry[V])
+	}
+	m.m[k] = e
+	return nil
+}
+
+// Delete implements Store.
+func (m *Map[K, V]) Delete(k K) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.m, k)
+	return nil
+}
@@ -0,0 +1,40 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+var _ Store[string, int] = new(Map[string, int])
+
+func TestMap(t *testing.T) {
+	var m Map[string, int]
+	if _, ok, err := m.Get("a"); ok || err != nil {
+		t.Fatalf(`Get("a") on empty Map = _, %v, %v, want false, nil`, ok, err)
+	}
+	m.Set("a", 1, 0)
+	m.Set("b", 2, time.Hour)
+	m.Set("c", 3, -time.Second)
+	if v, ok, _ := m.Get("a"); !ok || v != 1 {
+		t.Errorf(`Get("a") = %v, %v, want 1, true`, v, ok)
+	}
+	if v, ok, _ := m.Get("b"); !ok || v != 2 {
+		t.Errorf(`Get("b") = %v, %v, want 2, true`, v, ok)
+	}
+	if v, ok, _ := m.Get("c"); !ok || v != 3 {
+		t.Errorf(`Get("c") with negative ttl = %v, %v, want 3, true`, v, ok)
+	}
+	m.Delete("a")
+	if _, ok, _ := m.Get("a"); ok {
+		t.Error(`Get("a") after Delete returned a value`)
+	}
+
+	m.Set("d", 4, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if _, ok, _ := m.Get("d"); ok {
+		t.Error(`Get("d") returned an expired value`)
+	}
+	if _, ok := m.m["d"]; ok {
+		t.Error("expired value was not dropped")
+	}
+}
@@ -0,0 +1,68 @@
+/*
+go-accessors generates thread-safe getters and setters for struct fields.
+
+For every given struct type T, which must contain a field of type
+sync.RWMutex (usually embedded), the created code will contain a getter and a
+setter for every unexported field f of T
+
+	func (s *T) F() FT
+	func (s *T) SetF(v FT)
+
+which take the read respectively the write lock of the mutex. This is meant
+for objects, like configs, that are read often and changed occasionally at
+runtime, complementing the read-once model of go-lazy. Note that values of
+reference types (like slices and maps) are returned as they are, so they must
+not be modified after being set.
+
+Fields can be annotated with a struct tag to control the generated code:
+
+	accessor:"-"
+		no accessors are generated for the field.
+	accessor:"get"
+		only a getter is generated for the field.
+
+The CLI is still not entirely finalized, it may be subject to change for now.
+
+Usage:
+
+	go-accessors [flags] -type <type>[,<type>...] [<dir>]
+
+dir is the directory of the package to use and defaults to the current
+directory.
+
+The flags are:
+
+	-type types
+		comma-separated list of struct type names. Required.
+
+	-out file
+		output file, defaults to stdout.
+
+	-check
+		do not write the output file, but fail if it is not up to date, e.g.
+		to verify generated code in CI. Requires -out.
+
+	-template file
+		execute the template in file instead of the built-in one, with the
+		same data. The templates associated with the built-in one can be
+		invoked with {{ template "name" . }} and are replaced by templates
+		of the same name defined in file.
+
+	-lint
+		do not write the output file, but check the code generated by
+		-template for syntax and type errors, together with the other files
+		in the directory of the output file. Errors are reported at the
+		line of the template that generated the code.
+*/
+package main
+
+import (
+	"os"
+
+	"merovius.de/go-misc/internal/cmd/accessors"
+	"merovius.de/go-misc/internal/gen"
+)
+
+func main() {
+	gen.Main(accessors.Run, os.Args[1:])
+}
@@ -0,0 +1,70 @@
+/*
+go-atomic generates typed atomic wrappers.
+
+For each wrapped type T, the created code will contain a type with Load,
+Store, Swap and CompareAndSwap methods, similar to the types in sync/atomic.
+The zero value holds the zero value of T. Values must not be copied after
+first use.
+
+Arbitrary types are boxed: every Store allocates a copy of the value, which
+is published via an atomic.Pointer. CompareAndSwap compares values with ==, so
+it requires T to be comparable; it can be omitted with -nocompare.
+
+Integer types (the predeclared ones, not types defined from them) get
+specialized wrappers without boxing and with an additional Add method, backed
+by the integer types of sync/atomic. Types narrower than 32 bits are stored
+in 32 bits and updated with a compare-and-swap loop.
+
+The generated code requires Go 1.19 or later.
+
+The CLI is still not entirely finalized, it may be subject to change for now.
+
+Usage:
+
+	go-atomic [flags] <name> <type> [<name> <type> ...]
+
+You must pass an even number of arguments. For each wrapped type you need to
+give the name of the wrapper type and the wrapped type, e.g.
+
+	go-atomic AtomicConfig Config AtomicInt16 int16
+
+The flags are:
+
+	-package pkg
+		what package the generated file should reside in. Defaults to
+		$GOPACKAGE, as set by go generate.
+
+	-nocompare
+		do not generate CompareAndSwap for boxed types.
+
+	-out file
+		output file, defaults to stdout.
+
+	-check
+		do not write the output file, but fail if it is not up to date, e.g.
+		to verify generated code in CI. Requires -out.
+
+	-template file
+		execute the template in file instead of the built-in one, with the
+		same data. The templates associated with the built-in one can be
+		invoked with {{ template "name" . }} and are replaced by templates
+		of the same name defined in file.
+
+	-lint
+		do not write the output file, but check the code generated by
+		-template for syntax and type errors, together with the other files
+		in the directory of the output file. Errors are reported at the
+		line of the template that generated the code.
+*/
+package main
+
+import (
+	"os"
+
+	"merovius.de/go-misc/internal/cmd/atomic"
+	"merovius.de/go-misc/internal/gen"
+)
+
+func main() {
+	gen.Main(atomic.Run, os.Args[1:])
+}
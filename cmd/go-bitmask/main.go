@@ -0,0 +1,70 @@
+/*
+go-bitmask generates bit-flag types.
+
+For each given type T with flags A, B, …, the created code will contain
+
+	type T uint32
+
+	const (
+		TA T = 1 << iota
+		TB
+		…
+	)
+
+together with methods to test, set and clear flags, a String method listing
+the set flags, like "A|B", and JSON marshaling as an array of flag names, like
+["A","B"]. Unmarshaling fails for unknown flag names, marshaling fails if bits
+without a name are set.
+
+The CLI is still not entirely finalized, it may be subject to change for now.
+
+Usage:
+
+	go-bitmask [flags] <type> <flag>[,<flag>...] [<type> <flag>[,<flag>...] ...]
+
+You must pass an even number of arguments. For each type you need to give the
+name of the type and a comma-separated list of its flags, e.g.
+
+	go-bitmask Perm Read,Write,Exec
+
+The flags are:
+
+	-package pkg
+		what package the generated file should reside in. Defaults to
+		$GOPACKAGE, as set by go generate.
+
+	-base type
+		the underlying type of the generated types. One of uint8, uint16,
+		uint32 and uint64. Defaults to uint32.
+
+	-out file
+		output file, defaults to stdout.
+
+	-check
+		do not write the output file, but fail if it is not up to date, e.g.
+		to verify generated code in CI. Requires -out.
+
+	-template file
+		execute the template in file instead of the built-in one, with the
+		same data. The templates associated with the built-in one can be
+		invoked with {{ template "name" . }} and are replaced by templates
+		of the same name defined in file.
+
+	-lint
+		do not write the output file, but check the code generated by
+		-template for syntax and type errors, together with the other files
+		in the directory of the output file. Errors are reported at the
+		line of the template that generated the code.
+*/
+package main
+
+import (
+	"os"
+
+	"merovius.de/go-misc/internal/cmd/bitmask"
+	"merovius.de/go-misc/internal/gen"
+)
+
+func main() {
+	gen.Main(bitmask.Run, os.Args[1:])
+}
@@ -0,0 +1,60 @@
+/*
+go-builder generates fluent builders for struct types.
+
+For every given struct type T, the created code will contain a type TBuilder
+with a With<Field> method per field, returning the builder to allow chaining,
+and a Build method returning the built T. Build fails, if any required field
+was not set.
+
+Fields can be annotated with a struct tag to control the generated code:
+
+	builder:"required"
+		the field must be set before calling Build.
+	builder:"-"
+		no setter is generated for the field.
+
+The CLI is still not entirely finalized, it may be subject to change for now.
+
+Usage:
+
+	go-builder [flags] -type <type>[,<type>...] [<dir>]
+
+dir is the directory of the package to use and defaults to the current
+directory.
+
+The flags are:
+
+	-type types
+		comma-separated list of struct type names. Required.
+
+	-out file
+		output file, defaults to stdout.
+
+	-check
+		do not write the output file, but fail if it is not up to date, e.g.
+		to verify generated code in CI. Requires -out.
+
+	-template file
+		execute the template in file instead of the built-in one, with the
+		same data. The templates associated with the built-in one can be
+		invoked with {{ template "name" . }} and are replaced by templates
+		of the same name defined in file.
+
+	-lint
+		do not write the output file, but check the code generated by
+		-template for syntax and type errors, together with the other files
+		in the directory of the output file. Errors are reported at the
+		line of the template that generated the code.
+*/
+package main
+
+import (
+	"os"
+
+	"merovius.de/go-misc/internal/cmd/builder"
+	"merovius.de/go-misc/internal/gen"
+)
+
+func main() {
+	gen.Main(builder.Run, os.Args[1:])
+}
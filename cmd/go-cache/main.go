@@ -0,0 +1,191 @@
+/*
+go-cache generates typed caches around loader functions.
+
+For every given name and loader signature func(K) (V, error), the created code
+will contain a type, like
+
+	type Name struct { … }
+
+	func NewName(load func(K) (V, error), size int, hooks *NameHooks) *Name
+
+	func (c *Name) Get(k K) (V, error)
+
+Get returns the cached value for k or calls load to get it. Concurrent misses
+for the same key share a single call to load. Errors are returned to all
+callers waiting for the call, but are not cached.
+
+Entries are evicted according to -policy:
+
+	lru
+		if more than size entries are cached, the least recently used is
+		evicted. A size of 0 means entries are never evicted.
+	2q
+		the 2Q algorithm: new entries are kept in a FIFO queue and are only
+		promoted to the LRU list of frequently used entries, if they are
+		requested again shortly after being evicted from the queue. So a scan
+		over many keys can not evict the frequently used ones. size must be
+		positive.
+	ttl
+		entries expire after a fixed duration, which is passed to the
+		constructor instead of a size.
+
+The hooks are called on cache hits, misses and evictions, so they can be used
+to collect metrics. Unlike merovius.de/go-misc/cmd/go-memoize, which never
+forgets a successful result unless it is evicted for space, the generated
+caches support expiry, invalidation and metrics.
+
+The CLI is still not entirely finalized, it may be subject to change for now.
+
+Usage:
+
+	go-cache [flags] <name> <signature> [<name> <signature> ...]
+
+You must pass an even number of arguments. For each cache you need to give its
+name and the signature of its loader, e.g.
+
+	go-cache -policy 2q UserCache 'func(id int64) (*User, error)'
+
+The flags are:
+
+	-package pkg
+		what package the generated file should reside in. Defaults to
+		$GOPACKAGE, as set by go generate.
+
+	-out file
+		output file, defaults to stdout.
+
+	-check
+		do not write the output file, but fail if it is not up to date, e.g.
+		to verify generated code in CI. Requires -out.
+
+	-template file
+		execute the template in file instead of the built-in one, with the
+		same data. The templates associated with the built-in one can be
+		invoked with {{ template "name" . }} and are replaced by templates
+		of the same name defined in file.
+
+	-lint
+		do not write the output file, but check the code generated by
+		-template for syntax and type errors, together with the other files
+		in the directory of the output file. Errors are reported at the
+		line of the template that generated the code.
+
+	-allow-unsafe
+		generate code even if a type refers to unsafe.Pointer or to a
+		cgo type like C.int. By default this is refused, as such values
+		often point to memory that is not managed by the garbage
+		collector and might be freed while the cached value is still in
+		use.
+
+	-policy policy
+		eviction policy to use. One of "lru", "2q" and "ttl". Defaults to
+		"lru".
+
+	-cost func
+		with -policy=lru, limit the total cost of the cached values to
+		size, instead of their number. func is the name of a function in
+		the package of type func(V) int, returning the cost of a value,
+		e.g. its size in bytes. The least recently used values are evicted
+		until the total cost is at most size. A value costing more than
+		size is returned, but not cached, and evicts no other values.
+
+	-jitter fraction
+		with -policy=ttl, let every entry expire after the ttl reduced by
+		a random fraction of it, up to fraction, e.g. 0.1. So values loaded
+		at the same time, e.g. at startup, do not all expire and are loaded
+		again at the same instant. Entries expiring early are never
+		returned, but may be evicted up to fraction of the ttl late, so
+		storing stays constant time. Must be less than 1. Defaults to 0.
+
+	-max-stale d
+		with -policy=ttl, keep expired values for up to d longer. If the
+		loader fails to load a value again, the expired value is returned
+		instead of the error, which is passed to the Stale hook. The
+		loader is called again on the next miss.
+
+	-janitor
+		with -policy=ttl, generate a method
+
+			func (c *Name) StartJanitor(interval time.Duration) *NameJanitor
+
+		starting a goroutine, which removes the expired values from the
+		cache every interval, until the Stop method of the returned
+		NameJanitor is called. Otherwise, expired values are only removed
+		when they are accessed or new values are stored, so they can use
+		memory for long, if the cache is not used. StartJanitor panics, if
+		interval is not positive.
+
+	-coalesce d
+		let misses for a key up to d after a call to the loader for it
+		finished share the result of the call, like concurrent misses do,
+		e.g. 10ms. This smooths bursts of duplicate requests, e.g. when
+		the loader failed or the value was evicted right away, at the cost
+		of a timer for every call to the loader. Invalidate ends the
+		sharing of a finished call.
+
+	-error-ttl d
+		cache errors of the loader for d, e.g. 5s, which should be shorter
+		than the lifetime of values. Misses for the key in that time return
+		the error, instead of calling the loader again, so a failing
+		dependency is not called on every request. Invalidate removes a
+		cached error. Defaults to 0, meaning errors are not cached.
+
+	-stats
+		record statistics of the caches and generate a method Stats,
+		returning the numbers of hits, misses, evictions, calls to the
+		loader and failed ones and the total time spent in the loader, to
+		size caches from real data. The hooks get a field Load, called
+		after every call to the loader with its duration and error.
+
+	-warm
+		generate methods
+
+			func (c *Name) Warm(ctx context.Context, keys func(yield func(K) bool), n int) error
+			func (c *Name) WarmValues(values func(yield func(K, V) bool))
+
+		preloading values, e.g. when deploying. Warm loads the values for
+		keys, calling the loader for up to n keys concurrently, and returns
+		an error wrapping a *NameWarmError for every key the loader failed
+		for. It stops, once ctx is done, and then also returns the error
+		of ctx, if keys were skipped. WarmValues stores the given values
+		without calling the loader.
+		Both take iterators, like those returned by slices.Values and
+		maps.All, but can also be called with plain funcs before Go 1.23.
+
+	-persist
+		generate a method
+
+			func (c *Name) Persist(s NameStore) error
+
+		loading the values in s into the cache and then storing cached
+		values in s and deleting evicted and invalidated ones, so that
+		they survive restarts of the program. Expired values are not
+		loaded. NameStore is an interface, which can be implemented by
+		any storage. The generated NameFile implements it by appending to
+		a file, which is compacted when it is opened with OpenNameFile.
+		Keys and values must be encodable as JSON to use it.
+
+	-store
+		generate a method
+
+			func (c *Name) SetStore(s cache.Store[K, V])
+
+		backing the cache by a merovius.de/go-misc/cache.Store, e.g. one
+		using Redis or bigcache, to share values with other processes.
+		Missing values are looked up in s before calling the loader and
+		loaded values are stored in s, with the ttl of the cache for the
+		ttl policy. Invalidate also deletes the value from s. Errors of s
+		are passed to the StoreError hook. Requires Go 1.18.
+*/
+package main
+
+import (
+	"os"
+
+	"merovius.de/go-misc/internal/cmd/cache"
+	"merovius.de/go-misc/internal/gen"
+)
+
+func main() {
+	gen.Main(cache.Run, os.Args[1:])
+}
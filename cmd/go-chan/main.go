@@ -0,0 +1,69 @@
+/*
+go-chan generates typed channel utilities.
+
+For each given name N and element type T, the created code will contain
+
+	func OrDoneN(done <-chan struct{}, c <-chan T) <-chan T
+	func MergeN(done <-chan struct{}, cs ...<-chan T) <-chan T
+	func FanOutN(done <-chan struct{}, c <-chan T, n int) []<-chan T
+	func WorkN(done <-chan struct{}, c <-chan T, n int, f func(T))
+
+OrDoneN forwards the values of c until c is closed or done is closed. MergeN
+(fan-in) forwards the values of all cs to a single channel. FanOutN
+distributes the values of c over n channels, each value being sent to
+whichever channel is ready to receive it first. WorkN calls f for the values
+of c in a bounded pool of n goroutines and returns after all of them
+finished.
+
+All returned channels are closed when their sources are exhausted or done is
+closed, so they can be used with range. Closing done stops all goroutines
+started by the functions, without leaking any.
+
+The CLI is still not entirely finalized, it may be subject to change for now.
+
+Usage:
+
+	go-chan [flags] <name> <type> [<name> <type> ...]
+
+You must pass an even number of arguments. For each element type you need to
+give the name used as a suffix of the functions and the type, e.g.
+
+	go-chan Jobs *Job
+
+The flags are:
+
+	-package pkg
+		what package the generated file should reside in. Defaults to
+		$GOPACKAGE, as set by go generate.
+
+	-out file
+		output file, defaults to stdout.
+
+	-check
+		do not write the output file, but fail if it is not up to date, e.g.
+		to verify generated code in CI. Requires -out.
+
+	-template file
+		execute the template in file instead of the built-in one, with the
+		same data. The templates associated with the built-in one can be
+		invoked with {{ template "name" . }} and are replaced by templates
+		of the same name defined in file.
+
+	-lint
+		do not write the output file, but check the code generated by
+		-template for syntax and type errors, together with the other files
+		in the directory of the output file. Errors are reported at the
+		line of the template that generated the code.
+*/
+package main
+
+import (
+	"os"
+
+	"merovius.de/go-misc/internal/cmd/chans"
+	"merovius.de/go-misc/internal/gen"
+)
+
+func main() {
+	gen.Main(chans.Run, os.Args[1:])
+}
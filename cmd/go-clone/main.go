@@ -0,0 +1,73 @@
+/*
+go-clone generates shallow Clone methods for struct types.
+
+For every given struct type T, the created code will contain a method
+
+	func (s *T) Clone() *T
+
+which returns a shallow copy of s. How fields of reference types (pointers,
+slices, maps, channels, functions and interfaces) are handled is determined
+by a rule, which can be set per field with a struct tag:
+
+	clone:"share"
+		the clone shares the referenced value with s.
+	clone:"copy"
+		the referenced value is copied, one level deep: the clone gets a new
+		slice or map with the same elements or a pointer to a copy of the
+		pointed to value. Only valid for pointers, slices and maps.
+	clone:"nil"
+		the field is set to nil in the clone.
+
+Fields without a tag use the rule given by -default. Unlike go-deepcopy, no
+code recurses into the copied values, so cloning is cheap and predictable.
+
+The CLI is still not entirely finalized, it may be subject to change for now.
+
+Usage:
+
+	go-clone [flags] -type <type>[,<type>...] [<dir>]
+
+dir is the directory of the package to use and defaults to the current
+directory.
+
+The flags are:
+
+	-type types
+		comma-separated list of struct type names. Required.
+
+	-default rule
+		rule for reference fields without a struct tag. One of share, copy
+		and nil. Defaults to share. Fields which can not be copied are
+		shared, if the default rule is copy.
+
+	-out file
+		output file, defaults to stdout.
+
+	-check
+		do not write the output file, but fail if it is not up to date, e.g.
+		to verify generated code in CI. Requires -out.
+
+	-template file
+		execute the template in file instead of the built-in one, with the
+		same data. The templates associated with the built-in one can be
+		invoked with {{ template "name" . }} and are replaced by templates
+		of the same name defined in file.
+
+	-lint
+		do not write the output file, but check the code generated by
+		-template for syntax and type errors, together with the other files
+		in the directory of the output file. Errors are reported at the
+		line of the template that generated the code.
+*/
+package main
+
+import (
+	"os"
+
+	"merovius.de/go-misc/internal/cmd/clone"
+	"merovius.de/go-misc/internal/gen"
+)
+
+func main() {
+	gen.Main(clone.Run, os.Args[1:])
+}
@@ -0,0 +1,69 @@
+/*
+go-constructor generates constructors with functional options.
+
+For every given struct type T, the created code will contain an option type
+TOption, an option With<Field> for every field and a constructor
+
+	func NewT(opts ...TOption) (*T, error)
+
+which applies the options to a new T and returns an error, if an option for a
+required field was not given. If T has no required fields, the constructor
+does not return an error. If *T has a SetDefaults method (e.g. generated by
+go-defaults), it is called after applying the options, so defaults are only
+used for fields not set by an option.
+
+As the constructors have no other parameters, they are a good fit for lazily
+constructed singletons, e.g. with the types of merovius.de/go-misc/lazy.
+
+Fields can be annotated with a struct tag to control the generated code:
+
+	constructor:"required"
+		the option for the field must be given.
+	constructor:"-"
+		no option is generated for the field.
+
+The CLI is still not entirely finalized, it may be subject to change for now.
+
+Usage:
+
+	go-constructor [flags] -type <type>[,<type>...] [<dir>]
+
+dir is the directory of the package to use and defaults to the current
+directory.
+
+The flags are:
+
+	-type types
+		comma-separated list of struct type names. Required.
+
+	-out file
+		output file, defaults to stdout.
+
+	-check
+		do not write the output file, but fail if it is not up to date, e.g.
+		to verify generated code in CI. Requires -out.
+
+	-template file
+		execute the template in file instead of the built-in one, with the
+		same data. The templates associated with the built-in one can be
+		invoked with {{ template "name" . }} and are replaced by templates
+		of the same name defined in file.
+
+	-lint
+		do not write the output file, but check the code generated by
+		-template for syntax and type errors, together with the other files
+		in the directory of the output file. Errors are reported at the
+		line of the template that generated the code.
+*/
+package main
+
+import (
+	"os"
+
+	"merovius.de/go-misc/internal/cmd/constructor"
+	"merovius.de/go-misc/internal/gen"
+)
+
+func main() {
+	gen.Main(constructor.Run, os.Args[1:])
+}
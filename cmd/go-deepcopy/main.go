@@ -0,0 +1,61 @@
+/*
+go-deepcopy generates DeepCopy methods for struct types.
+
+For every given struct type T, the created code will contain a method
+
+	func (s *T) DeepCopy() *T
+
+which returns a copy of s that does not share any memory reachable via
+pointers, slices, maps or arrays with s. Values of types given to -type are
+copied with their DeepCopy method, so recursive types need to be included.
+Interfaces, functions and channels are copied as is, as are fields of structs
+from other packages that can not be accessed.
+
+The generated code is reflection-free and thus considerably faster than
+generic deep copying via package reflect.
+
+The CLI is still not entirely finalized, it may be subject to change for now.
+
+Usage:
+
+	go-deepcopy [flags] -type <type>[,<type>...] [<dir>]
+
+dir is the directory of the package to use and defaults to the current
+directory.
+
+The flags are:
+
+	-type types
+		comma-separated list of struct type names. Required.
+
+	-out file
+		output file, defaults to stdout.
+
+	-check
+		do not write the output file, but fail if it is not up to date, e.g.
+		to verify generated code in CI. Requires -out.
+
+	-template file
+		execute the template in file instead of the built-in one, with the
+		same data. The templates associated with the built-in one can be
+		invoked with {{ template "name" . }} and are replaced by templates
+		of the same name defined in file.
+
+	-lint
+		do not write the output file, but check the code generated by
+		-template for syntax and type errors, together with the other files
+		in the directory of the output file. Errors are reported at the
+		line of the template that generated the code.
+*/
+package main
+
+import (
+	"os"
+
+	"merovius.de/go-misc/internal/cmd/deepcopy"
+	"merovius.de/go-misc/internal/gen"
+)
+
+func main() {
+	gen.Main(deepcopy.Run, os.Args[1:])
+}
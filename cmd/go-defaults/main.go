@@ -0,0 +1,69 @@
+/*
+go-defaults generates SetDefaults methods for struct types.
+
+For every given struct type T, the created code will contain a method
+
+	func (s *T) SetDefaults()
+
+which sets every field annotated with a default:"..." struct tag to its
+default, if the field has its zero value. Fields whose type is also given to
+-type get their SetDefaults method called (if they are not nil), so defaults
+of nested structs are applied as well.
+
+For fields with an underlying type of string, the tag contains the default
+verbatim. For all other fields, it contains a Go expression, which is
+type-checked in the scope of the declaration of T when generating code, so
+invalid defaults are caught early and no reflection is needed at runtime:
+
+	type Config struct {
+		Addr    string        `default:":8080"`
+		Workers int           `default:"4"`
+		Timeout time.Duration `default:"5 * time.Second"`
+		Tags    []string      `default:"[]string{\"a\", \"b\"}"`
+	}
+
+The CLI is still not entirely finalized, it may be subject to change for now.
+
+Usage:
+
+	go-defaults [flags] -type <type>[,<type>...] [<dir>]
+
+dir is the directory of the package to use and defaults to the current
+directory.
+
+The flags are:
+
+	-type types
+		comma-separated list of struct type names. Required.
+
+	-out file
+		output file, defaults to stdout.
+
+	-check
+		do not write the output file, but fail if it is not up to date, e.g.
+		to verify generated code in CI. Requires -out.
+
+	-template file
+		execute the template in file instead of the built-in one, with the
+		same data. The templates associated with the built-in one can be
+		invoked with {{ template "name" . }} and are replaced by templates
+		of the same name defined in file.
+
+	-lint
+		do not write the output file, but check the code generated by
+		-template for syntax and type errors, together with the other files
+		in the directory of the output file. Errors are reported at the
+		line of the template that generated the code.
+*/
+package main
+
+import (
+	"os"
+
+	"merovius.de/go-misc/internal/cmd/defaults"
+	"merovius.de/go-misc/internal/gen"
+)
+
+func main() {
+	gen.Main(defaults.Run, os.Args[1:])
+}
@@ -0,0 +1,61 @@
+/*
+go-enum generates helper methods for enumeration types.
+
+For every given type, it collects the constants of that type declared in the
+package and generates a String method, a Parse<type> function, a
+<type>Values function, returning all values in order of declaration, and
+MarshalText/UnmarshalText methods. The latter are used by encoding/json and
+other encoders, so the values are encoded as their names.
+
+The type must have an integer underlying type. If several constants have the
+same value, the first one is used as its name, but all names can be parsed.
+
+The CLI is still not entirely finalized, it may be subject to change for now.
+
+Usage:
+
+	go-enum [flags] -type <type>[,<type>...] [<dir>]
+
+dir is the directory of the package to use and defaults to the current
+directory.
+
+The flags are:
+
+	-type types
+		comma-separated list of type names. Required.
+
+	-trimprefix prefix
+		prefix to remove from the constant names, to get the names of the
+		values.
+
+	-out file
+		output file, defaults to stdout.
+
+	-check
+		do not write the output file, but fail if it is not up to date, e.g.
+		to verify generated code in CI. Requires -out.
+
+	-template file
+		execute the template in file instead of the built-in one, with the
+		same data. The templates associated with the built-in one can be
+		invoked with {{ template "name" . }} and are replaced by templates
+		of the same name defined in file.
+
+	-lint
+		do not write the output file, but check the code generated by
+		-template for syntax and type errors, together with the other files
+		in the directory of the output file. Errors are reported at the
+		line of the template that generated the code.
+*/
+package main
+
+import (
+	"os"
+
+	"merovius.de/go-misc/internal/cmd/enum"
+	"merovius.de/go-misc/internal/gen"
+)
+
+func main() {
+	gen.Main(enum.Run, os.Args[1:])
+}
@@ -0,0 +1,73 @@
+/*
+go-equal generates Equal methods for struct types.
+
+For every given struct type T, the created code will contain a method
+
+	func (a T) Equal(b T) bool
+
+which reports whether a and b are deeply equal, with the same semantics as
+reflect.DeepEqual, but without using reflection or allocating. In
+particular, pointers are equal if they point to equal values and nil slices
+and maps are not equal to empty ones. Values of types given to -type and of
+types with an Equal method of the same shape (like time.Time) are compared with
+that method. Interfaces and channels are compared with ==. Functions are
+only equal if both are nil.
+
+Fields can be excluded from the comparison with the -exclude flag or by
+annotating them with the struct tag equal:"-".
+
+The CLI is still not entirely finalized, it may be subject to change for now.
+
+Usage:
+
+	go-equal [flags] -type <type>[,<type>...] [<dir>]
+
+dir is the directory of the package to use and defaults to the current
+directory.
+
+The flags are:
+
+	-type types
+		comma-separated list of struct type names. Required.
+
+	-exclude fields
+		comma-separated list of fields to exclude from comparison. Fields
+		can be given as <field>, to exclude them from all types, or as
+		<type>.<field>.
+
+	-epsilon tolerance
+		maximum absolute difference for floating point and complex values
+		to be considered equal. Defaults to 0, meaning they are compared with
+		==.
+
+	-out file
+		output file, defaults to stdout.
+
+	-check
+		do not write the output file, but fail if it is not up to date, e.g.
+		to verify generated code in CI. Requires -out.
+
+	-template file
+		execute the template in file instead of the built-in one, with the
+		same data. The templates associated with the built-in one can be
+		invoked with {{ template "name" . }} and are replaced by templates
+		of the same name defined in file.
+
+	-lint
+		do not write the output file, but check the code generated by
+		-template for syntax and type errors, together with the other files
+		in the directory of the output file. Errors are reported at the
+		line of the template that generated the code.
+*/
+package main
+
+import (
+	"os"
+
+	"merovius.de/go-misc/internal/cmd/equal"
+	"merovius.de/go-misc/internal/gen"
+)
+
+func main() {
+	gen.Main(equal.Run, os.Args[1:])
+}
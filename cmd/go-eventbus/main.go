@@ -0,0 +1,63 @@
+/*
+go-eventbus generates typed publish/subscribe buses.
+
+For each event type T, the created code will contain a bus type delivering
+values of type T to its subscribers, without the interface{} values and type
+assertions of a generic bus. Subscribers either register a callback with
+Subscribe or receive events on a channel returned by SubscribeChan. Both
+return a func to cancel the subscription.
+
+Publish calls the callbacks synchronously, in the order they subscribed, and
+blocks until every channel subscriber received the event or cancelled its
+subscription. Subscribers that should not slow down publishers therefore need
+a buffered channel or a callback that does not block. The channels are never
+closed, as a cancelled subscriber might still be receiving.
+
+The CLI is still not entirely finalized, it may be subject to change for now.
+
+Usage:
+
+	go-eventbus [flags] <name> <type> [<name> <type> ...]
+
+You must pass an even number of arguments. For each bus you need to give the
+name of the bus type and the type of its events, e.g.
+
+	go-eventbus UserCreatedBus *UserCreated
+
+The flags are:
+
+	-package pkg
+		what package the generated file should reside in. Defaults to
+		$GOPACKAGE, as set by go generate.
+
+	-out file
+		output file, defaults to stdout.
+
+	-check
+		do not write the output file, but fail if it is not up to date, e.g.
+		to verify generated code in CI. Requires -out.
+
+	-template file
+		execute the template in file instead of the built-in one, with the
+		same data. The templates associated with the built-in one can be
+		invoked with {{ template "name" . }} and are replaced by templates
+		of the same name defined in file.
+
+	-lint
+		do not write the output file, but check the code generated by
+		-template for syntax and type errors, together with the other files
+		in the directory of the output file. Errors are reported at the
+		line of the template that generated the code.
+*/
+package main
+
+import (
+	"os"
+
+	"merovius.de/go-misc/internal/cmd/eventbus"
+	"merovius.de/go-misc/internal/gen"
+)
+
+func main() {
+	gen.Main(eventbus.Run, os.Args[1:])
+}
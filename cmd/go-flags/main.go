@@ -0,0 +1,82 @@
+/*
+go-flags generates flag registration code for config structs.
+
+For every given struct type T, the created code will contain a method
+
+	func (c *T) RegisterFlags(fs *flag.FlagSet) error
+
+which registers a flag with fs for every field of T. Fields are initialized to
+their default values first, which are then overridden by environment
+variables, if set, and finally by the flags, when fs is parsed. RegisterFlags
+returns an error, if the value of an environment variable is invalid.
+
+Fields can be annotated with struct tags to control the generated code:
+
+	flag:"name"
+		the name of the flag. Defaults to the field name in kebab-case,
+		e.g. "max-conns" for MaxConns. flag:"-" skips the field.
+	default:"value"
+		the default value, in the syntax accepted on the command line.
+		Defaults to the zero value.
+	env:"NAME"
+		the environment variable to read. Defaults to the field name in
+		SCREAMING_SNAKE_CASE, prefixed with -env-prefix, if that is given.
+		Otherwise, no environment variable is read. env:"-" disables the
+		environment variable for the field.
+	usage:"text"
+		the usage string of the flag.
+
+Supported field types are those with an underlying type of string, bool, int,
+int64, uint, uint64 or float64, time.Duration and types whose pointer
+implements flag.Value. Default values are validated while generating code.
+
+The CLI is still not entirely finalized, it may be subject to change for now.
+
+Usage:
+
+	go-flags [flags] -type <type>[,<type>...] [<dir>]
+
+dir is the directory of the package to use and defaults to the current
+directory.
+
+The flags are:
+
+	-type types
+		comma-separated list of struct type names. Required.
+
+	-env-prefix prefix
+		read environment variables for all fields, named with the given
+		prefix. For example, with -env-prefix=APP_, the field MaxConns is
+		read from $APP_MAX_CONNS.
+
+	-out file
+		output file, defaults to stdout.
+
+	-check
+		do not write the output file, but fail if it is not up to date, e.g.
+		to verify generated code in CI. Requires -out.
+
+	-template file
+		execute the template in file instead of the built-in one, with the
+		same data. The templates associated with the built-in one can be
+		invoked with {{ template "name" . }} and are replaced by templates
+		of the same name defined in file.
+
+	-lint
+		do not write the output file, but check the code generated by
+		-template for syntax and type errors, together with the other files
+		in the directory of the output file. Errors are reported at the
+		line of the template that generated the code.
+*/
+package main
+
+import (
+	"os"
+
+	"merovius.de/go-misc/internal/cmd/flags"
+	"merovius.de/go-misc/internal/gen"
+)
+
+func main() {
+	gen.Main(flags.Run, os.Args[1:])
+}
@@ -0,0 +1,70 @@
+/*
+go-future generates typed future/promise pairs.
+
+For each result type T and name N, the created code will contain a type
+NFuture, which is used to wait for a result, and a type NPromise, which is
+used to provide it:
+
+	func NewNFuture() (*NFuture, *NPromise)
+	func (p *NPromise) Resolve(v T) bool
+	func (p *NPromise) Reject(err error) bool
+	func (f *NFuture) Done() <-chan struct{}
+	func (f *NFuture) Wait() (T, error)
+
+A future is settled by the first call to Resolve or Reject; later calls have
+no effect and return false. Done returns a channel that is closed once the
+future is settled, so futures can be used in select statements. Wait blocks
+until the future is settled and returns its value or error.
+
+These are the semantics of a general runtime Future, so generated futures
+can be used wherever a type with Done and Wait methods of this shape is
+expected.
+
+The CLI is still not entirely finalized, it may be subject to change for now.
+
+Usage:
+
+	go-future [flags] <name> <type> [<name> <type> ...]
+
+You must pass an even number of arguments. For each result type you need to
+give the name prefix of the generated types and the result type, e.g.
+
+	go-future Response *http.Response
+
+The flags are:
+
+	-package pkg
+		what package the generated file should reside in. Defaults to
+		$GOPACKAGE, as set by go generate.
+
+	-out file
+		output file, defaults to stdout.
+
+	-check
+		do not write the output file, but fail if it is not up to date, e.g.
+		to verify generated code in CI. Requires -out.
+
+	-template file
+		execute the template in file instead of the built-in one, with the
+		same data. The templates associated with the built-in one can be
+		invoked with {{ template "name" . }} and are replaced by templates
+		of the same name defined in file.
+
+	-lint
+		do not write the output file, but check the code generated by
+		-template for syntax and type errors, together with the other files
+		in the directory of the output file. Errors are reported at the
+		line of the template that generated the code.
+*/
+package main
+
+import (
+	"os"
+
+	"merovius.de/go-misc/internal/cmd/future"
+	"merovius.de/go-misc/internal/gen"
+)
+
+func main() {
+	gen.Main(future.Run, os.Args[1:])
+}
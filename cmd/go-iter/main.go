@@ -0,0 +1,63 @@
+/*
+go-iter generates lazy sequence types.
+
+For each element type T, the created code will contain a sequence type
+
+	type Seq func() (T, bool)
+
+which is a pull function: every call returns the next element and true, or
+false once the sequence is exhausted. Sequences are lazy: the adapters Map,
+Filter and Take return new sequences without consuming any elements, which
+only happens when the resulting sequence is pulled, e.g. by Each or Slice.
+
+This provides some of the convenience of iter.Seq for code that has to
+support Go versions before 1.23.
+
+The CLI is still not entirely finalized, it may be subject to change for now.
+
+Usage:
+
+	go-iter [flags] <name> <type> [<name> <type> ...]
+
+You must pass an even number of arguments. For each element type you need to
+give the name of the sequence type and the element type, e.g.
+
+	go-iter IntSeq int
+
+The flags are:
+
+	-package pkg
+		what package the generated file should reside in. Defaults to
+		$GOPACKAGE, as set by go generate.
+
+	-out file
+		output file, defaults to stdout.
+
+	-check
+		do not write the output file, but fail if it is not up to date, e.g.
+		to verify generated code in CI. Requires -out.
+
+	-template file
+		execute the template in file instead of the built-in one, with the
+		same data. The templates associated with the built-in one can be
+		invoked with {{ template "name" . }} and are replaced by templates
+		of the same name defined in file.
+
+	-lint
+		do not write the output file, but check the code generated by
+		-template for syntax and type errors, together with the other files
+		in the directory of the output file. Errors are reported at the
+		line of the template that generated the code.
+*/
+package main
+
+import (
+	"os"
+
+	"merovius.de/go-misc/internal/cmd/iter"
+	"merovius.de/go-misc/internal/gen"
+)
+
+func main() {
+	gen.Main(iter.Run, os.Args[1:])
+}
@@ -8,161 +8,502 @@ used to generate a lazily evaluated version of that type. See
 merovius.de/go-misc/lazy for details on the usage of this function.
 
 The generated implementations are concurrency-safe and have a reasonably low
-overhead.
+overhead. Which implementation is fastest depends on the machine, so several
+can be selected with -impl and compared with the bench subcommand.
 
 The CLI is still not entirely finalized, it may be subject to change for now.
 
 Usage:
+
 	go-lazy [flags] [<name> <type> ...]
+	go-lazy bench [flags]
+	go-lazy migrate [flags] [<dir>]
+	go-lazy upgrade [flags] [<dir>]
+	go-lazy proxy [flags] -type <type>[,<type>...] [<dir>]
+
 You must pass an even number of arguments. For each wrapped type you need to
 give the name of the function and the type you want to wrap it.
 
 The flags are:
+
 	-package pkg
 		what package the generated file should reside in. Defaults to "lazy".
 
 	-out file
 		output file, defaults to stdout.
-*/
-package main
 
-import (
-	"bytes"
-	"flag"
-	"go/format"
-	"io"
-	"log"
-	"os"
-	"text/template"
-)
+	-check
+		do not write the output file, but fail if it is not up to date, e.g.
+		to verify generated code in CI. Requires -out.
 
-var implTemplate = template.Must(template.New("lazy.go").Parse(`
-// This file is automatically generated by merovius.de/go-misc/cmdgo-lazy.
+	-template file
+		execute the template in file instead of the built-in one, with the
+		same data. The templates associated with the built-in one can be
+		invoked with {{ template "name" . }} and are replaced by templates
+		of the same name defined in file.
 
-package {{ .Package }}
+	-lint
+		do not write the output file, but check the code generated by
+		-template for syntax and type errors, together with the other files
+		in the directory of the output file. Errors are reported at the
+		line of the template that generated the code.
 
-import (
-	"sync"
-	"sync/atomic"
-)
+	-allow-unsafe
+		generate code even if a type refers to unsafe.Pointer or to a
+		cgo type like C.int. By default this is refused, as such values
+		often point to memory that is not managed by the garbage
+		collector and might be freed while the evaluated value is still
+		in use.
 
-{{ range .Types }}
-	{{ template "impl" . }}
-{{ end }}
-`))
-
-var _ = template.Must(implTemplate.New("impl").Parse(`
-// lazy{{ .Name }} implements lazy evaluation for {{ .Type }}.
-type lazy{{ .Name }} struct {
-	v {{ .Type }}
-	f func() {{ .Type }}
-	m sync.Mutex
-	o uint32
-}
+	-generic
+		generate generic constructors, with the type of the values as a
+		type parameter, instead of one per type. The arguments are then
+		only the names of the constructors, e.g.
 
-func (v *lazy{{ .Name }}) Get() {{ .Type }} {
-	if atomic.LoadUint32(&v.o) == 1 {
-		return v.v
-	}
+			go-lazy -generic Lazy
 
-	v.m.Lock()
-	defer v.m.Unlock()
+		Can not be used with -versioned, -style=value, -slab, -pad, -tests,
+		-properties or -config. Requires Go 1.18.
 
-	if v.o == 0 {
-		v.v = v.f()
-		v.o = 1
-		v.f = nil
-	}
-	return v.v
-}
+	-versioned
+		generate versioned lazy values instead. For each wrapped type, a type
+		Versioned<name> is created, which tags every evaluation with a
+		generation and can be invalidated conditionally, to avoid reload races
+		between readers and refreshers. Like Get, their TryGet method returns
+		the value and its generation, but only if it is already evaluated.
 
-// {{ .Name }} provides lazy evaluation for {{ .Type }}. f is called exactly
-// once, when the result is first used.
-func {{ .Name }} (f func() {{ .Type }}) func() {{ .Type }} {
-	return (&lazy{{ .Name}}{f:f}).Get
-}
-`))
+	-impl impl
+		implementation strategy of the generated code. Can not be used with
+		-versioned. One of
+			atomic: an atomic flag checked before locking a mutex (the default)
+			mutex:  a mutex locked on every call
+			once:   a sync.Once
+			header: an atomic pointer to a separately allocated header with
+			        a mutex and f, which becomes garbage after evaluation,
+			        to reduce the memory used by evaluated values
+			striped: an atomic flag checked before locking one of a
+			        fixed number of locks shared by all lazy values, to
+			        reduce the memory used by many rarely evaluated values
 
-type pkg struct {
-	Package string
-	Types   []typ
-}
+	-stripes n
+		number of locks shared by the lazy values with -impl=striped, 64 by
+		default. The lock is not held while a value is evaluated, so lazy
+		values sharing it can still depend on each other.
 
-type typ struct {
-	Name string
-	Type string
-}
+	-style style
+		style of the generated lazy values. Either "func" (the default), to
+		generate a function <name> returning a func evaluating the value, or
+		"value", to generate a type <name> instead. Values of the type can
+		be used as struct fields, so a struct with many lazy values needs no
+		allocation per value. They are initialized with Init(f) and
+		evaluated with Get, e.g.
 
-var defaultTypes = []typ{
-	{Name: "Bool", Type: "bool"},
-	{Name: "Byte", Type: "byte"},
-	{Name: "Complex64", Type: "complex64"},
-	{Name: "Complex128", Type: "complex128"},
-	{Name: "Float32", Type: "float32"},
-	{Name: "Float64", Type: "float64"},
-	{Name: "Error", Type: "error"},
-	{Name: "Int", Type: "int"},
-	{Name: "Int8", Type: "int8"},
-	{Name: "Int16", Type: "int16"},
-	{Name: "Int32", Type: "int32"},
-	{Name: "Int64", Type: "int64"},
-	{Name: "Interface", Type: "interface{}"},
-	{Name: "Rune", Type: "rune"},
-	{Name: "String", Type: "string"},
-	{Name: "Uint", Type: "uint"},
-	{Name: "Uint8", Type: "uint8"},
-	{Name: "Uint16", Type: "uint16"},
-	{Name: "Uint32", Type: "uint32"},
-	{Name: "Uint64", Type: "uint64"},
-	{Name: "Uintptr", Type: "uintptr"},
-}
+			type Server struct {
+				config LazyConfig
+			}
 
-var (
-	pkgName = flag.String("package", "lazy", "Package the file should be in")
-	outFile = flag.String("out", "", "Where to write the output (defaults to stdout)")
-)
+			s.config.Init(loadConfig)
+			cfg := s.config.Get()
 
-func main() {
-	flag.Parse()
-	log.SetFlags(0)
-
-	var out io.Writer
-	if *outFile == "" {
-		out = os.Stdout
-	} else {
-		f, err := os.Create(*outFile)
-		if err != nil {
-			log.Fatal(err)
-		}
-		defer f.Close()
-
-		out = f
-	}
+		TryGet returns the value only if it is already evaluated, without
+		evaluating it or waiting for an evaluation in progress, e.g. for
+		latency critical paths. With -impl=mutex, it requires Go 1.18.
+		The funcs of the func style can only be called, so they have no
+		TryGet; use -style=value or -versioned for it. Can not be used
+		with -versioned.
 
-	if flag.NArg()%2 != 0 {
-		log.Fatal("Usage: go-lazy [-package=<pkg>] [<name> <type>]...")
-	}
+	-registry
+		register every created lazy value in the generated package and
+		generate a function LazyForceAll, evaluating all of them, e.g. to
+		warm them up before serving requests. Values are evaluated in groups
+		of the same priority, in ascending order, and the values of a group
+		concurrently. The priority of a type is set in the -config file and
+		defaults to 0. Registered values are never freed, so this should
+		only be used for long-lived values. Can not be used with -versioned.
+		LazyWarmup returns the same as a func() error, which reports panics
+		of evaluations as errors, for use with errgroup.Group.Go. The error
+		is joined from one per panic, naming its lazy value, and requires
+		Go 1.20. LazyWarmupN(n, priorities) limits the number of concurrent
+		evaluations to n and starts values in order of their priority, as
+		soon as a previous evaluation is done, instead of waiting for
+		groups. priorities overrides the priorities of values by name, so
+		critical values can be evaluated first, when the limit is reached.
 
-	var types []typ
-	for i := 0; i < flag.NArg(); i += 2 {
-		types = append(types, typ{Name: flag.Arg(i), Type: flag.Arg(i + 1)})
-	}
-	if len(types) == 0 {
-		types = defaultTypes
-	}
+	-debug-handler
+		with -registry, record when registered values are evaluated, how
+		long their evaluation takes and whether it panics, and generate a
+		function LazyDebugHandler, returning an http.Handler which lists
+		them as plain text. It can be mounted under an existing debug
+		endpoint, e.g. /debug/lazy. As lazy values have no errors, a panic
+		of the evaluation is reported instead.
 
-	buf := new(bytes.Buffer)
+	-snapshot
+		with -registry, generate functions LazySnapshot and LazySeed. The
+		former writes the evaluated registered values, which can be encoded
+		as JSON, to an io.Writer and the latter seeds them from a snapshot,
+		so that they return the value of the snapshot instead of being
+		evaluated, e.g. to cut the latency after the program is restarted.
+		Values are identified by the name of their constructor and the order
+		they were created in, so a snapshot only fits values created in the
+		same order, like package-level variables. LazySaveSnapshot and
+		LazyLoadSnapshot do the same with a file, which is ignored if it
+		does not exist.
 
-	if err := implTemplate.Execute(buf, pkg{Package: *pkgName, Types: types}); err != nil {
-		log.Fatal(err)
-	}
+	-stats
+		with -style=value or -versioned, generate a method Stats on the lazy
+		value types, returning a LazyStats with the number of evaluations,
+		the time the last one finished and, if it panicked, an error
+		describing the panic. This is meant for health reporting, e.g. of
+		how often versioned values are recomputed.
 
-	output, err := format.Source(buf.Bytes())
-	if err != nil {
-		log.Fatal(err)
-	}
+	-marshal formats
+		with -style=value, implement the marshaling interfaces of the given
+		comma-separated formats on the lazy value types: "json" for
+		encoding/json, "yaml" for gopkg.in/yaml.v3 and "msgpack" for
+		github.com/vmihailenco/msgpack/v5, which the package must then
+		depend on. Marshaling evaluates the value, unmarshaling
+		initializes it with the decoded value and must be used instead of
+		Init.
+
+	-proto
+		with -style=value, generate conversions of the lazy value types from
+		and to protobuf wrapper messages, as defined by
+		google.golang.org/protobuf/types/known/wrapperspb, and pointers, as
+		used for optional fields. ToProto and ToOptional evaluate the value,
+		FromProto and FromOptional initialize it with the value of the
+		field, or with a func computing it, if the field is not set. Only
+		the types with a wrapper message are supported, e.g. int64 and
+		string.
+
+	-hash
+		with -style=value, generate a method Hash on the lazy value types,
+		returning a 64-bit FNV-1a hash of the value, which is evaluated if
+		necessary. The hash is computed by generated code, without
+		reflection, and does not change between processes, so it can be
+		used for content-addressed caches. Types built from predeclared
+		types, pointers, slices, arrays, maps and structs are supported.
+		Other named types, including those of other packages like
+		time.Time, must have a method Hash() uint64, even if their
+		underlying type is supported, as their declaration is not loaded.
+
+	-mobile
+		generate a type <name>Mobile for every type, wrapping a lazy value
+		so that it can be bound with gomobile, which does not support func
+		types. New<name>Mobile creates it from the func getting the value,
+		e.g. the func returned by <name> or the Get method of a <name>, and
+		is meant to be called by Go code. Its Get method is bound. Only
+		types gomobile supports can be wrapped: signed integers, floats,
+		bool, string, []byte, error and pointers to exported types of the
+		package. Can not be used with -versioned, -generic or -otel.
+
+	-equal
+		with -style=value, generate a method Equal on the lazy value types,
+		reporting whether the values of two lazy values are equal, e.g. for
+		change detection. Both values are evaluated if necessary and
+		compared with ==, unless -equal-func is given.
+
+	-equal-func
+		with -equal, the name of a function comparing two values instead,
+		e.g. bytes.Equal. It must accept all generated types.
+
+	-slog
+		with -style=value, implement slog.LogValuer on the lazy value types.
+		A lazy value is logged as a group with whether it is evaluated and
+		its value only if it is, so log statements never trigger an
+		evaluation. Requires Go 1.21.
+
+	-expvar
+		with -style=value, generate a method Publish(name) on the lazy value
+		types, publishing a lazy value as an expvar.Var, e.g. to be served
+		under /debug/vars. Like -slog, it shows whether the value is
+		evaluated and the value only if it is.
+
+	-close
+		with -style=value, generate a method Close on the lazy value types,
+		releasing the value once, if it was evaluated, by calling its Close
+		method, if it implements io.Closer. Values evaluated after Close are
+		released immediately. This avoids leaking lazily created clients on
+		shutdown.
+
+	-finalizer
+		with -close, the name of a function releasing values instead, which
+		is called with the value and returns an error. It must accept all
+		generated types, e.g. by being generic.
+
+	-notify
+		with -style=value, generate a method Evaluated on the lazy value
+		types, returning a channel, which is closed once the value is
+		evaluated, without evaluating it. The generated function
+		LazyEvaluated waits for several of them, e.g. to start background
+		work only after the main path paid the costs of initialization:
+
+			go func() {
+				<-LazyEvaluated(&s.config, &s.db)
+				s.fillCaches()
+			}()
+
+		As LazyEvaluated is declared once per file, all lazy values of a
+		package using -notify must be generated into the same file.
+
+	-target target
+		compiler the generated code is for. Either "gc" (the default) or
+		"tinygo", for WASM and embedded builds. With "tinygo", the default
+		-impl is mutex, which needs no atomic operations, and -impl=header,
+		-pad and -pprof can not be used, as they rely on unsafe pointer
+		atomics, cache line sizes and runtime/pprof. None of the generated
+		code uses finalizers or 64-bit atomic operations.
+
+	-slab
+		additionally generate a slab allocator for each wrapped type. A type
+		<name>Slab is created, whose New method is equivalent to <name>, but
+		allocates the lazy values in contiguous slabs. This reduces the
+		allocations needed for many lazy values, like one per row of a large
+		result set. Can not be used with -versioned.
+
+	-pad
+		pad lazy values to a multiple of the cache line size, to avoid false
+		sharing when many of them, e.g. in a slab, are evaluated concurrently
+		from different cores. The cache line size is chosen for $GOARCH, as
+		set by go generate, or the architecture go-lazy runs on. So when
+		targeting several architectures, the output should be generated per
+		architecture, into files with a _$GOARCH suffix. Can not be used with
+		-versioned.
+
+	-pprof
+		run the evaluation of lazy values with the pprof label lazy=<name>
+		(see runtime/pprof.Do), so expensive initializations can be
+		attributed in CPU profiles. Requires Go 1.9. Can not be used with
+		-versioned.
+
+	-otel
+		start a span around the evaluation of lazy values. The generated
+		functions then take a context.Context, which is passed to f and is the
+		parent of the span, so the evaluation shows up in the trace of the
+		request first using the value. Spans are started by a LazyTracer,
+		which is generated together with the lazy values and must be set with
+		SetLazyTracer, so the generated code does not depend on OpenTelemetry.
+		As these are declared once per file, all lazy values of a package
+		using -otel must be generated into the same file. Can not be used with
+		-versioned.
+
+	-slow d
+		report evaluations of lazy values taking longer than d, by calling
+		LazySlowWarn, which logs a warning by default. The threshold can be
+		changed with LazySlowThreshold. Both are generated together with the
+		lazy values, with the same restriction as for -otel. Can not be used
+		with -versioned.
+
+	-pinned
+		evaluate lazy values on the goroutine running LazyRun, which is
+		generated together with the lazy values, with the same restriction
+		as for -otel. Using a lazy value blocks, until LazyRun evaluated
+		it. This allows wrapping libraries, which must be called on a
+		specific thread, by calling runtime.LockOSThread before LazyRun.
+		Can not be used with -versioned, -pprof, -tests or -properties.
+
+	-contention
+		measure how long goroutines are blocked, waiting for another one to
+		evaluate a lazy value, and report it to LazyContention, if it is
+		set. The measurement is only compiled in with the lazycontention
+		build tag, so it costs nothing otherwise, which helps diagnosing
+		thundering herds on startup. It needs the files <out>_contention.go
+		and <out>_nocontention.go, which are written alongside the -out file.
+		Requires -impl=atomic or -impl=header.
+
+	-tests file
+		also write stress tests for the generated code to file, which should
+		end in _test.go. For every wrapped type, the tests get lazy values
+		from many goroutines at once and fail, unless the function counting
+		its calls was called exactly once. They also check with
+		testing.AllocsPerRun that getting an evaluated value does not
+		allocate, which holds for all implementations. With -check, the
+		tests are checked instead of written.
+
+	-test-goroutines n
+		number of goroutines getting a lazy value at once. Defaults to 8.
+
+	-test-iterations n
+		number of lazy values tested for every GOMAXPROCS value. Defaults to
+		100.
 
-	if _, err := out.Write(output); err != nil {
-		log.Fatal(err)
+	-test-procs list
+		comma-separated list of GOMAXPROCS values to run the tests with.
+		Defaults to 1,2,4,8.
+
+	-properties file
+		also write property tests for the generated code to file, which
+		should end in _test.go. They use testing/quick to check, for random
+		values, that lazy values do not call their function before they are
+		used and that getting them repeatedly returns the same value. For
+		versioned values, they also check that invalidating the current
+		generation forces re-evaluation and invalidating a past one does
+		nothing. Values of the wrapped types must be generated by
+		testing/quick, so interface, func and chan types are not supported
+		and other types might need to implement quick.Generator.
+
+	-deprecated
+		mark lazy values of types referring to deprecated types as
+		deprecated, so that linters like staticcheck report their uses.
+		The doc comments of the named types are read from the source of
+		their packages, which are found like imports of the output
+		directory, using the import paths of the -config file.
+
+	-config file
+		read additional types from the JSON file, e.g.
+
+			{
+				"types": [
+					{"name": "Duration", "type": "time.Duration"},
+					{
+						"name": "Handle",
+						"type": "int",
+						"platforms": {"windows": "syscall.Handle"}
+					}
+				],
+				"imports": {"sys": "golang.org/x/sys/unix"}
+			}
+
+		If no types are given as arguments, only the types in the file are
+		generated. A type with platforms, given as GOOS, GOARCH or
+		GOOS/GOARCH, is generated into separate files with matching
+		//go:build constraints, named after the output file. On the given
+		platforms, the type given for them is used. On all other platforms,
+		the default type is used, e.g. with -out lazy.go, Handle wraps a
+		syscall.Handle in lazy_windows.go and an int in
+		lazy_windows_other.go. This requires -out and Go 1.17. Types with
+		platforms are not covered by -tests and -properties.
+
+		Packages used by types are imported under their name. imports maps
+		names to import paths, if they differ. A type can also have a
+		"priority" for -registry.
+
+	-record-inputs
+		record the arguments, a hash of the -config file and one of the
+		declarations of the types of the output directory the lazy values
+		refer to in a //gen:inputs comment of the generated file. The
+		lazystale analyzer of merovius.de/go-misc/cmd/lazycheck reports the
+		file as out of date when they change, e.g. during go vet.
+
+	-manifest file
+		write a JSON manifest of the generated files to file, for tools
+		consuming them. It lists the exported symbols of every lazy value
+		and the ones shared by all of them, with their file and line, and
+		the flags go-lazy was run with. Requires -out.
+
+The bench subcommand generates benchmarks for every implementation strategy,
+runs them with go test and prints the results, together with a recommendation
+for this machine. Its flags are:
+
+	-benchtime d
+		run time of each benchmark, as accepted by go test. Defaults to 1s.
+
+	-cpu list
+		comma-separated list of GOMAXPROCS values to run the benchmarks with,
+		as accepted by go test.
+
+	-keep
+		keep the generated benchmark package, instead of removing it, and
+		print its location.
+
+The migrate subcommand rewrites hand-written lazy values using sync.Once in the
+package in dir, which defaults to the current directory, like
+
+	var x T
+	var once sync.Once
+
+	func getX() T {
+		once.Do(func() {
+			x = compute()
+		})
+		return x
 	}
+
+into
+
+	var getX = lazyX(func() T {
+		return compute()
+	})
+
+if x and once are not used anywhere else. Builtin types use the constructors of
+merovius.de/go-misc/lazy instead. For other types, a //go:generate directive
+generating the constructors is added, so go generate needs to be run
+afterwards. Its flags are:
+
+	-w
+		write the rewritten files, instead of printing a diff, which uses the
+		diff command.
+
+	-out file
+		output file of the added //go:generate directives. Defaults to
+		lazy_migrated.go.
+
+	-regexp
+		also rewrite package level regexps compiled from a constant, like
+
+			var re = regexp.MustCompile(`…`)
+
+		into
+
+			var re = lazy.Regexp(`…`)
+
+		and their uses from re to re(), so they are compiled when first
+		used, instead of when the package is initialized. Regexps, which
+		are assigned to or have their address taken, are left alone.
+
+The upgrade subcommand upgrades a //go:generate directive of the package in
+dir, which defaults to the current directory, generating one func style
+constructor per type to -generic. The uses of the constructors in the package
+are replaced by the generic one, whose type argument is inferred in calls and
+given explicitly otherwise. Directives generating exported constructors, which
+other packages might use, are not upgraded. Its flags are:
+
+	-w
+		write the upgraded files and generate the output of the directive
+		again, instead of printing a diff, which uses the diff command.
+
+	-name name
+		name of the generic constructor. Defaults to lazyValue.
+
+The proxy subcommand generates lazy proxies for interface types of the package
+in dir, which defaults to the current directory. For every given interface type
+I, the created code contains a constructor
+
+	func NewLazyI(newImpl func() I) I
+
+returning an I, which calls newImpl when one of its methods is first called and
+forwards all calls to the result. This allows exposing an I right away, while
+deferring its expensive construction until it is used. Its flags are:
+
+	-type types
+		comma-separated list of interface type names. Required.
+
+	-err
+		generate constructors
+
+			func NewLazyI(newImpl func() (I, error), fallback I) I
+
+		instead, to surface errors of the construction instead of
+		panicking. While newImpl fails, it is called again by every call
+		and calls are forwarded to fallback, if it is not nil. Otherwise,
+		methods with an error result return the error of newImpl as their
+		first error result, with zero values for the other results, and
+		only methods without one panic.
+
+	-out file
+		output file, defaults to stdout.
+
+	-check, -template, -lint
+		as for go-lazy itself.
+*/
+package main
+
+import (
+	"os"
+
+	"merovius.de/go-misc/internal/cmd/lazy"
+	"merovius.de/go-misc/internal/gen"
+)
+
+func main() {
+	gen.Main(lazy.Run, os.Args[1:])
 }
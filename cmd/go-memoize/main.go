@@ -0,0 +1,137 @@
+/*
+go-memoize generates concurrency-safe memoization wrappers for functions.
+
+For every given function signature, the created code will contain a function
+that wraps a function of that signature, such that it is called at most once
+for every distinct set of arguments. Concurrent calls with the same arguments
+wait for the first one to finish. Functions with more than one argument are
+keyed by a struct of all arguments, so all argument types must be comparable.
+
+It is the generalization of merovius.de/go-misc/cmd/go-lazy from thunks to
+functions.
+
+The CLI is still not entirely finalized, it may be subject to change for now.
+
+Usage:
+
+	go-memoize [flags] <name> <signature> [<name> <signature> ...]
+	go-memoize bench [flags]
+
+You must pass an even number of arguments. For each wrapped signature you need
+to give the name of the function and the signature you want to wrap, e.g.
+
+	go-memoize -max 128 Lookup 'func(host string, port int) ([]string, error)'
+
+The flags are:
+
+	-package pkg
+		what package the generated file should reside in. Defaults to
+		$GOPACKAGE, as set by go generate.
+
+	-out file
+		output file, defaults to stdout.
+
+	-check
+		do not write the output file, but fail if it is not up to date, e.g.
+		to verify generated code in CI. Requires -out.
+
+	-template file
+		execute the template in file instead of the built-in one, with the
+		same data. The templates associated with the built-in one can be
+		invoked with {{ template "name" . }} and are replaced by templates
+		of the same name defined in file.
+
+	-lint
+		do not write the output file, but check the code generated by
+		-template for syntax and type errors, together with the other files
+		in the directory of the output file. Errors are reported at the
+		line of the template that generated the code.
+
+	-allow-unsafe
+		generate code even if a type refers to unsafe.Pointer or to a
+		cgo type like C.int. By default this is refused, as such values
+		often point to memory that is not managed by the garbage
+		collector and might be freed while the cached result is still in
+		use.
+
+	-max n
+		maximum number of results to keep per wrapped function. If more
+		results are cached, one is evicted according to -policy. Defaults to
+		0, meaning results are never evicted.
+
+	-policy policy
+		eviction policy to use, if -max is given. Either "lru" (evict the
+		least recently used result) or "fifo" (evict the oldest result).
+		Defaults to "lru".
+
+	-ctx policy
+		for functions taking a context.Context as their first argument,
+		leave the context out of the cache key and decide which context the
+		shared evaluation for a set of arguments runs with. One of
+			first:    the context of the first caller. If it is canceled,
+			          the evaluation is, for all callers
+			detached: a context with the values of the context of the
+			          first caller, which is never canceled
+			merged:   a context with the values of the context of the
+			          first caller, which is canceled once the contexts of
+			          all waiting callers are done. Callers stop waiting
+			          when their context is done and return its error, so
+			          the function must return an error as its last result
+		By default, the context is part of the cache key, like any other
+		argument.
+
+	-ctx-timeout d
+		with -ctx=detached or -ctx=merged, cancel the context of the
+		evaluation after d.
+
+	-backend backend
+		how the results are cached. Either "mutex" (a map guarded by a
+		mutex) or "syncmap" (a sync.Map). A sync.Map avoids contention on
+		the mutex for read-mostly workloads with many goroutines, but
+		allocates more for every new set of arguments. "syncmap" can not
+		be used with -max or -ctx=merged. Defaults to "mutex".
+
+	-fuzz file
+		also write fuzz targets to file, which should end in _test.go. For
+		every function given by -fuzz-funcs, a target Fuzz<name> checks that
+		the memoized function always returns the same as calling the original
+		function directly. As the memoized function is shared by all inputs,
+		this catches results cached for the wrong arguments and bugs in
+		eviction. Requires -fuzz-funcs and Go 1.18 to run the targets.
+
+	-fuzz-funcs name=func[,name=func...]
+		for every memoized function name to fuzz, the function func in the
+		package it is compared to. func must be deterministic and all
+		arguments of name must be of a type supported by fuzzing, i.e. a
+		string, bool or numeric type, e.g.
+
+			go-memoize -out memoize.go -fuzz memoize_fuzz_test.go \
+				-fuzz-funcs Square=square Square 'func(int) int'
+
+The bench subcommand generates both backends for a func(int) int, benchmarks
+calls with cached arguments, sequentially and from many goroutines, and with new
+arguments, runs them with go test and prints the results, together with a
+recommendation for this machine. Its flags are:
+
+	-benchtime d
+		run time of each benchmark, as accepted by go test. Defaults to 1s.
+
+	-cpu list
+		comma-separated list of GOMAXPROCS values to run the benchmarks with,
+		as accepted by go test.
+
+	-keep
+		keep the generated benchmark package and print its location.
+*/
+package main
+
+import (
+	"os"
+
+	"merovius.de/go-misc/internal/cmd/memoize"
+	"merovius.de/go-misc/internal/gen"
+)
+
+func main() {
+	gen.Main(memoize.Run, os.Args[1:])
+}
@@ -0,0 +1,65 @@
+/*
+go-mock generates minimal mocks for interface types.
+
+For every given interface type I, the created code will contain a type IMock
+implementing I. For every method M of I, IMock has a field MFunc of the
+method's type, which is called by M. Calling a method whose field is nil
+panics. This is the same as hand-writing mocks, just without the typing:
+
+	m := &StoreMock{
+		GetFunc: func(ctx context.Context, key string) ([]byte, error) {
+			return nil, ErrNotFound
+		},
+	}
+
+The CLI is still not entirely finalized, it may be subject to change for now.
+
+Usage:
+
+	go-mock [flags] [<dir>]
+
+dir is the directory of the package to use and defaults to the current
+directory.
+
+The flags are:
+
+	-type types
+		comma-separated list of interface type names. Defaults to all
+		exported interface types of the package.
+
+	-package pkg
+		what package the generated file should reside in. Defaults to the
+		package in dir. If a different package is given, e.g. the external
+		test package, the mocked interfaces are imported.
+
+	-out file
+		output file, defaults to stdout.
+
+	-check
+		do not write the output file, but fail if it is not up to date, e.g.
+		to verify generated code in CI. Requires -out.
+
+	-template file
+		execute the template in file instead of the built-in one, with the
+		same data. The templates associated with the built-in one can be
+		invoked with {{ template "name" . }} and are replaced by templates
+		of the same name defined in file.
+
+	-lint
+		do not write the output file, but check the code generated by
+		-template for syntax and type errors, together with the other files
+		in the directory of the output file. Errors are reported at the
+		line of the template that generated the code.
+*/
+package main
+
+import (
+	"os"
+
+	"merovius.de/go-misc/internal/cmd/mock"
+	"merovius.de/go-misc/internal/gen"
+)
+
+func main() {
+	gen.Main(mock.Run, os.Args[1:])
+}
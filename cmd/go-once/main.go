@@ -0,0 +1,67 @@
+/*
+go-once generates typed wrappers, that call a function only once.
+
+For every given signature, the created code will contain a function that wraps
+a function of that signature, such that it is only called once and its results
+are returned on every call. It is the equivalent of sync.OnceFunc,
+sync.OnceValue and sync.OnceValues for code that can not use Go 1.21 yet. In
+particular, if the wrapped function panics, every call panics with the same
+value.
+
+The CLI is still not entirely finalized, it may be subject to change for now.
+
+Usage:
+
+	go-once [flags] <name> <signature> [<name> <signature> ...]
+
+You must pass an even number of arguments. For each wrapped signature you need
+to give the name of the function and the signature you want to wrap, e.g.
+
+	go-once LoadConfig 'func() (*Config, error)'
+
+Signatures can have any number of results, but no parameters.
+
+The flags are:
+
+	-package pkg
+		what package the generated file should reside in. Defaults to
+		$GOPACKAGE, as set by go generate.
+
+	-out file
+		output file, defaults to stdout.
+
+	-check
+		do not write the output file, but fail if it is not up to date, e.g.
+		to verify generated code in CI. Requires -out.
+
+	-template file
+		execute the template in file instead of the built-in one, with the
+		same data. The templates associated with the built-in one can be
+		invoked with {{ template "name" . }} and are replaced by templates
+		of the same name defined in file.
+
+	-lint
+		do not write the output file, but check the code generated by
+		-template for syntax and type errors, together with the other files
+		in the directory of the output file. Errors are reported at the
+		line of the template that generated the code.
+
+	-allow-unsafe
+		generate code even if a type refers to unsafe.Pointer or to a
+		cgo type like C.int. By default this is refused, as such values
+		often point to memory that is not managed by the garbage
+		collector and might be freed while the stored result is still in
+		use.
+*/
+package main
+
+import (
+	"os"
+
+	"merovius.de/go-misc/internal/cmd/once"
+	"merovius.de/go-misc/internal/gen"
+)
+
+func main() {
+	gen.Main(once.Run, os.Args[1:])
+}
@@ -0,0 +1,54 @@
+/*
+go-optional generates optional value types.
+
+When no types are given, it generates optional types for all builtin types and
+for interface{}. For each wrapped type, the created code will contain a type
+that either holds a value of that type or nothing, which allows expressing
+optionality without resorting to pointers.
+
+The CLI is still not entirely finalized, it may be subject to change for now.
+
+Usage:
+
+	go-optional [flags] [<name> <type> ...]
+
+You must pass an even number of arguments. For each wrapped type you need to
+give the name of the optional type and the type you want to wrap.
+
+The flags are:
+
+	-package pkg
+		what package the generated file should reside in. Defaults to
+		"optional".
+
+	-out file
+		output file, defaults to stdout.
+
+	-check
+		do not write the output file, but fail if it is not up to date, e.g.
+		to verify generated code in CI. Requires -out.
+
+	-template file
+		execute the template in file instead of the built-in one, with the
+		same data. The templates associated with the built-in one can be
+		invoked with {{ template "name" . }} and are replaced by templates
+		of the same name defined in file.
+
+	-lint
+		do not write the output file, but check the code generated by
+		-template for syntax and type errors, together with the other files
+		in the directory of the output file. Errors are reported at the
+		line of the template that generated the code.
+*/
+package main
+
+import (
+	"os"
+
+	"merovius.de/go-misc/internal/cmd/optional"
+	"merovius.de/go-misc/internal/gen"
+)
+
+func main() {
+	gen.Main(optional.Run, os.Args[1:])
+}
@@ -0,0 +1,58 @@
+/*
+go-pool generates typed wrappers around sync.Pool.
+
+For each wrapped type T, the created code will contain a pool type handing out
+values of type *T, without exposing the interface{} based API of sync.Pool.
+Values can be reset before being returned to the pool, either by a hook given
+to the constructor or, if none is given, by their Reset method, if they have
+one.
+
+The CLI is still not entirely finalized, it may be subject to change for now.
+
+Usage:
+
+	go-pool [flags] <name> <type> [<name> <type> ...]
+
+You must pass an even number of arguments. For each wrapped type you need to
+give the name of the pool type and the type of the pooled values (without the
+pointer), e.g.
+
+	go-pool BufferPool bytes.Buffer
+
+The flags are:
+
+	-package pkg
+		what package the generated file should reside in. Defaults to
+		$GOPACKAGE, as set by go generate.
+
+	-out file
+		output file, defaults to stdout.
+
+	-check
+		do not write the output file, but fail if it is not up to date, e.g.
+		to verify generated code in CI. Requires -out.
+
+	-template file
+		execute the template in file instead of the built-in one, with the
+		same data. The templates associated with the built-in one can be
+		invoked with {{ template "name" . }} and are replaced by templates
+		of the same name defined in file.
+
+	-lint
+		do not write the output file, but check the code generated by
+		-template for syntax and type errors, together with the other files
+		in the directory of the output file. Errors are reported at the
+		line of the template that generated the code.
+*/
+package main
+
+import (
+	"os"
+
+	"merovius.de/go-misc/internal/cmd/pool"
+	"merovius.de/go-misc/internal/gen"
+)
+
+func main() {
+	gen.Main(pool.Run, os.Args[1:])
+}
@@ -0,0 +1,69 @@
+/*
+go-ring generates typed bounded ring buffers.
+
+For each element type T, the created code will contain a ring buffer type,
+that can be used as a single-producer, single-consumer (SPSC) queue: At any
+time, at most one goroutine may push values and at most one goroutine may pop
+values. Under that constraint, the ring buffer is safe for concurrent use and
+does not take any locks on the fast path, which makes it considerably cheaper
+than a buffered channel.
+
+Values can be pushed and popped with the non-blocking TryPush and TryPop
+methods, or with the blocking Push and Pop methods, which wait for space or
+values to become available. Close can be used by the producer to signal that
+no more values will be pushed, after which Pop drains the remaining values and
+then fails.
+
+The ring buffer uses 64-bit atomic operations. On 386 and 32-bit ARM and MIPS,
+these are only aligned in allocated ring buffers and in the first field of
+allocated structs. The generated code asserts that the fields are aligned
+within the ring buffer type.
+
+The CLI is still not entirely finalized, it may be subject to change for now.
+
+Usage:
+
+	go-ring [flags] <name> <type> [<name> <type> ...]
+
+You must pass an even number of arguments. For each element type you need to
+give the name of the ring buffer type and the element type, e.g.
+
+	go-ring SampleRing Sample
+
+The flags are:
+
+	-package pkg
+		what package the generated file should reside in. Defaults to
+		$GOPACKAGE, as set by go generate.
+
+	-out file
+		output file, defaults to stdout.
+
+	-check
+		do not write the output file, but fail if it is not up to date, e.g.
+		to verify generated code in CI. Requires -out.
+
+	-template file
+		execute the template in file instead of the built-in one, with the
+		same data. The templates associated with the built-in one can be
+		invoked with {{ template "name" . }} and are replaced by templates
+		of the same name defined in file.
+
+	-lint
+		do not write the output file, but check the code generated by
+		-template for syntax and type errors, together with the other files
+		in the directory of the output file. Errors are reported at the
+		line of the template that generated the code.
+*/
+package main
+
+import (
+	"os"
+
+	"merovius.de/go-misc/internal/cmd/ring"
+	"merovius.de/go-misc/internal/gen"
+)
+
+func main() {
+	gen.Main(ring.Run, os.Args[1:])
+}
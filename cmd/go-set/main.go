@@ -0,0 +1,79 @@
+/*
+go-set generates typed set containers.
+
+For each element type T, the created code will contain a set type with methods
+to add, delete and look up elements, to combine sets and to iterate over their
+elements, either in unspecified or in sorted order. The zero value of a set is
+an empty set, ready to use.
+
+The CLI is still not entirely finalized, it may be subject to change for now.
+
+Usage:
+
+	go-set [flags] <name> <type> [<name> <type> ...]
+
+You must pass an even number of arguments. For each element type you need to
+give the name of the set type and the element type, e.g.
+
+	go-set StringSet string IDSet ID
+
+With -generic, you only give the names of the set types.
+
+The flags are:
+
+	-package pkg
+		what package the generated file should reside in. Defaults to
+		$GOPACKAGE, as set by go generate.
+
+	-sync
+		generate sets that are safe for concurrent use, guarded by a
+		sync.RWMutex.
+
+	-unordered
+		do not generate the Sorted method. Sorted compares elements with <,
+		so it has to be omitted for element types that are not ordered.
+
+	-generic
+		generate generic set types, with the element type as a type
+		parameter, instead of one set type per element type. The arguments
+		are then only the names of the set types, e.g.
+
+			go-set -generic Set
+
+		Type parameters are only constrained as far as the generated code
+		requires, so the element type must be comparable. As methods can
+		not add constraints, Sorted is generated as a function
+		Sorted<name> instead, whose element type must also be ordered.
+		Requires Go 1.18.
+
+	-out file
+		output file, defaults to stdout.
+
+	-check
+		do not write the output file, but fail if it is not up to date, e.g.
+		to verify generated code in CI. Requires -out.
+
+	-template file
+		execute the template in file instead of the built-in one, with the
+		same data. The templates associated with the built-in one can be
+		invoked with {{ template "name" . }} and are replaced by templates
+		of the same name defined in file.
+
+	-lint
+		do not write the output file, but check the code generated by
+		-template for syntax and type errors, together with the other files
+		in the directory of the output file. Errors are reported at the
+		line of the template that generated the code.
+*/
+package main
+
+import (
+	"os"
+
+	"merovius.de/go-misc/internal/cmd/set"
+	"merovius.de/go-misc/internal/gen"
+)
+
+func main() {
+	gen.Main(set.Run, os.Args[1:])
+}
@@ -0,0 +1,72 @@
+/*
+go-sorted generates sort.Interface implementations for slices of structs.
+
+For every given struct type T and every order given to -by, the created code
+will contain a slice type, like
+
+	type TByAge []T
+
+implementing sort.Interface by comparing the fields of the order, and a
+Search method doing a binary search for the first element whose fields are
+not less than the given values. An order can consist of multiple fields,
+separated by "+", which are compared lexicographically: -by Last+First
+generates TByLastFirst, with a method
+
+	func (s TByLastFirst) Search(last string, first string) int
+
+The comparisons are generated code, so unlike sort.Slice, sorting does not
+need a closure or reflection. The fields of an order must have an ordered
+underlying type.
+
+The CLI is still not entirely finalized, it may be subject to change for now.
+
+Usage:
+
+	go-sorted [flags] -type <type>[,<type>...] -by <order>[,<order>...] [<dir>]
+
+dir is the directory of the package to use and defaults to the current
+directory.
+
+The flags are:
+
+	-type types
+		comma-separated list of struct type names. Required.
+
+	-by orders
+		comma-separated list of orders, each a "+"-separated list of field
+		names. Required. Every type must have the fields of every order.
+
+	-ptr
+		generate slices of pointers, like []*T, instead of slices of values.
+
+	-out file
+		output file, defaults to stdout.
+
+	-check
+		do not write the output file, but fail if it is not up to date, e.g.
+		to verify generated code in CI. Requires -out.
+
+	-template file
+		execute the template in file instead of the built-in one, with the
+		same data. The templates associated with the built-in one can be
+		invoked with {{ template "name" . }} and are replaced by templates
+		of the same name defined in file.
+
+	-lint
+		do not write the output file, but check the code generated by
+		-template for syntax and type errors, together with the other files
+		in the directory of the output file. Errors are reported at the
+		line of the template that generated the code.
+*/
+package main
+
+import (
+	"os"
+
+	"merovius.de/go-misc/internal/cmd/sorted"
+	"merovius.de/go-misc/internal/gen"
+)
+
+func main() {
+	gen.Main(sorted.Run, os.Args[1:])
+}
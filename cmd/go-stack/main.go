@@ -0,0 +1,65 @@
+/*
+go-stack generates typed stack containers.
+
+For each element type T, the created code will contain a LIFO stack type with
+Push, Pop, Peek and Len methods. Stacks can optionally be bounded, in which
+case Push fails when the stack is full. The zero value of a stack is an empty,
+unbounded stack, ready to use. All stacks are safe for concurrent use.
+
+By default, the stacks are guarded by a sync.Mutex. With -lockfree, a
+lock-free stack based on compare-and-swap operations is generated instead,
+which allocates on every Push but never blocks. As it uses 64-bit atomic
+operations, on 386 and 32-bit ARM and MIPS a lock-free stack must be
+allocated, or be the first field of an allocated struct.
+
+The CLI is still not entirely finalized, it may be subject to change for now.
+
+Usage:
+
+	go-stack [flags] <name> <type> [<name> <type> ...]
+
+You must pass an even number of arguments. For each element type you need to
+give the name of the stack type and the element type, e.g.
+
+	go-stack IntStack int
+
+The flags are:
+
+	-package pkg
+		what package the generated file should reside in. Defaults to
+		$GOPACKAGE, as set by go generate.
+
+	-lockfree
+		generate lock-free stacks.
+
+	-out file
+		output file, defaults to stdout.
+
+	-check
+		do not write the output file, but fail if it is not up to date, e.g.
+		to verify generated code in CI. Requires -out.
+
+	-template file
+		execute the template in file instead of the built-in one, with the
+		same data. The templates associated with the built-in one can be
+		invoked with {{ template "name" . }} and are replaced by templates
+		of the same name defined in file.
+
+	-lint
+		do not write the output file, but check the code generated by
+		-template for syntax and type errors, together with the other files
+		in the directory of the output file. Errors are reported at the
+		line of the template that generated the code.
+*/
+package main
+
+import (
+	"os"
+
+	"merovius.de/go-misc/internal/cmd/stack"
+	"merovius.de/go-misc/internal/gen"
+)
+
+func main() {
+	gen.Main(stack.Run, os.Args[1:])
+}
@@ -0,0 +1,76 @@
+/*
+go-visitor generates visitors for sum-type-like interfaces.
+
+For every given interface type I, whose implementations in the package form
+a closed set of variants A, B, …, the created code will contain
+
+	type IVisitor interface {
+		VisitA(A)
+		VisitB(*B)
+		…
+	}
+
+	func (x A) Accept(v IVisitor)  { v.VisitA(x) }
+	func (x *B) Accept(v IVisitor) { v.VisitB(x) }
+
+	func MatchI(x I, a func(A), b func(*B), …)
+
+Variants are passed by value or by pointer, depending on whether their value
+or only their pointer type implements I. MatchI calls the func for the
+dynamic type of x and panics for nil or unknown variants. As it takes one
+func per variant, adding a variant changes its signature, so every call has
+to be updated to handle the new variant, or it fails to compile.
+
+By default, all named, non-interface types of the package implementing I are
+considered variants, ignoring an Accept method of I, so it can be declared
+before the code is generated.
+
+The CLI is still not entirely finalized, it may be subject to change for now.
+
+Usage:
+
+	go-visitor [flags] -type <type>[,<type>...] [<dir>]
+
+dir is the directory of the package to use and defaults to the current
+directory.
+
+The flags are:
+
+	-type types
+		comma-separated list of interface type names. Required.
+
+	-variants types
+		comma-separated list of the variants. Defaults to all implementations
+		of the interface. Can only be used with a single interface.
+
+	-out file
+		output file, defaults to stdout.
+
+	-check
+		do not write the output file, but fail if it is not up to date, e.g.
+		to verify generated code in CI. Requires -out.
+
+	-template file
+		execute the template in file instead of the built-in one, with the
+		same data. The templates associated with the built-in one can be
+		invoked with {{ template "name" . }} and are replaced by templates
+		of the same name defined in file.
+
+	-lint
+		do not write the output file, but check the code generated by
+		-template for syntax and type errors, together with the other files
+		in the directory of the output file. Errors are reported at the
+		line of the template that generated the code.
+*/
+package main
+
+import (
+	"os"
+
+	"merovius.de/go-misc/internal/cmd/visitor"
+	"merovius.de/go-misc/internal/gen"
+)
+
+func main() {
+	gen.Main(visitor.Run, os.Args[1:])
+}
@@ -0,0 +1,82 @@
+/*
+go-wrap generates decorators for interface types.
+
+For every given interface type I, the created code will contain a constructor
+per selected decorator, returning an I that wraps another I and calls the
+corresponding method of it from every method:
+
+	-log
+		func NewLoggingI(next I, logf func(format string, v ...interface{})) I
+
+		logs the arguments, results and duration of every call. logf can be
+		log.Printf, for example.
+
+	-metrics
+		func NewMetricsI(next I, observe func(method string, d time.Duration, err error)) I
+
+		calls observe after every call, with the error returned by the
+		method, if its last result is an error.
+
+	-retry
+		func NewRetryingI(next I, attempts int, backoff func(attempt int) time.Duration) I
+
+		retries methods whose last result is an error until they succeed or
+		attempts calls were made, sleeping backoff(i) after the i'th failed
+		attempt, if backoff is not nil. If the first parameter of a method is
+		a context.Context, retrying stops when it is done. Other methods are
+		called once.
+
+	-trace
+		func NewTracingI(next I, start func(ctx context.Context, method string) (context.Context, func(err error))) I
+
+		calls start before every call and the returned func after it, with
+		the error returned by the method, if any. If the first parameter of a
+		method is a context.Context, it is passed to start and replaced by the
+		returned context; otherwise context.Background() is used. This makes
+		it straightforward to plug in any tracing library.
+
+The CLI is still not entirely finalized, it may be subject to change for now.
+
+Usage:
+
+	go-wrap [flags] -type <type>[,<type>...] [<dir>]
+
+dir is the directory of the package to use and defaults to the current
+directory. At least one of the decorator flags above must be given.
+
+The flags are:
+
+	-type types
+		comma-separated list of interface type names. Required.
+
+	-out file
+		output file, defaults to stdout.
+
+	-check
+		do not write the output file, but fail if it is not up to date, e.g.
+		to verify generated code in CI. Requires -out.
+
+	-template file
+		execute the template in file instead of the built-in one, with the
+		same data. The templates associated with the built-in one can be
+		invoked with {{ template "name" . }} and are replaced by templates
+		of the same name defined in file.
+
+	-lint
+		do not write the output file, but check the code generated by
+		-template for syntax and type errors, together with the other files
+		in the directory of the output file. Errors are reported at the
+		line of the template that generated the code.
+*/
+package main
+
+import (
+	"os"
+
+	"merovius.de/go-misc/internal/cmd/wrap"
+	"merovius.de/go-misc/internal/gen"
+)
+
+func main() {
+	gen.Main(wrap.Run, os.Args[1:])
+}
@@ -0,0 +1,126 @@
+/*
+gomisc runs the code generators of merovius.de/go-misc/cmd as subcommands of
+a single binary.
+
+Every generator go-<name> is available as the subcommand <name>, taking the
+same flags and arguments, so
+
+	gomisc lazy -versioned -out versioned.go
+
+is equivalent to
+
+	go-lazy -versioned -out versioned.go
+
+All generators support the -out and -check flags, so
+
+	gomisc lazy -check -out lazy.go
+
+fails if lazy.go is not what go-lazy would generate, e.g. to verify generated
+code in CI. Likewise, all generators support replacing their built-in
+template with -template and checking the code generated by it with -lint.
+
+Usage:
+
+	gomisc <command> [flags] [args...]
+
+	gomisc help
+		lists the available commands.
+*/
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"merovius.de/go-misc/internal/cmd/accessors"
+	"merovius.de/go-misc/internal/cmd/atomic"
+	"merovius.de/go-misc/internal/cmd/bitmask"
+	"merovius.de/go-misc/internal/cmd/builder"
+	"merovius.de/go-misc/internal/cmd/cache"
+	"merovius.de/go-misc/internal/cmd/chans"
+	"merovius.de/go-misc/internal/cmd/clone"
+	"merovius.de/go-misc/internal/cmd/constructor"
+	"merovius.de/go-misc/internal/cmd/deepcopy"
+	"merovius.de/go-misc/internal/cmd/defaults"
+	"merovius.de/go-misc/internal/cmd/enum"
+	"merovius.de/go-misc/internal/cmd/equal"
+	"merovius.de/go-misc/internal/cmd/eventbus"
+	"merovius.de/go-misc/internal/cmd/flags"
+	"merovius.de/go-misc/internal/cmd/future"
+	"merovius.de/go-misc/internal/cmd/iter"
+	"merovius.de/go-misc/internal/cmd/lazy"
+	"merovius.de/go-misc/internal/cmd/memoize"
+	"merovius.de/go-misc/internal/cmd/mock"
+	"merovius.de/go-misc/internal/cmd/once"
+	"merovius.de/go-misc/internal/cmd/optional"
+	"merovius.de/go-misc/internal/cmd/pool"
+	"merovius.de/go-misc/internal/cmd/result"
+	"merovius.de/go-misc/internal/cmd/ring"
+	"merovius.de/go-misc/internal/cmd/set"
+	"merovius.de/go-misc/internal/cmd/sorted"
+	"merovius.de/go-misc/internal/cmd/stack"
+	"merovius.de/go-misc/internal/cmd/visitor"
+	"merovius.de/go-misc/internal/cmd/wrap"
+	"merovius.de/go-misc/internal/gen"
+)
+
+var commands = map[string]func(args []string) error{
+	"accessors":   accessors.Run,
+	"atomic":      atomic.Run,
+	"bitmask":     bitmask.Run,
+	"builder":     builder.Run,
+	"cache":       cache.Run,
+	"chan":        chans.Run,
+	"clone":       clone.Run,
+	"constructor": constructor.Run,
+	"deepcopy":    deepcopy.Run,
+	"defaults":    defaults.Run,
+	"enum":        enum.Run,
+	"equal":       equal.Run,
+	"eventbus":    eventbus.Run,
+	"flags":       flags.Run,
+	"future":      future.Run,
+	"iter":        iter.Run,
+	"lazy":        lazy.Run,
+	"memoize":     memoize.Run,
+	"mock":        mock.Run,
+	"once":        once.Run,
+	"optional":    optional.Run,
+	"pool":        pool.Run,
+	"result":      result.Run,
+	"ring":        ring.Run,
+	"set":         set.Run,
+	"sorted":      sorted.Run,
+	"stack":       stack.Run,
+	"visitor":     visitor.Run,
+	"wrap":        wrap.Run,
+}
+
+func usage() {
+	var names []string
+	for n := range commands {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	fmt.Fprintln(os.Stderr, "Usage: gomisc <command> [flags] [args...]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "The commands are:")
+	for _, n := range names {
+		fmt.Fprintf(os.Stderr, "\t%s\n", n)
+	}
+}
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] == "help" {
+		usage()
+		os.Exit(2)
+	}
+	run, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "gomisc: unknown command %q\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+	gen.Main(run, os.Args[2:])
+}
@@ -0,0 +1,33 @@
+/*
+lazycheck reports misuse of lazy values generated by go-lazy.
+
+It runs the analyzers of merovius.de/go-misc/lazy/lazycheck on the given
+packages and can be used as a standalone command or with go vet:
+
+	lazycheck ./...
+	go vet -vettool=$(which lazycheck) ./...
+
+Suggested fixes can be applied with -fix.
+
+The analyzers are:
+
+	lazycopy
+		reports copied lazy values and lazy values of the func style,
+		which are called right after they are created.
+	lazycycle
+		reports lazy values depending on themselves, which deadlock
+		when evaluated.
+	lazystale
+		reports files generated by go-lazy -record-inputs, which are
+		out of date.
+*/
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/multichecker"
+	"merovius.de/go-misc/lazy/lazycheck"
+)
+
+func main() {
+	multichecker.Main(lazycheck.Copy, lazycheck.Cycle, lazycheck.Stale)
+}
@@ -0,0 +1,151 @@
+// Package accessors implements go-accessors. See merovius.de/go-misc/cmd/go-accessors for its
+// documentation.
+package accessors // import "merovius.de/go-misc/internal/cmd/accessors"
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"go/types"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"merovius.de/go-misc/internal/gen"
+)
+
+var implTemplate = template.Must(template.New("accessors.go").Parse(`
+// This file is automatically generated by merovius.de/go-misc/cmd/go-accessors.
+
+package {{ .Package }}
+
+{{ if .Imports -}}
+import (
+{{- range .Imports }}
+	{{ . }}
+{{- end }}
+)
+{{- end }}
+
+{{ range .Types }}
+	{{ template "impl" . }}
+{{ end }}
+`))
+
+var _ = template.Must(implTemplate.New("impl").Parse(`
+{{- range .Fields }}
+// {{ .Method }} returns the value of {{ .Name }}.
+func (s *{{ $.Name }}) {{ .Method }}() {{ .Type }} {
+	s.{{ $.Mutex }}RLock()
+	defer s.{{ $.Mutex }}RUnlock()
+	return s.{{ .Name }}
+}
+{{ if .Set }}
+// Set{{ .Method }} sets the value of {{ .Name }} to v.
+func (s *{{ $.Name }}) Set{{ .Method }}(v {{ .Type }}) {
+	s.{{ $.Mutex }}Lock()
+	defer s.{{ $.Mutex }}Unlock()
+	s.{{ .Name }} = v
+}
+{{ end }}
+{{- end }}
+`))
+
+type pkg struct {
+	Package string
+	Imports []string
+	Types   []accessors
+}
+
+type accessors struct {
+	Name string
+	// Mutex is the selector of the mutex field, followed by a dot, or empty,
+	// if the mutex is embedded.
+	Mutex  string
+	Fields []field
+}
+
+type field struct {
+	Name   string
+	Method string
+	Type   string
+	Set    bool
+}
+
+func isRWMutex(t types.Type) bool {
+	n, ok := t.(*types.Named)
+	return ok && n.Obj().Pkg() != nil && n.Obj().Pkg().Path() == "sync" && n.Obj().Name() == "RWMutex"
+}
+
+// collect returns the accessors for the struct type name in p.
+func collect(p *gen.Package, im *gen.Imports, name string) (accessors, error) {
+	_, s, err := p.Struct(name)
+	if err != nil {
+		return accessors{}, err
+	}
+	a := accessors{Name: name}
+	mutex := false
+	for i := 0; i < s.NumFields(); i++ {
+		v := s.Field(i)
+		if isRWMutex(v.Type()) {
+			if !v.Embedded() {
+				a.Mutex = v.Name() + "."
+			}
+			mutex = true
+			continue
+		}
+		tag := reflect.StructTag(s.Tag(i)).Get("accessor")
+		if v.Exported() || v.Embedded() || v.Name() == "_" || tag == "-" {
+			continue
+		}
+		a.Fields = append(a.Fields, field{
+			Name:   v.Name(),
+			Method: gen.Exported(v.Name()),
+			Type:   im.TypeString(v.Type()),
+			Set:    tag != "get",
+		})
+	}
+	if !mutex {
+		return accessors{}, fmt.Errorf("%s has no field of type sync.RWMutex", name)
+	}
+	return a, nil
+}
+
+var (
+	flags     = flag.NewFlagSet("go-accessors", flag.ContinueOnError)
+	typeNames = flags.String("type", "", "Comma-separated list of struct type names")
+	out       = gen.OutputFlags(flags)
+)
+
+// Run runs go-accessors with the command line arguments args, which do not include
+// the program name.
+func Run(args []string) error {
+	if err := gen.ParseFlags(flags, args); err != nil {
+		return err
+	}
+	if *typeNames == "" || flags.NArg() > 1 {
+		return errors.New("Usage: go-accessors -type=<type>[,<type>...] [<dir>]")
+	}
+	dir := "."
+	if flags.NArg() == 1 {
+		dir = flags.Arg(0)
+	}
+
+	lp, err := gen.LoadPackage(dir)
+	if err != nil {
+		return err
+	}
+
+	im := gen.NewImports(lp.Types)
+	p := pkg{Package: lp.Types.Name()}
+	for _, name := range strings.Split(*typeNames, ",") {
+		a, err := collect(lp, im, name)
+		if err != nil {
+			return err
+		}
+		p.Types = append(p.Types, a)
+	}
+	p.Imports = im.List()
+
+	return out.Write(implTemplate, p)
+}
@@ -0,0 +1,83 @@
+package accessors
+
+import (
+	"testing"
+
+	"merovius.de/go-misc/internal/gen/gentest"
+)
+
+func TestMain(m *testing.M) {
+	gentest.Main(m, Run)
+}
+
+// src declares the types of the tests, with a named and an embedded mutex.
+const src = `package stats
+
+import (
+	"sync"
+	"time"
+)
+
+type Stats struct {
+	mu      sync.RWMutex
+	count   int
+	last    time.Time ` + "`accessor:\"get\"`" + `
+	cache   map[string]int ` + "`accessor:\"-\"`" + `
+	Public  string
+}
+
+type Config struct {
+	sync.RWMutex
+	name string
+	tags []string
+}
+`
+
+func TestGolden(t *testing.T) {
+	dir := gentest.Dir(t, map[string]string{"stats.go": src})
+	gentest.Generate(t, dir, "-type=Stats,Config", "-out=accessors.go")
+	gentest.Golden(t, dir, "accessors.go", "accessors.go.golden")
+	gentest.Vet(t, dir)
+}
+
+func TestErrors(t *testing.T) {
+	dir := gentest.Dir(t, map[string]string{"stats.go": src + "\ntype NoMutex struct{ n int }\n"})
+	gentest.Fail(t, dir, "-type=NoMutex", "-out=accessors.go")
+	gentest.Fail(t, dir, "-type=Missing", "-out=accessors.go")
+	gentest.Fail(t, dir, "-out=accessors.go")
+}
+
+func TestAccessors(t *testing.T) {
+	dir := gentest.Dir(t, map[string]string{"stats.go": src, "accessors_test.go": `package stats
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestAccessors(t *testing.T) {
+	var (
+		s  Stats
+		c  Config
+		wg sync.WaitGroup
+	)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			s.SetCount(i)
+			s.Count()
+			s.Last()
+			c.SetName("c")
+			c.Name()
+		}(i)
+	}
+	wg.Wait()
+	if c.Name() != "c" {
+		t.Errorf("Name() == %q, want \"c\"", c.Name())
+	}
+}
+`})
+	gentest.Generate(t, dir, "-type=Stats,Config", "-out=accessors.go")
+	gentest.Test(t, dir, "-race")
+}
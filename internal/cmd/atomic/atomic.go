@@ -0,0 +1,200 @@
+// Package atomic implements go-atomic. See merovius.de/go-misc/cmd/go-atomic for its
+// documentation.
+package atomic // import "merovius.de/go-misc/internal/cmd/atomic"
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"text/template"
+
+	"merovius.de/go-misc/internal/gen"
+)
+
+var implTemplate = template.Must(template.New("atomic.go").Parse(`
+// This file is automatically generated by merovius.de/go-misc/cmd/go-atomic.
+
+package {{ .Package }}
+
+import "sync/atomic"
+
+{{ range .Types }}
+	{{- if .Backing }}
+		{{ template "int" . }}
+	{{- else }}
+		{{ template "impl" . }}
+	{{- end }}
+{{ end }}
+`))
+
+var _ = template.Must(implTemplate.New("impl").Parse(`
+// {{ .Name }} is an atomic {{ .Type }}. The zero value holds the zero value
+// of {{ .Type }}.
+type {{ .Name }} struct {
+	p atomic.Pointer[{{ .Type }}]
+}
+
+// Load atomically loads the value of a.
+func (a *{{ .Name }}) Load() {{ .Type }} {
+	if p := a.p.Load(); p != nil {
+		return *p
+	}
+	var zero {{ .Type }}
+	return zero
+}
+
+// Store atomically stores v in a.
+func (a *{{ .Name }}) Store(v {{ .Type }}) {
+	a.p.Store(&v)
+}
+
+// Swap atomically stores new in a and returns the previous value.
+func (a *{{ .Name }}) Swap(new {{ .Type }}) (old {{ .Type }}) {
+	if p := a.p.Swap(&new); p != nil {
+		return *p
+	}
+	return old
+}
+{{ if .Compare }}
+// CompareAndSwap atomically stores new in a, if its value is equal to old. It
+// returns whether new was stored.
+func (a *{{ .Name }}) CompareAndSwap(old, new {{ .Type }}) bool {
+	n := &new
+	for {
+		p := a.p.Load()
+		var cur {{ .Type }}
+		if p != nil {
+			cur = *p
+		}
+		if cur != old {
+			return false
+		}
+		if a.p.CompareAndSwap(p, n) {
+			return true
+		}
+	}
+}
+{{ end }}
+`))
+
+var _ = template.Must(implTemplate.New("int").Parse(`
+// {{ .Name }} is an atomic {{ .Type }}. The zero value is 0.
+type {{ .Name }} struct {
+	v atomic.{{ .Backing }}
+}
+
+// Load atomically loads the value of a.
+func (a *{{ .Name }}) Load() {{ .Type }} {
+	return {{ .Type }}(a.v.Load())
+}
+
+// Store atomically stores v in a.
+func (a *{{ .Name }}) Store(v {{ .Type }}) {
+	a.v.Store({{ .Raw }}(v))
+}
+
+// Swap atomically stores new in a and returns the previous value.
+func (a *{{ .Name }}) Swap(new {{ .Type }}) (old {{ .Type }}) {
+	return {{ .Type }}(a.v.Swap({{ .Raw }}(new)))
+}
+
+// CompareAndSwap atomically stores new in a, if its value is equal to old. It
+// returns whether new was stored.
+func (a *{{ .Name }}) CompareAndSwap(old, new {{ .Type }}) bool {
+	return a.v.CompareAndSwap({{ .Raw }}(old), {{ .Raw }}(new))
+}
+
+// Add atomically adds delta to a and returns the new value.
+func (a *{{ .Name }}) Add(delta {{ .Type }}) (new {{ .Type }}) {
+{{- if .Narrow }}
+	for {
+		o := a.v.Load()
+		n := {{ .Type }}(o) + delta
+		if a.v.CompareAndSwap(o, {{ .Raw }}(n)) {
+			return n
+		}
+	}
+{{- else }}
+	return {{ .Type }}(a.v.Add({{ .Raw }}(delta)))
+{{- end }}
+}
+`))
+
+// backing maps the predeclared integer types to the sync/atomic types used to
+// store them. int and uint are stored as uintptr, which has the same size.
+var backing = map[string]string{
+	"int":     "Uintptr",
+	"int8":    "Int32",
+	"int16":   "Int32",
+	"int32":   "Int32",
+	"rune":    "Int32",
+	"int64":   "Int64",
+	"uint":    "Uintptr",
+	"uint8":   "Uint32",
+	"byte":    "Uint32",
+	"uint16":  "Uint32",
+	"uint32":  "Uint32",
+	"uint64":  "Uint64",
+	"uintptr": "Uintptr",
+}
+
+var narrow = map[string]bool{
+	"int8":   true,
+	"int16":  true,
+	"uint8":  true,
+	"byte":   true,
+	"uint16": true,
+}
+
+type pkg struct {
+	Package string
+	Types   []atomicType
+}
+
+type atomicType struct {
+	Name    string
+	Type    string
+	Backing string
+	Narrow  bool
+	Compare bool
+}
+
+// Raw returns the type of the values stored in the backing type.
+func (t atomicType) Raw() string {
+	return gen.Unexported(t.Backing)
+}
+
+var (
+	flags     = flag.NewFlagSet("go-atomic", flag.ContinueOnError)
+	pkgName   = flags.String("package", os.Getenv("GOPACKAGE"), "Package the file should be in")
+	noCompare = flags.Bool("nocompare", false, "Do not generate CompareAndSwap for boxed types")
+	out       = gen.OutputFlags(flags)
+)
+
+// Run runs go-atomic with the command line arguments args, which do not include
+// the program name.
+func Run(args []string) error {
+	if err := gen.ParseFlags(flags, args); err != nil {
+		return err
+	}
+
+	types, err := gen.ParseTypes(flags.Args())
+	if err != nil || flags.NArg() == 0 {
+		return errors.New("Usage: go-atomic [-package=<pkg>] [-nocompare] <name> <type> [<name> <type>]...")
+	}
+	if *pkgName == "" {
+		return errors.New("no package given")
+	}
+
+	p := pkg{Package: *pkgName}
+	for _, t := range types {
+		p.Types = append(p.Types, atomicType{
+			Name:    t.Name,
+			Type:    t.Type,
+			Backing: backing[t.Type],
+			Narrow:  narrow[t.Type],
+			Compare: !*noCompare,
+		})
+	}
+	return out.Write(implTemplate, p)
+}
@@ -0,0 +1,97 @@
+package atomic
+
+import (
+	"testing"
+
+	"merovius.de/go-misc/internal/gen/gentest"
+)
+
+func TestMain(m *testing.M) {
+	gentest.Main(m, Run)
+}
+
+func TestGolden(t *testing.T) {
+	tcs := []struct {
+		name string
+		args []string
+	}{
+		{"default", []string{"Int", "int", "Int8", "int8", "Uint64", "uint64", "String", "string"}},
+		{"nocompare", []string{"-nocompare", "Strings", "[]string", "Rune", "rune"}},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := gentest.Dir(t, nil)
+			gentest.Generate(t, dir, append([]string{"-package=atomic", "-out=atomic.go"}, tc.args...)...)
+			gentest.Golden(t, dir, "atomic.go", tc.name+".go.golden")
+			gentest.Vet(t, dir)
+			gentest.Vet(t, dir, "GOARCH=386")
+		})
+	}
+}
+
+func TestUsage(t *testing.T) {
+	dir := gentest.Dir(t, nil)
+	gentest.Fail(t, dir, "-package=atomic", "-out=atomic.go")
+	gentest.Fail(t, dir, "-package=atomic", "-out=atomic.go", "Int")
+	gentest.Fail(t, dir, "-package=", "-out=atomic.go", "Int", "int")
+}
+
+// atomicTest tests the generated types under concurrent use. It is run with
+// the race detector.
+const atomicTest = `package atomic
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestAtomic(t *testing.T) {
+	var (
+		i  Int
+		i8 Int8
+		s  String
+		wg sync.WaitGroup
+	)
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				i.Add(1)
+				i8.Add(1)
+				for {
+					old := s.Load()
+					if s.CompareAndSwap(old, old+"x") {
+						break
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	// 400 wraps around in an int8.
+	if i.Load() != 400 || i8.Load() != -112 || len(s.Load()) != 400 {
+		t.Errorf("got %d, %d, %d, want 400, -112, 400", i.Load(), i8.Load(), len(s.Load()))
+	}
+	if old := i8.Swap(-1); old != -112 || i8.Load() != -1 {
+		t.Errorf("Swap(-1) == %d, Load() == %d, want -112, -1", old, i8.Load())
+	}
+	if i8.CompareAndSwap(0, 1) || !i8.CompareAndSwap(-1, 1) || i8.Load() != 1 {
+		t.Errorf("CompareAndSwap did not compare the sign-extended value")
+	}
+	var u Uint64
+	if u.Add(^uint64(0)) != ^uint64(0) {
+		t.Errorf("Add(max) == %d", u.Load())
+	}
+	var z String
+	if z.Swap("a") != "" || z.CompareAndSwap("", "b") || !z.CompareAndSwap("a", "b") {
+		t.Errorf("Swap or CompareAndSwap on zero String failed")
+	}
+}
+`
+
+func TestAtomic(t *testing.T) {
+	dir := gentest.Dir(t, map[string]string{"atomic_test.go": atomicTest})
+	gentest.Generate(t, dir, "-package=atomic", "-out=atomic.go", "Int", "int", "Int8", "int8", "Uint64", "uint64", "String", "string")
+	gentest.Test(t, dir, "-race")
+}
@@ -0,0 +1,196 @@
+// Package bitmask implements go-bitmask. See merovius.de/go-misc/cmd/go-bitmask for its
+// documentation.
+package bitmask // import "merovius.de/go-misc/internal/cmd/bitmask"
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"go/token"
+	"os"
+	"strings"
+	"text/template"
+
+	"merovius.de/go-misc/internal/gen"
+)
+
+var implTemplate = template.Must(template.New("bitmask.go").Parse(`
+// This file is automatically generated by merovius.de/go-misc/cmd/go-bitmask.
+
+package {{ .Package }}
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+{{ range .Types }}
+	{{ template "impl" . }}
+{{ end }}
+`))
+
+var _ = template.Must(implTemplate.New("impl").Parse(`
+// {{ .Name }} is a set of flags.
+type {{ .Name }} {{ .Base }}
+
+// Flags of {{ .Name }}.
+const (
+{{- range $i, $f := .Flags }}
+	{{ $.Name }}{{ $f }}{{ if eq $i 0 }} {{ $.Name }} = 1 << iota{{ end }}
+{{- end }}
+)
+
+// {{ .Known }} has the bits of all flags of {{ .Name }} set.
+const {{ .Known }} {{ .Name }} = 1<<{{ len .Flags }} - 1
+
+var {{ .Names }} = [...]string{
+{{- range .Flags }}
+	"{{ . }}",
+{{- end }}
+}
+
+// Has returns whether all flags in f are set in v.
+func (v {{ .Name }}) Has(f {{ .Name }}) bool {
+	return v&f == f
+}
+
+// Set sets the flags in f.
+func (v *{{ .Name }}) Set(f {{ .Name }}) {
+	*v |= f
+}
+
+// Clear clears the flags in f.
+func (v *{{ .Name }}) Clear(f {{ .Name }}) {
+	*v &^= f
+}
+
+// String returns the names of the flags set in v, separated by "|". Bits
+// without a name are included in hexadecimal.
+func (v {{ .Name }}) String() string {
+	if v == 0 {
+		return "0"
+	}
+	var l []string
+	for i, n := range {{ .Names }} {
+		if v&(1<<uint(i)) != 0 {
+			l = append(l, n)
+		}
+	}
+	if r := v &^ {{ .Known }}; r != 0 {
+		l = append(l, "0x"+strconv.FormatUint(uint64(r), 16))
+	}
+	return strings.Join(l, "|")
+}
+
+// MarshalJSON implements json.Marshaler, encoding v as an array of the names
+// of the set flags.
+func (v {{ .Name }}) MarshalJSON() ([]byte, error) {
+	if v&^{{ .Known }} != 0 {
+		return nil, errors.New("{{ .Name }} " + v.String() + " has unknown bits set")
+	}
+	l := []string{}
+	for i, n := range {{ .Names }} {
+		if v&(1<<uint(i)) != 0 {
+			l = append(l, n)
+		}
+	}
+	return json.Marshal(l)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, decoding an array of flag names.
+func (v *{{ .Name }}) UnmarshalJSON(b []byte) error {
+	var l []string
+	if err := json.Unmarshal(b, &l); err != nil {
+		return err
+	}
+	var r {{ .Name }}
+outer:
+	for _, s := range l {
+		for i, n := range {{ .Names }} {
+			if s == n {
+				r |= 1 << uint(i)
+				continue outer
+			}
+		}
+		return errors.New("unknown {{ .Name }} flag " + strconv.Quote(s))
+	}
+	*v = r
+	return nil
+}
+`))
+
+type pkg struct {
+	Package string
+	Types   []bitmask
+}
+
+type bitmask struct {
+	Name  string
+	Base  string
+	Flags []string
+}
+
+// Names returns the name of the variable containing the flag names.
+func (b bitmask) Names() string {
+	return gen.Unexported(b.Name) + "Names"
+}
+
+// Known returns the name of the constant with the bits of all flags set.
+func (b bitmask) Known() string {
+	return gen.Unexported(b.Name) + "Known"
+}
+
+var bits = map[string]int{
+	"uint8":  8,
+	"uint16": 16,
+	"uint32": 32,
+	"uint64": 64,
+}
+
+var (
+	flags   = flag.NewFlagSet("go-bitmask", flag.ContinueOnError)
+	pkgName = flags.String("package", os.Getenv("GOPACKAGE"), "Package the file should be in")
+	base    = flags.String("base", "uint32", "Underlying type of the generated types")
+	out     = gen.OutputFlags(flags)
+)
+
+// Run runs go-bitmask with the command line arguments args, which do not include
+// the program name.
+func Run(args []string) error {
+	if err := gen.ParseFlags(flags, args); err != nil {
+		return err
+	}
+	types, err := gen.ParseTypes(flags.Args())
+	if err != nil || flags.NArg() == 0 {
+		return errors.New("Usage: go-bitmask [-package=<pkg>] [-base=<type>] <type> <flag>[,<flag>...] [<type> <flag>[,<flag>...]]...")
+	}
+	if *pkgName == "" {
+		return errors.New("no package given")
+	}
+	n, ok := bits[*base]
+	if !ok {
+		return fmt.Errorf("invalid base type %q", *base)
+	}
+
+	p := pkg{Package: *pkgName}
+	for _, t := range types {
+		b := bitmask{Name: t.Name, Base: *base, Flags: strings.Split(t.Type, ",")}
+		if len(b.Flags) > n {
+			return fmt.Errorf("%s has %d flags, but %s only has %d bits", b.Name, len(b.Flags), b.Base, n)
+		}
+		seen := make(map[string]bool)
+		for _, f := range b.Flags {
+			if !token.IsIdentifier(b.Name + f) {
+				return fmt.Errorf("invalid flag name %q for %s", f, b.Name)
+			}
+			if seen[f] {
+				return fmt.Errorf("duplicate flag %s for %s", f, b.Name)
+			}
+			seen[f] = true
+		}
+		p.Types = append(p.Types, b)
+	}
+	return out.Write(implTemplate, p)
+}
@@ -0,0 +1,96 @@
+package bitmask
+
+import (
+	"testing"
+
+	"merovius.de/go-misc/internal/gen/gentest"
+)
+
+func TestMain(m *testing.M) {
+	gentest.Main(m, Run)
+}
+
+func TestGolden(t *testing.T) {
+	tcs := []struct {
+		name string
+		args []string
+	}{
+		{"default", []string{"Perm", "Read,Write,Exec", "Opt", "Verbose,DryRun"}},
+		{"uint8", []string{"-base=uint8", "Day", "Mon,Tue,Wed,Thu,Fri,Sat,Sun,Holiday"}},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := gentest.Dir(t, nil)
+			gentest.Generate(t, dir, append([]string{"-package=bitmask", "-out=bitmask.go"}, tc.args...)...)
+			gentest.Golden(t, dir, "bitmask.go", tc.name+".go.golden")
+			gentest.Vet(t, dir)
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	dir := gentest.Dir(t, nil)
+	for _, args := range [][]string{
+		{"Perm"},
+		{"-base=int", "Perm", "Read"},
+		{"-base=uint8", "Perm", "A,B,C,D,E,F,G,H,I"},
+		{"Perm", "Read,Read"},
+		{"Perm", "Read,Write-Only"},
+		{"-package=", "Perm", "Read"},
+	} {
+		gentest.Fail(t, dir, append([]string{"-package=bitmask", "-out=bitmask.go"}, args...)...)
+	}
+}
+
+func TestBitmask(t *testing.T) {
+	dir := gentest.Dir(t, map[string]string{"bitmask_test.go": `package bitmask
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestBitmask(t *testing.T) {
+	var p Perm
+	p.Set(PermRead | PermExec)
+	p.Clear(PermRead)
+	if !p.Has(PermExec) || p.Has(PermRead|PermExec) {
+		t.Errorf("Has reports wrong flags for %v", p)
+	}
+	tcs := []struct {
+		p    Perm
+		want string
+	}{
+		{0, "0"},
+		{PermRead | PermWrite, "Read|Write"},
+		{PermExec | 1<<4, "Exec|0x10"},
+	}
+	for _, tc := range tcs {
+		if got := tc.p.String(); got != tc.want {
+			t.Errorf("String() == %q, want %q", got, tc.want)
+		}
+	}
+
+	b, err := json.Marshal(PermRead | PermExec)
+	if err != nil || string(b) != ` + "`" + `["Read","Exec"]` + "`" + ` {
+		t.Fatalf("Marshal() == %s, %v", b, err)
+	}
+	var q Perm
+	if err := json.Unmarshal(b, &q); err != nil || q != PermRead|PermExec {
+		t.Errorf("Unmarshal() == %v, %v", q, err)
+	}
+	if err := json.Unmarshal([]byte(` + "`" + `["Delete"]` + "`" + `), &q); err == nil {
+		t.Error("Unmarshal succeeded with unknown flag")
+	}
+	if _, err := json.Marshal(Perm(1 << 5)); err == nil {
+		t.Error("Marshal succeeded with unknown bits")
+	}
+	if DayHoliday != 1<<7 {
+		t.Errorf("DayHoliday == %d, want 128", DayHoliday)
+	}
+}
+`})
+	gentest.Generate(t, dir, "-package=bitmask", "-out=bitmask.go", "Perm", "Read,Write,Exec")
+	gentest.Generate(t, dir, "-package=bitmask", "-base=uint8", "-out=day.go", "Day", "Mon,Tue,Wed,Thu,Fri,Sat,Sun,Holiday")
+	gentest.Test(t, dir)
+}
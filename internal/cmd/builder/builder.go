@@ -0,0 +1,153 @@
+// Package builder implements go-builder. See merovius.de/go-misc/cmd/go-builder for its
+// documentation.
+package builder // import "merovius.de/go-misc/internal/cmd/builder"
+
+import (
+	"errors"
+	"flag"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"merovius.de/go-misc/internal/gen"
+)
+
+var implTemplate = template.Must(template.New("builder.go").Parse(`
+// This file is automatically generated by merovius.de/go-misc/cmd/go-builder.
+
+package {{ .Package }}
+
+{{ if .Imports -}}
+import (
+{{- range .Imports }}
+	{{ . }}
+{{- end }}
+)
+{{- end }}
+
+{{ range .Types }}
+	{{ template "impl" . }}
+{{ end }}
+`))
+
+var _ = template.Must(implTemplate.New("impl").Parse(`
+// {{ .Name }}Builder is a fluent builder for {{ .Name }} values.
+type {{ .Name }}Builder struct {
+	v {{ .Name }}
+{{- range .Fields }}{{ if .Required }}
+	set{{ .Setter }} bool
+{{- end }}{{ end }}
+}
+
+// New{{ .Name }}Builder returns a new {{ .Name }}Builder.
+func New{{ .Name }}Builder() *{{ .Name }}Builder {
+	return new({{ .Name }}Builder)
+}
+{{ range .Fields }}
+// With{{ .Setter }} sets the field {{ .Name }}{{ if .Required }}, which is required{{ end }}.
+func (b *{{ $.Name }}Builder) With{{ .Setter }}(v {{ .Type }}) *{{ $.Name }}Builder {
+	b.v.{{ .Name }} = v
+{{- if .Required }}
+	b.set{{ .Setter }} = true
+{{- end }}
+	return b
+}
+{{ end }}
+// Build returns the built {{ .Name }}. It returns an error, if a required
+// field was not set.
+func (b *{{ .Name }}Builder) Build() ({{ .Name }}, error) {
+{{- range .Fields }}{{ if .Required }}
+	if !b.set{{ .Setter }} {
+		return {{ $.Name }}{}, errors.New("required field {{ $.Name }}.{{ .Name }} not set")
+	}
+{{- end }}{{ end }}
+	return b.v, nil
+}
+`))
+
+type pkg struct {
+	Package string
+	Imports []string
+	Types   []*builder
+}
+
+type builder struct {
+	Name   string
+	Fields []field
+}
+
+type field struct {
+	Name     string
+	Setter   string
+	Type     string
+	Required bool
+}
+
+// collect returns the builder for the struct type name in p.
+func collect(p *gen.Package, im *gen.Imports, name string) (*builder, bool, error) {
+	_, s, err := p.Struct(name)
+	if err != nil {
+		return nil, false, err
+	}
+
+	b := &builder{Name: name}
+	var required bool
+	for i := 0; i < s.NumFields(); i++ {
+		f := s.Field(i)
+		tag := reflect.StructTag(s.Tag(i)).Get("builder")
+		if tag == "-" || f.Name() == "_" {
+			continue
+		}
+		fd := field{
+			Name:     f.Name(),
+			Setter:   gen.Exported(f.Name()),
+			Type:     im.TypeString(f.Type()),
+			Required: tag == "required",
+		}
+		required = required || fd.Required
+		b.Fields = append(b.Fields, fd)
+	}
+	return b, required, nil
+}
+
+var (
+	flags     = flag.NewFlagSet("go-builder", flag.ContinueOnError)
+	typeNames = flags.String("type", "", "Comma-separated list of struct type names")
+	out       = gen.OutputFlags(flags)
+)
+
+// Run runs go-builder with the command line arguments args, which do not include
+// the program name.
+func Run(args []string) error {
+	if err := gen.ParseFlags(flags, args); err != nil {
+		return err
+	}
+	if *typeNames == "" || flags.NArg() > 1 {
+		return errors.New("Usage: go-builder -type=<type>[,<type>...] [<dir>]")
+	}
+	dir := "."
+	if flags.NArg() == 1 {
+		dir = flags.Arg(0)
+	}
+
+	lp, err := gen.LoadPackage(dir)
+	if err != nil {
+		return err
+	}
+
+	im := gen.NewImports(lp.Types)
+	p := pkg{Package: lp.Types.Name()}
+	for _, name := range strings.Split(*typeNames, ",") {
+		b, required, err := collect(lp, im, name)
+		if err != nil {
+			return err
+		}
+		if required {
+			im.Add("errors", "errors")
+		}
+		p.Types = append(p.Types, b)
+	}
+	p.Imports = im.List()
+
+	return out.Write(implTemplate, p)
+}
@@ -0,0 +1,58 @@
+package builder
+
+import (
+	"testing"
+
+	"merovius.de/go-misc/internal/gen/gentest"
+)
+
+func TestMain(m *testing.M) {
+	gentest.Main(m, Run)
+}
+
+// src declares the types of the tests, with required, skipped, unexported
+// and imported fields.
+const src = `package server
+
+import "time"
+
+type Server struct {
+	Addr    string ` + "`builder:\"required\"`" + `
+	Timeout time.Duration
+	retries int
+	cache   map[string][]byte ` + "`builder:\"-\"`" + `
+}
+
+type Client struct {
+	Name string
+}
+`
+
+func TestGolden(t *testing.T) {
+	dir := gentest.Dir(t, map[string]string{"server.go": src})
+	gentest.Generate(t, dir, "-type=Server,Client", "-out=builder.go")
+	gentest.Golden(t, dir, "builder.go", "builder.go.golden")
+	gentest.Vet(t, dir)
+}
+
+func TestBuilder(t *testing.T) {
+	dir := gentest.Dir(t, map[string]string{"server.go": src, "builder_test.go": `package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuild(t *testing.T) {
+	if _, err := NewServerBuilder().WithTimeout(time.Second).Build(); err == nil {
+		t.Errorf("Build() succeeded without required Addr")
+	}
+	s, err := NewServerBuilder().WithAddr(":80").WithRetries(3).Build()
+	if err != nil || s.Addr != ":80" || s.retries != 3 {
+		t.Errorf("Build() == %+v, %v, want Addr :80 and 3 retries", s, err)
+	}
+}
+`})
+	gentest.Generate(t, dir, "-type=Server,Client", "-out=builder.go")
+	gentest.Test(t, dir)
+}
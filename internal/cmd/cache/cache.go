@@ -0,0 +1,1205 @@
+// Package cache implements go-cache. See merovius.de/go-misc/cmd/go-cache for its
+// documentation.
+package cache // import "merovius.de/go-misc/internal/cmd/cache"
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"merovius.de/go-misc/internal/gen"
+)
+
+var implTemplate = template.Must(template.New("cache.go").Parse(`
+// This file is automatically generated by merovius.de/go-misc/cmd/go-cache.
+
+package {{ .Package }}
+
+import (
+{{- if .Persist }}
+	"bufio"
+	"bytes"
+{{- end }}
+	"container/list"
+{{- if .Warm }}
+	"context"
+{{- end }}
+{{- if .Persist }}
+	"encoding/json"
+{{- end }}
+	"errors"
+{{- if or .Persist .Warm }}
+	"fmt"
+{{- end }}
+{{- if .Persist }}
+	"io"
+{{- end }}
+{{- if .Jitter }}
+	"math/rand"
+{{- end }}
+{{- if .Persist }}
+	"os"
+{{- end }}
+{{- if and .Persist (eq .Policy "ttl") }}
+	"sort"
+{{- end }}
+	"sync"
+{{- if or (eq .Policy "ttl") .Persist .Coalesce .ErrorTTL .Stats }}
+	"time"
+{{- end }}
+{{- if .Store }}
+
+	"merovius.de/go-misc/cache"
+{{- end }}
+)
+
+{{ range .Caches }}
+	{{ template "impl" . }}
+{{ end }}
+`))
+
+var _ = template.Must(implTemplate.New("impl").Parse(`
+// {{ .Name }} caches values of type {{ .Value }} by keys of type {{ .Key }}, loading
+// missing values with a loader function.
+{{- if and (eq .Policy "lru") .Cost }} Values are evicted by least recent use,
+// once their total cost exceeds the size of the cache.
+{{- else if eq .Policy "lru" }} Values are evicted by least recent use.
+{{- else if eq .Policy "2q" }} Values are evicted according to the 2Q
+// algorithm.
+{{- else }} Values expire after a fixed
+// duration.
+{{- end }} It is safe for concurrent use.
+type {{ .Name }} struct {
+	load  func({{ .Key }}) ({{ .Value }}, error)
+	hooks {{ .Name }}Hooks
+
+	mu    sync.Mutex
+	calls map[{{ .Key }}]*{{ .Call }}
+	items map[{{ .Key }}]*list.Element
+{{- if eq .Policy "lru" }}
+	size  int
+	l     *list.List
+{{- if .Cost }}
+	// cost is the total cost of the cached values.
+	cost int
+{{- end }}
+{{- else if eq .Policy "2q" }}
+	size  int
+	// in is the FIFO queue of new entries, out the FIFO queue of keys
+	// recently evicted from in and main the LRU list of entries promoted
+	// from out.
+	in, out, main *list.List
+{{- else }}
+	ttl   time.Duration
+	l     *list.List
+{{- end }}
+{{- if .Persist }}
+	st    {{ .Name }}Store
+{{- end }}
+{{- if .Stats }}
+	stats {{ .Name }}Stats
+{{- end }}
+{{- if .Store }}
+	backend cache.Store[{{ .Key }}, {{ .Value }}]
+{{- end }}
+}
+
+// {{ .Name }}Hooks are called on events of a {{ .Name }}, e.g. to collect
+// metrics. Nil funcs are ignored. Evict is called with the cache locked and
+// must not call its methods.
+type {{ .Name }}Hooks struct {
+	Hit   func({{ .Key }})
+	Miss  func({{ .Key }})
+	Evict func({{ .Key }})
+{{- if .Stats }}
+	// Load is called after every call to the loader, with its duration and
+	// error, e.g. to record a histogram of the durations.
+	Load func({{ .Key }}, time.Duration, error)
+{{- end }}
+{{- if .MaxStale }}
+	// Stale is called when a value is returned after it expired, because
+	// the loader failed with the given error.
+	Stale func({{ .Key }}, error)
+{{- end }}
+{{- if or .Persist .Store }}
+	// StoreError is called with errors of the stores of the cache, which
+	// can not be returned otherwise. It may be called with the cache locked
+	// and must not call its methods.
+	StoreError func(error)
+{{- end }}
+}
+
+// {{ .Entry }} is an element of the lists of a {{ .Name }}.
+type {{ .Entry }} struct {
+	k {{ .Key }}
+	v {{ .Value }}
+{{- if eq .Policy "2q" }}
+	l *list.List
+{{- else if eq .Policy "ttl" }}
+	exp time.Time
+{{- end }}
+{{- if .Cost }}
+	cost int
+{{- end }}
+}
+
+{{ if or .Coalesce .ErrorTTL -}}
+// {{ .Call }} is a call to the loader of a {{ .Name }}, which is in flight or
+// finished recently.
+{{- else -}}
+// {{ .Call }} is an in-flight call to the loader of a {{ .Name }}.
+{{- end }}
+type {{ .Call }} struct {
+	wg  sync.WaitGroup
+	v   {{ .Value }}
+	err error
+{{- if or .Coalesce .ErrorTTL }}
+	done bool
+{{- end }}
+{{- if .MaxStale }}
+	// stale says whether v has expired and is returned, because the loader
+	// failed.
+	stale bool
+{{- end }}
+}
+
+// New{{ .Name }} returns a new {{ .Name }}, loading missing values with load.
+{{- if and (eq .Policy "lru") .Cost }} The
+// total cost of the cached values, as returned by {{ .Cost }}, is at most size, if
+// it is positive. A value costing more than size is not cached.
+{{- else if eq .Policy "lru" }} At
+// most size values are cached, if size is positive.
+{{- else if eq .Policy "2q" }} At
+// most size values are cached. It panics, if size is not positive.
+{{- else }} Values
+// expire after ttl.
+{{- end }} hooks may be nil.
+func New{{ .Name }}(load func({{ .Key }}) ({{ .Value }}, error), {{ if eq .Policy "ttl" }}ttl time.Duration{{ else }}size int{{ end }}, hooks *{{ .Name }}Hooks) *{{ .Name }} {
+{{- if eq .Policy "2q" }}
+	if size <= 0 {
+		panic("size of {{ .Name }} must be positive")
+	}
+{{- end }}
+	c := &{{ .Name }}{
+		load:  load,
+		calls: make(map[{{ .Key }}]*{{ .Call }}),
+		items: make(map[{{ .Key }}]*list.Element),
+{{- if eq .Policy "lru" }}
+		size:  size,
+		l:     list.New(),
+{{- else if eq .Policy "2q" }}
+		size:  size,
+		in:    list.New(),
+		out:   list.New(),
+		main:  list.New(),
+{{- else }}
+		ttl:   ttl,
+		l:     list.New(),
+{{- end }}
+	}
+	if hooks != nil {
+		c.hooks = *hooks
+	}
+{{- if .Stats }}
+	c.load = c.measured(load)
+{{- end }}
+	return c
+}
+
+// Get returns the value for k, calling the loader if it is not cached.
+// Concurrent calls for the same missing key share a single call to the loader.
+{{- if .ErrorTTL }}
+// Errors of the loader are returned and cached for a fixed duration, so that a
+// failing loader is not called on every miss.
+{{- else }}
+// Errors of the loader are returned, but not cached.
+{{- end }}
+{{- if .Coalesce }}
+// Misses shortly after a call to the loader finished share its result
+{{- if not .ErrorTTL }},
+// including an error
+{{- end }}.
+{{- end }}
+func (c *{{ .Name }}) Get(k {{ .Key }}) ({{ .Value }}, error) {
+	c.mu.Lock()
+	if v, ok := c.lookup(k); ok {
+{{- if .Stats }}
+		c.stats.Hits++
+{{- end }}
+		c.mu.Unlock()
+		if c.hooks.Hit != nil {
+			c.hooks.Hit(k)
+		}
+		return v, nil
+	}
+	if call, ok := c.calls[k]; ok {
+		c.mu.Unlock()
+		call.wg.Wait()
+		return call.v, call.err
+	}
+	call := new({{ .Call }})
+	call.wg.Add(1)
+	c.calls[k] = call
+{{- if .Stats }}
+	c.stats.Misses++
+{{- end }}
+	c.mu.Unlock()
+
+	if c.hooks.Miss != nil {
+		c.hooks.Miss(k)
+	}
+	loaded := false
+	defer func() {
+		if !loaded {
+			call.err = errors.New("loader of {{ .Name }} panicked")
+		}
+		c.mu.Lock()
+{{- if .Coalesce }}
+		// Misses shortly after share the result of the call.
+		call.done = true
+		d := {{ .Coalesce }}
+{{- if .ErrorTTL }}
+		if call.err != nil {
+			d = {{ .ErrorTTL }}
+		}
+{{- end }}
+		time.AfterFunc(d, c.forget(k, call))
+{{- else if .ErrorTTL }}
+		call.done = true
+		if call.err == nil {
+			delete(c.calls, k)
+		} else {
+			// Misses share the error for a while.
+			time.AfterFunc({{ .ErrorTTL }}, c.forget(k, call))
+		}
+{{- else }}
+		delete(c.calls, k)
+{{- end }}
+		if call.err == nil{{ if .MaxStale }} && !call.stale{{ end }} {
+			c.store(k, call.v)
+		}
+		c.mu.Unlock()
+		call.wg.Done()
+	}()
+{{- if .Store }}
+	call.v, call.err = c.fetch(k)
+{{- else }}
+	call.v, call.err = c.load(k)
+{{- end }}
+{{- if .MaxStale }}
+	if call.err != nil {
+		c.mu.Lock()
+		v, ok := c.stale(k)
+		c.mu.Unlock()
+		if ok {
+			if c.hooks.Stale != nil {
+				c.hooks.Stale(k, call.err)
+			}
+			call.v, call.err, call.stale = v, nil, true
+		}
+	}
+{{- end }}
+	loaded = true
+	return call.v, call.err
+}
+
+{{- if .Store }}
+// Invalidate removes the value for k from the cache and from its store, if
+// any. It does not affect calls to the loader in progress.
+{{- else }}
+// Invalidate removes the value for k from the cache. It does not affect
+// calls to the loader in progress.
+{{- end }}
+func (c *{{ .Name }}) Invalidate(k {{ .Key }}) {
+{{- if .Store }}
+	c.mu.Lock()
+	if e, ok := c.items[k]; ok {
+		c.remove(e)
+	}
+	{{- template "dropCall" . }}
+	c.mu.Unlock()
+	if c.backend != nil {
+		if err := c.backend.Delete(k); err != nil {
+			c.storeError(err)
+		}
+	}
+{{- else }}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[k]; ok {
+		c.remove(e)
+	}
+	{{- template "dropCall" . }}
+{{- end }}
+}
+
+{{- if .Stats }}
+// {{ .Name }}Stats are statistics of a {{ .Name }}.
+type {{ .Name }}Stats struct {
+	// Hits is the number of calls to Get, which returned a cached value,
+	// and Misses the number of those, which had to load it. Calls waiting
+	// for a value loaded by another one are not counted.
+	Hits   uint64
+	Misses uint64
+	// Evictions is the number of values evicted from the cache, other than
+	// by Invalidate.
+	Evictions uint64
+	// Loads is the number of finished calls to the loader, LoadErrors the
+	// number of those, which failed or panicked, and LoadTime their total
+	// duration.
+	Loads      uint64
+	LoadErrors uint64
+	LoadTime   time.Duration
+}
+
+// MeanLoadTime returns the mean duration of the calls to the loader, or 0, if
+// there were none.
+func (s {{ .Name }}Stats) MeanLoadTime() time.Duration {
+	if s.Loads == 0 {
+		return 0
+	}
+	return s.LoadTime / time.Duration(s.Loads)
+}
+
+// Stats returns statistics of c.
+func (c *{{ .Name }}) Stats() {{ .Name }}Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// measured returns load, recording statistics of its calls in c.
+func (c *{{ .Name }}) measured(load func({{ .Key }}) ({{ .Value }}, error)) func({{ .Key }}) ({{ .Value }}, error) {
+	return func(k {{ .Key }}) (v {{ .Value }}, err error) {
+		start := time.Now()
+		done := false
+		defer func() {
+			if !done {
+				err = errors.New("loader of {{ .Name }} panicked")
+			}
+			d := time.Since(start)
+			c.mu.Lock()
+			c.stats.Loads++
+			c.stats.LoadTime += d
+			if err != nil {
+				c.stats.LoadErrors++
+			}
+			c.mu.Unlock()
+			if c.hooks.Load != nil {
+				c.hooks.Load(k, d, err)
+			}
+		}()
+		v, err = load(k)
+		done = true
+		return v, err
+	}
+}
+
+{{ end }}
+{{- if .Warm }}
+// {{ .Name }}WarmError is the error of loading a key in Warm.
+type {{ .Name }}WarmError struct {
+	Key {{ .Key }}
+	Err error
+}
+
+func (e *{{ .Name }}WarmError) Error() string {
+	return fmt.Sprintf("warming %v: %v", e.Key, e.Err)
+}
+
+func (e *{{ .Name }}WarmError) Unwrap() error {
+	return e.Err
+}
+
+// Warm loads the values for keys into c, calling the loader for up to n keys
+// concurrently, e.g. to preload values when deploying. keys can be any
+// iterator, like one returned by slices.Values or maps.Keys. It stops
+// iterating, once ctx is done. The returned error joins a *{{ .Name }}WarmError
+// for every key the loader failed for and the error of ctx, if keys were
+// skipped because of it.
+func (c *{{ .Name }}) Warm(ctx context.Context, keys func(yield func({{ .Key }}) bool), n int) error {
+	if n < 1 {
+		n = 1
+	}
+	var (
+		wg      sync.WaitGroup
+		sem     = make(chan struct{}, n)
+		mu      sync.Mutex
+		errs    []error
+		skipped bool
+	)
+	keys(func(k {{ .Key }}) bool {
+		if ctx.Err() != nil {
+			skipped = true
+			return false
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			skipped = true
+			return false
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if _, err := c.Get(k); err != nil {
+				mu.Lock()
+				errs = append(errs, &{{ .Name }}WarmError{k, err})
+				mu.Unlock()
+			}
+		}()
+		return true
+	})
+	wg.Wait()
+	if skipped {
+		errs = append(errs, ctx.Err())
+	}
+	return errors.Join(errs...)
+}
+
+// WarmValues stores the given values in c, without calling the loader, e.g.
+// to preload values read in bulk. values can be any iterator, like one
+// returned by maps.All.
+func (c *{{ .Name }}) WarmValues(values func(yield func({{ .Key }}, {{ .Value }}) bool)) {
+	values(func(k {{ .Key }}, v {{ .Value }}) bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.store(k, v)
+		return true
+	})
+}
+
+{{ end }}
+// Len returns the number of cached values.
+func (c *{{ .Name }}) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+{{- if eq .Policy "2q" }}
+	return c.in.Len() + c.main.Len()
+{{- else }}
+	return c.l.Len()
+{{- end }}
+}
+
+func (c *{{ .Name }}) remove(e *list.Element) {
+	en := e.Value.(*{{ .Entry }})
+	delete(c.items, en.k)
+{{- if .Persist }}
+	c.deleted(en.k)
+{{- end }}
+{{- if .Cost }}
+	c.cost -= en.cost
+{{- end }}
+{{- if eq .Policy "2q" }}
+	en.l.Remove(e)
+{{- else }}
+	c.l.Remove(e)
+{{- end }}
+}
+
+func (c *{{ .Name }}) evict(e *list.Element) {
+	c.remove(e)
+{{- if .Stats }}
+	c.stats.Evictions++
+{{- end }}
+	if c.hooks.Evict != nil {
+		c.hooks.Evict(e.Value.(*{{ .Entry }}).k)
+	}
+}
+{{- if or .Coalesce .ErrorTTL }}
+
+// forget returns a func removing the finished call for k, unless it has been
+// replaced since.
+func (c *{{ .Name }}) forget(k {{ .Key }}, call *{{ .Call }}) func() {
+	return func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.calls[k] == call {
+			delete(c.calls, k)
+		}
+	}
+}
+{{- end }}
+{{ if eq .Policy "lru" }}
+func (c *{{ .Name }}) lookup(k {{ .Key }}) (v {{ .Value }}, ok bool) {
+	e, ok := c.items[k]
+	if !ok {
+		return v, false
+	}
+	c.l.MoveToFront(e)
+	return e.Value.(*{{ .Entry }}).v, true
+}
+
+func (c *{{ .Name }}) store(k {{ .Key }}, v {{ .Value }}) {
+{{- if .Cost }}
+	cost := {{ .Cost }}(v)
+	if c.size > 0 && cost > c.size {
+		// v would evict all other values and then itself, so it is not
+		// cached, but replaces an old value for k.
+		if e, ok := c.items[k]; ok {
+			c.remove(e)
+		}
+		return
+	}
+{{- end }}
+{{- if .Persist }}
+	c.stored(k, v, time.Time{})
+{{- end }}
+{{- if .Cost }}
+	if e, ok := c.items[k]; ok {
+		en := e.Value.(*{{ .Entry }})
+		c.cost += cost - en.cost
+		en.v, en.cost = v, cost
+		c.l.MoveToFront(e)
+	} else {
+		c.items[k] = c.l.PushFront(&{{ .Entry }}{k: k, v: v, cost: cost})
+		c.cost += cost
+	}
+	for c.size > 0 && c.cost > c.size {
+		c.evict(c.l.Back())
+	}
+{{- else }}
+	if e, ok := c.items[k]; ok {
+		e.Value.(*{{ .Entry }}).v = v
+		c.l.MoveToFront(e)
+		return
+	}
+	c.items[k] = c.l.PushFront(&{{ .Entry }}{k: k, v: v})
+	if c.size > 0 && c.l.Len() > c.size {
+		c.evict(c.l.Back())
+	}
+{{- end }}
+}
+{{ else if eq .Policy "2q" }}
+func (c *{{ .Name }}) lookup(k {{ .Key }}) (v {{ .Value }}, ok bool) {
+	e, ok := c.items[k]
+	if !ok {
+		return v, false
+	}
+	en := e.Value.(*{{ .Entry }})
+	switch en.l {
+	case c.main:
+		c.main.MoveToFront(e)
+	case c.out:
+		return v, false
+	}
+	return en.v, true
+}
+
+func (c *{{ .Name }}) store(k {{ .Key }}, v {{ .Value }}) {
+{{- if .Persist }}
+	c.stored(k, v, time.Time{})
+{{- end }}
+	if e, ok := c.items[k]; ok {
+		en := e.Value.(*{{ .Entry }})
+		if en.l != c.out {
+			en.v = v
+			return
+		}
+		c.remove(e)
+		c.items[k] = c.main.PushFront(&{{ .Entry }}{k: k, v: v, l: c.main})
+	} else {
+		c.items[k] = c.in.PushFront(&{{ .Entry }}{k: k, v: v, l: c.in})
+	}
+	for c.in.Len()+c.main.Len() > c.size {
+		if c.in.Len() > c.size/4 || c.main.Len() == 0 {
+			// Demote the oldest new entry to a key in out.
+			e := c.in.Back()
+			c.evict(e)
+			en := e.Value.(*{{ .Entry }})
+			var zero {{ .Value }}
+			en.v, en.l = zero, c.out
+			c.items[en.k] = c.out.PushFront(en)
+			if c.out.Len() > c.size/2 {
+				c.remove(c.out.Back())
+			}
+		} else {
+			c.evict(c.main.Back())
+		}
+	}
+}
+{{ else }}
+func (c *{{ .Name }}) lookup(k {{ .Key }}) (v {{ .Value }}, ok bool) {
+	e, ok := c.items[k]
+	if !ok {
+		return v, false
+	}
+	en := e.Value.(*{{ .Entry }})
+	if !time.Now().Before(en.exp) {
+{{- if .MaxStale }}
+		// Stale entries are kept as a fallback, in case the loader fails.
+		if !time.Now().Before(en.exp.Add({{ .MaxStale }})) {
+			c.evict(e)
+		}
+{{- else }}
+		c.evict(e)
+{{- end }}
+		return v, false
+	}
+	return en.v, true
+}
+
+func (c *{{ .Name }}) store(k {{ .Key }}, v {{ .Value }}) {
+	if e, ok := c.items[k]; ok {
+		c.remove(e)
+	}
+	now := time.Now()
+{{- if .Jitter }}
+	exp := now.Add(c.lifetime())
+	// With jitter, entries can expire before entries stored earlier. They are
+	// still appended, to store in constant time, so the sweeps below remove
+	// them up to {{ .Jitter }} of the ttl late. lookup never returns them.
+	c.items[k] = c.l.PushBack(&{{ .Entry }}{k: k, v: v, exp: exp})
+{{- if .Persist }}
+	c.stored(k, v, exp)
+{{- end }}
+{{- else }}
+	c.items[k] = c.l.PushBack(&{{ .Entry }}{k: k, v: v, exp: now.Add(c.ttl)})
+{{- if .Persist }}
+	c.stored(k, v, now.Add(c.ttl))
+{{- end }}
+{{- end }}
+{{- if .MaxStale }}
+{{- if .Jitter }}
+	// Entries are ordered by expiry, up to jitter, so entries which are too
+	// stale are at the front.
+{{- else }}
+	// Entries are ordered by expiry, so entries which are too stale are at
+	// the front.
+{{- end }}
+	for e := c.l.Front(); e != nil && !now.Before(e.Value.(*{{ .Entry }}).exp.Add({{ .MaxStale }})); e = c.l.Front() {
+		c.evict(e)
+	}
+}
+
+// stale returns the value for k, if it has expired less than {{ .MaxStaleDoc }} ago.
+func (c *{{ .Name }}) stale(k {{ .Key }}) (v {{ .Value }}, ok bool) {
+	e, ok := c.items[k]
+	if !ok {
+		return v, false
+	}
+	en := e.Value.(*{{ .Entry }})
+	if !time.Now().Before(en.exp.Add({{ .MaxStale }})) {
+		return v, false
+	}
+	return en.v, true
+}
+{{- else }}
+{{- if .Jitter }}
+	// Entries are ordered by expiry, up to jitter, so expired entries are at
+	// the front.
+{{- else }}
+	// Entries are ordered by expiry, so expired entries are at the front.
+{{- end }}
+	for e := c.l.Front(); e != nil && !now.Before(e.Value.(*{{ .Entry }}).exp); e = c.l.Front() {
+		c.evict(e)
+	}
+}
+{{- end }}
+{{- if .Jitter }}
+
+// lifetime returns the duration an entry stored now is cached for: the ttl of
+// c, reduced by a random fraction of up to {{ .Jitter }}, so that entries stored
+// at the same time do not all expire at once.
+func (c *{{ .Name }}) lifetime() time.Duration {
+	return c.ttl - time.Duration(rand.Float64()*{{ .Jitter }}*float64(c.ttl))
+}
+{{- end }}
+{{- if .Janitor }}
+
+// {{ .Name }}Janitor removes expired values from a {{ .Name }} in the
+// background. See StartJanitor.
+type {{ .Name }}Janitor struct {
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// StartJanitor starts a goroutine removing the expired values from c every
+// interval, so that they do not use memory until they are accessed again. It
+// runs until Stop is called on the returned {{ .Name }}Janitor. It panics, if
+// interval is not positive.
+func (c *{{ .Name }}) StartJanitor(interval time.Duration) *{{ .Name }}Janitor {
+	if interval <= 0 {
+		panic("interval of {{ .Name }}Janitor must be positive")
+	}
+	j := &{{ .Name }}Janitor{stop: make(chan struct{}), done: make(chan struct{})}
+	go func() {
+		defer close(j.done)
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				c.expire()
+			case <-j.stop:
+				return
+			}
+		}
+	}()
+	return j
+}
+
+// Stop stops j and waits for its goroutine to return. It can be called more
+// than once.
+func (j *{{ .Name }}Janitor) Stop() {
+	j.once.Do(func() { close(j.stop) })
+	<-j.done
+}
+
+// expire evicts the expired values from c.
+func (c *{{ .Name }}) expire() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	for e := c.l.Front(); e != nil && !now.Before(e.Value.(*{{ .Entry }}).exp{{ if .MaxStale }}.Add({{ .MaxStale }}){{ end }}); e = c.l.Front() {
+		c.evict(e)
+	}
+}
+{{- end }}
+{{ end }}
+{{- if or .Persist .Store }}
+func (c *{{ .Name }}) storeError(err error) {
+	if c.hooks.StoreError != nil {
+		c.hooks.StoreError(err)
+	}
+}
+{{ end }}
+{{- if .Store }}
+	{{ template "store" . }}
+{{- end }}
+{{- if .Persist }}
+	{{ template "persist" . }}
+{{- end }}
+`))
+
+var _ = template.Must(implTemplate.New("dropCall").Parse(`
+{{- if or .Coalesce .ErrorTTL }}
+	if call, ok := c.calls[k]; ok && call.done {
+		delete(c.calls, k)
+	}
+{{- end -}}
+`))
+
+var _ = template.Must(implTemplate.New("store").Parse(`
+// SetStore sets the store c looks up missing values in, before calling its
+// loader. Loaded values are stored in s
+{{- if eq .Policy "ttl" }}, expiring after the ttl of c
+{{- end }}, so that it can be
+// shared with other processes. If looking up a value in s fails, the loader is
+// called. SetStore must be called before c is used.
+func (c *{{ .Name }}) SetStore(s cache.Store[{{ .Key }}, {{ .Value }}]) {
+	c.backend = s
+}
+
+// fetch returns the value for k from the store of c, if any, or else calls the
+// loader and stores its result.
+func (c *{{ .Name }}) fetch(k {{ .Key }}) ({{ .Value }}, error) {
+	if c.backend == nil {
+		return c.load(k)
+	}
+	if v, ok, err := c.backend.Get(k); err != nil {
+		c.storeError(err)
+	} else if ok {
+		return v, nil
+	}
+	v, err := c.load(k)
+	if err == nil {
+		if err := c.backend.Set(k, v, {{ if .Jitter }}c.lifetime(){{ else if eq .Policy "ttl" }}c.ttl{{ else }}0{{ end }}); err != nil {
+			c.storeError(err)
+		}
+	}
+	return v, err
+}
+`))
+
+var _ = template.Must(implTemplate.New("persist").Parse(`
+// {{ .Name }}Store persists the values of a {{ .Name }}, so that they survive
+// restarts of the program. See Persist.
+type {{ .Name }}Store interface {
+	// Load calls f for every stored value, with the time it expires, which
+	// is zero if it does not.
+	Load(f func(k {{ .Key }}, v {{ .Value }}, exp time.Time)) error
+	// Store stores the value v for k, expiring at exp.
+	Store(k {{ .Key }}, v {{ .Value }}, exp time.Time) error
+	// Delete deletes the value for k.
+	Delete(k {{ .Key }}) error
+}
+
+// Persist loads the values stored in s into c and then keeps s up to date:
+// Values are stored in s when they are cached and deleted when they are
+// evicted or invalidated. Values which have expired are not loaded.
+{{- if eq .Policy "ttl" }} Values
+// expire at the time they were stored with, but at most after the ttl of c.
+{{- end }}
+// Persist must be called before c is used and at most once. The methods of s
+// are called with c locked, so they should be fast, like those of
+// {{ .Name }}File.
+func (c *{{ .Name }}) Persist(s {{ .Name }}Store) error {
+	type record struct {
+		k   {{ .Key }}
+		v   {{ .Value }}
+		exp time.Time
+	}
+	var recs []record
+	now := time.Now()
+	err := s.Load(func(k {{ .Key }}, v {{ .Value }}, exp time.Time) {
+		if exp.IsZero() || now.Before(exp) {
+			recs = append(recs, record{k, v, exp})
+		}
+	})
+	if err != nil {
+		return err
+	}
+{{- if eq .Policy "ttl" }}
+	// Entries must be ordered by expiry.
+	for i, r := range recs {
+		if max := now.Add(c.ttl); r.exp.IsZero() || r.exp.After(max) {
+			recs[i].exp = max
+		}
+	}
+	sort.SliceStable(recs, func(i, j int) bool {
+		return recs[i].exp.Before(recs[j].exp)
+	})
+{{- end }}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, r := range recs {
+{{- if eq .Policy "ttl" }}
+		if e, ok := c.items[r.k]; ok {
+			c.remove(e)
+		}
+		c.items[r.k] = c.l.PushBack(&{{ .Entry }}{k: r.k, v: r.v, exp: r.exp})
+{{- else }}
+		c.store(r.k, r.v)
+{{- end }}
+	}
+	c.st = s
+	return nil
+}
+
+// stored stores v for k in the {{ .Name }}Store of c, if any.
+func (c *{{ .Name }}) stored(k {{ .Key }}, v {{ .Value }}, exp time.Time) {
+	if c.st == nil {
+		return
+	}
+	if err := c.st.Store(k, v, exp); err != nil {
+		c.storeError(err)
+	}
+}
+
+// deleted deletes the value for k from the {{ .Name }}Store of c, if any.
+func (c *{{ .Name }}) deleted(k {{ .Key }}) {
+	if c.st == nil {
+		return
+	}
+	if err := c.st.Delete(k); err != nil {
+		c.storeError(err)
+	}
+}
+
+// {{ .Name }}File is a {{ .Name }}Store appending the values to a file, as
+// lines of JSON. Keys and values must thus be encodable as JSON. It is safe for
+// concurrent use.
+type {{ .Name }}File struct {
+	mu   sync.Mutex
+	f    *os.File
+	recs []{{ .Record }}
+}
+
+// {{ .Record }} is a line of a {{ .Name }}File.
+type {{ .Record }} struct {
+	K       {{ .Key }}   ` + "`" + `json:"k"` + "`" + `
+	V       {{ .Value }} ` + "`" + `json:"v"` + "`" + `
+	Exp     time.Time ` + "`" + `json:"exp"` + "`" + `
+	Deleted bool      ` + "`" + `json:"deleted,omitempty"` + "`" + `
+}
+
+// Open{{ .Name }}File opens the {{ .Name }}File name, creating it if it does
+// not exist. The file is compacted, by dropping values which have been
+// overwritten, deleted or have expired. A partially written last line, e.g.
+// after a crash, is ignored.
+func Open{{ .Name }}File(name string) (*{{ .Name }}File, error) {
+	b, err := os.ReadFile(name)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	var (
+		recs []{{ .Record }}
+		idx  = make(map[{{ .Key }}]int)
+		dead = make(map[int]bool)
+		now  = time.Now()
+		r    = bufio.NewReader(bytes.NewReader(b))
+	)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		}
+		var rec {{ .Record }}
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if i, ok := idx[rec.K]; ok {
+			dead[i] = true
+			delete(idx, rec.K)
+		}
+		if rec.Deleted || !rec.Exp.IsZero() && !now.Before(rec.Exp) {
+			continue
+		}
+		idx[rec.K] = len(recs)
+		recs = append(recs, rec)
+	}
+	live := recs[:0]
+	for i, rec := range recs {
+		if !dead[i] {
+			live = append(live, rec)
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, rec := range live {
+		if err := enc.Encode(rec); err != nil {
+			return nil, err
+		}
+	}
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0666); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp, name); err != nil {
+		os.Remove(tmp)
+		return nil, err
+	}
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return nil, err
+	}
+	return &{{ .Name }}File{f: f, recs: live}, nil
+}
+
+// Load implements {{ .Name }}Store. It calls f with the values read by
+// Open{{ .Name }}File, in the order they were stored.
+func (s *{{ .Name }}File) Load(f func(k {{ .Key }}, v {{ .Value }}, exp time.Time)) error {
+	s.mu.Lock()
+	recs := s.recs
+	s.recs = nil
+	s.mu.Unlock()
+	for _, rec := range recs {
+		f(rec.K, rec.V, rec.Exp)
+	}
+	return nil
+}
+
+// Store implements {{ .Name }}Store.
+func (s *{{ .Name }}File) Store(k {{ .Key }}, v {{ .Value }}, exp time.Time) error {
+	return s.append({{ .Record }}{K: k, V: v, Exp: exp})
+}
+
+// Delete implements {{ .Name }}Store.
+func (s *{{ .Name }}File) Delete(k {{ .Key }}) error {
+	return s.append({{ .Record }}{K: k, Deleted: true})
+}
+
+func (s *{{ .Name }}File) append(rec {{ .Record }}) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.f.Write(append(b, '\n'))
+	return err
+}
+
+// Close closes the file of s.
+func (s *{{ .Name }}File) Close() error {
+	return s.f.Close()
+}
+`))
+
+type pkg struct {
+	Package string
+	Policy  string
+	Persist bool
+	Store   bool
+	Jitter  string
+	// Coalesce is the duration misses share the result of a finished call to
+	// the loader, as a literal, or "".
+	Coalesce string
+	// ErrorTTL is the duration errors of the loader are cached for, as a
+	// literal, or "".
+	ErrorTTL string
+	// MaxStale is the duration expired values are returned for, if the
+	// loader fails, as a literal, or "". MaxStaleDoc is the same, for
+	// comments.
+	MaxStale    string
+	MaxStaleDoc string
+	Stats       bool
+	Cost        string
+	Janitor     bool
+	Warm        bool
+	Caches      []cache
+}
+
+type cache struct {
+	Name   string
+	Key    string
+	Value  string
+	Policy string
+	// Persist says whether the cache can be persisted with a Store.
+	Persist bool
+	// Store says whether the cache can be backed by a cache.Store.
+	Store bool
+	// Jitter is the maximum fraction of the ttl entries expire early by,
+	// as a literal, or "".
+	Jitter      string
+	Coalesce    string
+	ErrorTTL    string
+	MaxStale    string
+	MaxStaleDoc string
+	// Stats says whether the cache records statistics.
+	Stats bool
+	// Cost is the function returning the cost of values, with -policy=lru,
+	// or "".
+	Cost string
+	// Janitor says whether expired values can be removed in the
+	// background, with -policy=ttl.
+	Janitor bool
+	// Warm says whether the cache has methods preloading values.
+	Warm bool
+}
+
+// Entry returns the name of the type of list elements of c.
+func (c cache) Entry() string {
+	return gen.Unexported(c.Name) + "Entry"
+}
+
+// Record returns the name of the type of lines of the file store of c.
+func (c cache) Record() string {
+	return gen.Unexported(c.Name) + "Record"
+}
+
+// Call returns the name of the type of in-flight loader calls of c.
+func (c cache) Call() string {
+	return gen.Unexported(c.Name) + "Call"
+}
+
+// parseCache parses the loader signature of the cache name.
+func parseCache(name, sig string) (cache, error) {
+	f, err := gen.ParseFunc(sig)
+	if err != nil {
+		return cache{}, fmt.Errorf("%s: %v", name, err)
+	}
+	if len(f.Params) != 1 || f.Variadic || len(f.Results) != 2 || f.Results[1].Type != "error" {
+		return cache{}, fmt.Errorf("loader of %s must have signature func(K) (V, error), not %s", name, sig)
+	}
+	k := f.Params[0].Type
+	if strings.HasPrefix(k, "[]") || strings.HasPrefix(k, "map[") || strings.HasPrefix(k, "func(") {
+		return cache{}, fmt.Errorf("key type %s of %s is not comparable", k, name)
+	}
+	return cache{Name: name, Key: k, Value: f.Results[0].Type}, nil
+}
+
+var policies = map[string]bool{
+	"lru": true,
+	"2q":  true,
+	"ttl": true,
+}
+
+var (
+	flags       = flag.NewFlagSet("go-cache", flag.ContinueOnError)
+	pkgName     = flags.String("package", os.Getenv("GOPACKAGE"), "Package the file should be in")
+	out         = gen.OutputFlags(flags)
+	policy      = flags.String("policy", "lru", `Eviction policy, "lru", "2q" or "ttl"`)
+	stats       = flags.Bool("stats", false, "Generate a Stats method and a Load hook, to collect statistics of caches")
+	warm        = flags.Bool("warm", false, "Generate Warm and WarmValues methods, preloading values")
+	coalesce    = flags.Duration("coalesce", 0, "Duration misses share the result of a finished call to the loader")
+	errorTTL    = flags.Duration("error-ttl", 0, "Duration errors of the loader are cached for")
+	maxStale    = flags.Duration("max-stale", 0, "Duration expired values are returned for, if the loader fails, with -policy=ttl")
+	costFunc    = flags.String("cost", "", "Function returning the cost of a value, to limit the total cost instead of the number of values, with -policy=lru")
+	janitor     = flags.Bool("janitor", false, "Generate a StartJanitor method, removing expired values in the background, with -policy=ttl")
+	jitter      = flags.Float64("jitter", 0, "Maximum fraction of the ttl entries expire early by, with -policy=ttl")
+	store       = flags.Bool("store", false, "Generate a SetStore method, to back caches by a merovius.de/go-misc/cache.Store")
+	persist     = flags.Bool("persist", false, "Generate a Persist method and a file store, to keep cached values across restarts")
+	allowUnsafe = flags.Bool("allow-unsafe", false, "Allow caching values of unsafe.Pointer and cgo types")
+)
+
+// Run runs go-cache with the command line arguments args, which do not include
+// the program name.
+func Run(args []string) error {
+	if err := gen.ParseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 || flags.NArg()%2 != 0 {
+		return errors.New("Usage: go-cache [-package=<pkg>] [-policy=<policy>] [-cost=<func>] [-jitter=<fraction>] [-max-stale=<d>] [-janitor] [-coalesce=<d>] [-error-ttl=<d>] [-stats] [-warm] [-persist] [-store] [-allow-unsafe] <name> <signature> [<name> <signature>]...")
+	}
+	if *pkgName == "" {
+		return errors.New("no package given")
+	}
+	if !policies[*policy] {
+		return fmt.Errorf("unknown eviction policy %q", *policy)
+	}
+	if *jitter < 0 || *jitter >= 1 {
+		return errors.New("-jitter must be at least 0 and less than 1")
+	}
+	if *coalesce < 0 || *errorTTL < 0 {
+		return errors.New("-coalesce and -error-ttl must not be negative")
+	}
+	if *jitter != 0 && *policy != "ttl" {
+		return errors.New("-jitter requires -policy=ttl")
+	}
+	if *costFunc != "" && *policy != "lru" {
+		return errors.New("-cost requires -policy=lru")
+	}
+	if *janitor && *policy != "ttl" {
+		return errors.New("-janitor requires -policy=ttl")
+	}
+	if *maxStale < 0 {
+		return errors.New("-max-stale must not be negative")
+	}
+	if *maxStale != 0 && *policy != "ttl" {
+		return errors.New("-max-stale requires -policy=ttl")
+	}
+
+	p := pkg{Package: *pkgName, Policy: *policy, Persist: *persist, Store: *store, Stats: *stats, Cost: *costFunc, Janitor: *janitor, Warm: *warm}
+	if *jitter != 0 {
+		p.Jitter = strconv.FormatFloat(*jitter, 'g', -1, 64)
+	}
+	if *coalesce != 0 {
+		p.Coalesce = gen.DurationLiteral(*coalesce)
+	}
+	if *errorTTL != 0 {
+		p.ErrorTTL = gen.DurationLiteral(*errorTTL)
+	}
+	if *maxStale != 0 {
+		p.MaxStale, p.MaxStaleDoc = gen.DurationLiteral(*maxStale), maxStale.String()
+	}
+	for i := 0; i < flags.NArg(); i += 2 {
+		c, err := parseCache(flags.Arg(i), flags.Arg(i+1))
+		if err != nil {
+			return err
+		}
+		if !*allowUnsafe {
+			if err := gen.CheckUnsafe(flags.Arg(i + 1)); err != nil {
+				return err
+			}
+		}
+		c.Policy, c.Persist, c.Store, c.Jitter = *policy, *persist, *store, p.Jitter
+		c.Coalesce, c.ErrorTTL = p.Coalesce, p.ErrorTTL
+		c.MaxStale, c.MaxStaleDoc = p.MaxStale, p.MaxStaleDoc
+		c.Stats, c.Cost, c.Janitor, c.Warm = p.Stats, p.Cost, p.Janitor, p.Warm
+		p.Caches = append(p.Caches, c)
+	}
+
+	return out.Write(implTemplate, p)
+}
@@ -0,0 +1,681 @@
+package cache
+
+import (
+	"testing"
+
+	"merovius.de/go-misc/internal/gen/gentest"
+)
+
+func TestMain(m *testing.M) {
+	gentest.Main(m, Run)
+}
+
+// users declares the value type of the caches of the golden tests and its
+// cost function.
+const users = `package users
+
+type User struct {
+	ID   int64
+	Name string
+}
+
+func size(u *User) int { return len(u.Name) }
+`
+
+func TestGolden(t *testing.T) {
+	tcs := []struct {
+		name string
+		args []string
+	}{
+		{"default", nil},
+		{"2q", []string{"-policy=2q"}},
+		{"ttl", []string{"-policy=ttl"}},
+		{"persist", []string{"-persist"}},
+		{"persist_ttl", []string{"-persist", "-policy=ttl"}},
+		{"store", []string{"-store"}},
+		{"jitter", []string{"-policy=ttl", "-jitter=0.5"}},
+		{"coalesce", []string{"-coalesce=10ms"}},
+		{"error_ttl", []string{"-error-ttl=5s"}},
+		{"max_stale", []string{"-policy=ttl", "-max-stale=1m"}},
+		{"stats", []string{"-stats"}},
+		{"cost", []string{"-cost=size"}},
+		{"janitor", []string{"-policy=ttl", "-janitor"}},
+		{"warm", []string{"-warm"}},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := gentest.Dir(t, map[string]string{"users.go": users})
+			args := append([]string{"-package=users", "-out=cache.go"}, tc.args...)
+			gentest.Generate(t, dir, append(args, "UserCache", "func(id int64) (*User, error)")...)
+			gentest.Golden(t, dir, "cache.go", tc.name+".go.golden")
+			gentest.Vet(t, dir)
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	dir := gentest.Dir(t, nil)
+	for _, args := range [][]string{
+		{"IntCache"},
+		{"IntCache", "func(int) int"},
+		{"IntCache", "func([]int) (int, error)"},
+		{"-policy=lfu", "IntCache", "func(int) (int, error)"},
+		{"-package=", "IntCache", "func(int) (int, error)"},
+	} {
+		gentest.Fail(t, dir, append([]string{"-package=memo", "-out=cache.go"}, args...)...)
+	}
+}
+
+// cacheTest runs the tests in src with the race detector, on a cache
+// IntCache with a loader of type func(int) (int, error), generated with args.
+// The tests can use the loader declared in loaderTest.
+func cacheTest(t *testing.T, src string, args ...string) {
+	t.Helper()
+	dir := gentest.Dir(t, map[string]string{"loader_test.go": loaderTest, "cache_test.go": src})
+	args = append([]string{"-package=memo", "-out=cache.go"}, args...)
+	gentest.Generate(t, dir, append(args, "IntCache", "func(int) (int, error)")...)
+	gentest.Test(t, dir, "-race")
+}
+
+// loaderTest declares a loader counting its calls.
+const loaderTest = `package memo
+
+import (
+	"errors"
+	"sync"
+)
+
+// loader loads the values of IntCaches, counting its calls per key. Keys
+// below 0 fail, 0 panics.
+type loader struct {
+	mu    sync.Mutex
+	calls map[int]int
+}
+
+func newLoader() *loader {
+	return &loader{calls: make(map[int]int)}
+}
+
+func (l *loader) load(k int) (int, error) {
+	l.mu.Lock()
+	l.calls[k]++
+	l.mu.Unlock()
+	switch {
+	case k < 0:
+		return 0, errors.New("negative key")
+	case k == 0:
+		panic("zero key")
+	}
+	return 2 * k, nil
+}
+
+func (l *loader) n(k int) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.calls[k]
+}
+`
+
+func TestLRU(t *testing.T) {
+	cacheTest(t, `package memo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEvict(t *testing.T) {
+	l := newLoader()
+	var evicted []int
+	c := NewIntCache(l.load, 2, &IntCacheHooks{Evict: func(k int) { evicted = append(evicted, k) }})
+	for _, k := range []int{1, 2, 1, 3, 1} {
+		if v, err := c.Get(k); v != 2*k || err != nil {
+			t.Fatalf("Get(%d) == %d, %v, want %d, nil", k, v, err, 2*k)
+		}
+	}
+	if len(evicted) != 1 || evicted[0] != 2 || c.Len() != 2 {
+		t.Fatalf("evicted %v, Len() == %d, want [2], 2", evicted, c.Len())
+	}
+	if l.n(1) != 1 {
+		t.Errorf("loaded 1 %d times, want 1", l.n(1))
+	}
+	c.Get(2)
+	if l.n(2) != 2 {
+		t.Errorf("loaded evicted 2 %d times, want 2", l.n(2))
+	}
+	c.Invalidate(2)
+	c.Get(2)
+	if l.n(2) != 3 {
+		t.Errorf("loaded invalidated 2 %d times, want 3", l.n(2))
+	}
+}
+
+func TestErrors(t *testing.T) {
+	l := newLoader()
+	c := NewIntCache(l.load, 0, nil)
+	for i := 0; i < 2; i++ {
+		if _, err := c.Get(-1); err == nil {
+			t.Error("Get(-1) succeeded")
+		}
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("Get(0) did not panic")
+				}
+			}()
+			c.Get(0)
+		}()
+	}
+	// Neither errors nor panics are cached.
+	if l.n(-1) != 2 || l.n(0) != 2 || c.Len() != 0 {
+		t.Errorf("loaded -1 and 0 %d and %d times, Len() == %d, want 2, 2, 0", l.n(-1), l.n(0), c.Len())
+	}
+}
+
+func TestShared(t *testing.T) {
+	release := make(chan struct{})
+	var calls int
+	c := NewIntCache(func(k int) (int, error) {
+		calls++
+		<-release
+		return k, nil
+	}, 0, nil)
+	done := make(chan int)
+	for i := 0; i < 4; i++ {
+		go func() {
+			v, _ := c.Get(1)
+			done <- v
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	for i := 0; i < 4; i++ {
+		if v := <-done; v != 1 {
+			t.Errorf("Get(1) == %d, want 1", v)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("loader called %d times for concurrent misses, want 1", calls)
+	}
+}
+`)
+}
+
+func Test2Q(t *testing.T) {
+	cacheTest(t, `package memo
+
+import "testing"
+
+func TestScan(t *testing.T) {
+	l := newLoader()
+	c := NewIntCache(l.load, 4, nil)
+	// 1 is loaded twice, in some distance, so it is promoted to the main
+	// queue and survives a scan of other keys.
+	for k := 1; k <= 5; k++ {
+		c.Get(k)
+	}
+	c.Get(1)
+	for k := 6; k <= 20; k++ {
+		c.Get(k)
+		if c.Len() > 4 {
+			t.Fatalf("Len() == %d, want at most 4", c.Len())
+		}
+	}
+	c.Get(1)
+	if l.n(1) != 2 {
+		t.Errorf("loaded 1 %d times, want 2", l.n(1))
+	}
+	c.Get(6)
+	if l.n(6) != 2 {
+		t.Errorf("loaded scanned 6 %d times, want 2", l.n(6))
+	}
+}
+`, "-policy=2q")
+}
+
+func TestTTL(t *testing.T) {
+	cacheTest(t, `package memo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExpire(t *testing.T) {
+	l := newLoader()
+	var evicted []int
+	c := NewIntCache(l.load, 50*time.Millisecond, &IntCacheHooks{Evict: func(k int) { evicted = append(evicted, k) }})
+	c.Get(1)
+	c.Get(1)
+	if l.n(1) != 1 {
+		t.Fatalf("loaded 1 %d times before it expired, want 1", l.n(1))
+	}
+	time.Sleep(60 * time.Millisecond)
+	// Storing 2 evicts the expired 1.
+	c.Get(2)
+	if len(evicted) != 1 || evicted[0] != 1 || c.Len() != 1 {
+		t.Fatalf("evicted %v, Len() == %d, want [1], 1", evicted, c.Len())
+	}
+	c.Get(1)
+	if l.n(1) != 2 {
+		t.Errorf("loaded 1 %d times after it expired, want 2", l.n(1))
+	}
+}
+`, "-policy=ttl")
+}
+
+func TestPersist(t *testing.T) {
+	cacheTest(t, `package memo
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "cache.json")
+	l := newLoader()
+	open := func() *IntCache {
+		c := NewIntCache(l.load, 50*time.Millisecond, nil)
+		f, err := OpenIntCacheFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { f.Close() })
+		if err := c.Persist(f); err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	c := open()
+	for _, k := range []int{1, 2, 3} {
+		c.Get(k)
+	}
+	c.Invalidate(2)
+
+	c = open()
+	if c.Len() != 2 {
+		t.Fatalf("Len() == %d after reopening, want 2", c.Len())
+	}
+	for _, k := range []int{1, 2, 3} {
+		if v, err := c.Get(k); v != 2*k || err != nil {
+			t.Fatalf("Get(%d) == %d, %v, want %d, nil", k, v, err, 2*k)
+		}
+	}
+	if l.n(1) != 1 || l.n(2) != 2 || l.n(3) != 1 {
+		t.Errorf("loaded 1, 2, 3 %d, %d, %d times, want 1, 2, 1", l.n(1), l.n(2), l.n(3))
+	}
+
+	// Expired values are not loaded.
+	time.Sleep(60 * time.Millisecond)
+	if c = open(); c.Len() != 0 {
+		t.Errorf("Len() == %d after values expired, want 0", c.Len())
+	}
+}
+`, "-policy=ttl", "-persist")
+}
+
+func TestStore(t *testing.T) {
+	cacheTest(t, `package memo
+
+import (
+	"testing"
+
+	"merovius.de/go-misc/cache"
+)
+
+func TestShare(t *testing.T) {
+	l := newLoader()
+	var m cache.Map[int, int]
+	c1, c2 := NewIntCache(l.load, 0, nil), NewIntCache(l.load, 0, nil)
+	c1.SetStore(&m)
+	c2.SetStore(&m)
+	c1.Get(1)
+	if v, err := c2.Get(1); v != 2 || err != nil {
+		t.Fatalf("Get(1) == %d, %v, want 2, nil", v, err)
+	}
+	if l.n(1) != 1 {
+		t.Errorf("loaded 1 %d times with a shared store, want 1", l.n(1))
+	}
+
+	// Invalidate removes the value from the store as well.
+	c1.Invalidate(1)
+	if _, ok, _ := m.Get(1); ok {
+		t.Error("Invalidate(1) did not remove 1 from the store")
+	}
+	c1.Get(1)
+	if l.n(1) != 2 {
+		t.Errorf("loaded invalidated 1 %d times, want 2", l.n(1))
+	}
+}
+`, "-store")
+}
+
+func TestJitter(t *testing.T) {
+	cacheTest(t, `package memo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExpire(t *testing.T) {
+	l := newLoader()
+	c := NewIntCache(l.load, 200*time.Millisecond, nil)
+	start := time.Now()
+	for k := 1; k <= 100; k++ {
+		c.Get(k)
+	}
+	time.Sleep(50*time.Millisecond - time.Since(start))
+	for k := 1; k <= 100; k++ {
+		if c.Get(k); l.n(k) != 1 {
+			t.Fatalf("loaded %d %d times before it expired, want 1", k, l.n(k))
+		}
+	}
+	// Every value expires after 100ms to 200ms, so about half of them
+	// expired after 150ms.
+	time.Sleep(150*time.Millisecond - time.Since(start))
+	var reloaded int
+	for k := 1; k <= 100; k++ {
+		if c.Get(k); l.n(k) > 1 {
+			reloaded++
+		}
+	}
+	if reloaded == 0 || reloaded == 100 {
+		t.Errorf("reloaded %d of 100 values after 150ms, want some", reloaded)
+	}
+}
+`, "-policy=ttl", "-jitter=0.5")
+}
+
+func TestCoalesce(t *testing.T) {
+	cacheTest(t, `package memo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestShare(t *testing.T) {
+	l := newLoader()
+	c := NewIntCache(l.load, 1, nil)
+	// 2 evicts 1, but misses for 1 share the finished call for 50ms.
+	c.Get(1)
+	c.Get(2)
+	if v, err := c.Get(1); v != 2 || err != nil {
+		t.Fatalf("Get(1) == %d, %v, want 2, nil", v, err)
+	}
+	c.Get(-1)
+	if _, err := c.Get(-1); err == nil {
+		t.Fatal("Get(-1) succeeded")
+	}
+	if l.n(1) != 1 || l.n(-1) != 1 {
+		t.Fatalf("loaded 1 and -1 %d and %d times, want 1, 1", l.n(1), l.n(-1))
+	}
+
+	// Invalidate ends the sharing.
+	c.Get(2)
+	c.Invalidate(1)
+	c.Get(1)
+	if l.n(1) != 2 {
+		t.Errorf("loaded invalidated 1 %d times, want 2", l.n(1))
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	c.Get(-1)
+	if l.n(-1) != 2 {
+		t.Errorf("loaded -1 %d times after 60ms, want 2", l.n(-1))
+	}
+}
+`, "-coalesce=50ms")
+}
+
+func TestErrorTTL(t *testing.T) {
+	cacheTest(t, `package memo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestErrors(t *testing.T) {
+	l := newLoader()
+	c := NewIntCache(l.load, 1, nil)
+	for i := 0; i < 2; i++ {
+		if _, err := c.Get(-1); err == nil {
+			t.Fatal("Get(-1) succeeded")
+		}
+		c.Get(-2)
+	}
+	if l.n(-1) != 1 || l.n(-2) != 1 {
+		t.Fatalf("loaded -1 and -2 %d and %d times, want 1, 1", l.n(-1), l.n(-2))
+	}
+
+	// Values are not shared after the call finished.
+	c.Get(1)
+	c.Get(2)
+	c.Get(1)
+	if l.n(1) != 2 {
+		t.Errorf("loaded evicted 1 %d times, want 2", l.n(1))
+	}
+
+	// Invalidate removes a cached error.
+	c.Invalidate(-2)
+	c.Get(-2)
+	if l.n(-2) != 2 {
+		t.Errorf("loaded invalidated -2 %d times, want 2", l.n(-2))
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	c.Get(-1)
+	if l.n(-1) != 2 {
+		t.Errorf("loaded -1 %d times after its error expired, want 2", l.n(-1))
+	}
+}
+`, "-error-ttl=50ms")
+}
+
+func TestMaxStale(t *testing.T) {
+	cacheTest(t, `package memo
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFallback(t *testing.T) {
+	var fail atomic.Bool
+	var calls, stale atomic.Int32
+	c := NewIntCache(func(k int) (int, error) {
+		calls.Add(1)
+		if fail.Load() {
+			return 0, errors.New("failed")
+		}
+		return k, nil
+	}, 50*time.Millisecond, &IntCacheHooks{Stale: func(int, error) { stale.Add(1) }})
+	c.Get(1)
+	time.Sleep(60 * time.Millisecond)
+	fail.Store(true)
+	for i := 0; i < 2; i++ {
+		if v, err := c.Get(1); v != 1 || err != nil {
+			t.Fatalf("Get(1) == %d, %v with a failing loader, want stale 1, nil", v, err)
+		}
+	}
+	// Stale values are returned, but the loader is called on every miss.
+	if calls.Load() != 3 || stale.Load() != 2 {
+		t.Errorf("loader called %d times, Stale %d times, want 3, 2", calls.Load(), stale.Load())
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if _, err := c.Get(1); err == nil {
+		t.Error("Get(1) succeeded after max-stale")
+	}
+}
+`, "-policy=ttl", "-max-stale=100ms")
+}
+
+func TestStats(t *testing.T) {
+	cacheTest(t, `package memo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	l := newLoader()
+	var loads, failed int
+	c := NewIntCache(l.load, 1, &IntCacheHooks{Load: func(k int, d time.Duration, err error) {
+		loads++
+		if err != nil {
+			failed++
+		}
+	}})
+	for _, k := range []int{1, 1, 2, -1} {
+		c.Get(k)
+	}
+	func() {
+		defer func() { recover() }()
+		c.Get(0)
+	}()
+	want := IntCacheStats{Hits: 1, Misses: 4, Evictions: 1, Loads: 4, LoadErrors: 2}
+	got := c.Stats()
+	if got.LoadTime <= 0 || got.MeanLoadTime() != got.LoadTime/4 {
+		t.Errorf("LoadTime, MeanLoadTime() == %v, %v, want positive, LoadTime/4", got.LoadTime, got.MeanLoadTime())
+	}
+	got.LoadTime = 0
+	if got != want {
+		t.Errorf("Stats() == %+v, want %+v", got, want)
+	}
+	if loads != 4 || failed != 2 {
+		t.Errorf("Load hook called %d times, %d with an error, want 4, 2", loads, failed)
+	}
+}
+`, "-stats")
+}
+
+func TestCost(t *testing.T) {
+	cacheTest(t, `package memo
+
+import "testing"
+
+func cost(v int) int { return v }
+
+func TestEvict(t *testing.T) {
+	l := newLoader()
+	var evicted []int
+	c := NewIntCache(l.load, 10, &IntCacheHooks{Evict: func(k int) { evicted = append(evicted, k) }})
+	// The values cost 2, 4 and 6, so 1 is evicted.
+	for _, k := range []int{1, 2, 3} {
+		c.Get(k)
+	}
+	if len(evicted) != 1 || evicted[0] != 1 || c.Len() != 2 {
+		t.Fatalf("evicted %v, Len() == %d, want [1], 2", evicted, c.Len())
+	}
+
+	// A value costing more than size is returned, but not cached, and
+	// evicts nothing.
+	for i := 0; i < 2; i++ {
+		if v, err := c.Get(6); v != 12 || err != nil {
+			t.Fatalf("Get(6) == %d, %v, want 12, nil", v, err)
+		}
+	}
+	if l.n(6) != 2 || len(evicted) != 1 || c.Len() != 2 {
+		t.Errorf("loaded 6 %d times, evicted %v, Len() == %d, want 2, [1], 2", l.n(6), evicted, c.Len())
+	}
+	c.Get(2)
+	c.Get(3)
+	if l.n(2) != 1 || l.n(3) != 1 {
+		t.Errorf("loaded 2 and 3 %d and %d times, want 1, 1", l.n(2), l.n(3))
+	}
+}
+`, "-cost=cost")
+}
+
+func TestJanitor(t *testing.T) {
+	cacheTest(t, `package memo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExpire(t *testing.T) {
+	var evicted []int
+	c := NewIntCache(newLoader().load, 20*time.Millisecond, &IntCacheHooks{Evict: func(k int) { evicted = append(evicted, k) }})
+	j := c.StartJanitor(10 * time.Millisecond)
+	c.Get(1)
+	c.Get(2)
+	time.Sleep(60 * time.Millisecond)
+	j.Stop()
+	// The janitor evicted the values, without the cache being used.
+	if len(evicted) != 2 || c.Len() != 0 {
+		t.Errorf("evicted %v, Len() == %d, want [1 2], 0", evicted, c.Len())
+	}
+}
+
+func TestInterval(t *testing.T) {
+	c := NewIntCache(newLoader().load, time.Second, nil)
+	defer func() {
+		if recover() == nil {
+			t.Error("StartJanitor(0) did not panic")
+		}
+	}()
+	c.StartJanitor(0)
+}
+`, "-policy=ttl", "-janitor")
+}
+
+func TestWarm(t *testing.T) {
+	cacheTest(t, `package memo
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestWarm(t *testing.T) {
+	l := newLoader()
+	c := NewIntCache(l.load, 0, nil)
+	err := c.Warm(context.Background(), slices.Values([]int{1, -1, 2, 3}), 2)
+	var we *IntCacheWarmError
+	if !errors.As(err, &we) || we.Key != -1 {
+		t.Fatalf("Warm() == %v, want *IntCacheWarmError for -1", err)
+	}
+	if c.Len() != 3 {
+		t.Errorf("Len() == %d after Warm, want 3", c.Len())
+	}
+
+	c.WarmValues(func(yield func(int, int) bool) { yield(4, 42) })
+	if v, _ := c.Get(4); v != 42 || l.n(4) != 0 {
+		t.Errorf("Get(4) == %d, loaded %d times after WarmValues, want 42, 0", v, l.n(4))
+	}
+}
+
+func TestCancel(t *testing.T) {
+	c := NewIntCache(newLoader().load, 0, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := c.Warm(ctx, slices.Values([]int{1, 2}), 1); !errors.Is(err, context.Canceled) || c.Len() != 0 {
+		t.Errorf("Warm() == %v, Len() == %d with canceled ctx, want context.Canceled, 0", err, c.Len())
+	}
+
+	// If all keys are loaded, ctx being done is not an error.
+	ctx, cancel = context.WithCancel(context.Background())
+	keys := func(yield func(int) bool) {
+		for k := 1; k <= 2 && yield(k); k++ {
+		}
+		cancel()
+	}
+	if err := c.Warm(ctx, keys, 1); err != nil || c.Len() != 2 {
+		t.Errorf("Warm() == %v, Len() == %d, want nil, 2", err, c.Len())
+	}
+}
+`, "-warm")
+}
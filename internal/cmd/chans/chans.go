@@ -0,0 +1,145 @@
+// Package chans implements go-chan. See merovius.de/go-misc/cmd/go-chan for its
+// documentation.
+package chans // import "merovius.de/go-misc/internal/cmd/chans"
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"text/template"
+
+	"merovius.de/go-misc/internal/gen"
+)
+
+var implTemplate = template.Must(template.New("chan.go").Parse(`
+// This file is automatically generated by merovius.de/go-misc/cmd/go-chan.
+
+package {{ .Package }}
+
+import "sync"
+
+{{ range .Types }}
+	{{ template "impl" . }}
+{{ end }}
+`))
+
+var _ = template.Must(implTemplate.New("impl").Parse(`
+// OrDone{{ .Name }} returns a channel receiving the values of c. It is closed
+// when c is closed or done is closed.
+func OrDone{{ .Name }}(done <-chan struct{}, c <-chan {{ .Type }}) <-chan {{ .Type }} {
+	out := make(chan {{ .Type }})
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case v, ok := <-c:
+				if !ok {
+					return
+				}
+				select {
+				case out <- v:
+				case <-done:
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Merge{{ .Name }} returns a channel receiving the values of all cs. It is
+// closed when all cs are closed or done is closed.
+func Merge{{ .Name }}(done <-chan struct{}, cs ...<-chan {{ .Type }}) <-chan {{ .Type }} {
+	out := make(chan {{ .Type }})
+	var wg sync.WaitGroup
+	wg.Add(len(cs))
+	for _, c := range cs {
+		go func(c <-chan {{ .Type }}) {
+			defer wg.Done()
+			for v := range OrDone{{ .Name }}(done, c) {
+				select {
+				case out <- v:
+				case <-done:
+					return
+				}
+			}
+		}(c)
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
+
+// FanOut{{ .Name }} returns n channels, receiving the values of c. Every value
+// is sent to only one of them, whichever is ready first. They are closed when
+// c is closed or done is closed.
+func FanOut{{ .Name }}(done <-chan struct{}, c <-chan {{ .Type }}, n int) []<-chan {{ .Type }} {
+	in := OrDone{{ .Name }}(done, c)
+	outs := make([]<-chan {{ .Type }}, n)
+	for i := range outs {
+		out := make(chan {{ .Type }})
+		outs[i] = out
+		go func() {
+			defer close(out)
+			for v := range in {
+				select {
+				case out <- v:
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+	return outs
+}
+
+// Work{{ .Name }} calls f for every value of c, in n concurrent goroutines.
+// It returns when c is closed or done is closed and all calls to f returned.
+func Work{{ .Name }}(done <-chan struct{}, c <-chan {{ .Type }}, n int, f func({{ .Type }})) {
+	in := OrDone{{ .Name }}(done, c)
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			for v := range in {
+				f(v)
+			}
+		}()
+	}
+	wg.Wait()
+}
+`))
+
+type pkg struct {
+	Package string
+	Types   []gen.Type
+}
+
+var (
+	flags   = flag.NewFlagSet("go-chan", flag.ContinueOnError)
+	pkgName = flags.String("package", os.Getenv("GOPACKAGE"), "Package the file should be in")
+	out     = gen.OutputFlags(flags)
+)
+
+// Run runs go-chan with the command line arguments args, which do not include
+// the program name.
+func Run(args []string) error {
+	if err := gen.ParseFlags(flags, args); err != nil {
+		return err
+	}
+
+	types, err := gen.ParseTypes(flags.Args())
+	if err != nil || flags.NArg() == 0 {
+		return errors.New("Usage: go-chan [-package=<pkg>] <name> <type> [<name> <type>]...")
+	}
+	if *pkgName == "" {
+		return errors.New("no package given")
+	}
+
+	return out.Write(implTemplate, pkg{Package: *pkgName, Types: types})
+}
@@ -0,0 +1,104 @@
+package chans
+
+import (
+	"testing"
+
+	"merovius.de/go-misc/internal/gen/gentest"
+)
+
+func TestMain(m *testing.M) {
+	gentest.Main(m, Run)
+}
+
+func TestGolden(t *testing.T) {
+	dir := gentest.Dir(t, nil)
+	gentest.Generate(t, dir, "-package=chans", "-out=chan.go", "Int", "int", "Bytes", "[]byte")
+	gentest.Golden(t, dir, "chan.go", "chan.go.golden")
+	gentest.Vet(t, dir)
+}
+
+func TestUsage(t *testing.T) {
+	dir := gentest.Dir(t, nil)
+	gentest.Fail(t, dir, "-package=chans", "-out=chan.go")
+	gentest.Fail(t, dir, "-package=chans", "-out=chan.go", "Int")
+	gentest.Fail(t, dir, "-package=", "-out=chan.go", "Int", "int")
+}
+
+// chanTest tests the helpers for int channels. It is run with the race
+// detector.
+const chanTest = `package chans
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+// gen returns a channel receiving 0 to n-1.
+func gen(n int) <-chan int {
+	c := make(chan int)
+	go func() {
+		defer close(c)
+		for i := 0; i < n; i++ {
+			c <- i
+		}
+	}()
+	return c
+}
+
+func TestMerge(t *testing.T) {
+	sum := 0
+	for v := range MergeInt(nil, gen(10), gen(10), gen(10)) {
+		sum += v
+	}
+	if sum != 3*45 {
+		t.Errorf("sum of merged values == %d, want %d", sum, 3*45)
+	}
+}
+
+func TestFanOut(t *testing.T) {
+	n := 0
+	for range MergeInt(nil, FanOutInt(nil, gen(100), 4)...) {
+		n++
+	}
+	if n != 100 {
+		t.Errorf("received %d values, want 100", n)
+	}
+}
+
+func TestWork(t *testing.T) {
+	var sum int64
+	WorkInt(nil, gen(100), 4, func(v int) { atomic.AddInt64(&sum, int64(v)) })
+	if sum != 4950 {
+		t.Errorf("sum == %d, want 4950", sum)
+	}
+}
+
+func TestDone(t *testing.T) {
+	done := make(chan struct{})
+	// never is never closed, so only done ends the helpers.
+	never := make(chan int)
+	merged := MergeInt(done, never)
+	outs := FanOutInt(done, never, 2)
+	finished := make(chan struct{})
+	go func() {
+		WorkInt(done, never, 2, func(int) {})
+		close(finished)
+	}()
+	close(done)
+	for range merged {
+	}
+	for _, c := range outs {
+		for range c {
+		}
+	}
+	<-finished
+	for range OrDoneInt(done, never) {
+	}
+}
+`
+
+func TestChans(t *testing.T) {
+	dir := gentest.Dir(t, map[string]string{"chan_test.go": chanTest})
+	gentest.Generate(t, dir, "-package=chans", "-out=chan.go", "Int", "int")
+	gentest.Test(t, dir, "-race")
+}
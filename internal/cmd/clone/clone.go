@@ -0,0 +1,190 @@
+// Package clone implements go-clone. See merovius.de/go-misc/cmd/go-clone for its
+// documentation.
+package clone // import "merovius.de/go-misc/internal/cmd/clone"
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"go/types"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"merovius.de/go-misc/internal/gen"
+)
+
+var implTemplate = template.Must(template.New("clone.go").Parse(`
+// This file is automatically generated by merovius.de/go-misc/cmd/go-clone.
+
+package {{ .Package }}
+
+{{ if .Imports -}}
+import (
+{{- range .Imports }}
+	{{ . }}
+{{- end }}
+)
+{{- end }}
+
+{{ range .Types }}
+// Clone returns a shallow copy of s.
+func (s *{{ .Name }}) Clone() *{{ .Name }} {
+	if s == nil {
+		return nil
+	}
+	d := new({{ .Name }})
+	*d = *s
+{{- range .Fields }}
+{{- if eq .Rule "nil" }}
+	d.{{ .Name }} = nil
+{{- else if eq .Kind "slice" }}
+	if s.{{ .Name }} != nil {
+		d.{{ .Name }} = make({{ .Type }}, len(s.{{ .Name }}))
+		copy(d.{{ .Name }}, s.{{ .Name }})
+	}
+{{- else if eq .Kind "map" }}
+	if s.{{ .Name }} != nil {
+		d.{{ .Name }} = make({{ .Type }}, len(s.{{ .Name }}))
+		for k, v := range s.{{ .Name }} {
+			d.{{ .Name }}[k] = v
+		}
+	}
+{{- else if eq .Kind "pointer" }}
+	if s.{{ .Name }} != nil {
+		d.{{ .Name }} = new({{ .Elem }})
+		*d.{{ .Name }} = *s.{{ .Name }}
+	}
+{{- end }}
+{{- end }}
+	return d
+}
+{{ end }}
+`))
+
+type pkg struct {
+	Package string
+	Imports []string
+	Types   []clone
+}
+
+type clone struct {
+	Name   string
+	Fields []field
+}
+
+// field is a field that is not simply shared.
+type field struct {
+	Name string
+	Rule string
+	// Kind is the kind of the field: "pointer", "slice", "map" or "other".
+	Kind string
+	Type string
+	// Elem is the element type of pointers.
+	Elem string
+}
+
+// kind returns the kind of t and whether t is a reference type.
+func kind(t types.Type) (string, bool) {
+	switch t.Underlying().(type) {
+	case *types.Pointer:
+		return "pointer", true
+	case *types.Slice:
+		return "slice", true
+	case *types.Map:
+		return "map", true
+	case *types.Chan, *types.Signature, *types.Interface:
+		return "other", true
+	}
+	return "", false
+}
+
+var rules = map[string]bool{
+	"share": true,
+	"copy":  true,
+	"nil":   true,
+}
+
+// collect returns the clone for the struct type name in p.
+func collect(p *gen.Package, im *gen.Imports, name, def string) (clone, error) {
+	_, s, err := p.Struct(name)
+	if err != nil {
+		return clone{}, err
+	}
+	c := clone{Name: name}
+	for i := 0; i < s.NumFields(); i++ {
+		v := s.Field(i)
+		rule, tagged := reflect.StructTag(s.Tag(i)).Lookup("clone")
+		k, ref := kind(v.Type())
+		if tagged && !rules[rule] {
+			return clone{}, fmt.Errorf("invalid clone rule %q for %s.%s", rule, name, v.Name())
+		}
+		if tagged && !ref {
+			return clone{}, fmt.Errorf("clone rule for %s.%s, which is not of a reference type", name, v.Name())
+		}
+		if !ref || v.Name() == "_" {
+			continue
+		}
+		if !tagged {
+			rule = def
+			if rule == "copy" && k == "other" {
+				rule = "share"
+			}
+		}
+		if rule == "copy" && k == "other" {
+			return clone{}, fmt.Errorf("%s.%s of type %s can not be copied", name, v.Name(), v.Type())
+		}
+		if rule == "share" {
+			continue
+		}
+		f := field{Name: v.Name(), Rule: rule, Kind: k, Type: im.TypeString(v.Type())}
+		if pt, ok := v.Type().Underlying().(*types.Pointer); ok {
+			f.Elem = im.TypeString(pt.Elem())
+		}
+		c.Fields = append(c.Fields, f)
+	}
+	return c, nil
+}
+
+var (
+	flags     = flag.NewFlagSet("go-clone", flag.ContinueOnError)
+	typeNames = flags.String("type", "", "Comma-separated list of struct type names")
+	defRule   = flags.String("default", "share", "Rule for reference fields without a struct tag (share, copy or nil)")
+	out       = gen.OutputFlags(flags)
+)
+
+// Run runs go-clone with the command line arguments args, which do not include
+// the program name.
+func Run(args []string) error {
+	if err := gen.ParseFlags(flags, args); err != nil {
+		return err
+	}
+	if *typeNames == "" || flags.NArg() > 1 {
+		return errors.New("Usage: go-clone [-default=<rule>] -type=<type>[,<type>...] [<dir>]")
+	}
+	if !rules[*defRule] {
+		return fmt.Errorf("invalid default rule %q", *defRule)
+	}
+	dir := "."
+	if flags.NArg() == 1 {
+		dir = flags.Arg(0)
+	}
+
+	lp, err := gen.LoadPackage(dir)
+	if err != nil {
+		return err
+	}
+
+	im := gen.NewImports(lp.Types)
+	p := pkg{Package: lp.Types.Name()}
+	for _, name := range strings.Split(*typeNames, ",") {
+		c, err := collect(lp, im, name, *defRule)
+		if err != nil {
+			return err
+		}
+		p.Types = append(p.Types, c)
+	}
+	p.Imports = im.List()
+
+	return out.Write(implTemplate, p)
+}
@@ -0,0 +1,92 @@
+package clone
+
+import (
+	"testing"
+
+	"merovius.de/go-misc/internal/gen/gentest"
+)
+
+func TestMain(m *testing.M) {
+	gentest.Main(m, Run)
+}
+
+// src declares the type of the tests, with reference fields with and without
+// rules.
+const src = `package doc
+
+type Person struct{ Name string }
+
+type Doc struct {
+	Title  string
+	Tags   []string
+	Meta   map[string]string ` + "`clone:\"copy\"`" + `
+	Parent *Doc              ` + "`clone:\"share\"`" + `
+	Cache  map[string]int    ` + "`clone:\"nil\"`" + `
+	Author *Person
+	OnSave func()
+}
+`
+
+func TestGolden(t *testing.T) {
+	for _, rule := range []string{"share", "copy", "nil"} {
+		t.Run(rule, func(t *testing.T) {
+			dir := gentest.Dir(t, map[string]string{"doc.go": src})
+			gentest.Generate(t, dir, "-default="+rule, "-type=Doc", "-out=clone.go")
+			gentest.Golden(t, dir, "clone.go", rule+".go.golden")
+			gentest.Vet(t, dir)
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	dir := gentest.Dir(t, map[string]string{"doc.go": src + `
+type Rule struct {
+	Tags []string ` + "`clone:\"deep\"`" + `
+}
+
+type Value struct {
+	N int ` + "`clone:\"copy\"`" + `
+}
+
+type Func struct {
+	F func() ` + "`clone:\"copy\"`" + `
+}
+`})
+	for _, typ := range []string{"Rule", "Value", "Func", "Missing"} {
+		gentest.Fail(t, dir, "-type="+typ, "-out=clone.go")
+	}
+	gentest.Fail(t, dir, "-default=deep", "-type=Doc", "-out=clone.go")
+	gentest.Fail(t, dir, "-out=clone.go")
+}
+
+func TestClone(t *testing.T) {
+	dir := gentest.Dir(t, map[string]string{"doc.go": src, "clone_test.go": `package doc
+
+import "testing"
+
+func TestClone(t *testing.T) {
+	d := &Doc{
+		Title:  "a",
+		Tags:   []string{"x"},
+		Meta:   map[string]string{"k": "v"},
+		Parent: &Doc{Title: "p"},
+		Cache:  map[string]int{"k": 1},
+		Author: &Person{"me"},
+	}
+	c := d.Clone()
+	if c.Title != "a" || c.Parent != d.Parent || c.Cache != nil || c.Author == d.Author || c.Author.Name != "me" {
+		t.Errorf("Clone() == %+v", c)
+	}
+	c.Tags[0] = "y"
+	c.Meta["k"] = "w"
+	if d.Tags[0] != "x" || d.Meta["k"] != "v" {
+		t.Errorf("changing the clone changed the original: %+v", d)
+	}
+	if (*Doc)(nil).Clone() != nil {
+		t.Errorf("Clone of nil is not nil")
+	}
+}
+`})
+	gentest.Generate(t, dir, "-default=copy", "-type=Doc", "-out=clone.go")
+	gentest.Test(t, dir)
+}
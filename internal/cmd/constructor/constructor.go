@@ -0,0 +1,184 @@
+// Package constructor implements go-constructor. See merovius.de/go-misc/cmd/go-constructor for its
+// documentation.
+package constructor // import "merovius.de/go-misc/internal/cmd/constructor"
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"go/types"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"merovius.de/go-misc/internal/gen"
+)
+
+var implTemplate = template.Must(template.New("constructor.go").Parse(`
+// This file is automatically generated by merovius.de/go-misc/cmd/go-constructor.
+
+package {{ .Package }}
+
+{{ if .Imports -}}
+import (
+{{- range .Imports }}
+	{{ . }}
+{{- end }}
+)
+{{- end }}
+
+{{ range .Types }}
+	{{ template "impl" . }}
+{{ end }}
+`))
+
+var _ = template.Must(implTemplate.New("impl").Parse(`
+// {{ .Name }}Option configures a {{ .Name }} created by New{{ .Name }}.
+type {{ .Name }}Option func(*{{ .Options }})
+
+type {{ .Options }} struct {
+	v {{ .Name }}
+{{- range .Fields }}{{ if .Required }}
+	set{{ .Setter }} bool
+{{- end }}{{ end }}
+}
+{{ range .Fields }}
+// With{{ .Setter }} sets the field {{ .Name }}{{ if .Required }}. It is required{{ end }}.
+func With{{ .Setter }}(v {{ .Type }}) {{ $.Name }}Option {
+	return func(o *{{ $.Options }}) {
+		o.v.{{ .Name }} = v
+{{- if .Required }}
+		o.set{{ .Setter }} = true
+{{- end }}
+	}
+}
+{{ end }}
+// New{{ .Name }} returns a new {{ .Name }}, configured by opts.
+{{- if .Required }} It returns an
+// error, if an option for a required field is missing.{{ end }}
+func New{{ .Name }}(opts ...{{ .Name }}Option) {{ if .Required }}(*{{ .Name }}, error){{ else }}*{{ .Name }}{{ end }} {
+	o := new({{ .Options }})
+	for _, opt := range opts {
+		opt(o)
+	}
+{{- range .Fields }}{{ if .Required }}
+	if !o.set{{ .Setter }} {
+		return nil, errors.New("missing required option With{{ .Setter }} for {{ $.Name }}")
+	}
+{{- end }}{{ end }}
+{{- if .Defaults }}
+	o.v.SetDefaults()
+{{- end }}
+	return &o.v{{ if .Required }}, nil{{ end }}
+}
+`))
+
+type pkg struct {
+	Package string
+	Imports []string
+	Types   []constructor
+}
+
+type constructor struct {
+	Name     string
+	Fields   []field
+	Required bool
+	Defaults bool
+}
+
+// Options returns the name of the type the options are applied to.
+func (c constructor) Options() string {
+	return gen.Unexported(c.Name) + "Options"
+}
+
+type field struct {
+	Name     string
+	Setter   string
+	Type     string
+	Required bool
+}
+
+// hasSetDefaults returns whether t has a method SetDefaults().
+func hasSetDefaults(t types.Type) bool {
+	obj, _, _ := types.LookupFieldOrMethod(t, false, nil, "SetDefaults")
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return false
+	}
+	sig := fn.Type().(*types.Signature)
+	return sig.Params().Len() == 0 && sig.Results().Len() == 0
+}
+
+// collect returns the constructor for the struct type name in p.
+func collect(p *gen.Package, im *gen.Imports, name string) (constructor, error) {
+	n, s, err := p.Struct(name)
+	if err != nil {
+		return constructor{}, err
+	}
+	c := constructor{Name: name, Defaults: hasSetDefaults(types.NewPointer(n))}
+	for i := 0; i < s.NumFields(); i++ {
+		v := s.Field(i)
+		tag := reflect.StructTag(s.Tag(i)).Get("constructor")
+		if tag == "-" || v.Name() == "_" {
+			continue
+		}
+		f := field{
+			Name:     v.Name(),
+			Setter:   gen.Exported(v.Name()),
+			Type:     im.TypeString(v.Type()),
+			Required: tag == "required",
+		}
+		c.Required = c.Required || f.Required
+		c.Fields = append(c.Fields, f)
+	}
+	return c, nil
+}
+
+var (
+	flags     = flag.NewFlagSet("go-constructor", flag.ContinueOnError)
+	typeNames = flags.String("type", "", "Comma-separated list of struct type names")
+	out       = gen.OutputFlags(flags)
+)
+
+// Run runs go-constructor with the command line arguments args, which do not include
+// the program name.
+func Run(args []string) error {
+	if err := gen.ParseFlags(flags, args); err != nil {
+		return err
+	}
+	if *typeNames == "" || flags.NArg() > 1 {
+		return errors.New("Usage: go-constructor -type=<type>[,<type>...] [<dir>]")
+	}
+	dir := "."
+	if flags.NArg() == 1 {
+		dir = flags.Arg(0)
+	}
+
+	lp, err := gen.LoadPackage(dir)
+	if err != nil {
+		return err
+	}
+
+	im := gen.NewImports(lp.Types)
+	p := pkg{Package: lp.Types.Name()}
+	options := make(map[string]string)
+	for _, name := range strings.Split(*typeNames, ",") {
+		c, err := collect(lp, im, name)
+		if err != nil {
+			return err
+		}
+		for _, f := range c.Fields {
+			if other, ok := options[f.Setter]; ok {
+				return fmt.Errorf("option With%s is generated for both %s and %s, exclude one with constructor:\"-\"", f.Setter, other, name)
+			}
+			options[f.Setter] = name
+		}
+		if c.Required {
+			im.Add("errors", "errors")
+		}
+		p.Types = append(p.Types, c)
+	}
+	p.Imports = im.List()
+
+	return out.Write(implTemplate, p)
+}
@@ -0,0 +1,81 @@
+package constructor
+
+import (
+	"testing"
+
+	"merovius.de/go-misc/internal/gen/gentest"
+)
+
+func TestMain(m *testing.M) {
+	gentest.Main(m, Run)
+}
+
+// src declares the types of the tests. Server has required fields and a
+// SetDefaults method, Client has neither.
+const src = `package server
+
+import "time"
+
+type Server struct {
+	Addr    string ` + "`constructor:\"required\"`" + `
+	Timeout time.Duration
+	retries int
+	cache   map[string]int ` + "`constructor:\"-\"`" + `
+}
+
+func (s *Server) SetDefaults() {
+	if s.Timeout == 0 {
+		s.Timeout = time.Second
+	}
+}
+
+type Client struct {
+	BaseURL string
+	Verbose bool
+}
+`
+
+func TestGolden(t *testing.T) {
+	dir := gentest.Dir(t, map[string]string{"server.go": src})
+	gentest.Generate(t, dir, "-type=Server,Client", "-out=constructor.go")
+	gentest.Golden(t, dir, "constructor.go", "constructor.go.golden")
+	gentest.Vet(t, dir)
+}
+
+func TestErrors(t *testing.T) {
+	dir := gentest.Dir(t, map[string]string{"server.go": src + "\ntype Proxy struct{ Addr string }\n"})
+	// Both Server and Proxy would get a WithAddr option.
+	gentest.Fail(t, dir, "-type=Server,Proxy", "-out=constructor.go")
+	gentest.Fail(t, dir, "-type=Missing", "-out=constructor.go")
+	gentest.Fail(t, dir, "-out=constructor.go")
+}
+
+func TestConstructor(t *testing.T) {
+	dir := gentest.Dir(t, map[string]string{"server.go": src, "constructor_test.go": `package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConstructor(t *testing.T) {
+	if _, err := NewServer(WithTimeout(time.Minute)); err == nil {
+		t.Error("NewServer succeeded without required option")
+	}
+	s, err := NewServer(WithAddr(":80"), WithRetries(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Addr != ":80" || s.retries != 3 || s.Timeout != time.Second {
+		t.Errorf("NewServer() == %+v", s)
+	}
+
+	c := NewClient(WithBaseURL("http://example.com"), WithVerbose(true))
+	if c.BaseURL != "http://example.com" || !c.Verbose {
+		t.Errorf("NewClient() == %+v", c)
+	}
+}
+`})
+	gentest.Generate(t, dir, "-type=Server,Client", "-out=constructor.go")
+	gentest.Test(t, dir)
+}
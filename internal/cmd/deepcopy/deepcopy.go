@@ -0,0 +1,233 @@
+// Package deepcopy implements go-deepcopy. See merovius.de/go-misc/cmd/go-deepcopy for its
+// documentation.
+package deepcopy // import "merovius.de/go-misc/internal/cmd/deepcopy"
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/types"
+	"strings"
+	"text/template"
+
+	"merovius.de/go-misc/internal/gen"
+)
+
+var implTemplate = template.Must(template.New("deepcopy.go").Parse(`
+// This file is automatically generated by merovius.de/go-misc/cmd/go-deepcopy.
+
+package {{ .Package }}
+
+{{ if .Imports -}}
+import (
+{{- range .Imports }}
+	{{ . }}
+{{- end }}
+)
+{{- end }}
+
+{{ range .Types }}
+// DeepCopy returns a deep copy of s.
+func (s *{{ .Name }}) DeepCopy() *{{ .Name }} {
+	if s == nil {
+		return nil
+	}
+	d := new({{ .Name }})
+	*d = *s
+	{{- .Body }}
+	return d
+}
+{{ end }}
+`))
+
+type pkg struct {
+	Package string
+	Imports []string
+	Types   []method
+}
+
+type method struct {
+	Name string
+	Body string
+}
+
+// copier generates code to deep copy values.
+type copier struct {
+	pkg      *types.Package
+	im       *gen.Imports
+	selected map[*types.Named]bool
+	buf      *bytes.Buffer
+	vars     int
+	err      error
+}
+
+// needsCopy returns whether values of type t reference memory, that needs
+// to be copied.
+func (c *copier) needsCopy(t types.Type, seen map[*types.Named]bool) bool {
+	if n, ok := t.(*types.Named); ok {
+		if c.selected[n] {
+			return true
+		}
+		if seen[n] {
+			return false
+		}
+		seen[n] = true
+		defer delete(seen, n)
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Pointer, *types.Slice, *types.Map:
+		return true
+	case *types.Array:
+		return c.needsCopy(u.Elem(), seen)
+	case *types.Struct:
+		for i := 0; i < u.NumFields(); i++ {
+			if c.accessible(u.Field(i)) && c.needsCopy(u.Field(i).Type(), seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c *copier) accessible(f *types.Var) bool {
+	return f.Exported() || f.Pkg() == c.pkg
+}
+
+func (c *copier) newVar(prefix string) string {
+	c.vars++
+	return fmt.Sprintf("%s%d", prefix, c.vars)
+}
+
+// fix emits code that turns dst, which holds a shallow copy of src, into a
+// deep copy of src.
+func (c *copier) fix(dst, src string, t types.Type, stack map[*types.Named]bool) {
+	if !c.needsCopy(t, make(map[*types.Named]bool)) {
+		return
+	}
+	if n, ok := t.(*types.Named); ok {
+		if c.selected[n] {
+			fmt.Fprintf(c.buf, "\n%s = *%s.DeepCopy()", dst, src)
+			return
+		}
+		if stack[n] {
+			c.err = fmt.Errorf("recursive type %s must be given to -type", n.Obj().Name())
+			return
+		}
+		stack[n] = true
+		defer delete(stack, n)
+	}
+
+	switch u := t.Underlying().(type) {
+	case *types.Pointer:
+		if n, ok := u.Elem().(*types.Named); ok && c.selected[n] {
+			fmt.Fprintf(c.buf, "\n%s = %s.DeepCopy()", dst, src)
+			return
+		}
+		fmt.Fprintf(c.buf, "\nif %s != nil {", src)
+		fmt.Fprintf(c.buf, "\n%s = new(%s)", dst, c.im.TypeString(u.Elem()))
+		fmt.Fprintf(c.buf, "\n*%s = *%s", dst, src)
+		if _, ok := u.Elem().Underlying().(*types.Struct); ok {
+			// Fields of pointers to structs can be selected directly.
+			c.fix(dst, src, u.Elem(), stack)
+		} else {
+			c.fix("(*"+dst+")", "(*"+src+")", u.Elem(), stack)
+		}
+		c.buf.WriteString("\n}")
+	case *types.Slice:
+		fmt.Fprintf(c.buf, "\nif %s != nil {", src)
+		fmt.Fprintf(c.buf, "\n%s = make(%s, len(%s))", dst, c.im.TypeString(t), src)
+		fmt.Fprintf(c.buf, "\ncopy(%s, %s)", dst, src)
+		if c.needsCopy(u.Elem(), make(map[*types.Named]bool)) {
+			i := c.newVar("i")
+			fmt.Fprintf(c.buf, "\nfor %s := range %s {", i, src)
+			c.fix(dst+"["+i+"]", src+"["+i+"]", u.Elem(), stack)
+			c.buf.WriteString("\n}")
+		}
+		c.buf.WriteString("\n}")
+	case *types.Array:
+		i := c.newVar("i")
+		fmt.Fprintf(c.buf, "\nfor %s := range %s {", i, src)
+		c.fix(dst+"["+i+"]", src+"["+i+"]", u.Elem(), stack)
+		c.buf.WriteString("\n}")
+	case *types.Map:
+		k, v := c.newVar("k"), c.newVar("v")
+		fmt.Fprintf(c.buf, "\nif %s != nil {", src)
+		fmt.Fprintf(c.buf, "\n%s = make(%s, len(%s))", dst, c.im.TypeString(t), src)
+		fmt.Fprintf(c.buf, "\nfor %s, %s := range %s {", k, v, src)
+		if n, ok := u.Elem().(*types.Named); ok && c.selected[n] {
+			v = "*" + v + ".DeepCopy()"
+		} else if c.needsCopy(u.Elem(), make(map[*types.Named]bool)) {
+			w := c.newVar("w")
+			fmt.Fprintf(c.buf, "\n%s := %s", w, v)
+			c.fix(w, v, u.Elem(), stack)
+			v = w
+		}
+		fmt.Fprintf(c.buf, "\n%s[%s] = %s", dst, k, v)
+		c.buf.WriteString("\n}\n}")
+	case *types.Struct:
+		for i := 0; i < u.NumFields(); i++ {
+			if f := u.Field(i); c.accessible(f) {
+				c.fix(dst+"."+f.Name(), src+"."+f.Name(), f.Type(), stack)
+			}
+		}
+	}
+}
+
+var (
+	flags     = flag.NewFlagSet("go-deepcopy", flag.ContinueOnError)
+	typeNames = flags.String("type", "", "Comma-separated list of struct type names")
+	out       = gen.OutputFlags(flags)
+)
+
+// Run runs go-deepcopy with the command line arguments args, which do not include
+// the program name.
+func Run(args []string) error {
+	if err := gen.ParseFlags(flags, args); err != nil {
+		return err
+	}
+	if *typeNames == "" || flags.NArg() > 1 {
+		return errors.New("Usage: go-deepcopy -type=<type>[,<type>...] [<dir>]")
+	}
+	dir := "."
+	if flags.NArg() == 1 {
+		dir = flags.Arg(0)
+	}
+
+	lp, err := gen.LoadPackage(dir)
+	if err != nil {
+		return err
+	}
+
+	c := &copier{
+		pkg:      lp.Types,
+		im:       gen.NewImports(lp.Types),
+		selected: make(map[*types.Named]bool),
+	}
+	names := strings.Split(*typeNames, ",")
+	var structs []*types.Struct
+	for _, name := range names {
+		n, s, err := lp.Struct(name)
+		if err != nil {
+			return err
+		}
+		c.selected[n] = true
+		structs = append(structs, s)
+	}
+
+	p := pkg{Package: lp.Types.Name()}
+	for i, s := range structs {
+		c.buf = new(bytes.Buffer)
+		for j := 0; j < s.NumFields(); j++ {
+			f := s.Field(j)
+			c.fix("d."+f.Name(), "s."+f.Name(), f.Type(), make(map[*types.Named]bool))
+		}
+		if c.err != nil {
+			return c.err
+		}
+		p.Types = append(p.Types, method{Name: names[i], Body: c.buf.String()})
+	}
+	p.Imports = c.im.List()
+
+	return out.Write(implTemplate, p)
+}
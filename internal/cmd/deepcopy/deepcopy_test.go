@@ -0,0 +1,74 @@
+package deepcopy
+
+import (
+	"testing"
+
+	"merovius.de/go-misc/internal/gen/gentest"
+)
+
+func TestMain(m *testing.M) {
+	gentest.Main(m, Run)
+}
+
+// src declares the types of the tests, with pointers, slices, arrays and maps
+// of types, which need copying, and a recursive type.
+const src = `package tree
+
+type Node struct {
+	Name     string
+	Children []*Node
+	Attrs    map[string]*Attr
+	Grid     [2][]int
+	Parent   *Node
+}
+
+type Attr struct {
+	Values [][]string
+	Meta   *map[string]int
+}
+`
+
+func TestGolden(t *testing.T) {
+	dir := gentest.Dir(t, map[string]string{"tree.go": src})
+	gentest.Generate(t, dir, "-type=Node,Attr", "-out=deepcopy.go")
+	gentest.Golden(t, dir, "deepcopy.go", "deepcopy.go.golden")
+	gentest.Vet(t, dir)
+}
+
+func TestRecursive(t *testing.T) {
+	// Node refers to itself, so it needs its own DeepCopy method.
+	gentest.Fail(t, gentest.Dir(t, map[string]string{"tree.go": src + "\ntype Tree struct{ Root *Node }\n"}), "-type=Tree", "-out=deepcopy.go")
+}
+
+func TestDeepCopy(t *testing.T) {
+	dir := gentest.Dir(t, map[string]string{"tree.go": src, "deepcopy_test.go": `package tree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDeepCopy(t *testing.T) {
+	meta := map[string]int{"a": 1}
+	root := &Node{Name: "root", Grid: [2][]int{{1}, {2}}}
+	root.Children = []*Node{{Name: "child"}}
+	root.Parent = &Node{Name: "parent"}
+	root.Attrs = map[string]*Attr{"x": {Values: [][]string{{"v"}}, Meta: &meta}}
+
+	c := root.DeepCopy()
+	if !reflect.DeepEqual(c, root) {
+		t.Fatalf("DeepCopy() == %+v, want %+v", c, root)
+	}
+	c.Children[0].Name = "changed"
+	c.Attrs["x"].Values[0][0] = "changed"
+	(*c.Attrs["x"].Meta)["a"] = 2
+	c.Grid[0][0] = 2
+	c.Parent.Name = "changed"
+	if root.Children[0].Name != "child" || root.Parent.Name != "parent" || root.Attrs["x"].Values[0][0] != "v" || meta["a"] != 1 || root.Grid[0][0] != 1 {
+		t.Errorf("changing the copy changed the original: %+v", root)
+	}
+}
+`})
+	gentest.Generate(t, dir, "-type=Node,Attr", "-out=deepcopy.go")
+	gentest.Test(t, dir)
+}
@@ -0,0 +1,213 @@
+// Package defaults implements go-defaults. See merovius.de/go-misc/cmd/go-defaults for its
+// documentation.
+package defaults // import "merovius.de/go-misc/internal/cmd/defaults"
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"merovius.de/go-misc/internal/gen"
+)
+
+var implTemplate = template.Must(template.New("defaults.go").Parse(`
+// This file is automatically generated by merovius.de/go-misc/cmd/go-defaults.
+
+package {{ .Package }}
+
+{{ if .Imports -}}
+import (
+{{- range .Imports }}
+	{{ . }}
+{{- end }}
+)
+{{- end }}
+
+{{ range .Types }}
+// SetDefaults sets the fields of s, which have their zero value, to their
+// defaults.
+func (s *{{ .Name }}) SetDefaults() {
+{{- range .Fields }}
+{{- if .Nested }}
+	{{ if .Pointer }}if s.{{ .Name }} != nil {
+		s.{{ .Name }}.SetDefaults()
+	}{{ else }}s.{{ .Name }}.SetDefaults(){{ end }}
+{{- else }}
+	if {{ .Zero }} {
+		s.{{ .Name }} = {{ .Default }}
+	}
+{{- end }}
+{{- end }}
+}
+{{ end }}
+`))
+
+type pkg struct {
+	Package string
+	Imports []string
+	Types   []defaults
+}
+
+type defaults struct {
+	Name   string
+	Fields []field
+}
+
+type field struct {
+	Name string
+	// Zero is the condition that the field has its zero value.
+	Zero string
+	// Default is the expression of the default value.
+	Default string
+	// Nested is set, if SetDefaults of the field is called.
+	Nested  bool
+	Pointer bool
+}
+
+// zero returns the condition that x of type t has its zero value.
+func zero(im *gen.Imports, x string, t types.Type) (string, error) {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsString != 0:
+			return x + ` == ""`, nil
+		case u.Info()&types.IsBoolean != 0:
+			return "!" + x, nil
+		case u.Info()&types.IsNumeric != 0:
+			return x + " == 0", nil
+		}
+	case *types.Pointer, *types.Slice, *types.Map, *types.Chan, *types.Signature, *types.Interface:
+		return x + " == nil", nil
+	case *types.Struct, *types.Array:
+		if types.Comparable(t) {
+			return x + " == (" + im.TypeString(t) + "{})", nil
+		}
+	}
+	return "", fmt.Errorf("can not compare %s to its zero value", t)
+}
+
+// check type-checks the default expression e for a field of type t in the
+// scope of tn and records the packages it references.
+func check(p *gen.Package, im *gen.Imports, tn *types.TypeName, t types.Type, e string) error {
+	typ := types.TypeString(t, func(q *types.Package) string {
+		if q == p.Types {
+			return ""
+		}
+		return q.Name()
+	})
+	expr, err := parser.ParseExpr("func() (_ " + typ + ") { return " + e + " }")
+	if err != nil {
+		return err
+	}
+	info := &types.Info{Uses: make(map[*ast.Ident]types.Object)}
+	if err := types.CheckExpr(p.Fset, p.Types, tn.Pos(), expr, info); err != nil {
+		// The position refers to the synthesized expression, not the tag.
+		if terr, ok := err.(types.Error); ok {
+			return errors.New(terr.Msg)
+		}
+		return err
+	}
+	ret := expr.(*ast.FuncLit).Body.List[0].(*ast.ReturnStmt)
+	ast.Inspect(ret, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok {
+			if pn, ok := info.Uses[id].(*types.PkgName); ok {
+				im.Add(pn.Imported().Path(), pn.Name())
+			}
+		}
+		return true
+	})
+	return nil
+}
+
+// collect returns the defaults for the struct type name in p.
+func collect(p *gen.Package, im *gen.Imports, name string, selected map[*types.Named]bool) (defaults, error) {
+	n, s, err := p.Struct(name)
+	if err != nil {
+		return defaults{}, err
+	}
+	d := defaults{Name: name}
+	for i := 0; i < s.NumFields(); i++ {
+		v := s.Field(i)
+		f := field{Name: v.Name()}
+		def, ok := reflect.StructTag(s.Tag(i)).Lookup("default")
+		if !ok {
+			t := v.Type()
+			if pt, ok := t.(*types.Pointer); ok {
+				t, f.Pointer = pt.Elem(), true
+			}
+			if nt, ok := t.(*types.Named); ok && selected[nt] {
+				f.Nested = true
+				d.Fields = append(d.Fields, f)
+			}
+			continue
+		}
+		if f.Zero, err = zero(im, "s."+v.Name(), v.Type()); err != nil {
+			return defaults{}, fmt.Errorf("field %s.%s: %v", name, v.Name(), err)
+		}
+		if b, ok := v.Type().Underlying().(*types.Basic); ok && b.Info()&types.IsString != 0 {
+			f.Default = strconv.Quote(def)
+		} else if err := check(p, im, n.Obj(), v.Type(), def); err != nil {
+			return defaults{}, fmt.Errorf("invalid default for %s.%s: %v", name, v.Name(), err)
+		} else {
+			f.Default = def
+		}
+		d.Fields = append(d.Fields, f)
+	}
+	return d, nil
+}
+
+var (
+	flags     = flag.NewFlagSet("go-defaults", flag.ContinueOnError)
+	typeNames = flags.String("type", "", "Comma-separated list of struct type names")
+	out       = gen.OutputFlags(flags)
+)
+
+// Run runs go-defaults with the command line arguments args, which do not include
+// the program name.
+func Run(args []string) error {
+	if err := gen.ParseFlags(flags, args); err != nil {
+		return err
+	}
+	if *typeNames == "" || flags.NArg() > 1 {
+		return errors.New("Usage: go-defaults -type=<type>[,<type>...] [<dir>]")
+	}
+	dir := "."
+	if flags.NArg() == 1 {
+		dir = flags.Arg(0)
+	}
+
+	lp, err := gen.LoadPackage(dir)
+	if err != nil {
+		return err
+	}
+
+	names := strings.Split(*typeNames, ",")
+	selected := make(map[*types.Named]bool)
+	for _, name := range names {
+		n, err := lp.Named(name)
+		if err != nil {
+			return err
+		}
+		selected[n] = true
+	}
+
+	im := gen.NewImports(lp.Types)
+	p := pkg{Package: lp.Types.Name()}
+	for _, name := range names {
+		d, err := collect(lp, im, name, selected)
+		if err != nil {
+			return err
+		}
+		p.Types = append(p.Types, d)
+	}
+	p.Imports = im.List()
+
+	return out.Write(implTemplate, p)
+}
@@ -0,0 +1,112 @@
+package defaults
+
+import (
+	"testing"
+
+	"merovius.de/go-misc/internal/gen/gentest"
+)
+
+func TestMain(m *testing.M) {
+	gentest.Main(m, Run)
+}
+
+// src declares the types of the tests, with defaults of several kinds and
+// nested types.
+const src = `package server
+
+import "time"
+
+const DefaultMax = 10
+
+type Server struct {
+	Addr    string        ` + "`default:\":8080\"`" + `
+	Timeout time.Duration ` + "`default:\"5 * time.Second\"`" + `
+	Debug   bool          ` + "`default:\"true\"`" + `
+	Tags    []string      ` + "`default:\"[]string{\\\"a\\\"}\"`" + `
+	Origin  Point         ` + "`default:\"Point{1, 2}\"`" + `
+	Limits  Limits
+	TLS     *TLS
+	Name    string
+}
+
+type Limits struct {
+	Max int ` + "`default:\"DefaultMax\"`" + `
+}
+
+type TLS struct {
+	Cert string ` + "`default:\"cert.pem\"`" + `
+}
+
+type Point struct{ X, Y int }
+`
+
+func TestGolden(t *testing.T) {
+	tcs := []struct {
+		name string
+		args []string
+	}{
+		{"default", []string{"-type=Server,Limits,TLS"}},
+		{"flat", []string{"-type=Server"}},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := gentest.Dir(t, map[string]string{"server.go": src})
+			gentest.Generate(t, dir, append(tc.args, "-out=defaults.go")...)
+			gentest.Golden(t, dir, "defaults.go", tc.name+".go.golden")
+			gentest.Vet(t, dir)
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	dir := gentest.Dir(t, map[string]string{"bad.go": `package bad
+
+type Type struct {
+	N int ` + "`default:\"\\\"x\\\"\"`" + `
+}
+
+type Unknown struct {
+	N int ` + "`default:\"Missing\"`" + `
+}
+
+type Compare struct {
+	V struct{ S []int } ` + "`default:\"struct{ S []int }{}\"`" + `
+}
+`})
+	for _, typ := range []string{"Type", "Unknown", "Compare", "Missing"} {
+		gentest.Fail(t, dir, "-type="+typ, "-out=defaults.go")
+	}
+	gentest.Fail(t, dir, "-out=defaults.go")
+}
+
+func TestSetDefaults(t *testing.T) {
+	dir := gentest.Dir(t, map[string]string{"server.go": src, "defaults_test.go": `package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetDefaults(t *testing.T) {
+	s := Server{Addr: ":80", TLS: new(TLS)}
+	s.SetDefaults()
+	if s.Addr != ":80" {
+		t.Errorf("SetDefaults overwrote Addr")
+	}
+	if s.Timeout != 5*time.Second || !s.Debug || len(s.Tags) != 1 || s.Origin != (Point{1, 2}) || s.Name != "" {
+		t.Errorf("SetDefaults() did not set the defaults: %+v", s)
+	}
+	if s.Limits.Max != DefaultMax || s.TLS.Cert != "cert.pem" {
+		t.Errorf("SetDefaults() did not set the defaults of nested values: %+v, %+v", s.Limits, s.TLS)
+	}
+
+	s = Server{}
+	s.SetDefaults()
+	if s.TLS != nil {
+		t.Errorf("SetDefaults() allocated TLS")
+	}
+}
+`})
+	gentest.Generate(t, dir, "-type=Server,Limits,TLS", "-out=defaults.go")
+	gentest.Test(t, dir)
+}
@@ -0,0 +1,187 @@
+// Package enum implements go-enum. See merovius.de/go-misc/cmd/go-enum for its
+// documentation.
+package enum // import "merovius.de/go-misc/internal/cmd/enum"
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"go/constant"
+	"go/types"
+	"sort"
+	"strings"
+	"text/template"
+
+	"merovius.de/go-misc/internal/gen"
+)
+
+var implTemplate = template.Must(template.New("enum.go").Parse(`
+// This file is automatically generated by merovius.de/go-misc/cmd/go-enum.
+
+package {{ .Package }}
+
+import (
+	"fmt"
+	"strconv"
+)
+
+{{ range .Types }}
+	{{ template "impl" . }}
+{{ end }}
+`))
+
+var _ = template.Must(implTemplate.New("impl").Parse(`
+// String returns the name of v.
+func (v {{ .Name }}) String() string {
+	switch v {
+{{- range .Values }}
+	case {{ .Const }}:
+		return {{ printf "%q" .Name }}
+{{- end }}
+	}
+{{- if .Unsigned }}
+	return "{{ .Name }}(" + strconv.FormatUint(uint64(v), 10) + ")"
+{{- else }}
+	return "{{ .Name }}(" + strconv.FormatInt(int64(v), 10) + ")"
+{{- end }}
+}
+
+// IsValid returns whether v is a declared value of {{ .Name }}.
+func (v {{ .Name }}) IsValid() bool {
+	switch v {
+	case {{ range $i, $v := .Values }}{{ if $i }}, {{ end }}{{ $v.Const }}{{ end }}:
+		return true
+	}
+	return false
+}
+
+// Parse{{ .Name }} returns the {{ .Name }} with the given name.
+func Parse{{ .Name }}(s string) ({{ .Name }}, error) {
+	switch s {
+{{- range .Names }}
+	case {{ printf "%q" .Name }}:
+		return {{ .Const }}, nil
+{{- end }}
+	}
+	return 0, fmt.Errorf("invalid {{ .Name }} %q", s)
+}
+
+// {{ .Name }}Values returns all values of {{ .Name }}, in order of declaration.
+func {{ .Name }}Values() []{{ .Name }} {
+	return []{{ .Name }}{
+{{- range .Values }}
+		{{ .Const }},
+{{- end }}
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (v {{ .Name }}) MarshalText() ([]byte, error) {
+	if !v.IsValid() {
+		return nil, fmt.Errorf("invalid {{ .Name }} %s", v)
+	}
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *{{ .Name }}) UnmarshalText(b []byte) error {
+	p, err := Parse{{ .Name }}(string(b))
+	if err != nil {
+		return err
+	}
+	*v = p
+	return nil
+}
+`))
+
+type pkg struct {
+	Package string
+	Types   []*enum
+}
+
+type enum struct {
+	Name     string
+	Unsigned bool
+	// Values contains one constant per distinct value, Names contains all
+	// constants.
+	Values []value
+	Names  []value
+}
+
+type value struct {
+	Const string
+	Name  string
+}
+
+// collect returns the enum described by the constants of type name in p.
+func collect(p *types.Package, name, trim string) (*enum, error) {
+	obj, ok := p.Scope().Lookup(name).(*types.TypeName)
+	if !ok {
+		return nil, fmt.Errorf("no type %s in package %s", name, p.Name())
+	}
+	b, ok := obj.Type().Underlying().(*types.Basic)
+	if !ok || b.Info()&types.IsInteger == 0 {
+		return nil, fmt.Errorf("type %s does not have an integer underlying type", name)
+	}
+
+	var consts []*types.Const
+	for _, n := range p.Scope().Names() {
+		if c, ok := p.Scope().Lookup(n).(*types.Const); ok && types.Identical(c.Type(), obj.Type()) {
+			consts = append(consts, c)
+		}
+	}
+	if len(consts) == 0 {
+		return nil, fmt.Errorf("no constants of type %s", name)
+	}
+	sort.Slice(consts, func(i, j int) bool { return consts[i].Pos() < consts[j].Pos() })
+
+	e := &enum{Name: name, Unsigned: b.Info()&types.IsUnsigned != 0}
+	seen := make(map[string]bool)
+	for _, c := range consts {
+		v := value{Const: c.Name(), Name: strings.TrimPrefix(c.Name(), trim)}
+		e.Names = append(e.Names, v)
+		if k := c.Val().ExactString(); !seen[k] && c.Val().Kind() == constant.Int {
+			seen[k] = true
+			e.Values = append(e.Values, v)
+		}
+	}
+	return e, nil
+}
+
+var (
+	flags      = flag.NewFlagSet("go-enum", flag.ContinueOnError)
+	typeNames  = flags.String("type", "", "Comma-separated list of type names")
+	trimPrefix = flags.String("trimprefix", "", "Prefix to remove from constant names")
+	out        = gen.OutputFlags(flags)
+)
+
+// Run runs go-enum with the command line arguments args, which do not include
+// the program name.
+func Run(args []string) error {
+	if err := gen.ParseFlags(flags, args); err != nil {
+		return err
+	}
+	if *typeNames == "" || flags.NArg() > 1 {
+		return errors.New("Usage: go-enum [-trimprefix=<prefix>] -type=<type>[,<type>...] [<dir>]")
+	}
+	dir := "."
+	if flags.NArg() == 1 {
+		dir = flags.Arg(0)
+	}
+
+	lp, err := gen.LoadPackage(dir)
+	if err != nil {
+		return err
+	}
+
+	p := pkg{Package: lp.Types.Name()}
+	for _, name := range strings.Split(*typeNames, ",") {
+		e, err := collect(lp.Types, name, *trimPrefix)
+		if err != nil {
+			return err
+		}
+		p.Types = append(p.Types, e)
+	}
+
+	return out.Write(implTemplate, p)
+}
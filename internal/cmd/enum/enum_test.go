@@ -0,0 +1,79 @@
+package enum
+
+import (
+	"testing"
+
+	"merovius.de/go-misc/internal/gen/gentest"
+)
+
+func TestMain(m *testing.M) {
+	gentest.Main(m, Run)
+}
+
+// src declares the enums of the tests: a signed one with an alias and an
+// unsigned one.
+const src = `package color
+
+type Color int
+
+const (
+	ColorRed Color = iota
+	ColorGreen
+	ColorBlue
+	ColorDefault = ColorRed
+)
+
+type Level uint8
+
+const (
+	Debug Level = iota + 1
+	Info
+)
+`
+
+func TestGolden(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		args []string
+	}{
+		{"default", []string{"-type=Color,Level"}},
+		{"trimprefix", []string{"-type=Color", "-trimprefix=Color"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := gentest.Dir(t, map[string]string{"color.go": src})
+			gentest.Generate(t, dir, append(tc.args, "-out=enum.go")...)
+			gentest.Golden(t, dir, "enum.go", tc.name+".go.golden")
+			gentest.Vet(t, dir)
+		})
+	}
+}
+
+func TestEnum(t *testing.T) {
+	dir := gentest.Dir(t, map[string]string{"color.go": src, "enum_test.go": `package color
+
+import "testing"
+
+func TestColor(t *testing.T) {
+	for _, c := range ColorValues() {
+		p, err := ParseColor(c.String())
+		if p != c || err != nil {
+			t.Errorf("ParseColor(%q) == %v, %v, want %v, <nil>", c.String(), p, err, c)
+		}
+	}
+	if c, err := ParseColor("Default"); c != ColorRed || err != nil {
+		t.Errorf("ParseColor(%q) == %v, %v, want %v, <nil>", "Default", c, err, ColorRed)
+	}
+	if got := Color(7).String(); got != "Color(7)" {
+		t.Errorf("Color(7).String() == %q, want %q", got, "Color(7)")
+	}
+	if _, err := Color(7).MarshalText(); err == nil {
+		t.Errorf("Color(7).MarshalText() succeeded")
+	}
+	if n := len(ColorValues()); n != 3 {
+		t.Errorf("len(ColorValues()) == %d, want 3", n)
+	}
+}
+`})
+	gentest.Generate(t, dir, "-type=Color", "-trimprefix=Color", "-out=enum.go")
+	gentest.Test(t, dir)
+}
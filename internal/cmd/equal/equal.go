@@ -0,0 +1,243 @@
+// Package equal implements go-equal. See merovius.de/go-misc/cmd/go-equal for its
+// documentation.
+package equal // import "merovius.de/go-misc/internal/cmd/equal"
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"merovius.de/go-misc/internal/gen"
+)
+
+var implTemplate = template.Must(template.New("equal.go").Parse(`
+// This file is automatically generated by merovius.de/go-misc/cmd/go-equal.
+
+package {{ .Package }}
+
+{{ if .Imports -}}
+import (
+{{- range .Imports }}
+	{{ . }}
+{{- end }}
+)
+{{- end }}
+
+{{ range .Types }}
+// Equal returns whether a and b are deeply equal.
+func (a {{ .Name }}) Equal(b {{ .Name }}) bool {
+	{{- .Body }}
+	return true
+}
+{{ end }}
+`))
+
+type pkg struct {
+	Package string
+	Imports []string
+	Types   []method
+}
+
+type method struct {
+	Name string
+	Body string
+}
+
+// comparer generates code to compare values.
+type comparer struct {
+	pkg      *types.Package
+	im       *gen.Imports
+	selected map[*types.Named]bool
+	epsilon  string
+	buf      *bytes.Buffer
+	vars     int
+	err      error
+}
+
+func (c *comparer) newVar(prefix string) string {
+	c.vars++
+	return fmt.Sprintf("%s%d", prefix, c.vars)
+}
+
+// hasEqual returns whether t has a method Equal(t) bool.
+func hasEqual(t types.Type) bool {
+	obj, _, _ := types.LookupFieldOrMethod(t, false, nil, "Equal")
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return false
+	}
+	sig := fn.Type().(*types.Signature)
+	if sig.Params().Len() != 1 || sig.Results().Len() != 1 {
+		return false
+	}
+	return types.Identical(sig.Params().At(0).Type(), t) && types.Identical(sig.Results().At(0).Type(), types.Typ[types.Bool])
+}
+
+// cmp emits code returning false, if a and b are not equal.
+func (c *comparer) cmp(a, b string, t types.Type, stack map[*types.Named]bool) {
+	if n, ok := t.(*types.Named); ok {
+		if c.selected[n] || hasEqual(n) {
+			if strings.HasPrefix(b, "(*") {
+				b = b[1 : len(b)-1]
+			}
+			fmt.Fprintf(c.buf, "\nif !%s.Equal(%s) {\nreturn false\n}", a, b)
+			return
+		}
+		if stack[n] {
+			c.err = fmt.Errorf("recursive type %s must be given to -type", n.Obj().Name())
+			return
+		}
+		stack[n] = true
+		defer delete(stack, n)
+	}
+
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		d := a + "-" + b
+		switch {
+		case c.epsilon != "" && u.Info()&types.IsFloat != 0:
+			if u.Kind() != types.Float64 || t != u {
+				d = "float64(" + d + ")"
+			}
+			c.im.Add("math", "math")
+			fmt.Fprintf(c.buf, "\nif %s != %s && math.Abs(%s) > %s {\nreturn false\n}", a, b, d, c.epsilon)
+		case c.epsilon != "" && u.Info()&types.IsComplex != 0:
+			if u.Kind() != types.Complex128 || t != u {
+				d = "complex128(" + d + ")"
+			}
+			c.im.Add("math/cmplx", "cmplx")
+			fmt.Fprintf(c.buf, "\nif %s != %s && cmplx.Abs(%s) > %s {\nreturn false\n}", a, b, d, c.epsilon)
+		default:
+			fmt.Fprintf(c.buf, "\nif %s != %s {\nreturn false\n}", a, b)
+		}
+	case *types.Pointer:
+		fmt.Fprintf(c.buf, "\nif %s != %s {", a, b)
+		fmt.Fprintf(c.buf, "\nif %s == nil || %s == nil {\nreturn false\n}", a, b)
+		c.cmp("(*"+a+")", "(*"+b+")", u.Elem(), stack)
+		c.buf.WriteString("\n}")
+	case *types.Slice:
+		fmt.Fprintf(c.buf, "\nif (%s == nil) != (%s == nil) || len(%s) != len(%s) {\nreturn false\n}", a, b, a, b)
+		i := c.newVar("i")
+		fmt.Fprintf(c.buf, "\nfor %s := range %s {", i, a)
+		c.cmp(a+"["+i+"]", b+"["+i+"]", u.Elem(), stack)
+		c.buf.WriteString("\n}")
+	case *types.Array:
+		i := c.newVar("i")
+		fmt.Fprintf(c.buf, "\nfor %s := range %s {", i, a)
+		c.cmp(a+"["+i+"]", b+"["+i+"]", u.Elem(), stack)
+		c.buf.WriteString("\n}")
+	case *types.Map:
+		fmt.Fprintf(c.buf, "\nif (%s == nil) != (%s == nil) || len(%s) != len(%s) {\nreturn false\n}", a, b, a, b)
+		k, va, vb := c.newVar("k"), c.newVar("v"), c.newVar("v")
+		fmt.Fprintf(c.buf, "\nfor %s, %s := range %s {", k, va, a)
+		fmt.Fprintf(c.buf, "\n%s, ok := %s[%s]\nif !ok {\nreturn false\n}", vb, b, k)
+		c.cmp(va, vb, u.Elem(), stack)
+		c.buf.WriteString("\n}")
+	case *types.Struct:
+		for i := 0; i < u.NumFields(); i++ {
+			f := u.Field(i)
+			if f.Name() == "_" {
+				continue
+			}
+			if !f.Exported() && f.Pkg() != c.pkg {
+				if !types.Comparable(t) {
+					c.err = fmt.Errorf("can not compare %s, as it has inaccessible fields and is not comparable", t)
+					return
+				}
+				fmt.Fprintf(c.buf, "\nif %s != %s {\nreturn false\n}", a, b)
+				return
+			}
+		}
+		for i := 0; i < u.NumFields(); i++ {
+			if f := u.Field(i); f.Name() != "_" {
+				c.cmp(a+"."+f.Name(), b+"."+f.Name(), f.Type(), stack)
+			}
+		}
+	case *types.Signature:
+		fmt.Fprintf(c.buf, "\nif %s != nil || %s != nil {\nreturn false\n}", a, b)
+	default:
+		fmt.Fprintf(c.buf, "\nif %s != %s {\nreturn false\n}", a, b)
+	}
+}
+
+var (
+	flags     = flag.NewFlagSet("go-equal", flag.ContinueOnError)
+	typeNames = flags.String("type", "", "Comma-separated list of struct type names")
+	exclude   = flags.String("exclude", "", "Comma-separated list of fields to exclude")
+	epsilon   = flags.Float64("epsilon", 0, "Maximum difference of equal floating point values")
+	out       = gen.OutputFlags(flags)
+)
+
+// Run runs go-equal with the command line arguments args, which do not include
+// the program name.
+func Run(args []string) error {
+	if err := gen.ParseFlags(flags, args); err != nil {
+		return err
+	}
+	if *typeNames == "" || flags.NArg() > 1 {
+		return errors.New("Usage: go-equal [-exclude=<fields>] [-epsilon=<tolerance>] -type=<type>[,<type>...] [<dir>]")
+	}
+	if *epsilon < 0 {
+		return errors.New("-epsilon must not be negative")
+	}
+	dir := "."
+	if flags.NArg() == 1 {
+		dir = flags.Arg(0)
+	}
+
+	excluded := make(map[string]bool)
+	if *exclude != "" {
+		for _, f := range strings.Split(*exclude, ",") {
+			excluded[f] = true
+		}
+	}
+
+	lp, err := gen.LoadPackage(dir)
+	if err != nil {
+		return err
+	}
+
+	c := &comparer{
+		pkg:      lp.Types,
+		im:       gen.NewImports(lp.Types),
+		selected: make(map[*types.Named]bool),
+	}
+	if *epsilon > 0 {
+		c.epsilon = strconv.FormatFloat(*epsilon, 'g', -1, 64)
+	}
+	names := strings.Split(*typeNames, ",")
+	var structs []*types.Struct
+	for _, name := range names {
+		n, s, err := lp.Struct(name)
+		if err != nil {
+			return err
+		}
+		c.selected[n] = true
+		structs = append(structs, s)
+	}
+
+	p := pkg{Package: lp.Types.Name()}
+	for i, s := range structs {
+		c.buf = new(bytes.Buffer)
+		for j := 0; j < s.NumFields(); j++ {
+			f := s.Field(j)
+			if f.Name() == "_" || excluded[f.Name()] || excluded[names[i]+"."+f.Name()] || reflect.StructTag(s.Tag(j)).Get("equal") == "-" {
+				continue
+			}
+			c.cmp("a."+f.Name(), "b."+f.Name(), f.Type(), make(map[*types.Named]bool))
+		}
+		if c.err != nil {
+			return c.err
+		}
+		p.Types = append(p.Types, method{Name: names[i], Body: c.buf.String()})
+	}
+	p.Imports = c.im.List()
+
+	return out.Write(implTemplate, p)
+}
@@ -0,0 +1,109 @@
+package equal
+
+import (
+	"testing"
+
+	"merovius.de/go-misc/internal/gen/gentest"
+)
+
+func TestMain(m *testing.M) {
+	gentest.Main(m, Run)
+}
+
+// src declares the types of the tests, with fields of all kinds of types,
+// including a recursive type and a type with an Equal method.
+const src = `package shape
+
+import "time"
+
+type Point struct {
+	X, Y float64
+}
+
+type Shape struct {
+	Name     string
+	Points   []Point
+	Tags     map[string][]string
+	Matrix   [2][2]float32
+	Scale    *complex128
+	Created  time.Time
+	Children []*Shape
+	OnDraw   func()
+	cache    []byte ` + "`equal:\"-\"`" + `
+	version  int
+}
+`
+
+func TestGolden(t *testing.T) {
+	tcs := []struct {
+		name string
+		args []string
+	}{
+		{"default", nil},
+		{"exclude", []string{"-exclude=version,Shape.Tags"}},
+		{"epsilon", []string{"-epsilon=1e-9"}},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := gentest.Dir(t, map[string]string{"shape.go": src})
+			gentest.Generate(t, dir, append(tc.args, "-type=Shape,Point", "-out=equal.go")...)
+			gentest.Golden(t, dir, "equal.go", tc.name+".go.golden")
+			gentest.Vet(t, dir)
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	dir := gentest.Dir(t, map[string]string{"shape.go": src + "\ntype Scene struct{ Root *Shape }\n"})
+	// Shape refers to itself, so it needs its own Equal method.
+	gentest.Fail(t, dir, "-type=Scene", "-out=equal.go")
+	gentest.Fail(t, dir, "-epsilon=-1", "-type=Point", "-out=equal.go")
+	gentest.Fail(t, dir, "-out=equal.go")
+}
+
+func TestEqual(t *testing.T) {
+	dir := gentest.Dir(t, map[string]string{"shape.go": src, "equal_test.go": `package shape
+
+import "testing"
+
+func TestEqual(t *testing.T) {
+	s := func() Shape {
+		return Shape{
+			Name:     "s",
+			Points:   []Point{{1, 2}},
+			Tags:     map[string][]string{"a": {"b"}},
+			Children: []*Shape{{Name: "c"}},
+			cache:    []byte("x"),
+		}
+	}
+	a, b := s(), s()
+	if !a.Equal(b) {
+		t.Errorf("Equal() == false for equal values")
+	}
+	b.cache = nil
+	if !a.Equal(b) {
+		t.Errorf("Equal() == false for values differing in an ignored field")
+	}
+	changes := []func(*Shape){
+		func(s *Shape) { s.Points[0].Y = 3 },
+		func(s *Shape) { s.Tags["a"] = nil },
+		func(s *Shape) { s.Tags = nil },
+		func(s *Shape) { s.Children[0].Name = "d" },
+		func(s *Shape) { s.Children = append(s.Children, nil) },
+		func(s *Shape) { s.Matrix[1][1] = 1 },
+		func(s *Shape) { s.Scale = new(complex128) },
+		func(s *Shape) { s.OnDraw = func() {} },
+		func(s *Shape) { s.version++ },
+	}
+	for i, f := range changes {
+		b := s()
+		f(&b)
+		if a.Equal(b) || b.Equal(a) {
+			t.Errorf("Equal() == true after change %d", i)
+		}
+	}
+}
+`})
+	gentest.Generate(t, dir, "-type=Shape,Point", "-out=equal.go")
+	gentest.Test(t, dir)
+}
@@ -0,0 +1,123 @@
+// Package eventbus implements go-eventbus. See merovius.de/go-misc/cmd/go-eventbus for its
+// documentation.
+package eventbus // import "merovius.de/go-misc/internal/cmd/eventbus"
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"text/template"
+
+	"merovius.de/go-misc/internal/gen"
+)
+
+var implTemplate = template.Must(template.New("eventbus.go").Parse(`
+// This file is automatically generated by merovius.de/go-misc/cmd/go-eventbus.
+
+package {{ .Package }}
+
+import "sync"
+
+{{ range .Types }}
+	{{ template "impl" . }}
+{{ end }}
+`))
+
+var _ = template.Must(implTemplate.New("impl").Parse(`
+// {{ .Name }} delivers events of type {{ .Type }} to its subscribers. The zero
+// value is a bus without subscribers. A {{ .Name }} must not be copied after
+// first use.
+type {{ .Name }} struct {
+	mu sync.Mutex
+	// subs is never modified in place, so Publish can use it without holding
+	// mu.
+	subs []*func({{ .Type }})
+}
+
+// Subscribe registers f to be called for every published event. The returned
+// func cancels the subscription.
+func (b *{{ .Name }}) Subscribe(f func({{ .Type }})) (cancel func()) {
+	s := &f
+	b.mu.Lock()
+	b.subs = append(b.subs[:len(b.subs):len(b.subs)], s)
+	b.mu.Unlock()
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for i, o := range b.subs {
+			if o == s {
+				subs := make([]*func({{ .Type }}), 0, len(b.subs)-1)
+				b.subs = append(append(subs, b.subs[:i]...), b.subs[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// SubscribeChan returns a channel with buffer size n, receiving every
+// published event. The returned func cancels the subscription. The channel is
+// never closed.
+func (b *{{ .Name }}) SubscribeChan(n int) (<-chan {{ .Type }}, func()) {
+	ch := make(chan {{ .Type }}, n)
+	done := make(chan struct{})
+	cancel := b.Subscribe(func(v {{ .Type }}) {
+		select {
+		case ch <- v:
+		case <-done:
+		}
+	})
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			close(done)
+			cancel()
+		})
+	}
+}
+
+// Publish delivers v to all current subscribers.
+func (b *{{ .Name }}) Publish(v {{ .Type }}) {
+	b.mu.Lock()
+	subs := b.subs
+	b.mu.Unlock()
+	for _, s := range subs {
+		(*s)(v)
+	}
+}
+
+// Len returns the number of subscribers.
+func (b *{{ .Name }}) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
+`))
+
+type pkg struct {
+	Package string
+	Types   []gen.Type
+}
+
+var (
+	flags   = flag.NewFlagSet("go-eventbus", flag.ContinueOnError)
+	pkgName = flags.String("package", os.Getenv("GOPACKAGE"), "Package the file should be in")
+	out     = gen.OutputFlags(flags)
+)
+
+// Run runs go-eventbus with the command line arguments args, which do not include
+// the program name.
+func Run(args []string) error {
+	if err := gen.ParseFlags(flags, args); err != nil {
+		return err
+	}
+
+	types, err := gen.ParseTypes(flags.Args())
+	if err != nil || flags.NArg() == 0 {
+		return errors.New("Usage: go-eventbus [-package=<pkg>] <name> <type> [<name> <type>]...")
+	}
+	if *pkgName == "" {
+		return errors.New("no package given")
+	}
+
+	return out.Write(implTemplate, pkg{Package: *pkgName, Types: types})
+}
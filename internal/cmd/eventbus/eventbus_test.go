@@ -0,0 +1,127 @@
+package eventbus
+
+import (
+	"testing"
+
+	"merovius.de/go-misc/internal/gen/gentest"
+)
+
+func TestMain(m *testing.M) {
+	gentest.Main(m, Run)
+}
+
+func TestGolden(t *testing.T) {
+	dir := gentest.Dir(t, nil)
+	gentest.Generate(t, dir, "-package=events", "-out=eventbus.go", "IntBus", "int", "ErrorBus", "error")
+	gentest.Golden(t, dir, "eventbus.go", "eventbus.go.golden")
+	gentest.Vet(t, dir)
+}
+
+func TestUsage(t *testing.T) {
+	dir := gentest.Dir(t, nil)
+	gentest.Fail(t, dir, "-package=events", "-out=eventbus.go")
+	gentest.Fail(t, dir, "-package=events", "-out=eventbus.go", "IntBus")
+	gentest.Fail(t, dir, "-package=", "-out=eventbus.go", "IntBus", "int")
+}
+
+// busTest tests an IntBus under concurrent use. It is run with the race
+// detector.
+const busTest = `package events
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSubscribe(t *testing.T) {
+	var (
+		b      IntBus
+		got    []int
+		cancel = b.Subscribe(func(v int) { got = append(got, v) })
+	)
+	b.Publish(1)
+	b.Subscribe(func(int) {})
+	if b.Len() != 2 {
+		t.Errorf("Len() == %d, want 2", b.Len())
+	}
+	cancel()
+	cancel()
+	b.Publish(2)
+	if len(got) != 1 || got[0] != 1 || b.Len() != 1 {
+		t.Errorf("got %v, Len() == %d after cancel, want [1], 1", got, b.Len())
+	}
+}
+
+func TestConcurrent(t *testing.T) {
+	var (
+		b  IntBus
+		n  int64
+		wg sync.WaitGroup
+	)
+	// A subscriber, which is always there, to count the published events.
+	b.Subscribe(func(int) { atomic.AddInt64(&n, 1) })
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				b.Publish(j)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				// Canceling during Publish must not deadlock.
+				c := make(chan func(), 1)
+				c <- b.Subscribe(func(int) {
+					select {
+					case cancel := <-c:
+						cancel()
+					default:
+					}
+				})
+			}
+		}()
+	}
+	wg.Wait()
+	if n != 400 {
+		t.Errorf("subscriber called %d times, want 400", n)
+	}
+}
+
+func TestSubscribeChan(t *testing.T) {
+	var b IntBus
+	ch, cancel := b.SubscribeChan(1)
+	b.Publish(1)
+	if v := <-ch; v != 1 {
+		t.Fatalf("received %d, want 1", v)
+	}
+
+	// Publish blocks on the full channel, until the subscription is canceled.
+	b.Publish(2)
+	done := make(chan struct{})
+	go func() {
+		b.Publish(3)
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("Publish did not block on full channel")
+	case <-time.After(10 * time.Millisecond):
+	}
+	cancel()
+	<-done
+	cancel()
+	if b.Len() != 0 {
+		t.Errorf("Len() == %d after cancel, want 0", b.Len())
+	}
+}
+`
+
+func TestBus(t *testing.T) {
+	dir := gentest.Dir(t, map[string]string{"eventbus_test.go": busTest})
+	gentest.Generate(t, dir, "-package=events", "-out=eventbus.go", "IntBus", "int")
+	gentest.Test(t, dir, "-race")
+}
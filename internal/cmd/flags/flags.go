@@ -0,0 +1,371 @@
+// Package flags implements go-flags. See merovius.de/go-misc/cmd/go-flags for its
+// documentation.
+package flags // import "merovius.de/go-misc/internal/cmd/flags"
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+
+	"merovius.de/go-misc/internal/gen"
+)
+
+var implTemplate = template.Must(template.New("flags.go").Parse(`
+// This file is automatically generated by merovius.de/go-misc/cmd/go-flags.
+
+package {{ .Package }}
+
+{{ if .Imports -}}
+import (
+{{- range .Imports }}
+	{{ . }}
+{{- end }}
+)
+{{- end }}
+
+{{ range .Types }}
+	{{ template "impl" . }}
+{{ end }}
+`))
+
+var _ = template.Must(implTemplate.New("impl").Parse(`
+// RegisterFlags registers the fields of c as flags with fs. The fields are
+// set to their defaults, overridden by environment variables, if set.
+func (c *{{ .Name }}) RegisterFlags(fs *flag.FlagSet) error {
+{{- range .Fields }}
+{{- if eq .Kind "Value" }}
+{{- if .Default }}
+	if err := c.{{ .Name }}.Set({{ .Default }}); err != nil {
+		return fmt.Errorf("invalid default for -{{ .Flag }}: %v", err)
+	}
+{{- end }}
+	fs.Var(&c.{{ .Name }}, "{{ .Flag }}", {{ .Usage }})
+{{- else }}
+	fs.{{ .Kind }}Var({{ .Ptr }}, "{{ .Flag }}", {{ .Default }}, {{ .Usage }})
+{{- end }}
+{{- if .Env }}
+	if v, ok := os.LookupEnv("{{ .Env }}"); ok {
+{{- if eq .Kind "Value" }}
+		if err := c.{{ .Name }}.Set(v); err != nil {
+			return fmt.Errorf("invalid value %q for ${{ .Env }}: %v", v, err)
+		}
+{{- else if eq .Kind "String" }}
+		c.{{ .Name }} = {{ .Conv "v" }}
+{{- else }}
+		x, err := {{ .Parse }}
+		if err != nil {
+			return fmt.Errorf("invalid value %q for ${{ .Env }}: %v", v, err)
+		}
+		c.{{ .Name }} = {{ .Conv "x" }}
+{{- end }}
+	}
+{{- end }}
+{{- end }}
+	return nil
+}
+`))
+
+type pkg struct {
+	Package string
+	Imports []string
+	Types   []config
+}
+
+type config struct {
+	Name   string
+	Fields []field
+}
+
+type field struct {
+	Name string
+	// Kind is the suffix of the flag.FlagSet method used to register the
+	// field, or "Value" for flag.Value implementations.
+	Kind    string
+	Flag    string
+	Env     string
+	Usage   string
+	Default string
+	// Type is the type of the field.
+	Type string
+	// Base is the type used by the flag package and returned by Parse.
+	Base string
+}
+
+// Ptr returns the pointer to the field passed to the flag package.
+func (f field) Ptr() string {
+	if f.Type == f.Base {
+		return "&c." + f.Name
+	}
+	return "(*" + f.Base + ")(&c." + f.Name + ")"
+}
+
+// Conv returns the expression converting the variable v, as returned by
+// Parse, to the type of the field.
+func (f field) Conv(v string) string {
+	t := f.Base
+	switch f.Kind {
+	case "Int":
+		t = "int64"
+	case "Uint":
+		t = "uint64"
+	}
+	if f.Type == t {
+		return v
+	}
+	return f.Type + "(" + v + ")"
+}
+
+// Parse returns the expression parsing v into the base type.
+func (f field) Parse() string {
+	switch f.Kind {
+	case "Bool":
+		return "strconv.ParseBool(v)"
+	case "Int":
+		return "strconv.ParseInt(v, 0, strconv.IntSize)"
+	case "Int64":
+		return "strconv.ParseInt(v, 0, 64)"
+	case "Uint":
+		return "strconv.ParseUint(v, 0, strconv.IntSize)"
+	case "Uint64":
+		return "strconv.ParseUint(v, 0, 64)"
+	case "Float64":
+		return "strconv.ParseFloat(v, 64)"
+	case "Duration":
+		return "time.ParseDuration(v)"
+	}
+	panic("no parser for " + f.Kind)
+}
+
+// kinds maps basic kinds to the flag.FlagSet method suffix used for them.
+var kinds = map[types.BasicKind]string{
+	types.String:  "String",
+	types.Bool:    "Bool",
+	types.Int:     "Int",
+	types.Int64:   "Int64",
+	types.Uint:    "Uint",
+	types.Uint64:  "Uint64",
+	types.Float64: "Float64",
+}
+
+// kind returns the flag.FlagSet method suffix for values of type t.
+func kind(t types.Type) (string, bool) {
+	if n, ok := t.(*types.Named); ok && n.Obj().Pkg() != nil && n.Obj().Pkg().Path() == "time" && n.Obj().Name() == "Duration" {
+		return "Duration", true
+	}
+	if isValue(types.NewPointer(t)) {
+		return "Value", true
+	}
+	if b, ok := t.Underlying().(*types.Basic); ok {
+		k, ok := kinds[b.Kind()]
+		return k, ok
+	}
+	return "", false
+}
+
+// isValue returns whether t implements flag.Value.
+func isValue(t types.Type) bool {
+	var (
+		str = types.Typ[types.String]
+		err = types.Universe.Lookup("error").Type()
+	)
+	has := func(name string, param, result types.Type) bool {
+		obj, _, _ := types.LookupFieldOrMethod(t, false, nil, name)
+		fn, ok := obj.(*types.Func)
+		if !ok {
+			return false
+		}
+		sig := fn.Type().(*types.Signature)
+		if sig.Results().Len() != 1 || !types.Identical(sig.Results().At(0).Type(), result) {
+			return false
+		}
+		if param == nil {
+			return sig.Params().Len() == 0
+		}
+		return sig.Params().Len() == 1 && types.Identical(sig.Params().At(0).Type(), param)
+	}
+	return has("String", nil, str) && has("Set", str, err)
+}
+
+// literal validates the default value s for a field of the given kind and
+// returns it as a Go expression.
+func literal(kind, s string) (string, error) {
+	if kind == "String" || kind == "Value" {
+		return strconv.Quote(s), nil
+	}
+	if s == "" {
+		if kind == "Bool" {
+			return "false", nil
+		}
+		return "0", nil
+	}
+	var err error
+	switch kind {
+	case "Bool":
+		var b bool
+		b, err = strconv.ParseBool(s)
+		s = strconv.FormatBool(b)
+	case "Int", "Int64":
+		_, err = strconv.ParseInt(s, 0, 64)
+	case "Uint", "Uint64":
+		_, err = strconv.ParseUint(s, 0, 64)
+	case "Float64":
+		_, err = strconv.ParseFloat(s, 64)
+	case "Duration":
+		var d time.Duration
+		d, err = time.ParseDuration(s)
+		s = durationLiteral(d)
+	}
+	return s, err
+}
+
+// durationLiteral returns d as a multiple of the largest unit dividing it,
+// e.g. "1500 * time.Millisecond".
+func durationLiteral(d time.Duration) string {
+	units := []struct {
+		d    time.Duration
+		name string
+	}{
+		{time.Hour, "Hour"},
+		{time.Minute, "Minute"},
+		{time.Second, "Second"},
+		{time.Millisecond, "Millisecond"},
+		{time.Microsecond, "Microsecond"},
+	}
+	for _, u := range units {
+		if d%u.d == 0 {
+			return fmt.Sprintf("%d * time.%s", d/u.d, u.name)
+		}
+	}
+	return fmt.Sprintf("%d * time.Nanosecond", d)
+}
+
+// words splits an identifier into words, e.g. "HTTPAddr" into "HTTP" and
+// "Addr".
+func words(s string) []string {
+	var (
+		w []string
+		r = []rune(s)
+		i = 0
+	)
+	for j := 1; j <= len(r); j++ {
+		if j < len(r) && !(unicode.IsUpper(r[j]) && (unicode.IsLower(r[j-1]) || unicode.IsDigit(r[j-1]) || j+1 < len(r) && unicode.IsLower(r[j+1]))) && r[j] != '_' {
+			continue
+		}
+		if word := strings.Trim(string(r[i:j]), "_"); word != "" {
+			w = append(w, word)
+		}
+		i = j
+	}
+	return w
+}
+
+// collect returns the config for the struct type name in p.
+func collect(p *gen.Package, im *gen.Imports, name, envPrefix string) (config, error) {
+	_, s, err := p.Struct(name)
+	if err != nil {
+		return config{}, err
+	}
+	c := config{Name: name}
+	for i := 0; i < s.NumFields(); i++ {
+		v := s.Field(i)
+		tag := reflect.StructTag(s.Tag(i))
+		if v.Name() == "_" || tag.Get("flag") == "-" {
+			continue
+		}
+		f := field{
+			Name:  v.Name(),
+			Flag:  tag.Get("flag"),
+			Env:   tag.Get("env"),
+			Usage: strconv.Quote(tag.Get("usage")),
+			Type:  im.TypeString(v.Type()),
+		}
+		k, ok := kind(v.Type())
+		if !ok {
+			return config{}, fmt.Errorf("unsupported type %s of field %s.%s", v.Type(), name, v.Name())
+		}
+		f.Kind = k
+		switch k {
+		case "Duration":
+			f.Base = im.TypeString(v.Type())
+		case "Value":
+		default:
+			f.Base = strings.ToLower(k)
+		}
+		if f.Flag == "" {
+			f.Flag = strings.ToLower(strings.Join(words(v.Name()), "-"))
+		}
+		if f.Env == "" && envPrefix != "" {
+			f.Env = envPrefix + strings.ToUpper(strings.Join(words(v.Name()), "_"))
+		}
+		if f.Env == "-" {
+			f.Env = ""
+		}
+		if f.Default, err = literal(k, tag.Get("default")); err != nil {
+			return config{}, fmt.Errorf("invalid default for %s.%s: %v", name, v.Name(), err)
+		}
+		if k == "Value" && tag.Get("default") == "" {
+			f.Default = ""
+		}
+		c.Fields = append(c.Fields, f)
+	}
+	return c, nil
+}
+
+var (
+	flags     = flag.NewFlagSet("go-flags", flag.ContinueOnError)
+	typeNames = flags.String("type", "", "Comma-separated list of struct type names")
+	envPrefix = flags.String("env-prefix", "", "Read environment variables with the given prefix for all fields")
+	out       = gen.OutputFlags(flags)
+)
+
+// Run runs go-flags with the command line arguments args, which do not include
+// the program name.
+func Run(args []string) error {
+	if err := gen.ParseFlags(flags, args); err != nil {
+		return err
+	}
+	if *typeNames == "" || flags.NArg() > 1 {
+		return errors.New("Usage: go-flags [-env-prefix=<prefix>] -type=<type>[,<type>...] [<dir>]")
+	}
+	dir := "."
+	if flags.NArg() == 1 {
+		dir = flags.Arg(0)
+	}
+
+	lp, err := gen.LoadPackage(dir)
+	if err != nil {
+		return err
+	}
+
+	im := gen.NewImports(lp.Types)
+	im.Add("flag", "flag")
+	p := pkg{Package: lp.Types.Name()}
+	for _, name := range strings.Split(*typeNames, ",") {
+		c, err := collect(lp, im, name, *envPrefix)
+		if err != nil {
+			return err
+		}
+		for _, f := range c.Fields {
+			if f.Kind == "Value" && f.Default != "" || f.Env != "" {
+				im.Add("fmt", "fmt")
+			}
+			if f.Env != "" {
+				im.Add("os", "os")
+			}
+			if f.Env != "" && f.Kind != "Value" && f.Kind != "String" && f.Kind != "Duration" {
+				im.Add("strconv", "strconv")
+			}
+		}
+		p.Types = append(p.Types, c)
+	}
+	p.Imports = im.List()
+
+	return out.Write(implTemplate, p)
+}
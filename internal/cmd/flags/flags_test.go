@@ -0,0 +1,139 @@
+package flags
+
+import (
+	"strings"
+	"testing"
+
+	"merovius.de/go-misc/internal/gen/gentest"
+)
+
+func TestMain(m *testing.M) {
+	gentest.Main(m, Run)
+}
+
+// src declares the config type of the tests, with fields of all supported
+// kinds.
+const src = `package config
+
+import (
+	"errors"
+	"time"
+)
+
+type Size uint64
+
+type Level int
+
+func (l *Level) String() string {
+	return [...]string{"info", "debug"}[*l]
+}
+
+func (l *Level) Set(s string) error {
+	switch s {
+	case "info":
+		*l = 0
+	case "debug":
+		*l = 1
+	default:
+		return errors.New("invalid level")
+	}
+	return nil
+}
+
+type Config struct {
+	Addr     string        ` + "`default:\":8080\" usage:\"listen address\"`" + `
+	Debug    bool          ` + "`env:\"DEBUG\"`" + `
+	Workers  int           ` + "`default:\"4\"`" + `
+	Timeout  time.Duration ` + "`default:\"1.5s\"`" + `
+	MaxSize  Size          ` + "`default:\"0x100\"`" + `
+	Ratio    float64       ` + "`flag:\"r\" env:\"-\"`" + `
+	Level    Level         ` + "`default:\"debug\"`" + `
+	HTTPAddr string
+	secret   string ` + "`flag:\"-\"`" + `
+}
+`
+
+func TestGolden(t *testing.T) {
+	tcs := []struct {
+		name string
+		args []string
+	}{
+		{"default", nil},
+		{"env", []string{"-env-prefix=APP_"}},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := gentest.Dir(t, map[string]string{"config.go": src})
+			gentest.Generate(t, dir, append(tc.args, "-type=Config", "-out=flags.go")...)
+			gentest.Golden(t, dir, "flags.go", tc.name+".go.golden")
+			gentest.Vet(t, dir)
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	dir := gentest.Dir(t, map[string]string{"config.go": "package config\n\ntype A struct{ C chan int }\n\ntype B struct{ N int `default:\"x\"` }\n"})
+	gentest.Fail(t, dir, "-type=A", "-out=flags.go")
+	gentest.Fail(t, dir, "-type=B", "-out=flags.go")
+	gentest.Fail(t, dir, "-out=flags.go")
+}
+
+func TestWords(t *testing.T) {
+	tcs := []struct {
+		in   string
+		want string
+	}{
+		{"Addr", "Addr"},
+		{"HTTPAddr", "HTTP Addr"},
+		{"MaxIdle2Conns", "Max Idle2 Conns"},
+		{"TLS_Cert", "TLS Cert"},
+		{"userID", "user ID"},
+	}
+	for _, tc := range tcs {
+		if s := strings.Join(words(tc.in), " "); s != tc.want {
+			t.Errorf("words(%q) == %q, want %q", tc.in, s, tc.want)
+		}
+	}
+}
+
+func TestFlags(t *testing.T) {
+	dir := gentest.Dir(t, map[string]string{"config.go": src, "flags_test.go": `package config
+
+import (
+	"flag"
+	"testing"
+	"time"
+)
+
+func TestFlags(t *testing.T) {
+	t.Setenv("APP_WORKERS", "8")
+	t.Setenv("DEBUG", "true")
+	t.Setenv("APP_RATIO", "invalid, but ignored")
+
+	var c Config
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	if err := c.RegisterFlags(fs); err != nil {
+		t.Fatal(err)
+	}
+	if c.Addr != ":8080" || c.Timeout != 1500*time.Millisecond || c.MaxSize != 256 || c.Level != 1 {
+		t.Errorf("defaults not set: %+v", c)
+	}
+	if c.Workers != 8 || !c.Debug {
+		t.Errorf("environment not read: %+v", c)
+	}
+	if err := fs.Parse([]string{"-http-addr=:80", "-r=0.5", "-level=info", "-workers=2"}); err != nil {
+		t.Fatal(err)
+	}
+	if c.HTTPAddr != ":80" || c.Ratio != 0.5 || c.Level != 0 || c.Workers != 2 {
+		t.Errorf("flags not parsed: %+v", c)
+	}
+
+	t.Setenv("APP_TIMEOUT", "soon")
+	if err := new(Config).RegisterFlags(flag.NewFlagSet("test", flag.ContinueOnError)); err == nil {
+		t.Error("RegisterFlags succeeded with invalid $APP_TIMEOUT")
+	}
+}
+`})
+	gentest.Generate(t, dir, "-env-prefix=APP_", "-type=Config", "-out=flags.go")
+	gentest.Test(t, dir)
+}
@@ -0,0 +1,109 @@
+// Package future implements go-future. See merovius.de/go-misc/cmd/go-future for its
+// documentation.
+package future // import "merovius.de/go-misc/internal/cmd/future"
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"text/template"
+
+	"merovius.de/go-misc/internal/gen"
+)
+
+var implTemplate = template.Must(template.New("future.go").Parse(`
+// This file is automatically generated by merovius.de/go-misc/cmd/go-future.
+
+package {{ .Package }}
+
+import "sync/atomic"
+
+{{ range .Types }}
+	{{ template "impl" . }}
+{{ end }}
+`))
+
+var _ = template.Must(implTemplate.New("impl").Parse(`
+// {{ .Name }}Future is the result of an asynchronous operation yielding a
+// {{ .Type }}. It is settled by the corresponding {{ .Name }}Promise.
+type {{ .Name }}Future struct {
+	state int32
+	done  chan struct{}
+	v     {{ .Type }}
+	err   error
+}
+
+// {{ .Name }}Promise settles a {{ .Name }}Future.
+type {{ .Name }}Promise struct {
+	f *{{ .Name }}Future
+}
+
+// New{{ .Name }}Future returns a new, unsettled {{ .Name }}Future and the
+// {{ .Name }}Promise to settle it.
+func New{{ .Name }}Future() (*{{ .Name }}Future, *{{ .Name }}Promise) {
+	f := &{{ .Name }}Future{done: make(chan struct{})}
+	return f, &{{ .Name }}Promise{f}
+}
+
+// Resolve settles the future with the value v. It returns false, if the
+// future was already settled.
+func (p *{{ .Name }}Promise) Resolve(v {{ .Type }}) bool {
+	return p.f.settle(v, nil)
+}
+
+// Reject settles the future with the error err. It returns false, if the
+// future was already settled.
+func (p *{{ .Name }}Promise) Reject(err error) bool {
+	var zero {{ .Type }}
+	return p.f.settle(zero, err)
+}
+
+func (f *{{ .Name }}Future) settle(v {{ .Type }}, err error) bool {
+	if !atomic.CompareAndSwapInt32(&f.state, 0, 1) {
+		return false
+	}
+	f.v, f.err = v, err
+	close(f.done)
+	return true
+}
+
+// Done returns a channel that is closed when f is settled.
+func (f *{{ .Name }}Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait waits for f to be settled and returns its value or error.
+func (f *{{ .Name }}Future) Wait() ({{ .Type }}, error) {
+	<-f.done
+	return f.v, f.err
+}
+`))
+
+type pkg struct {
+	Package string
+	Types   []gen.Type
+}
+
+var (
+	flags   = flag.NewFlagSet("go-future", flag.ContinueOnError)
+	pkgName = flags.String("package", os.Getenv("GOPACKAGE"), "Package the file should be in")
+	out     = gen.OutputFlags(flags)
+)
+
+// Run runs go-future with the command line arguments args, which do not include
+// the program name.
+func Run(args []string) error {
+	if err := gen.ParseFlags(flags, args); err != nil {
+		return err
+	}
+
+	types, err := gen.ParseTypes(flags.Args())
+	if err != nil || flags.NArg() == 0 {
+		return errors.New("Usage: go-future [-package=<pkg>] <name> <type> [<name> <type>]...")
+	}
+	if *pkgName == "" {
+		return errors.New("no package given")
+	}
+
+	return out.Write(implTemplate, pkg{Package: *pkgName, Types: types})
+}
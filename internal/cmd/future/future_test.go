@@ -0,0 +1,97 @@
+package future
+
+import (
+	"testing"
+
+	"merovius.de/go-misc/internal/gen/gentest"
+)
+
+func TestMain(m *testing.M) {
+	gentest.Main(m, Run)
+}
+
+func TestGolden(t *testing.T) {
+	dir := gentest.Dir(t, nil)
+	gentest.Generate(t, dir, "-package=future", "-out=future.go", "Int", "int", "Lines", "[]string")
+	gentest.Golden(t, dir, "future.go", "future.go.golden")
+	gentest.Vet(t, dir)
+}
+
+func TestUsage(t *testing.T) {
+	dir := gentest.Dir(t, nil)
+	gentest.Fail(t, dir, "-package=future", "-out=future.go")
+	gentest.Fail(t, dir, "-package=future", "-out=future.go", "Int")
+	gentest.Fail(t, dir, "-package=", "-out=future.go", "Int", "int")
+}
+
+// futureTest tests, that a future is settled exactly once, when settled
+// concurrently, and that all waiters observe the result. It is run with the
+// race detector.
+const futureTest = `package future
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSettle(t *testing.T) {
+	f, p := NewIntFuture()
+	select {
+	case <-f.Done():
+		t.Fatal("Done() closed before settling")
+	default:
+	}
+
+	const N = 8
+	var (
+		wg      sync.WaitGroup
+		settled int32
+		got     [N]int
+		errs    [N]error
+	)
+	for i := 0; i < N; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			got[i], errs[i] = f.Wait()
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			ok := false
+			if i%2 == 0 {
+				ok = p.Resolve(i + 1)
+			} else {
+				ok = p.Reject(errors.New("rejected"))
+			}
+			if ok {
+				atomic.AddInt32(&settled, 1)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if settled != 1 {
+		t.Fatalf("future was settled %d times", settled)
+	}
+	v, err := f.Wait()
+	if (v == 0) == (err == nil) {
+		t.Fatalf("Wait() == %v, %v, want either a value or an error", v, err)
+	}
+	for i := range got {
+		if got[i] != v || errs[i] != err {
+			t.Errorf("waiter %d got %v, %v, want %v, %v", i, got[i], errs[i], v, err)
+		}
+	}
+	<-f.Done()
+	if p.Resolve(42) {
+		t.Error("Resolve succeeded on a settled future")
+	}
+}
+`
+
+func TestFuture(t *testing.T) {
+	dir := gentest.Dir(t, map[string]string{"future_test.go": futureTest})
+	gentest.Generate(t, dir, "-package=future", "-out=future.go", "Int", "int")
+	gentest.Test(t, dir, "-race")
+}
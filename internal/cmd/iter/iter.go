@@ -0,0 +1,128 @@
+// Package iter implements go-iter. See merovius.de/go-misc/cmd/go-iter for its
+// documentation.
+package iter // import "merovius.de/go-misc/internal/cmd/iter"
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"text/template"
+
+	"merovius.de/go-misc/internal/gen"
+)
+
+var implTemplate = template.Must(template.New("iter.go").Parse(`
+// This file is automatically generated by merovius.de/go-misc/cmd/go-iter.
+
+package {{ .Package }}
+
+{{ range .Types }}
+	{{ template "impl" . }}
+{{ end }}
+`))
+
+var _ = template.Must(implTemplate.New("impl").Parse(`
+// {{ .Name }} is a lazy sequence of {{ .Type }} values. Every call returns the
+// next value and true, or false if the sequence is exhausted.
+type {{ .Name }} func() ({{ .Type }}, bool)
+
+// {{ .Name }}Of returns a {{ .Name }} yielding vs.
+func {{ .Name }}Of(vs ...{{ .Type }}) {{ .Name }} {
+	return func() ({{ .Type }}, bool) {
+		if len(vs) == 0 {
+			var zero {{ .Type }}
+			return zero, false
+		}
+		v := vs[0]
+		vs = vs[1:]
+		return v, true
+	}
+}
+
+// Map returns a sequence yielding f(v) for every v in s.
+func (s {{ .Name }}) Map(f func({{ .Type }}) {{ .Type }}) {{ .Name }} {
+	return func() ({{ .Type }}, bool) {
+		v, ok := s()
+		if !ok {
+			return v, false
+		}
+		return f(v), true
+	}
+}
+
+// Filter returns a sequence yielding the values v in s for which f(v) is
+// true.
+func (s {{ .Name }}) Filter(f func({{ .Type }}) bool) {{ .Name }} {
+	return func() ({{ .Type }}, bool) {
+		for {
+			v, ok := s()
+			if !ok || f(v) {
+				return v, ok
+			}
+		}
+	}
+}
+
+// Take returns a sequence yielding the first n values of s.
+func (s {{ .Name }}) Take(n int) {{ .Name }} {
+	return func() ({{ .Type }}, bool) {
+		if n <= 0 {
+			var zero {{ .Type }}
+			return zero, false
+		}
+		n--
+		return s()
+	}
+}
+
+// Each calls f for every value in s, until f returns false.
+func (s {{ .Name }}) Each(f func({{ .Type }}) bool) {
+	for {
+		v, ok := s()
+		if !ok || !f(v) {
+			return
+		}
+	}
+}
+
+// Slice returns all values in s.
+func (s {{ .Name }}) Slice() []{{ .Type }} {
+	var l []{{ .Type }}
+	for {
+		v, ok := s()
+		if !ok {
+			return l
+		}
+		l = append(l, v)
+	}
+}
+`))
+
+type pkg struct {
+	Package string
+	Types   []gen.Type
+}
+
+var (
+	flags   = flag.NewFlagSet("go-iter", flag.ContinueOnError)
+	pkgName = flags.String("package", os.Getenv("GOPACKAGE"), "Package the file should be in")
+	out     = gen.OutputFlags(flags)
+)
+
+// Run runs go-iter with the command line arguments args, which do not include
+// the program name.
+func Run(args []string) error {
+	if err := gen.ParseFlags(flags, args); err != nil {
+		return err
+	}
+
+	types, err := gen.ParseTypes(flags.Args())
+	if err != nil || flags.NArg() == 0 {
+		return errors.New("Usage: go-iter [-package=<pkg>] <name> <type> [<name> <type>]...")
+	}
+	if *pkgName == "" {
+		return errors.New("no package given")
+	}
+
+	return out.Write(implTemplate, pkg{Package: *pkgName, Types: types})
+}
@@ -0,0 +1,62 @@
+package iter
+
+import (
+	"testing"
+
+	"merovius.de/go-misc/internal/gen/gentest"
+)
+
+func TestMain(m *testing.M) {
+	gentest.Main(m, Run)
+}
+
+func TestGolden(t *testing.T) {
+	dir := gentest.Dir(t, nil)
+	gentest.Generate(t, dir, "-package=iter", "-out=iter.go", "Ints", "int", "Words", "string")
+	gentest.Golden(t, dir, "iter.go", "iter.go.golden")
+	gentest.Vet(t, dir)
+}
+
+func TestUsage(t *testing.T) {
+	dir := gentest.Dir(t, nil)
+	gentest.Fail(t, dir, "-package=iter", "-out=iter.go")
+	gentest.Fail(t, dir, "-package=iter", "-out=iter.go", "Ints")
+	gentest.Fail(t, dir, "-package=", "-out=iter.go", "Ints", "int")
+}
+
+func TestIter(t *testing.T) {
+	dir := gentest.Dir(t, map[string]string{"iter_test.go": `package iter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIter(t *testing.T) {
+	odd := func(v int) bool { return v%2 == 1 }
+	square := func(v int) int { return v * v }
+	got := IntsOf(1, 2, 3, 4, 5, 6, 7).Filter(odd).Map(square).Take(3).Slice()
+	if want := []int{1, 9, 25}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Slice() == %v, want %v", got, want)
+	}
+	if got := IntsOf().Slice(); got != nil {
+		t.Errorf("Slice() of empty sequence == %v, want nil", got)
+	}
+
+	var n int
+	IntsOf(1, 2, 3).Each(func(int) bool { n++; return n < 2 })
+	if n != 2 {
+		t.Errorf("Each called f %d times, want 2", n)
+	}
+
+	// Take must not consume values beyond n.
+	s := IntsOf(1, 2, 3)
+	s.Take(1).Slice()
+	if got, want := s.Slice(), []int{2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Slice() after Take(1) == %v, want %v", got, want)
+	}
+}
+`})
+	gentest.Generate(t, dir, "-package=iter", "-out=iter.go", "Ints", "int")
+	gentest.Test(t, dir)
+}
@@ -0,0 +1,231 @@
+package lazy
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"merovius.de/go-misc/internal/gen"
+)
+
+var benchTemplate = template.Must(template.New("bench_test.go").Parse(`
+package lazybench
+
+import "testing"
+
+var sink int
+
+// BenchmarkGet measures the fast path, after the value has been evaluated.
+func BenchmarkGet(b *testing.B) {
+{{- range . }}
+	b.Run("{{ . }}", func(b *testing.B) {
+		f := {{ $.Func . }}(func() int { return 42 })
+		f()
+		for i := 0; i < b.N; i++ {
+			sink = f()
+		}
+	})
+{{- end }}
+}
+
+// BenchmarkGetParallel measures the fast path under contention.
+func BenchmarkGetParallel(b *testing.B) {
+{{- range . }}
+	b.Run("{{ . }}", func(b *testing.B) {
+		f := {{ $.Func . }}(func() int { return 42 })
+		f()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				f()
+			}
+		})
+	})
+{{- end }}
+}
+
+// BenchmarkFirst measures creating a lazy value and evaluating it.
+func BenchmarkFirst(b *testing.B) {
+{{- range . }}
+	b.Run("{{ . }}", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			sink = {{ $.Func . }}(func() int { return i })()
+		}
+	})
+{{- end }}
+}
+`))
+
+// benchImpls is the data of benchTemplate.
+type benchImpls []string
+
+// Func returns the name of the generated function for impl.
+func (benchImpls) Func(impl string) string {
+	return gen.Exported(impl)
+}
+
+// benchmarks are the benchmarks in benchTemplate, in the order they are
+// reported.
+var benchmarks = []string{"Get", "GetParallel", "First"}
+
+// result is the result of a single benchmark run.
+type result struct {
+	ns     float64
+	bytes  string
+	allocs string
+}
+
+var (
+	benchFlags = flag.NewFlagSet("go-lazy bench", flag.ContinueOnError)
+	benchTime  = benchFlags.String("benchtime", "1s", "Run time of each benchmark, as accepted by go test")
+	benchCPU   = benchFlags.String("cpu", "", "Comma-separated list of GOMAXPROCS values, as accepted by go test")
+	keep       = benchFlags.Bool("keep", false, "Keep the generated benchmark package and print its location")
+)
+
+// runBench runs the bench subcommand.
+func runBench(args []string) error {
+	if err := gen.ParseFlags(benchFlags, args); err != nil {
+		return err
+	}
+	if benchFlags.NArg() != 0 {
+		return errors.New("Usage: go-lazy bench [-benchtime=<d>] [-cpu=<n>[,<n>...]] [-keep]")
+	}
+
+	dir, err := ioutil.TempDir("", "go-lazy-bench")
+	if err != nil {
+		return err
+	}
+	if *keep {
+		fmt.Fprintln(os.Stderr, "benchmark package in", dir)
+	} else {
+		defer os.RemoveAll(dir)
+	}
+
+	if err := writeBench(dir); err != nil {
+		return err
+	}
+
+	cmdArgs := []string{"test", "-run", "^$", "-bench", ".", "-benchmem", "-benchtime", *benchTime}
+	if *benchCPU != "" {
+		cmdArgs = append(cmdArgs, "-cpu", *benchCPU)
+	}
+	cmd := exec.Command("go", cmdArgs...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=")
+	cmd.Stderr = os.Stderr
+	output, err := cmd.Output()
+	if err != nil {
+		os.Stderr.Write(output)
+		return fmt.Errorf("running benchmarks: %v", err)
+	}
+
+	results, err := parseBench(output)
+	if err != nil {
+		return err
+	}
+	return report(results)
+}
+
+// writeBench writes a package benchmarking all implementations to dir.
+func writeBench(dir string) error {
+	mod := []byte("module lazybench\n")
+	if err := ioutil.WriteFile(filepath.Join(dir, "go.mod"), mod, 0666); err != nil {
+		return err
+	}
+	for _, impl := range impls {
+		opts := options{Impl: impl, Stripes: *stripes}
+		p := pkg{
+			Package: "lazybench",
+			options: opts,
+			Types:   []lazyType{{Name: benchImpls(nil).Func(impl), Type: "int", options: opts}},
+		}
+		o := &gen.Output{File: filepath.Join(dir, impl+".go")}
+		if err := o.Write(implTemplate, p); err != nil {
+			return err
+		}
+	}
+	o := &gen.Output{File: filepath.Join(dir, "bench_test.go")}
+	return o.Write(benchTemplate, benchImpls(impls))
+}
+
+// parseBench parses the output of go test -benchmem into results, keyed by
+// benchmark, implementation and GOMAXPROCS suffix, e.g. "GetParallel/once-8".
+func parseBench(output []byte) (map[string]result, error) {
+	results := make(map[string]result)
+	s := bufio.NewScanner(bytes.NewReader(output))
+	for s.Scan() {
+		f := strings.Fields(s.Text())
+		if len(f) < 8 || !strings.HasPrefix(f[0], "Benchmark") || f[3] != "ns/op" {
+			continue
+		}
+		ns, err := strconv.ParseFloat(f[2], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid benchmark output %q", s.Text())
+		}
+		results[strings.TrimPrefix(f[0], "Benchmark")] = result{ns, f[4], f[6]}
+	}
+	if len(results) == 0 {
+		return nil, errors.New("no benchmark results found")
+	}
+	return results, s.Err()
+}
+
+// report prints a table of results and recommends the implementation with the
+// fastest parallel Get at the highest GOMAXPROCS, as the fast path under
+// contention dominates for most lazy values.
+func report(results map[string]result) error {
+	// Benchmark names have a -<GOMAXPROCS> suffix, unless it is 1.
+	var procs []int
+	for k := range results {
+		if !strings.HasPrefix(k, "Get/"+impls[0]) {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimPrefix(k, "Get/"+impls[0]+"-"))
+		if err != nil {
+			n = 1
+		}
+		procs = append(procs, n)
+	}
+	sort.Ints(procs)
+	suffix := func(n int) string {
+		if n == 1 {
+			return ""
+		}
+		return "-" + strconv.Itoa(n)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "impl\tGOMAXPROCS\tGet\tGetParallel\tFirst\tFirst B/op\tFirst allocs/op\t")
+	for _, impl := range impls {
+		for _, n := range procs {
+			fmt.Fprintf(w, "%s\t%d\t", impl, n)
+			for _, b := range benchmarks {
+				fmt.Fprintf(w, "%.2f ns\t", results[b+"/"+impl+suffix(n)].ns)
+			}
+			first := results["First/"+impl+suffix(n)]
+			fmt.Fprintf(w, "%s\t%s\t\n", first.bytes, first.allocs)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	best, max := impls[0], suffix(procs[len(procs)-1])
+	for _, impl := range impls[1:] {
+		if results["GetParallel/"+impl+max].ns < results["GetParallel/"+best+max].ns {
+			best = impl
+		}
+	}
+	fmt.Printf("\nRecommendation: -impl=%s (fastest parallel Get on this machine)\n", best)
+	return nil
+}
@@ -0,0 +1,129 @@
+package lazy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+
+	"merovius.de/go-misc/internal/gen"
+)
+
+// config is the format of the file given by -config.
+type config struct {
+	// Types are generated in addition to the types given as arguments.
+	Types []configType `json:"types"`
+
+	// Imports maps the names of packages used in types to their import
+	// paths, if they differ.
+	Imports map[string]string `json:"imports"`
+}
+
+// configType is a type to generate lazy values for.
+type configType struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+
+	// Platforms maps platforms, given as GOOS, GOARCH or GOOS/GOARCH, to the
+	// type to use on them instead of Type.
+	Platforms map[string]string `json:"platforms"`
+
+	// Priority orders the evaluation of lazy values by LazyForceAll, with
+	// -registry.
+	Priority int `json:"priority"`
+}
+
+// loadConfig reads the config in file.
+func loadConfig(file string) (*config, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	c := new(config)
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(c); err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	for _, t := range c.Types {
+		if t.Name == "" || t.Type == "" {
+			return nil, fmt.Errorf("%s: every type needs a name and a type", file)
+		}
+		for pl := range t.Platforms {
+			if !validPlatform.MatchString(pl) {
+				return nil, fmt.Errorf("%s: invalid platform %q for %s", file, pl, t.Name)
+			}
+		}
+	}
+	return c, nil
+}
+
+// priorities returns the priorities of the types in c, by name.
+func (c *config) priorities() map[string]int {
+	m := make(map[string]int)
+	for _, t := range c.Types {
+		m[t.Name] = t.Priority
+	}
+	return m
+}
+
+var validPlatform = regexp.MustCompile(`^[a-z0-9]+(/[a-z0-9]+)?$`)
+
+// variant is a file containing the lazy values for platform-specific types,
+// on the platforms satisfying a build constraint.
+type variant struct {
+	// Constraint is the build constraint of the file and Suffix is appended
+	// to the name of the output file, to derive its name.
+	Constraint string
+	Suffix     string
+	Types      []gen.Type
+}
+
+// variants returns the files needed for the platform-specific types in ts.
+// Every such type gets a file for each of its platforms and one for all other
+// platforms, using its default type. Types with the same constraint share a
+// file.
+func variants(ts []configType) []*variant {
+	byConstraint := make(map[string]*variant)
+	var vs []*variant
+	add := func(constraint, suffix string, t gen.Type) {
+		v := byConstraint[constraint]
+		if v == nil {
+			v = &variant{Constraint: constraint, Suffix: suffix}
+			byConstraint[constraint] = v
+			vs = append(vs, v)
+		}
+		v.Types = append(v.Types, t)
+	}
+
+	for _, t := range ts {
+		if len(t.Platforms) == 0 {
+			continue
+		}
+		var platforms []string
+		for pl := range t.Platforms {
+			platforms = append(platforms, pl)
+		}
+		sort.Strings(platforms)
+
+		var not, suffixes []string
+		for _, pl := range platforms {
+			suffix := strings.Replace(pl, "/", "_", -1)
+			c := strings.Replace(pl, "/", " && ", -1)
+			add(c, suffix, gen.Type{Name: t.Name, Type: t.Platforms[pl]})
+			if strings.Contains(pl, "/") {
+				c = "(" + c + ")"
+			}
+			not = append(not, "!"+c)
+			suffixes = append(suffixes, suffix)
+		}
+		// The suffix must not end in a GOOS or GOARCH, which would
+		// constrain the file further.
+		add(strings.Join(not, " && "), strings.Join(suffixes, "_")+"_other", gen.Type{Name: t.Name, Type: t.Type})
+	}
+	sort.Slice(vs, func(i, j int) bool { return vs[i].Suffix < vs[j].Suffix })
+	return vs
+}
@@ -0,0 +1,192 @@
+package lazy
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/types"
+	"strings"
+	"text/template"
+)
+
+var _ = template.Must(implTemplate.New("hasher").Parse(`
+// lazyHash is a 64-bit FNV-1a hash.
+type lazyHash uint64
+
+const lazyHashOffset lazyHash = 14695981039346656037
+
+func (h *lazyHash) byte(b byte) {
+	*h ^= lazyHash(b)
+	*h *= 1099511628211
+}
+
+func (h *lazyHash) uint64(x uint64) {
+	for i := 0; i < 8; i++ {
+		h.byte(byte(x >> (8 * i)))
+	}
+}
+
+func (h *lazyHash) bool(b bool) {
+	if b {
+		h.byte(1)
+	} else {
+		h.byte(0)
+	}
+}
+
+// float hashes f, such that values comparing equal hash the same.
+func (h *lazyHash) float(f float64) {
+	if f == 0 {
+		// Normalize -0.
+		f = 0
+	}
+	h.uint64(math.Float64bits(f))
+}
+
+func (h *lazyHash) string(s string) {
+	h.uint64(uint64(len(s)))
+	for i := 0; i < len(s); i++ {
+		h.byte(s[i])
+	}
+}
+`))
+
+var _ = template.Must(implTemplate.New("hash").Parse(`
+// Hash returns a hash of the value of v, which is evaluated if necessary.
+// Equal values have equal hashes, independent of the process.
+func (v *{{ .Name }}) Hash() uint64 {
+	x := v.Get({{ if .Otel }}context.Background(){{ end }})
+	h := lazyHashOffset
+	{{ .HashCode }}
+	return uint64(h)
+}
+`))
+
+// hashCode returns statements hashing x, of type typ, into the lazyHash h.
+// Types that are not built from predeclared types and type literals must
+// have a method Hash() uint64. This includes named types with a supported
+// underlying type, as packages are not loaded to look it up.
+func hashCode(typ string) (string, error) {
+	e, err := parser.ParseExpr(typ)
+	if err != nil {
+		return "", fmt.Errorf("invalid type %q: %v", typ, err)
+	}
+	g := &hasher{}
+	if err := g.hash(e, "x", "h", 0); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(g.b.String()), nil
+}
+
+type hasher struct {
+	b strings.Builder
+	// n is the last suffix of generated variable names, which is increased
+	// for every loop, so that their names are unique.
+	n int
+}
+
+// id returns a new suffix for variable names.
+func (g *hasher) id() int {
+	g.n++
+	return g.n
+}
+
+func (g *hasher) printf(depth int, format string, args ...interface{}) {
+	g.b.WriteString(strings.Repeat("\t", depth+1))
+	fmt.Fprintf(&g.b, format, args...)
+	g.b.WriteByte('\n')
+}
+
+// hash writes the statements hashing x, of type e, into the lazyHash h,
+// indented for a block nesting of depth.
+func (g *hasher) hash(e ast.Expr, x, h string, depth int) error {
+	switch e := e.(type) {
+	case *ast.ParenExpr:
+		return g.hash(e.X, x, h, depth)
+	case *ast.Ident:
+		switch e.Name {
+		case "bool":
+			g.printf(depth, "%s.bool(%s)", h, x)
+		case "string":
+			g.printf(depth, "%s.string(%s)", h, x)
+		case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "uintptr", "byte", "rune":
+			g.printf(depth, "%s.uint64(uint64(%s))", h, x)
+		case "float32", "float64":
+			g.printf(depth, "%s.float(float64(%s))", h, x)
+		case "complex64", "complex128":
+			g.printf(depth, "%s.float(real(complex128(%s)))", h, x)
+			g.printf(depth, "%s.float(imag(complex128(%s)))", h, x)
+		case "error", "any":
+			return fmt.Errorf("can not hash interface type %s", e.Name)
+		default:
+			g.printf(depth, "%s.uint64(%s.Hash())", h, x)
+		}
+	case *ast.SelectorExpr:
+		g.printf(depth, "%s.uint64(%s.Hash())", h, x)
+	case *ast.StarExpr:
+		g.printf(depth, "%s.bool(%s != nil)", h, x)
+		g.printf(depth, "if %s != nil {", x)
+		if err := g.hash(e.X, "(*"+x+")", h, depth+1); err != nil {
+			return err
+		}
+		g.printf(depth, "}")
+	case *ast.ArrayType:
+		if e.Len == nil {
+			g.printf(depth, "%s.uint64(uint64(len(%s)))", h, x)
+		}
+		el := fmt.Sprintf("e%d", g.id())
+		g.printf(depth, "for _, %s := range %s {", el, x)
+		if err := g.hash(e.Elt, el, h, depth+1); err != nil {
+			return err
+		}
+		g.printf(depth, "}")
+	case *ast.MapType:
+		// Entries are hashed separately and combined by addition, which
+		// does not depend on the iteration order.
+		i := g.id()
+		k, el, eh, sum := fmt.Sprintf("k%d", i), fmt.Sprintf("e%d", i), fmt.Sprintf("h%d", i), fmt.Sprintf("sum%d", i)
+		g.printf(depth, "var %s uint64", sum)
+		g.printf(depth, "for %s, %s := range %s {", k, el, x)
+		g.printf(depth+1, "%s := lazyHashOffset", eh)
+		if err := g.hash(e.Key, k, eh, depth+1); err != nil {
+			return err
+		}
+		if err := g.hash(e.Value, el, eh, depth+1); err != nil {
+			return err
+		}
+		g.printf(depth+1, "%s += uint64(%s)", sum, eh)
+		g.printf(depth, "}")
+		g.printf(depth, "%s.uint64(uint64(len(%s)))", h, x)
+		g.printf(depth, "%s.uint64(%s)", h, sum)
+	case *ast.StructType:
+		for _, f := range e.Fields.List {
+			names := f.Names
+			if len(names) == 0 {
+				// An embedded field is named after its type.
+				t := f.Type
+				if s, ok := t.(*ast.StarExpr); ok {
+					t = s.X
+				}
+				if s, ok := t.(*ast.SelectorExpr); ok {
+					t = s.Sel
+				}
+				id, ok := t.(*ast.Ident)
+				if !ok {
+					return fmt.Errorf("can not hash embedded field %s", types.ExprString(f.Type))
+				}
+				names = []*ast.Ident{id}
+			}
+			for _, n := range names {
+				if n.Name == "_" {
+					continue
+				}
+				if err := g.hash(f.Type, x+"."+n.Name, h, depth); err != nil {
+					return err
+				}
+			}
+		}
+	default:
+		return fmt.Errorf("can not hash values of type %s", types.ExprString(e))
+	}
+	return nil
+}
@@ -0,0 +1,2151 @@
+// Package lazy implements go-lazy. See merovius.de/go-misc/cmd/go-lazy for its
+// documentation.
+package lazy // import "merovius.de/go-misc/internal/cmd/lazy"
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"merovius.de/go-misc/internal/gen"
+)
+
+var implTemplate = template.Must(template.New("lazy.go").Parse(`
+// This file is automatically generated by merovius.de/go-misc/cmdgo-lazy.
+{{- with .Inputs }}
+{{ . }}
+{{- end }}
+{{- if .Constraint }}
+
+//go:build {{ .Constraint }}
+{{- end }}
+
+package {{ .Package }}
+
+import (
+{{- range .Imports }}
+	{{ . }}
+{{- end }}
+)
+
+{{ if and .Pad (not .Constraint) -}}
+// lazyCacheLine is the size of a cache line on {{ .Arch }}.
+const lazyCacheLine = {{ .CacheLine }}
+{{- end }}
+
+{{ if and .Otel (not .Constraint) -}}
+	{{ template "tracer" }}
+{{- end }}
+
+{{ if and .Slow (not .Constraint) -}}
+	{{ template "slow" .Slow }}
+{{- end }}
+
+{{ if and .Pinned (not .Constraint) -}}
+	{{ template "pinned" }}
+{{- end }}
+
+{{ if and .Registry (not .Constraint) -}}
+	{{ template "registry" . }}
+{{- end }}
+
+{{ if and .Contention (not .Constraint) -}}
+// LazyContention is called with the name of a lazy value and the time a
+// goroutine was blocked, waiting for another one to evaluate it. It is only
+// called in builds with the lazycontention build tag and must not be changed
+// while lazy values are used.
+var LazyContention func(name string, d time.Duration)
+{{- end }}
+
+{{ if and (eq .Impl "striped") (not .Versioned) (not .Constraint) -}}
+	{{ template "stripes" .Stripes }}
+{{- end }}
+
+{{ if and .Hash (not .Constraint) -}}
+	{{ template "hasher" }}
+{{- end }}
+
+{{ if and .Stats (not .Constraint) -}}
+	{{ template "stats" }}
+{{- end }}
+
+{{ if and .Close (not .Constraint) -}}
+	{{ template "closer" }}
+{{- end }}
+
+{{ if and .Notify (not .Constraint) -}}
+	{{ template "notifier" }}
+{{- end }}
+
+{{ range .Types }}
+	{{ if $.Versioned }}
+		{{ template "versioned" . }}
+	{{ else if eq $.Impl "mutex" }}
+		{{ template "mutex" . }}
+	{{ else if eq $.Impl "once" }}
+		{{ template "once" . }}
+	{{ else if eq $.Impl "header" }}
+		{{ template "header" . }}
+	{{ else if eq $.Impl "striped" }}
+		{{ template "striped" . }}
+	{{ else }}
+		{{ template "impl" . }}
+	{{ end }}
+	{{ if not $.Versioned }}
+		{{ template "ctor" . }}
+	{{ end }}
+	{{ if or $.JSON $.YAML $.Msgpack }}
+		{{ template "marshal" . }}
+	{{ end }}
+	{{ if $.Proto }}
+		{{ template "proto" . }}
+	{{ end }}
+	{{ if $.Hash }}
+		{{ template "hash" . }}
+	{{ end }}
+	{{ if $.Equal }}
+		{{ template "equal" . }}
+	{{ end }}
+	{{ if $.Mobile }}
+		{{ template "mobile" . }}
+	{{ end }}
+	{{ if $.Pad }}
+		{{ template "pad" . }}
+	{{ end }}
+	{{ if $.Slab }}
+		{{ template "slab" . }}
+	{{ end }}
+{{ end }}
+`))
+
+var _ = template.Must(implTemplate.New("ctor").Parse(`
+{{- if .Value -}}
+// {{ .Name }} provides lazy evaluation for {{ .Type }}. It can be used as a
+// struct field, without allocating the lazy value separately. Init must be
+// called before Get, which calls f exactly once, when the result is first
+// used. A {{ .Name }} must not be copied after Init.
+//
+{{ .Doc }}
+type {{ .Name }} struct {
+	{{ .Alloc }}
+{{- if .Stats }}
+	stats lazyStats
+{{- end }}
+{{- if .Close }}
+	closer lazyCloser
+{{- end }}
+{{- if .Notify }}
+	notifier lazyNotifier
+{{- end }}
+{{- if or .Slog .Expvar }}
+	// evaluated is set to 1 after the evaluation, to report v without
+	// evaluating it.
+	evaluated uint32
+{{- end }}
+}
+
+// Init sets the function evaluating v. It must be called exactly once and
+// before Get.
+func (v *{{ .Name }}) Init(f {{ .FuncType }}) {
+{{- if or .Stats .Close .Slog .Expvar .Notify }}
+	eval := f
+	f = func({{ .Params }}) {{ .Type }} {
+	{{- if .Stats }}
+		defer func() { v.stats.end(recover()) }()
+	{{- end }}
+	{{- if or .Close .Slog .Expvar .Notify }}
+		x := eval({{ .Args }})
+	{{- end }}
+	{{- if or .Slog .Expvar }}
+		atomic.StoreUint32(&v.evaluated, 1)
+	{{- end }}
+	{{- if .Notify }}
+		v.notifier.done()
+	{{- end }}
+	{{- if .Close }}
+		v.closer.set(func() error {
+		{{- if .Finalizer }}
+			return {{ .Finalizer }}(x)
+		{{- else }}
+			if c, ok := interface{}(x).(io.Closer); ok {
+				return c.Close()
+			}
+			return nil
+		{{- end }}
+		})
+	{{- end }}
+	{{- if or .Close .Slog .Expvar .Notify }}
+		return x
+	{{- else }}
+		return eval({{ .Args }})
+	{{- end }}
+	}
+{{- end }}
+	{{ template "init" . }}
+}
+
+// TryGet returns the value of v and true, if it is evaluated. Otherwise, it
+// returns the zero value and false, without evaluating v or waiting for an
+// evaluation in progress.
+func (v *{{ .Name }}) TryGet() ({{ .Type }}, bool) {
+	if {{ .Done }} {
+		return v.v, true
+	}
+	var zero {{ .Type }}
+	return zero, false
+}
+{{- if .Slog }}
+
+// LogValue implements slog.LogValuer. It logs whether v is evaluated and its
+// value only if it is, so logging v never evaluates it.
+func (v *{{ .Name }}) LogValue() slog.Value {
+	if atomic.LoadUint32(&v.evaluated) == 0 {
+		return slog.GroupValue(slog.Bool("evaluated", false))
+	}
+	return slog.GroupValue(slog.Bool("evaluated", true), slog.Any("value", v.Get({{ if .Otel }}context.Background(){{ end }})))
+}
+{{- end }}
+{{- if .Expvar }}
+
+// Publish publishes v as an expvar.Var with the given name, e.g. to be served
+// under /debug/vars. It shows whether v is evaluated and its value only if it
+// is, so it never evaluates v. Like expvar.Publish, it panics if the name is
+// already used.
+func (v *{{ .Name }}) Publish(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		if atomic.LoadUint32(&v.evaluated) == 0 {
+			return map[string]interface{}{"evaluated": false}
+		}
+		return map[string]interface{}{"evaluated": true, "value": v.Get({{ if .Otel }}context.Background(){{ end }})}
+	}))
+}
+{{- end }}
+{{- if .Close }}
+
+// Close releases the value of v, if it was evaluated
+{{- if .Finalizer }}, by passing it to
+// {{ .Finalizer }}.
+{{- else }} and implements
+// io.Closer.
+{{- end }} It does so at most once and returns nil when called again. If v is
+// evaluated after Close, the value is released immediately.
+func (v *{{ .Name }}) Close() error {
+	return v.closer.Close()
+}
+{{- end }}
+{{- if .Notify }}
+
+// Evaluated returns a channel, which is closed once v is evaluated, without
+// evaluating it. If the evaluation panics, it stays open until v is evaluated
+// successfully.
+func (v *{{ .Name }}) Evaluated() <-chan struct{} {
+	return v.notifier.wait()
+}
+{{- end }}
+{{- if .Stats }}
+
+// Stats returns statistics of the evaluations of v.
+func (v *{{ .Name }}) Stats() LazyStats {
+	return v.stats.get()
+}
+{{- end }}
+{{- else -}}
+// {{ .Name }} provides lazy evaluation for {{ .Type }}. f is called exactly
+// once, when the result is first used.
+//
+{{ .Doc }}
+func {{ .Name }}{{ .TypeParams }}(f {{ .FuncType }}) {{ .FuncType }} {
+	{{ template "new" . }}
+}
+{{- end -}}
+`))
+
+var _ = template.Must(implTemplate.New("marshal").Parse(`
+{{- if .JSON -}}
+// MarshalJSON implements json.Marshaler, by encoding the value of v, which is
+// evaluated if necessary.
+func (v *{{ .Name }}) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.Get({{ if .Otel }}context.Background(){{ end }}))
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It initializes v with the
+// decoded value, so it must be used instead of Init.
+func (v *{{ .Name }}) UnmarshalJSON(b []byte) error {
+	var x {{ .Type }}
+	if err := json.Unmarshal(b, &x); err != nil {
+		return err
+	}
+	v.Init(func({{ .Params }}) {{ .Type }} { return x })
+	return nil
+}
+{{- end }}
+{{- if .YAML }}
+
+// MarshalYAML implements yaml.Marshaler, by returning the value of v, which
+// is evaluated if necessary.
+func (v *{{ .Name }}) MarshalYAML() (interface{}, error) {
+	return v.Get({{ if .Otel }}context.Background(){{ end }}), nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler. It initializes v with the
+// decoded value, so it must be used instead of Init.
+func (v *{{ .Name }}) UnmarshalYAML(n *yaml.Node) error {
+	var x {{ .Type }}
+	if err := n.Decode(&x); err != nil {
+		return err
+	}
+	v.Init(func({{ .Params }}) {{ .Type }} { return x })
+	return nil
+}
+{{- end }}
+{{- if .Msgpack }}
+
+// EncodeMsgpack implements msgpack.CustomEncoder, by encoding the value of v,
+// which is evaluated if necessary.
+func (v *{{ .Name }}) EncodeMsgpack(enc *msgpack.Encoder) error {
+	return enc.Encode(v.Get({{ if .Otel }}context.Background(){{ end }}))
+}
+
+// DecodeMsgpack implements msgpack.CustomDecoder. It initializes v with the
+// decoded value, so it must be used instead of Init.
+func (v *{{ .Name }}) DecodeMsgpack(dec *msgpack.Decoder) error {
+	var x {{ .Type }}
+	if err := dec.Decode(&x); err != nil {
+		return err
+	}
+	v.Init(func({{ .Params }}) {{ .Type }} { return x })
+	return nil
+}
+{{- end }}
+`))
+
+var _ = template.Must(implTemplate.New("proto").Parse(`
+// ToProto returns the value of v as a wrapper message, e.g. for a field of
+// type google.protobuf.{{ .Wrapper }}Value. The value is evaluated if necessary.
+func (v *{{ .Name }}) ToProto() *wrapperspb.{{ .Wrapper }}Value {
+	return wrapperspb.{{ .Wrapper }}(v.Get({{ if .Otel }}context.Background(){{ end }}))
+}
+
+// FromProto initializes v with the value of w. If w is nil, e.g. because the
+// field is not set, v is initialized with f instead. It must be used instead
+// of Init.
+func (v *{{ .Name }}) FromProto(w *wrapperspb.{{ .Wrapper }}Value, f {{ .FuncType }}) {
+	if w != nil {
+		x := w.GetValue()
+		f = func({{ .Params }}) {{ .Type }} { return x }
+	}
+	v.Init(f)
+}
+{{- if ne .Type "[]byte" }}
+
+// ToOptional returns a pointer to the value of v, e.g. for an optional field.
+// The value is evaluated if necessary.
+func (v *{{ .Name }}) ToOptional() *{{ .Type }} {
+	x := v.Get({{ if .Otel }}context.Background(){{ end }})
+	return &x
+}
+
+// FromOptional initializes v with *p. If p is nil, e.g. because the field is
+// not set, v is initialized with f instead. It must be used instead of Init.
+func (v *{{ .Name }}) FromOptional(p *{{ .Type }}, f {{ .FuncType }}) {
+	if p != nil {
+		x := *p
+		f = func({{ .Params }}) {{ .Type }} { return x }
+	}
+	v.Init(f)
+}
+{{- end }}
+`))
+
+var _ = template.Must(implTemplate.New("mobile").Parse(`
+// {{ .Name }}Mobile wraps a lazy value of {{ .Type }}, so it can be bound with
+// gomobile, which does not support func types.
+type {{ .Name }}Mobile struct {
+	get func() {{ .Type }}
+}
+
+// New{{ .Name }}Mobile returns a wrapper getting its value from get, e.g.
+// {{ if .Value }}the Get method of a {{ .Name }}{{ else }}a func returned by {{ .Name }}{{ end }}. It is meant to be called by Go code
+// and is not bound itself.
+func New{{ .Name }}Mobile(get func() {{ .Type }}) *{{ .Name }}Mobile {
+	return &{{ .Name }}Mobile{get: get}
+}
+
+// Get returns the value, which is evaluated if necessary.
+func (m *{{ .Name }}Mobile) Get() {{ .Type }} {
+	return m.get()
+}
+`))
+
+var _ = template.Must(implTemplate.New("equal").Parse(`
+// Equal reports whether the values of v and w are equal, according to
+// {{ if .EqualFunc }}{{ .EqualFunc }}{{ else }}=={{ end }}. Both are evaluated if necessary.
+func (v *{{ .Name }}) Equal(w *{{ .Name }}) bool {
+	{{- if .Otel }}
+	ctx := context.Background()
+	{{- end }}
+	{{- if .EqualFunc }}
+	return {{ .EqualFunc }}(v.Get({{ .Args }}), w.Get({{ .Args }}))
+	{{- else }}
+	return v.Get({{ .Args }}) == w.Get({{ .Args }})
+	{{- end }}
+}
+`))
+
+var _ = template.Must(implTemplate.New("new").Parse(`
+{{- if or .Pad .Registry (eq .Impl "header") -}}
+	v := new({{ .Alloc }})
+	{{ template "init" . }}
+	return v.Get
+{{- else -}}
+	return (&lazy{{ .Name }}{{ .TypeArgs }}{f: f}).Get
+{{- end -}}
+`))
+
+var _ = template.Must(implTemplate.New("init").Parse(`
+{{- if .Registry -}}
+	e := &lazyEntry{name: "{{ .Name }}", priority: {{ .Priority }}}
+	e.force = func() { v.Get({{ if .Otel }}context.Background(){{ end }}) }
+	{{- if .Snapshot }}
+	var (
+		seed   {{ .Type }}
+		seeded bool
+	)
+	e.export = func() ([]byte, error) {
+		return json.Marshal(v.Get({{ if .Otel }}context.Background(){{ end }}))
+	}
+	e.seed = func(b []byte) error {
+		var x {{ .Type }}
+		if err := json.Unmarshal(b, &x); err != nil {
+			return err
+		}
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		seed, seeded = x, true
+		return nil
+	}
+	{{- end }}
+	g := f
+	f = func({{ .Params }}) {{ .Type }} {
+		{{- if .Snapshot }}
+		e.mu.Lock()
+		if seeded {
+			e.done = true
+			e.mu.Unlock()
+			return seed
+		}
+		e.mu.Unlock()
+		{{- end }}
+		e.begin()
+		defer func() { e.end(recover()) }()
+		return g({{ .Args }})
+	}
+{{ end -}}
+{{- if eq .Impl "header" -}}
+	v.h = unsafe.Pointer(&lazy{{ .Name }}Header{{ .TypeArgs }}{f: f})
+{{- else -}}
+	v.f = f
+{{- end -}}
+{{- if .Registry }}
+	registerLazy(e)
+{{- end -}}
+`))
+
+var _ = template.Must(implTemplate.New("registry").Parse(`
+// lazyEntry is a lazy value known to LazyForceAll.
+type lazyEntry struct {
+	name     string
+	priority int
+	force    func()
+{{- if .Snapshot }}
+	// export encodes the value, once evaluated, and seed sets the value the
+	// evaluation returns, instead of calling the function.
+	export func() ([]byte, error)
+	seed   func([]byte) error
+{{- end }}
+
+	mu      sync.Mutex
+	started time.Time
+	// dur is the duration of the last evaluation and panic the value it
+	// panicked with, once done.
+	done  bool
+	dur   time.Duration
+	panic interface{}
+}
+
+// begin records the start of an evaluation of e.
+func (e *lazyEntry) begin() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.started, e.done = time.Now(), false
+}
+
+// end records the end of an evaluation of e, which panicked with p, if it is
+// not nil. The panic is continued.
+func (e *lazyEntry) end(p interface{}) {
+	e.mu.Lock()
+	e.done, e.dur, e.panic = true, time.Since(e.started), p
+	e.mu.Unlock()
+	if p != nil {
+		panic(p)
+	}
+}
+
+var lazyRegistry struct {
+	mu      sync.Mutex
+	entries []*lazyEntry
+}
+
+func registerLazy(e *lazyEntry) {
+	lazyRegistry.mu.Lock()
+	defer lazyRegistry.mu.Unlock()
+	lazyRegistry.entries = append(lazyRegistry.entries, e)
+}
+
+// lazyEntries returns the registered lazy values.
+func lazyEntries() []*lazyEntry {
+	lazyRegistry.mu.Lock()
+	defer lazyRegistry.mu.Unlock()
+	return append([]*lazyEntry(nil), lazyRegistry.entries...)
+}
+
+// LazyForceAll evaluates all lazy values created so far, e.g. to warm them up
+// before serving requests. Values are evaluated in groups of the same
+// priority, in ascending order. The values in a group are evaluated
+// concurrently and a group is only started after the previous one is done.
+func LazyForceAll() {
+	lazyForceAll(false)
+}
+
+// LazyWarmup returns a func evaluating all lazy values created so far, like
+// LazyForceAll. If evaluations panic, the func returns an error after the
+// group of the values is done, instead of crashing the program, and no
+// further groups are evaluated. The error joins one for every panic, naming
+// the lazy value. It can be passed to errgroup.Group.Go, e.g.
+//
+//	g.Go(LazyWarmup())
+func LazyWarmup() func() error {
+	return func() error {
+		return lazyForceAll(true)
+	}
+}
+
+// LazyWarmupN is like LazyWarmup, but evaluates at most n values at once, if
+// n is positive. Instead of waiting for groups, values are started in
+// ascending order of priority, whenever an evaluation is done, so that
+// critical values are evaluated first. priorities overrides the priorities of
+// lazy values by name, e.g. to get a server ready to serve requests, before
+// filling caches. Once an evaluation panicked, no further values are started.
+func LazyWarmupN(n int, priorities map[string]int) func() error {
+	return func() error {
+		entries := lazyEntries()
+		priority := func(e *lazyEntry) int {
+			if p, ok := priorities[e.name]; ok {
+				return p
+			}
+			return e.priority
+		}
+		sort.SliceStable(entries, func(i, j int) bool {
+			return priority(entries[i]) < priority(entries[j])
+		})
+		if n <= 0 || n > len(entries) {
+			n = len(entries)
+		}
+		var (
+			sem  = make(chan struct{}, n)
+			wg   sync.WaitGroup
+			mu   sync.Mutex
+			errs []error
+		)
+		for _, e := range entries {
+			sem <- struct{}{}
+			mu.Lock()
+			failed := len(errs) > 0
+			mu.Unlock()
+			if failed {
+				break
+			}
+			wg.Add(1)
+			go func(e *lazyEntry) {
+				defer func() {
+					if p := recover(); p != nil {
+						mu.Lock()
+						errs = append(errs, fmt.Errorf("evaluation of lazy %s panicked: %v", e.name, p))
+						mu.Unlock()
+					}
+					<-sem
+					wg.Done()
+				}()
+				e.force()
+			}(e)
+		}
+		wg.Wait()
+		return errors.Join(errs...)
+	}
+}
+
+// lazyForceAll implements LazyForceAll. If catch is set, panics of the
+// evaluations are recovered and returned as an error.
+func lazyForceAll(catch bool) error {
+	entries := lazyEntries()
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].priority < entries[j].priority
+	})
+	for i := 0; i < len(entries); {
+		j := i
+		for j < len(entries) && entries[j].priority == entries[i].priority {
+			j++
+		}
+		group := entries[i:j]
+		errs := make([]error, len(group))
+		var wg sync.WaitGroup
+		for k, e := range group {
+			wg.Add(1)
+			go func(k int, e *lazyEntry) {
+				defer wg.Done()
+				if catch {
+					defer func() {
+						if p := recover(); p != nil {
+							errs[k] = fmt.Errorf("evaluation of lazy %s panicked: %v", e.name, p)
+						}
+					}()
+				}
+				e.force()
+			}(k, e)
+		}
+		wg.Wait()
+		if err := errors.Join(errs...); err != nil {
+			return err
+		}
+		i = j
+	}
+	return nil
+}
+{{- if .Snapshot }}
+
+// lazySnapshotKeys returns the keys of entries in a snapshot. Entries are
+// identified by their name and the order they were created in.
+func lazySnapshotKeys(entries []*lazyEntry) []string {
+	n := make(map[string]int)
+	keys := make([]string, len(entries))
+	for i, e := range entries {
+		keys[i] = e.name + "#" + strconv.Itoa(n[e.name])
+		n[e.name]++
+	}
+	return keys
+}
+
+// LazySnapshot writes the values of the lazy values created and evaluated so
+// far to w, as a JSON object, so that they can be seeded with LazySeed on the
+// next start of the program. Values are identified by the name of their
+// constructor and the order they were created in, so a snapshot only fits
+// values created in the same order, e.g. package-level variables. Values of
+// types which can not be encoded, like funcs and channels, are left out.
+func LazySnapshot(w io.Writer) error {
+	entries := lazyEntries()
+	keys := lazySnapshotKeys(entries)
+	m := make(map[string]json.RawMessage)
+	for i, e := range entries {
+		e.mu.Lock()
+		done := e.done && e.panic == nil
+		e.mu.Unlock()
+		if !done {
+			continue
+		}
+		b, err := e.export()
+		if err != nil {
+			var ute *json.UnsupportedTypeError
+			if errors.As(err, &ute) {
+				continue
+			}
+			return fmt.Errorf("lazy %s: %w", e.name, err)
+		}
+		m[keys[i]] = b
+	}
+	return json.NewEncoder(w).Encode(m)
+}
+
+// LazySeed reads a snapshot written by LazySnapshot from r and seeds the lazy
+// values created so far with it. A seeded value is not evaluated, but returns
+// the value from the snapshot. Values which are already evaluated or not part
+// of the snapshot are not affected, so LazySeed should be called before lazy
+// values are used, e.g. at the start of main.
+func LazySeed(r io.Reader) error {
+	var m map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return err
+	}
+	entries := lazyEntries()
+	for i, k := range lazySnapshotKeys(entries) {
+		b, ok := m[k]
+		if !ok {
+			continue
+		}
+		if err := entries[i].seed(b); err != nil {
+			return fmt.Errorf("lazy %s: %w", entries[i].name, err)
+		}
+	}
+	return nil
+}
+
+// LazySaveSnapshot writes a snapshot like LazySnapshot to the named file. The
+// file is replaced atomically, so a concurrent LazyLoadSnapshot never reads a
+// partial snapshot.
+func LazySaveSnapshot(name string) error {
+	f, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".*")
+	if err != nil {
+		return err
+	}
+	if err = LazySnapshot(f); err == nil {
+		err = f.Close()
+	} else {
+		f.Close()
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), name)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// LazyLoadSnapshot seeds the lazy values created so far like LazySeed, from
+// the named file written by LazySaveSnapshot. If the file does not exist, e.g.
+// on the first start, it does nothing.
+func LazyLoadSnapshot(name string) error {
+	f, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return LazySeed(f)
+}
+{{- end }}
+{{- if .DebugHandler }}
+
+// LazyDebugHandler returns an http.Handler listing the lazy values created so
+// far, whether they are evaluated, how long their evaluation took and whether
+// it panicked. It can be mounted under a debug endpoint, e.g.
+//
+//	http.Handle("/debug/lazy", LazyDebugHandler())
+func LazyDebugHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tPRIORITY\tSTATE\tDURATION\tPANIC")
+		for _, e := range lazyEntries() {
+			e.mu.Lock()
+			state, dur, p := "pending", "", ""
+			switch {
+			case e.done && e.panic != nil:
+				state, dur, p = "panicked", e.dur.String(), fmt.Sprint(e.panic)
+			case e.done:
+				state, dur = "evaluated", e.dur.String()
+			case !e.started.IsZero():
+				state, dur = "evaluating", time.Since(e.started).String()
+			}
+			e.mu.Unlock()
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\n", e.name, e.priority, state, dur, p)
+		}
+		tw.Flush()
+	})
+}
+{{- end }}
+`))
+
+var _ = template.Must(implTemplate.New("eval").Parse(`
+{{- if .Pprof -}}
+	pprof.Do({{ if .Otel }}ctx{{ else }}context.Background(){{ end }}, pprof.Labels("lazy", "{{ .Name }}"), func({{ if .Otel }}ctx {{ end }}context.Context) {
+		{{ template "call" . }}
+	})
+{{- else -}}
+	{{ template "call" . }}
+{{- end -}}
+`))
+
+var _ = template.Must(implTemplate.New("call").Parse(`
+{{- if .Otel -}}
+	ctx, end := startLazySpan(ctx, "{{ .Name }}")
+	defer end()
+	{{ end -}}
+{{- if .Slow -}}
+	start := time.Now()
+	{{ end -}}
+	{{ if .Pinned -}}
+	lazyPinned(func() { v.v = {{ .Func }}({{ .Args }}) })
+	{{- else -}}
+	v.v = {{ .Func }}({{ .Args }})
+	{{- end }}
+{{- if .Slow }}
+	lazySlow("{{ .Name }}", time.Since(start))
+{{- end -}}
+`))
+
+var _ = template.Must(implTemplate.New("stats").Parse(`
+// LazyStats are statistics of the evaluations of a lazy value.
+type LazyStats struct {
+	// Evaluations is the number of finished evaluations, including those
+	// that panicked.
+	Evaluations uint64
+	// Last is the time the last evaluation finished, or the zero Time, if
+	// there was none.
+	Last time.Time
+	// Err describes the panic of the last evaluation, if it panicked. Lazy
+	// values can not fail otherwise.
+	Err error
+}
+
+type lazyStats struct {
+	m sync.Mutex
+	s LazyStats
+}
+
+// end records the end of an evaluation, which panicked with p, if it is not
+// nil. The panic is continued.
+func (s *lazyStats) end(p interface{}) {
+	s.m.Lock()
+	s.s.Evaluations++
+	s.s.Last = time.Now()
+	s.s.Err = nil
+	if p != nil {
+		s.s.Err = fmt.Errorf("panic: %v", p)
+	}
+	s.m.Unlock()
+	if p != nil {
+		panic(p)
+	}
+}
+
+func (s *lazyStats) get() LazyStats {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.s
+}
+`))
+
+// contentionTemplate generates the files measuring contention with and
+// without the lazycontention build tag, depending on .Tag.
+var contentionTemplate = template.Must(template.New("lazy_contention.go").Parse(`
+// This file is automatically generated by merovius.de/go-misc/cmd/go-lazy.
+
+{{ if .Tag -}}
+//go:build lazycontention
+{{- else -}}
+//go:build !lazycontention
+{{- end }}
+
+package {{ .Package }}
+
+import "time"
+{{ if .Tag }}
+func lazyWaitStart() time.Time {
+	return time.Now()
+}
+
+func lazyWaited(name string, start time.Time) {
+	if LazyContention != nil {
+		LazyContention(name, time.Since(start))
+	}
+}
+{{- else }}
+func lazyWaitStart() (t time.Time) {
+	return t
+}
+
+func lazyWaited(name string, start time.Time) {}
+{{- end }}
+`))
+
+var _ = template.Must(implTemplate.New("notifier").Parse(`
+// lazyNotifier is a channel closed after a lazy value is evaluated. It is only
+// created when needed.
+type lazyNotifier struct {
+	m  sync.Mutex
+	c  chan struct{}
+	ok bool
+}
+
+func (n *lazyNotifier) wait() <-chan struct{} {
+	n.m.Lock()
+	defer n.m.Unlock()
+	if n.c == nil {
+		n.c = make(chan struct{})
+		if n.ok {
+			close(n.c)
+		}
+	}
+	return n.c
+}
+
+func (n *lazyNotifier) done() {
+	n.m.Lock()
+	defer n.m.Unlock()
+	n.ok = true
+	if n.c != nil {
+		close(n.c)
+	}
+}
+
+// LazyEvaluated returns a channel, which is closed once all of vs are
+// evaluated by their users, without evaluating them. This allows deferring
+// background work, until the costs of initialization were paid. Until then,
+// a goroutine waits for the evaluations.
+func LazyEvaluated(vs ...interface{ Evaluated() <-chan struct{} }) <-chan struct{} {
+	c := make(chan struct{})
+	go func() {
+		for _, v := range vs {
+			<-v.Evaluated()
+		}
+		close(c)
+	}()
+	return c
+}
+`))
+
+var _ = template.Must(implTemplate.New("closer").Parse(`
+// lazyCloser releases the value of a lazy value once, when it is closed.
+type lazyCloser struct {
+	m      sync.Mutex
+	close  func() error
+	closed bool
+}
+
+// set sets the func releasing the value, after it is evaluated. If the lazy
+// value is already closed, the value is released immediately.
+func (c *lazyCloser) set(f func() error) {
+	c.m.Lock()
+	closed := c.closed
+	if !closed {
+		c.close = f
+	}
+	c.m.Unlock()
+	if closed {
+		f()
+	}
+}
+
+func (c *lazyCloser) Close() error {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	if c.close == nil {
+		return nil
+	}
+	return c.close()
+}
+`))
+
+var _ = template.Must(implTemplate.New("slow").Parse(`
+// LazySlowThreshold is the duration after which the evaluation of a lazy
+// value is considered slow.
+var LazySlowThreshold = {{ . }}
+
+// LazySlowWarn is called with the name of a lazy value and the duration of its
+// evaluation, if it took longer than LazySlowThreshold. By default, it logs a
+// warning. LazySlowThreshold and LazySlowWarn must not be changed while lazy
+// values are evaluated.
+var LazySlowWarn = func(name string, d time.Duration) {
+	log.Printf("warning: evaluation of lazy %s took %v", name, d)
+}
+
+func lazySlow(name string, d time.Duration) {
+	if d > LazySlowThreshold && LazySlowWarn != nil {
+		LazySlowWarn(name, d)
+	}
+}
+`))
+
+var _ = template.Must(implTemplate.New("pinned").Parse(`
+// lazyJobs are the evaluations of lazy values, waiting for LazyRun.
+var lazyJobs = make(chan func())
+
+// LazyRun evaluates lazy values on the calling goroutine, until ctx is done,
+// returning its error. Using a lazy value blocks, until LazyRun evaluated it,
+// so it must be running for lazy values to be used. Libraries requiring to be
+// called on a specific thread can be wrapped by calling runtime.LockOSThread
+// before LazyRun.
+//
+// Evaluations can not use other lazy values, which are not evaluated yet, as
+// that would wait for LazyRun, which is busy with the evaluation.
+func LazyRun(ctx context.Context) error {
+	for {
+		select {
+		case f := <-lazyJobs:
+			f()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// lazyPinned calls f on the goroutine running LazyRun and propagates its
+// panic, if it panics.
+func lazyPinned(f func()) {
+	var (
+		p  interface{}
+		ok bool
+	)
+	done := make(chan struct{})
+	lazyJobs <- func() {
+		defer close(done)
+		defer func() {
+			if !ok {
+				p = recover()
+			}
+		}()
+		f()
+		ok = true
+	}
+	<-done
+	if !ok {
+		panic(p)
+	}
+}
+`))
+
+var _ = template.Must(implTemplate.New("tracer").Parse(`
+// LazyTracer starts spans around the evaluation of lazy values. The
+// returned func ends the span. Using OpenTelemetry, it can be implemented as
+//
+//	type otelTracer struct{ trace.Tracer }
+//
+//	func (t otelTracer) StartLazySpan(ctx context.Context, name string) (context.Context, func()) {
+//		ctx, span := t.Start(ctx, name)
+//		return ctx, func() { span.End() }
+//	}
+type LazyTracer interface {
+	StartLazySpan(ctx context.Context, name string) (context.Context, func())
+}
+
+// lazyTracer contains a lazyTracerBox.
+var lazyTracer atomic.Value
+
+type lazyTracerBox struct {
+	t LazyTracer
+}
+
+// SetLazyTracer sets the LazyTracer used for all lazy values in this package.
+// By default, no spans are started.
+func SetLazyTracer(t LazyTracer) {
+	lazyTracer.Store(lazyTracerBox{t})
+}
+
+func startLazySpan(ctx context.Context, name string) (context.Context, func()) {
+	if b, _ := lazyTracer.Load().(lazyTracerBox); b.t != nil {
+		return b.t.StartLazySpan(ctx, "lazy."+name)
+	}
+	return ctx, func() {}
+}
+`))
+
+var _ = template.Must(implTemplate.New("pad").Parse(`
+// {{ .Alloc }} is a lazy{{ .Name }}, padded to a multiple of the cache line
+// size, so that values in an array aligned to a cache line do not share one.
+type {{ .Alloc }} struct {
+	lazy{{ .Name }}
+	_ [(lazyCacheLine - unsafe.Sizeof(lazy{{ .Name }}{})%lazyCacheLine) % lazyCacheLine]byte
+}
+`))
+
+var _ = template.Must(implTemplate.New("impl").Parse(`
+// lazy{{ .Name }} implements lazy evaluation for {{ .Type }}.
+type lazy{{ .Name }}{{ .TypeParams }} struct {
+	v {{ .Type }}
+	f {{ .FuncType }}
+	m sync.Mutex
+	o uint32
+}
+
+func (v *lazy{{ .Name }}{{ .TypeArgs }}) Get({{ .Params }}) {{ .Type }} {
+	if atomic.LoadUint32(&v.o) == 1 {
+		return v.v
+	}
+
+	{{ if .Contention }}wait := lazyWaitStart()
+	{{ end -}}
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	if v.o == 0 {
+		{{ template "eval" . }}
+		atomic.StoreUint32(&v.o, 1)
+		v.f = nil
+	}
+	{{- if .Contention }} else {
+		lazyWaited("{{ .Name }}", wait)
+	}
+	{{- end }}
+	return v.v
+}
+`))
+
+var _ = template.Must(implTemplate.New("mutex").Parse(`
+// lazy{{ .Name }} implements lazy evaluation for {{ .Type }}.
+type lazy{{ .Name }}{{ .TypeParams }} struct {
+	v    {{ .Type }}
+	f    {{ .FuncType }}
+	m    sync.Mutex
+{{- if .Value }}
+	// done is set to 1 after the evaluation, for TryGet.
+	done uint32
+{{- else }}
+	done bool
+{{- end }}
+}
+
+func (v *lazy{{ .Name }}{{ .TypeArgs }}) Get({{ .Params }}) {{ .Type }} {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	if {{ if .Value }}v.done == 0{{ else }}!v.done{{ end }} {
+		{{ template "eval" . }}
+		{{ if .Value }}atomic.StoreUint32(&v.done, 1){{ else }}v.done = true{{ end }}
+		v.f = nil
+	}
+	return v.v
+}
+`))
+
+var _ = template.Must(implTemplate.New("once").Parse(`
+// lazy{{ .Name }} implements lazy evaluation for {{ .Type }}.
+type lazy{{ .Name }}{{ .TypeParams }} struct {
+	v {{ .Type }}
+	f {{ .FuncType }}
+	o sync.Once
+{{- if .Value }}
+	// done is set to 1 after the evaluation, for TryGet.
+	done uint32
+{{- end }}
+}
+
+func (v *lazy{{ .Name }}{{ .TypeArgs }}) Get({{ .Params }}) {{ .Type }} {
+	{{ if .Otel }}v.o.Do(func() { v.init(ctx) }){{ else }}v.o.Do(v.init){{ end }}
+	return v.v
+}
+
+func (v *lazy{{ .Name }}{{ .TypeArgs }}) init({{ .Params }}) {
+	{{ template "eval" . }}
+	v.f = nil
+	{{- if .Value }}
+	atomic.StoreUint32(&v.done, 1)
+	{{- end }}
+}
+`))
+
+var _ = template.Must(implTemplate.New("header").Parse(`
+// lazy{{ .Name }} implements lazy evaluation for {{ .Type }}. Everything only
+// needed for the evaluation is kept in a separately allocated header, which
+// is dropped afterwards, leaving only the value.
+type lazy{{ .Name }}{{ .TypeParams }} struct {
+	h unsafe.Pointer
+	v {{ .Type }}
+}
+
+type lazy{{ .Name }}Header{{ .TypeParams }} struct {
+	m sync.Mutex
+	f {{ .FuncType }}
+}
+
+func (v *lazy{{ .Name }}{{ .TypeArgs }}) Get({{ .Params }}) {{ .Type }} {
+	h := (*lazy{{ .Name }}Header{{ .TypeArgs }})(atomic.LoadPointer(&v.h))
+	if h == nil {
+		return v.v
+	}
+
+	{{ if .Contention }}wait := lazyWaitStart()
+	{{ end -}}
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	if atomic.LoadPointer(&v.h) != nil {
+		{{ template "eval" . }}
+		atomic.StorePointer(&v.h, nil)
+	}
+	{{- if .Contention }} else {
+		lazyWaited("{{ .Name }}", wait)
+	}
+	{{- end }}
+	return v.v
+}
+`))
+
+var _ = template.Must(implTemplate.New("stripes").Parse(`
+// lazyStripe is a lock shared by lazy values, with a condition to wait for
+// their evaluation by other goroutines.
+type lazyStripe struct {
+	m sync.Mutex
+	c *sync.Cond
+}
+
+// lazyStripes are the locks of all lazy values in this package. A value uses
+// the one selected by its address.
+var lazyStripes [{{ . }}]lazyStripe
+
+func init() {
+	for i := range lazyStripes {
+		s := &lazyStripes[i]
+		s.c = sync.NewCond(&s.m)
+	}
+}
+
+func lazyStripeOf(p unsafe.Pointer) *lazyStripe {
+	return &lazyStripes[(uintptr(p)>>4)%uintptr(len(lazyStripes))]
+}
+`))
+
+var _ = template.Must(implTemplate.New("striped").Parse(`
+// lazy{{ .Name }} implements lazy evaluation for {{ .Type }}. Instead of a
+// mutex of its own, it uses one of lazyStripes, which is not held during the
+// evaluation, so that values sharing it can be evaluated by f.
+type lazy{{ .Name }}{{ .TypeParams }} struct {
+	v {{ .Type }}
+	f {{ .FuncType }}
+	// o is 0 before, 1 during and 2 after the evaluation.
+	o uint32
+}
+
+func (v *lazy{{ .Name }}{{ .TypeArgs }}) Get({{ .Params }}) {{ .Type }} {
+	if atomic.LoadUint32(&v.o) == 2 {
+		return v.v
+	}
+
+	s := lazyStripeOf(unsafe.Pointer(v))
+	s.m.Lock()
+	for v.o == 1 {
+		s.c.Wait()
+	}
+	if v.o == 2 {
+		s.m.Unlock()
+		return v.v
+	}
+	atomic.StoreUint32(&v.o, 1)
+	s.m.Unlock()
+
+	v.eval({{ .Args }})
+	return v.v
+}
+
+func (v *lazy{{ .Name }}{{ .TypeArgs }}) eval({{ .Params }}) {
+	done := false
+	defer func() {
+		s := lazyStripeOf(unsafe.Pointer(v))
+		s.m.Lock()
+		if done {
+			atomic.StoreUint32(&v.o, 2)
+			v.f = nil
+		} else {
+			// f panicked, so the next call tries again.
+			atomic.StoreUint32(&v.o, 0)
+		}
+		s.c.Broadcast()
+		s.m.Unlock()
+	}()
+	{{ template "eval" . }}
+	done = true
+}
+`))
+
+var _ = template.Must(implTemplate.New("slab").Parse(`
+// {{ .Name }}Slab allocates lazily evaluated {{ .Type }} values in slabs, to
+// reduce the number of allocations and improve locality when creating many of
+// them. A slab is only freed when none of its values is referenced anymore.
+type {{ .Name }}Slab struct {
+	m    sync.Mutex
+	n    int
+	free []{{ .Alloc }}
+}
+
+// New{{ .Name }}Slab returns a {{ .Name }}Slab allocating n values at a time.
+func New{{ .Name }}Slab(n int) *{{ .Name }}Slab {
+	if n <= 0 {
+		panic("slab size must be positive")
+	}
+	return &{{ .Name }}Slab{n: n}
+}
+
+// New provides lazy evaluation for {{ .Type }}, like {{ .Name }}, but
+// allocates from s.
+func (s *{{ .Name }}Slab) New(f {{ .FuncType }}) {{ .FuncType }} {
+	s.m.Lock()
+	if len(s.free) == 0 {
+		s.free = make([]{{ .Alloc }}, s.n)
+	}
+	v := &s.free[0]
+	s.free = s.free[1:]
+	s.m.Unlock()
+
+	{{ template "init" . }}
+	return v.Get
+}
+`))
+
+var _ = template.Must(implTemplate.New("versioned").Parse(`
+// Versioned{{ .Name }} provides lazy evaluation for {{ .Type }}, that can be
+// invalidated to force re-evaluation. Every evaluation is tagged with a
+// generation, which increases with every call of f.
+//
+// It is safe for concurrent use. Once it is evaluated, Get only needs an
+// atomic load. If f panics, the panic is propagated and f is called again by
+// the next Get. The result is only recomputed after InvalidateIf. Lazy values
+// can not fail, so errors returned as part of it are cached like any other
+// value.
+{{- with .Deprecated }}
+//
+{{ . }}
+{{- end }}
+type Versioned{{ .Name }} struct {
+	f func() {{ .Type }}
+	m sync.Mutex
+	n uint64
+	r atomic.Value
+{{- if .Stats }}
+
+	stats lazyStats
+{{- end }}
+}
+
+type versioned{{ .Name }}Result struct {
+	v {{ .Type }}
+	g uint64
+}
+
+// NewVersioned{{ .Name }} returns a Versioned{{ .Name }}, which calls f when the
+// result is first used and again after every successful invalidation.
+func NewVersioned{{ .Name }}(f func() {{ .Type }}) *Versioned{{ .Name }} {
+{{- if .Stats }}
+	v := new(Versioned{{ .Name }})
+	v.f = func() {{ .Type }} {
+		defer func() { v.stats.end(recover()) }()
+		return f()
+	}
+	return v
+{{- else }}
+	return &Versioned{{ .Name }}{f: f}
+{{- end }}
+}
+{{- if .Stats }}
+
+// Stats returns statistics of the evaluations of v.
+func (v *Versioned{{ .Name }}) Stats() LazyStats {
+	return v.stats.get()
+}
+{{- end }}
+
+// Get returns the value and the generation it was computed in.
+func (v *Versioned{{ .Name }}) Get() ({{ .Type }}, uint64) {
+	if r, _ := v.r.Load().(*versioned{{ .Name }}Result); r != nil {
+		return r.v, r.g
+	}
+
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	r, _ := v.r.Load().(*versioned{{ .Name }}Result)
+	if r == nil {
+		v.n++
+		r = &versioned{{ .Name }}Result{v.f(), v.n}
+		v.r.Store(r)
+	}
+	return r.v, r.g
+}
+
+// TryGet returns the value and the generation it was computed in and true, if
+// it is evaluated. Otherwise, it returns false, without evaluating the value
+// or waiting for an evaluation in progress.
+func (v *Versioned{{ .Name }}) TryGet() ({{ .Type }}, uint64, bool) {
+	if r, _ := v.r.Load().(*versioned{{ .Name }}Result); r != nil {
+		return r.v, r.g, true
+	}
+	var zero {{ .Type }}
+	return zero, 0, false
+}
+
+// InvalidateIf invalidates the value, if it was computed in generation gen.
+// If the value was already invalidated or re-evaluated since, it does
+// nothing. It returns whether the value was invalidated.
+func (v *Versioned{{ .Name }}) InvalidateIf(gen uint64) bool {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	r, _ := v.r.Load().(*versioned{{ .Name }}Result)
+	if r == nil || r.g != gen {
+		return false
+	}
+	v.r.Store((*versioned{{ .Name }}Result)(nil))
+	return true
+}
+`))
+
+type pkg struct {
+	Package   string
+	Versioned bool
+	options
+	Arch      string
+	CacheLine int
+	Types     []lazyType
+
+	// Inputs is the comment recording the inputs of the file, with
+	// -record-inputs.
+	Inputs string
+
+	// Constraint is the build constraint of a file containing only
+	// platform-specific types. Declarations shared by all types are omitted
+	// from it.
+	Constraint string
+
+	// importPaths maps the names of packages used in Types to their import
+	// paths, if they differ.
+	importPaths map[string]string
+}
+
+// Imports returns the import specs of the packages used in the generated
+// file.
+func (p pkg) Imports() []string {
+	var (
+		l     []string
+		types = len(p.Types) > 0
+		// Whether the declarations shared by all types are generated.
+		shared = p.Constraint == ""
+	)
+	add := func(cond bool, path string) {
+		if cond {
+			l = append(l, path)
+		}
+	}
+	add(types || shared && (p.Registry || p.Impl == "striped" && !p.Versioned), "sync")
+	add(types && (p.Versioned || p.Impl == "atomic" || p.Impl == "header" || p.Impl == "striped" || p.Value && (p.Impl == "mutex" || p.Impl == "once") || p.Slog || p.Expvar) || shared && p.Otel, "sync/atomic")
+	add(types && (p.Pad || p.Impl == "header" || p.Impl == "striped") || shared && p.Impl == "striped" && !p.Versioned, "unsafe")
+	add(types && (p.Pprof || p.Otel) || shared && p.Otel, "context")
+	add(types && p.Pprof, "runtime/pprof")
+	add(types && p.JSON, "encoding/json")
+	add(types && p.Slog, "log/slog")
+	add(types && p.Expvar, "expvar")
+	add(types && p.YAML, "gopkg.in/yaml.v3")
+	add(types && p.Msgpack, "github.com/vmihailenco/msgpack/v5")
+	add(types && p.Proto, "google.golang.org/protobuf/types/known/wrapperspb")
+	add(shared && p.Slow != "", "log")
+	add(shared && p.Pinned, "context")
+	add((types || shared) && p.Slow != "", "time")
+	add(shared && p.Registry, "sort")
+	add(shared && p.Registry, "time")
+	add(shared && p.Registry, "errors")
+	add(shared && (p.Registry || p.Stats), "fmt")
+	add(shared && (p.Stats || p.Close || p.Notify), "sync")
+	add(types && p.Close && p.Finalizer == "", "io")
+	add(shared && (p.Stats || p.Contention), "time")
+	add(shared && p.Hash, "math")
+	add((types || shared) && p.Snapshot, "encoding/json")
+	add(shared && p.Snapshot, "io")
+	add(shared && p.Snapshot, "os")
+	add(shared && p.Snapshot, "path/filepath")
+	add(shared && p.Snapshot, "strconv")
+	add(shared && p.DebugHandler, "net/http")
+	add(shared && p.DebugHandler, "text/tabwriter")
+	return p.importSpecs(l...)
+}
+
+// importSpecs returns the import specs of the packages with the given paths
+// and of those used in p.Types.
+func (p pkg) importSpecs(paths ...string) []string {
+	var l []string
+	seen := make(map[string]bool)
+	add := func(spec string) {
+		if !seen[spec] {
+			seen[spec] = true
+			l = append(l, spec)
+		}
+	}
+	for _, path := range paths {
+		add(strconv.Quote(path))
+	}
+	for _, t := range p.Types {
+		// The type has been parsed before.
+		names, _ := gen.Qualifiers(t.Type)
+		for _, n := range names {
+			path, ok := p.importPaths[n]
+			switch {
+			case n == "C":
+			case !ok:
+				add(strconv.Quote(n))
+			case path == n || strings.HasSuffix(path, "/"+n):
+				add(strconv.Quote(path))
+			default:
+				add(n + " " + strconv.Quote(path))
+			}
+		}
+	}
+	return l
+}
+
+// options are the options for generating non-versioned lazy values.
+type options struct {
+	Impl string
+	// Generic says whether constructors have the type of the values as a
+	// type parameter.
+	Generic bool
+	// Value says whether lazy values are generated as types, instead of as
+	// functions.
+	Value bool
+	Slab  bool
+	// Registry says whether lazy values are registered for LazyForceAll.
+	// DebugHandler says whether LazyDebugHandler is generated for them.
+	Registry     bool
+	DebugHandler bool
+	// Snapshot says whether registered values can be written to and
+	// seeded from a snapshot.
+	Snapshot bool
+	// Stats says whether lazy values of the value style and versioned lazy
+	// values record statistics of their evaluations.
+	Stats bool
+	// Contention says whether the time spent waiting for the evaluation by
+	// another goroutine is reported, in builds with the lazycontention tag.
+	Contention bool
+	// JSON, YAML and Msgpack say whether lazy values of the value style
+	// implement the marshaling interfaces of encoding/json,
+	// gopkg.in/yaml.v3 and github.com/vmihailenco/msgpack/v5.
+	JSON    bool
+	YAML    bool
+	Msgpack bool
+	// Proto says whether lazy values of the value style are converted from
+	// and to protobuf wrapper messages and optional fields.
+	Proto bool
+	// Hash says whether lazy values of the value style have a Hash method.
+	Hash bool
+	// Slog says whether lazy values of the value style implement
+	// slog.LogValuer.
+	Slog bool
+	// Expvar says whether lazy values of the value style can be published
+	// with expvar.
+	Expvar bool
+	// Mobile says whether wrappers of lazy values are generated, which can
+	// be bound with gomobile.
+	Mobile bool
+	// Equal says whether lazy values of the value style have an Equal
+	// method, comparing values with EqualFunc, if set, or else ==.
+	Equal     bool
+	EqualFunc string
+	// Close says whether lazy values of the value style have a Close
+	// method, releasing their value with Finalizer, if set, or else its
+	// Close method.
+	Close     bool
+	Finalizer string
+	// Notify says whether lazy values of the value style have an Evaluated
+	// method, notifying about their evaluation.
+	Notify bool
+	// Stripes is the number of locks shared by lazy values with the striped
+	// implementation.
+	Stripes int
+	// Pad says whether lazy values are padded to the cache line size.
+	Pad bool
+	// Pprof says whether evaluations are labeled for profiles.
+	Pprof bool
+	// Otel says whether evaluations are traced. Lazy values then take a
+	// context.Context.
+	Otel bool
+	// Slow is the default threshold for slow evaluations, if they should be
+	// reported.
+	Slow string
+	// Pinned says whether lazy values are evaluated by LazyRun.
+	Pinned bool
+}
+
+// lazyType is a type to generate lazy values for.
+type lazyType struct {
+	Name string
+	Type string
+	options
+
+	// Priority is the priority of lazy values of this type in
+	// LazyForceAll.
+	Priority int
+
+	// HashCode are the statements hashing a value x of this type into h,
+	// with -hash.
+	HashCode string
+
+	// Deprecated is the deprecation notice of lazy values of this type, as
+	// a comment, if it refers to deprecated types.
+	Deprecated string
+}
+
+// FuncType returns the type of the functions creating and returned by t.
+func (t lazyType) FuncType() string {
+	return "func(" + t.Params() + ") " + t.Type
+}
+
+// Params returns the parameters of lazy values of t.
+func (t lazyType) Params() string {
+	if t.Otel {
+		return "ctx context.Context"
+	}
+	return ""
+}
+
+// Args returns the arguments passed to the function creating a lazy value of
+// t.
+func (t lazyType) Args() string {
+	if t.Otel {
+		return "ctx"
+	}
+	return ""
+}
+
+// Done returns the condition, whether a lazy value v of t is evaluated,
+// checked atomically.
+func (t lazyType) Done() string {
+	switch t.Impl {
+	case "mutex", "once":
+		return "atomic.LoadUint32(&v.done) == 1"
+	case "header":
+		return "atomic.LoadPointer(&v.h) == nil"
+	case "striped":
+		return "atomic.LoadUint32(&v.o) == 2"
+	}
+	return "atomic.LoadUint32(&v.o) == 1"
+}
+
+// Func returns the expression for the function evaluating a lazy value v.
+func (t lazyType) Func() string {
+	if t.Impl == "header" {
+		return "h.f"
+	}
+	return "v.f"
+}
+
+// Doc returns a comment documenting the semantics of lazy values of t.
+func (t lazyType) Doc() string {
+	var l []string
+	switch t.Impl {
+	case "mutex":
+		l = append(l, "It is safe for concurrent use. Every use locks a mutex.")
+	case "once":
+		l = append(l, "It is safe for concurrent use, using a sync.Once.")
+	default:
+		l = append(l, "It is safe for concurrent use. Once it is evaluated, using it only needs an atomic load.")
+	}
+	if t.Impl == "once" {
+		l = append(l, "If f panics, the panic is propagated and the zero value is used from then on, without calling f again.")
+	} else {
+		l = append(l, "If f panics, the panic is propagated and f is called again by the next use.")
+	}
+	l = append(l, "The result is never recomputed. Lazy values can not fail, so errors returned as part of it are cached like any other value.")
+	if t.Otel {
+		l = append(l, "The context of the use triggering the evaluation is passed to f and the evaluation is traced with the LazyTracer.")
+	}
+	if t.Pinned {
+		l = append(l, "f is called on the goroutine running LazyRun.")
+	}
+	if t.Pprof {
+		l = append(l, fmt.Sprintf("The evaluation is labeled with lazy=%s in profiles.", t.Name))
+	}
+	if t.Slow != "" {
+		l = append(l, "Evaluations taking longer than LazySlowThreshold are reported to LazySlowWarn.")
+	}
+	if t.Registry {
+		l = append(l, fmt.Sprintf("Lazy values are registered for LazyForceAll with priority %d and never freed.", t.Priority))
+	}
+	doc := gen.Comment(strings.Join(l, " "))
+	if t.Deprecated != "" {
+		doc += "\n//\n" + t.Deprecated
+	}
+	return doc
+}
+
+// protoWrappers maps the types supported by -proto to the names of their
+// wrapper messages.
+var protoWrappers = map[string]string{
+	"bool":    "Bool",
+	"string":  "String",
+	"[]byte":  "Bytes",
+	"int32":   "Int32",
+	"int64":   "Int64",
+	"uint32":  "UInt32",
+	"uint64":  "UInt64",
+	"float32": "Float",
+	"float64": "Double",
+}
+
+// Wrapper returns the name of the protobuf wrapper message of t.
+func (t lazyType) Wrapper() string {
+	return protoWrappers[t.Type]
+}
+
+// Alloc returns the name of the type allocated for lazy values of t.
+func (t lazyType) Alloc() string {
+	if t.Pad {
+		return "padded" + t.Name
+	}
+	return "lazy" + t.Name + t.TypeArgs()
+}
+
+// TypeParams returns the type parameter list of the generic declarations of
+// t, with -generic, or "".
+func (t lazyType) TypeParams() string {
+	if t.Generic {
+		return "[T any]"
+	}
+	return ""
+}
+
+// TypeArgs returns the type arguments instantiating the generic declarations
+// of t, with -generic, or "".
+func (t lazyType) TypeArgs() string {
+	if t.Generic {
+		return "[T]"
+	}
+	return ""
+}
+
+// cacheLines contains the cache line sizes of architectures, as assumed by
+// golang.org/x/sys/cpu. Other architectures are assumed to use 64 bytes.
+var cacheLines = map[string]int{
+	"arm":      32,
+	"arm64":    128,
+	"mips":     32,
+	"mipsle":   32,
+	"mips64":   32,
+	"mips64le": 32,
+	"ppc64":    128,
+	"ppc64le":  128,
+	"s390x":    256,
+}
+
+// impls contains the implementation strategies selectable with -impl.
+//
+// None of them uses 64-bit atomic operations, which require 64-bit alignment
+// on 386 and 32-bit ARM and MIPS, where the compiler only guarantees it for
+// the first word of an allocated struct. An implementation adding them must
+// put the field first, not use it with -pad or -slab, which embed or allocate
+// values in arrays, and assert the alignment in the generated code, like
+// go-ring and go-stack -lockfree do:
+//
+//	var _ = [1]struct{}{}[unsafe.Offsetof(lazyT{}.n)%8]
+var impls = []string{"atomic", "mutex", "once", "header", "striped"}
+
+func validImpl(impl string) bool {
+	for _, i := range impls {
+		if i == impl {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	flags       = flag.NewFlagSet("go-lazy", flag.ContinueOnError)
+	pkgName     = flags.String("package", "lazy", "Package the file should be in")
+	out         = gen.OutputFlags(flags)
+	versioned   = flags.Bool("versioned", false, "Generate versioned lazy values")
+	generic     = flags.Bool("generic", false, "Generate generic constructors, with the type of the values as a type parameter, taking only names as arguments")
+	impl        = flags.String("impl", "atomic", "Implementation strategy (atomic, mutex, once, header or striped)")
+	stripes     = flags.Int("stripes", 64, "Number of locks shared by lazy values with -impl=striped")
+	style       = flags.String("style", "func", `Style of the generated lazy values, "func" or "value"`)
+	registry    = flags.Bool("registry", false, "Register lazy values, to evaluate them all with LazyForceAll")
+	debug       = flags.Bool("debug-handler", false, "Generate an http.Handler listing the registered lazy values")
+	snapshot    = flags.Bool("snapshot", false, "Generate functions writing the registered lazy values to a snapshot and seeding them from it")
+	closeFlag   = flags.Bool("close", false, "Generate a Close method for lazy value types, releasing their value")
+	notify      = flags.Bool("notify", false, "Generate an Evaluated method for lazy value types, returning a channel closed after their evaluation")
+	finalizer   = flags.String("finalizer", "", "Function releasing values with -close, instead of their Close method")
+	contention  = flags.Bool("contention", false, "Report time spent waiting for evaluations, with the lazycontention build tag")
+	marshal     = flags.String("marshal", "", `Comma-separated list of formats lazy value types can be marshaled to, "json", "yaml" or "msgpack"`)
+	proto       = flags.Bool("proto", false, "Generate conversions of lazy value types from and to protobuf wrapper messages")
+	hash        = flags.Bool("hash", false, "Generate a Hash method for lazy value types")
+	mobile      = flags.Bool("mobile", false, "Generate wrappers of lazy values, which can be bound with gomobile")
+	equal       = flags.Bool("equal", false, "Generate an Equal method for lazy value types")
+	equalFunc   = flags.String("equal-func", "", "Function comparing values with -equal, instead of ==")
+	slogFlag    = flags.Bool("slog", false, "Implement slog.LogValuer on lazy value types, without evaluating them")
+	expvarFlag  = flags.Bool("expvar", false, "Generate a method publishing lazy value types with expvar, without evaluating them")
+	stats       = flags.Bool("stats", false, "Generate a Stats method for lazy value types, returning statistics of their evaluations")
+	target      = flags.String("target", "gc", `Compiler to generate code for, "gc" or "tinygo"`)
+	slab        = flags.Bool("slab", false, "Generate slab allocators for lazy values")
+	pad         = flags.Bool("pad", false, "Pad lazy values to the cache line size of $GOARCH")
+	labels      = flags.Bool("pprof", false, "Label the evaluation of lazy values in profiles")
+	otel        = flags.Bool("otel", false, "Trace the evaluation of lazy values, which then take a context")
+	slow        = flags.Duration("slow", 0, "Warn about evaluations of lazy values taking longer than this (0 disables warnings)")
+	pinned      = flags.Bool("pinned", false, "Evaluate lazy values on the goroutine running the generated LazyRun")
+	testsFile   = flags.String("tests", "", "Where to write stress tests for the generated code")
+	testGo      = flags.Int("test-goroutines", 8, "Number of goroutines getting a lazy value at once in the generated tests")
+	testIter    = flags.Int("test-iterations", 100, "Number of lazy values tested for every GOMAXPROCS value in the generated tests")
+	testProcs   = flags.String("test-procs", "1,2,4,8", "Comma-separated list of GOMAXPROCS values for the generated tests")
+	allowUnsafe = flags.Bool("allow-unsafe", false, "Allow caching values of unsafe.Pointer and cgo types")
+	propsFile   = flags.String("properties", "", "Where to write property tests for the generated code")
+	deprecated  = flags.Bool("deprecated", false, "Mark lazy values of deprecated types as deprecated")
+	configFile  = flags.String("config", "", "JSON file with additional types, optionally varying by platform")
+	manifestOut = flags.String("manifest", "", "Where to write a JSON manifest of the generated symbols")
+	record      = flags.Bool("record-inputs", false, "Record the inputs in the generated file, to detect when it is out of date with lazycheck")
+)
+
+// isSet returns whether the flag with the given name was set on the command
+// line.
+func isSet(name string) bool {
+	set := false
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// lazyTypes checks the types ts and returns them as lazyTypes with the
+// options o and the given priorities.
+func lazyTypes(ts []gen.Type, o options, priorities map[string]int) ([]lazyType, error) {
+	var l []lazyType
+	for _, t := range ts {
+		if _, err := gen.Qualifiers(t.Type); err != nil {
+			return nil, err
+		}
+		if !*allowUnsafe {
+			if err := gen.CheckUnsafe(t.Type); err != nil {
+				return nil, err
+			}
+		}
+		if _, ok := protoWrappers[t.Type]; o.Proto && !ok {
+			return nil, fmt.Errorf("-proto does not support type %s", t.Type)
+		}
+		if o.Mobile && !mobileType(t.Type) {
+			return nil, fmt.Errorf("-mobile does not support type %s", t.Type)
+		}
+		if o.Equal && o.EqualFunc == "" && !comparable(t.Type) {
+			return nil, fmt.Errorf("%s is not comparable, use -equal-func", t.Type)
+		}
+		lt := lazyType{Name: t.Name, Type: t.Type, options: o, Priority: priorities[t.Name]}
+		if o.Hash {
+			code, err := hashCode(t.Type)
+			if err != nil {
+				return nil, err
+			}
+			lt.HashCode = code
+		}
+		l = append(l, lt)
+	}
+	return l, nil
+}
+
+// mobileType returns whether gomobile can bind values of the type expression
+// typ: signed integers, floats, bool, string, []byte, error and pointers to
+// exported named types of the package.
+func mobileType(typ string) bool {
+	switch typ {
+	case "bool", "int", "int8", "int16", "int32", "int64", "float32", "float64", "string", "[]byte", "error":
+		return true
+	}
+	return strings.HasPrefix(typ, "*") && ast.IsExported(typ[1:]) && token.IsIdentifier(typ[1:])
+}
+
+// comparable returns false if the type expression typ is obviously not
+// comparable.
+func comparable(typ string) bool {
+	for _, prefix := range []string{"[]", "map[", "func("} {
+		if strings.HasPrefix(typ, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// deprecate sets the deprecation notices of ts, looking up the types they
+// refer to relative to dir.
+func deprecate(ts []lazyType, dir string, paths map[string]string) error {
+	for i, t := range ts {
+		m, err := gen.Deprecations(dir, t.Type, paths)
+		if err != nil {
+			return err
+		}
+		var names []string
+		for name := range m {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var l []string
+		for _, name := range names {
+			l = append(l, fmt.Sprintf("%s is deprecated: %s", name, m[name]))
+		}
+		if len(l) > 0 {
+			ts[i].Deprecated = gen.Comment("Deprecated: " + strings.Join(l, " "))
+		}
+	}
+	return nil
+}
+
+// Run runs go-lazy with the command line arguments args, which do not include
+// the program name.
+func Run(args []string) error {
+	if len(args) > 0 && args[0] == "bench" {
+		return runBench(args[1:])
+	}
+	if len(args) > 0 && args[0] == "migrate" {
+		return runMigrate(args[1:])
+	}
+	if len(args) > 0 && args[0] == "upgrade" {
+		return runUpgrade(args[1:])
+	}
+	if len(args) > 0 && args[0] == "proxy" {
+		return runProxy(args[1:])
+	}
+	if err := gen.ParseFlags(flags, args); err != nil {
+		return err
+	}
+
+	var (
+		types []gen.Type
+		err   error
+		cfg   = new(config)
+	)
+	if *configFile != "" {
+		if cfg, err = loadConfig(*configFile); err != nil {
+			return err
+		}
+	}
+	switch {
+	case *generic:
+		// The arguments are only names.
+		for _, n := range flags.Args() {
+			types = append(types, gen.Type{Name: n, Type: "T"})
+		}
+		if len(types) == 0 {
+			err = errors.New("no names")
+		}
+	case flags.NArg() > 0 || *configFile == "":
+		types, err = gen.ParseTypes(flags.Args())
+	}
+	if err != nil {
+		return errors.New("Usage: go-lazy [-package=<pkg>] [-target=<target>] [-generic] [-versioned | -impl=<impl> [-style=<style>] [-registry [-debug-handler] [-snapshot]] [-slab] [-pad] [-pprof] [-otel] [-slow=<d>] [-pinned] [-contention]] [-stats] [-close [-finalizer=<func>]] [-notify] [-marshal=<formats>] [-proto] [-hash] [-slog] [-expvar] [-equal [-equal-func=<func>]] [-mobile] [-tests=<file>] [-properties=<file>] [-allow-unsafe] [-deprecated] [-config=<file>] [-record-inputs] [-manifest=<file>] [<name> <type>]...")
+	}
+	switch *target {
+	case "gc":
+	case "tinygo":
+		// The mutex implementation needs no atomics, which are emulated
+		// with locks on some TinyGo targets anyway. Versioned values
+		// have only one implementation.
+		if !isSet("impl") && !*versioned {
+			*impl = "mutex"
+		}
+		if *impl == "header" || *pad || *labels {
+			return errors.New("-impl=header, -pad and -pprof can not be used with -target=tinygo")
+		}
+	default:
+		return fmt.Errorf("unknown target %q", *target)
+	}
+	if !validImpl(*impl) {
+		return fmt.Errorf("unknown implementation %q", *impl)
+	}
+	if *versioned && *impl != "atomic" {
+		return errors.New("-impl can not be used with -versioned")
+	}
+	if *versioned && (*slab || *pad || *labels || *otel || *slow != 0 || *pinned) {
+		return errors.New("-slab, -pad, -pprof, -otel, -slow and -pinned can not be used with -versioned")
+	}
+	if *pinned && *labels {
+		// The labels would be set on the goroutine using the lazy value.
+		return errors.New("-pinned can not be used with -pprof")
+	}
+	if *pinned && (*testsFile != "" || *propsFile != "") {
+		// Nothing would run LazyRun, so the generated tests would hang.
+		return errors.New("-pinned can not be used with -tests or -properties")
+	}
+	if *generic && (*versioned || *style != "func" || *slab || *pad || *testsFile != "" || *propsFile != "" || *configFile != "") {
+		return errors.New("-generic can not be used with -versioned, -style=value, -slab, -pad, -tests, -properties or -config")
+	}
+	if *mobile && (*versioned || *generic || *otel) {
+		return errors.New("-mobile can not be used with -versioned, -generic or -otel")
+	}
+	if *stripes <= 0 {
+		return errors.New("-stripes must be positive")
+	}
+	if *slow < 0 {
+		return errors.New("-slow must not be negative")
+	}
+	if *style != "func" && *style != "value" {
+		return fmt.Errorf("unknown style %q", *style)
+	}
+	if *debug && !*registry {
+		return errors.New("-debug-handler requires -registry")
+	}
+	if *snapshot && !*registry {
+		return errors.New("-snapshot requires -registry")
+	}
+	if *stats && !*versioned && *style != "value" {
+		return errors.New("-stats requires -style=value or -versioned")
+	}
+	if *contention && (*versioned || *impl != "atomic" && *impl != "header") {
+		return errors.New("-contention requires -impl=atomic or -impl=header")
+	}
+	if *contention && out.File == "" {
+		return errors.New("-contention requires -out")
+	}
+	if *manifestOut != "" && out.File == "" {
+		return errors.New("-manifest requires -out")
+	}
+	if (*marshal != "" || *proto || *hash || *equal || *slogFlag || *expvarFlag) && *style != "value" {
+		return errors.New("-marshal, -proto, -hash, -equal, -slog and -expvar require -style=value")
+	}
+	if *equalFunc != "" && !*equal {
+		return errors.New("-equal-func requires -equal")
+	}
+	if *closeFlag && *style != "value" {
+		return errors.New("-close requires -style=value")
+	}
+	if *notify && *style != "value" {
+		return errors.New("-notify requires -style=value")
+	}
+	if *finalizer != "" && !*closeFlag {
+		return errors.New("-finalizer requires -close")
+	}
+	if *versioned && (*style != "func" || *registry) {
+		return errors.New("-style and -registry can not be used with -versioned")
+	}
+
+	o := options{Impl: *impl, Generic: *generic, Stripes: *stripes, Value: *style == "value", Slab: *slab, Registry: *registry, DebugHandler: *debug, Snapshot: *snapshot, Stats: *stats, Contention: *contention, Proto: *proto, Hash: *hash, Slog: *slogFlag, Expvar: *expvarFlag, Mobile: *mobile, Equal: *equal, EqualFunc: *equalFunc, Close: *closeFlag, Finalizer: *finalizer, Notify: *notify, Pad: *pad, Pprof: *labels, Otel: *otel, Pinned: *pinned}
+	if *slow != 0 {
+		o.Slow = gen.DurationLiteral(*slow)
+	}
+	if *marshal != "" {
+		for _, f := range strings.Split(*marshal, ",") {
+			switch f {
+			case "json":
+				o.JSON = true
+			case "yaml":
+				o.YAML = true
+			case "msgpack":
+				o.Msgpack = true
+			default:
+				return fmt.Errorf("unknown format %q", f)
+			}
+		}
+	}
+	p := pkg{Package: *pkgName, Versioned: *versioned, options: o, importPaths: cfg.Imports}
+	if *pad {
+		p.Arch = os.Getenv("GOARCH")
+		if p.Arch == "" {
+			p.Arch = runtime.GOARCH
+		}
+		p.CacheLine = 64
+		if n, ok := cacheLines[p.Arch]; ok {
+			p.CacheLine = n
+		}
+	}
+	for _, t := range cfg.Types {
+		if len(t.Platforms) == 0 {
+			types = append(types, gen.Type{Name: t.Name, Type: t.Type})
+		}
+	}
+	p.Types, err = lazyTypes(types, o, cfg.priorities())
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(out.File)
+	if *deprecated {
+		if err := deprecate(p.Types, dir, cfg.Imports); err != nil {
+			return err
+		}
+	}
+	vs := variants(cfg.Types)
+	if len(vs) > 0 && out.File == "" {
+		return errors.New("platform-specific types require -out")
+	}
+	if *record {
+		var typs []string
+		for _, t := range p.Types {
+			typs = append(typs, t.Type)
+		}
+		for _, v := range vs {
+			for _, t := range v.Types {
+				typs = append(typs, t.Type)
+			}
+		}
+		in, err := gen.RecordInputs(dir, args, *configFile, typs)
+		if err != nil {
+			return err
+		}
+		p.Inputs = in.Comment()
+	}
+	if *propsFile != "" {
+		for _, t := range p.Types {
+			if err := checkQuick(t.Type); err != nil {
+				return err
+			}
+		}
+	}
+	if err := out.Write(implTemplate, p); err != nil {
+		return err
+	}
+	files := []manifestFile{{Name: out.File, Types: p.Types}}
+	for _, v := range vs {
+		vp := p
+		vp.Constraint = v.Constraint
+		if vp.Types, err = lazyTypes(v.Types, o, cfg.priorities()); err != nil {
+			return err
+		}
+		if *deprecated {
+			if err := deprecate(vp.Types, dir, cfg.Imports); err != nil {
+				return err
+			}
+		}
+		vo := *out
+		vo.File = strings.TrimSuffix(out.File, ".go") + "_" + v.Suffix + ".go"
+		if err := vo.Write(implTemplate, vp); err != nil {
+			return err
+		}
+		files = append(files, manifestFile{Name: vo.File, Constraint: vp.Constraint, Types: vp.Types})
+	}
+
+	if *contention {
+		for _, tag := range []bool{true, false} {
+			co := &gen.Output{File: strings.TrimSuffix(out.File, ".go") + "_contention.go", Check: out.Check}
+			if !tag {
+				co.File = strings.TrimSuffix(out.File, ".go") + "_nocontention.go"
+			}
+			data := struct {
+				Package string
+				Tag     bool
+			}{*pkgName, tag}
+			if err := co.Write(contentionTemplate, data); err != nil {
+				return err
+			}
+			files = append(files, manifestFile{Name: co.File})
+		}
+	}
+
+	if *manifestOut != "" {
+		mo := &gen.Output{File: *manifestOut, Check: out.Check}
+		if err := writeManifest(mo, *pkgName, files); err != nil {
+			return err
+		}
+	}
+
+	if *propsFile != "" {
+		po := &gen.Output{File: *propsFile, Check: out.Check}
+		if err := po.Write(propertiesTemplate, properties{p}); err != nil {
+			return err
+		}
+	}
+	if *testsFile == "" {
+		return nil
+	}
+	if *testGo <= 0 || *testIter <= 0 {
+		return errors.New("-test-goroutines and -test-iterations must be positive")
+	}
+	procs, err := parseProcs(*testProcs)
+	if err != nil {
+		return err
+	}
+	to := &gen.Output{File: *testsFile, Check: out.Check}
+	return to.Write(testsTemplate, tests{p, *testGo, *testIter, procs})
+}
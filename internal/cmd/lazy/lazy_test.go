@@ -0,0 +1,223 @@
+package lazy
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"merovius.de/go-misc/internal/gen"
+)
+
+// goLazyArgs is the environment variable, which makes the test binary run
+// go-lazy with its newline-separated arguments, instead of the tests. The
+// flags are package variables, so every run needs a fresh process.
+const goLazyArgs = "GO_LAZY_TEST_ARGS"
+
+var update = flag.Bool("update", false, "Update the golden files in testdata")
+
+func TestMain(m *testing.M) {
+	if args, ok := os.LookupEnv(goLazyArgs); ok {
+		gen.Main(Run, strings.Split(args, "\n"))
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// goLazy runs go-lazy with args in dir and returns its output. It fails the
+// test, if go-lazy fails.
+func goLazy(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := runGoLazy(dir, args...)
+	if err != nil {
+		t.Fatalf("go-lazy %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return out
+}
+
+// goTest runs go test on a module in a new temporary directory, with the
+// given files and the code generated by go-lazy with args.
+func goTest(t *testing.T, files map[string]string, args ...string) {
+	t.Helper()
+	dir := t.TempDir()
+	files["go.mod"] = "module lazy\n\ngo 1.18\n"
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	goLazy(t, dir, args...)
+	cmd := exec.Command("go", "test")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("go test: %v\n%s", err, out)
+	}
+}
+
+// testPackage copies the package testdata/name to a new directory and returns
+// it. The directory is created in testdata, so that the package can import
+// packages of this module.
+func testPackage(t *testing.T, name string) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("testdata", "tmp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	files, err := filepath.Glob(filepath.Join("testdata", name, "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, filepath.Base(f)), b, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// generate runs the //go:generate directives running go-lazy in the files of
+// dir, like go generate.
+func generate(t *testing.T, dir string) {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, l := range strings.Split(string(b), "\n") {
+			if args := strings.TrimPrefix(l, "//go:generate go-lazy "); args != l {
+				goLazy(t, dir, strings.Fields(args)...)
+			}
+		}
+	}
+}
+
+// checkGolden fails the test, if the file name in dir differs from the golden
+// file testdata/golden. With -update, it writes the golden file instead.
+func checkGolden(t *testing.T, dir, name, golden string) {
+	t.Helper()
+	got, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden = filepath.Join("testdata", golden)
+	if *update {
+		if err := os.WriteFile(golden, got, 0666); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs from %s:\n%s", name, golden, got)
+	}
+}
+
+// runGoLazy runs go-lazy with args in dir and returns its combined output.
+func runGoLazy(dir string, args ...string) (string, error) {
+	cmd := exec.Command(os.Args[0])
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), goLazyArgs+"="+strings.Join(args, "\n"))
+	out, err := cmd.CombinedOutput()
+	return string(out), err
+}
+
+func TestTinyGoVersioned(t *testing.T) {
+	dir := t.TempDir()
+	goLazy(t, dir, "-target=tinygo", "-versioned", "-out=versioned.go", "Int", "int")
+	b, err := os.ReadFile(filepath.Join(dir, "versioned.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "func NewVersionedInt(") {
+		t.Errorf("go-lazy -target=tinygo -versioned did not generate NewVersionedInt")
+	}
+
+	if out, err := runGoLazy(dir, "-target=tinygo", "-versioned", "-impl=mutex", "Int", "int"); err == nil {
+		t.Errorf("go-lazy -target=tinygo -versioned -impl=mutex succeeded, want error\n%s", out)
+	}
+}
+
+func TestPinnedTests(t *testing.T) {
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"-pinned", "-tests=lazy_test.go", "Int", "int"},
+		{"-pinned", "-properties=props_test.go", "Int", "int"},
+	} {
+		if out, err := runGoLazy(dir, args...); err == nil {
+			t.Errorf("go-lazy %s succeeded, want error\n%s", strings.Join(args, " "), out)
+		}
+	}
+}
+
+func TestMutexTryGet(t *testing.T) {
+	goTest(t, map[string]string{"trylock_test.go": `package lazy
+
+import "testing"
+
+func TestTryGet(t *testing.T) {
+	var v Int
+	started, release := make(chan struct{}), make(chan struct{})
+	v.Init(func() int {
+		close(started)
+		<-release
+		return 42
+	})
+	go v.Get()
+	<-started
+	if _, ok := v.TryGet(); ok {
+		t.Errorf("TryGet() succeeded during evaluation")
+	}
+	close(release)
+	v.Get()
+
+	// TryGet must not depend on the lock, which Get holds.
+	v.m.Lock()
+	defer v.m.Unlock()
+	if got, ok := v.TryGet(); got != 42 || !ok {
+		t.Errorf("TryGet() == %v, %v while locked, want 42, true", got, ok)
+	}
+}
+`}, "-out=gen.go", "-impl=mutex", "-style=value", "Int", "int")
+}
+
+func TestHash(t *testing.T) {
+	goTest(t, map[string]string{"hash_test.go": `package lazy
+
+import "testing"
+
+func TestHash(t *testing.T) {
+	var a, b, c L
+	a.Init(func() struct{ A, B map[string][]int } {
+		return struct{ A, B map[string][]int }{A: map[string][]int{"x": {1}, "y": {2, 3}}, B: map[string][]int{"x": {1}}}
+	})
+	b.Init(func() struct{ A, B map[string][]int } {
+		return struct{ A, B map[string][]int }{A: map[string][]int{"y": {2, 3}, "x": {1}}, B: map[string][]int{"x": {1}}}
+	})
+	c.Init(func() struct{ A, B map[string][]int } {
+		return struct{ A, B map[string][]int }{A: map[string][]int{"x": {1}}, B: map[string][]int{"x": {1}, "y": {2, 3}}}
+	})
+	if a.Hash() != b.Hash() {
+		t.Errorf("equal values have different hashes")
+	}
+	if a.Hash() == c.Hash() {
+		t.Errorf("different values have equal hashes")
+	}
+}
+`}, "-out=gen.go", "-style=value", "-hash", "L", "struct{ A map[string][]int; B map[string][]int }")
+}
@@ -0,0 +1,166 @@
+package lazy
+
+import (
+	"encoding/json"
+	"flag"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+
+	"merovius.de/go-misc/internal/gen"
+)
+
+// manifest is the format of the file written by -manifest.
+type manifest struct {
+	Package string `json:"package"`
+
+	// Options are the flags go-lazy was run with, other than those naming
+	// output files.
+	Options map[string]string `json:"options"`
+
+	// Types are the generated lazy values. Types with platforms have an
+	// entry per generated file.
+	Types []manifestType `json:"types"`
+
+	// Shared are the symbols shared by all types.
+	Shared []symbol `json:"shared,omitempty"`
+}
+
+// manifestType is a lazy value generated for a type.
+type manifestType struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+
+	// Constraint is the build constraint of the file, for types with
+	// platforms.
+	Constraint string `json:"constraint,omitempty"`
+
+	Symbols []symbol `json:"symbols"`
+}
+
+// symbol is an exported declaration of a generated file.
+type symbol struct {
+	Name string `json:"name"`
+	// Kind is "const", "var", "type", "func" or "method".
+	Kind string `json:"kind"`
+	// Receiver is the name of the receiver type of a method.
+	Receiver string `json:"receiver,omitempty"`
+	// File is the name of the file, relative to the manifest, and Line
+	// the line of the declaration in it.
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+// manifestFile is a generated file to list in the manifest.
+type manifestFile struct {
+	Name       string
+	Constraint string
+	Types      []lazyType
+}
+
+// outputFlags are not listed as options in the manifest.
+var outputFlags = map[string]bool{
+	"out":        true,
+	"check":      true,
+	"template":   true,
+	"lint":       true,
+	"manifest":   true,
+	"tests":      true,
+	"properties": true,
+}
+
+// writeManifest writes the manifest of the generated files to o. The files
+// must exist already.
+func writeManifest(o *gen.Output, pkgName string, files []manifestFile) error {
+	m := &manifest{Package: pkgName, Options: make(map[string]string), Types: []manifestType{}}
+	flags.Visit(func(f *flag.Flag) {
+		if !outputFlags[f.Name] {
+			m.Options[f.Name] = f.Value.String()
+		}
+	})
+
+	dir := filepath.Dir(o.File)
+	for _, mf := range files {
+		syms, err := fileSymbols(mf.Name)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, mf.Name)
+		if err != nil {
+			return err
+		}
+		owner := make(map[string]int)
+		for i, t := range mf.Types {
+			for _, n := range []string{t.Name, t.Name + "Slab", "New" + t.Name + "Slab", "Versioned" + t.Name, "NewVersioned" + t.Name} {
+				owner[n] = i + 1
+			}
+		}
+		types := make([]manifestType, len(mf.Types))
+		for i, t := range mf.Types {
+			types[i] = manifestType{Name: t.Name, Type: t.Type, Constraint: mf.Constraint, Symbols: []symbol{}}
+		}
+		for _, s := range syms {
+			s.File = filepath.ToSlash(rel)
+			n := s.Name
+			if s.Kind == "method" {
+				n = s.Receiver
+			}
+			if i := owner[n]; i > 0 {
+				types[i-1].Symbols = append(types[i-1].Symbols, s)
+			} else if mf.Constraint == "" {
+				m.Shared = append(m.Shared, s)
+			}
+		}
+		m.Types = append(m.Types, types...)
+	}
+
+	b, err := json.MarshalIndent(m, "", "\t")
+	if err != nil {
+		return err
+	}
+	return o.WriteBytes(append(b, '\n'))
+}
+
+// fileSymbols returns the exported declarations of the named file, in order.
+func fileSymbols(name string) ([]symbol, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, name, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	var syms []symbol
+	add := func(id *ast.Ident, kind, recv string) {
+		if id.IsExported() {
+			syms = append(syms, symbol{Name: id.Name, Kind: kind, Receiver: recv, Line: fset.Position(id.Pos()).Line})
+		}
+	}
+	for _, d := range f.Decls {
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			if d.Recv == nil {
+				add(d.Name, "func", "")
+				continue
+			}
+			t := d.Recv.List[0].Type
+			if s, ok := t.(*ast.StarExpr); ok {
+				t = s.X
+			}
+			if id, ok := t.(*ast.Ident); ok && id.IsExported() {
+				add(d.Name, "method", id.Name)
+			}
+		case *ast.GenDecl:
+			for _, s := range d.Specs {
+				switch s := s.(type) {
+				case *ast.TypeSpec:
+					add(s.Name, "type", "")
+				case *ast.ValueSpec:
+					for _, n := range s.Names {
+						add(n, d.Tok.String(), "")
+					}
+				}
+			}
+		}
+	}
+	return syms, nil
+}
@@ -0,0 +1,605 @@
+package lazy
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"merovius.de/go-misc/internal/gen"
+)
+
+var (
+	migrateFlags = flag.NewFlagSet("go-lazy migrate", flag.ContinueOnError)
+	migrateWrite = migrateFlags.Bool("w", false, "Write the migrated files, instead of printing a diff")
+	migrateOut   = migrateFlags.String("out", "lazy_migrated.go", "File the go:generate directives added for non-builtin types write to")
+	migrateRe    = migrateFlags.Bool("regexp", false, "Also migrate package level regexps compiled with regexp.MustCompile to lazy.Regexp")
+)
+
+// getter is a hand-written lazy value, found by migrate:
+//
+//	var x T
+//	var once sync.Once
+//
+//	func getX() T {
+//		once.Do(func() {
+//			x = …
+//		})
+//		return x
+//	}
+type getter struct {
+	fn   *ast.FuncDecl
+	do   *ast.FuncLit
+	x    *types.Var
+	once *types.Var
+	typ  string
+}
+
+// lazyRegexp is a package level regexp, found by migrate with -regexp:
+//
+//	var re = regexp.MustCompile(`…`)
+type lazyRegexp struct {
+	spec *ast.ValueSpec
+	call *ast.CallExpr
+	v    *types.Var
+}
+
+// runMigrate runs the migrate subcommand.
+func runMigrate(args []string) error {
+	if err := gen.ParseFlags(migrateFlags, args); err != nil {
+		return err
+	}
+	if migrateFlags.NArg() > 1 {
+		return errors.New("Usage: go-lazy migrate [-w] [-out=<file>] [-regexp] [<dir>]")
+	}
+	dir := "."
+	if migrateFlags.NArg() == 1 {
+		dir = migrateFlags.Arg(0)
+	}
+
+	lp, err := gen.LoadPackage(dir)
+	if err != nil {
+		return err
+	}
+	if len(lp.Errors) > 0 {
+		return lp.Errors[0]
+	}
+	gs := findGetters(lp)
+	var rs []*lazyRegexp
+	if *migrateRe {
+		rs = findRegexps(lp)
+	}
+	if len(gs) == 0 && len(rs) == 0 {
+		if *migrateRe {
+			fmt.Fprintln(os.Stderr, "no sync.Once getters or regexps found")
+		} else {
+			fmt.Fprintln(os.Stderr, "no sync.Once getters found")
+		}
+		return nil
+	}
+
+	byFile := make(map[*ast.File][]*getter)
+	for _, g := range gs {
+		f := fileOf(lp, g.fn)
+		byFile[f] = append(byFile[f], g)
+	}
+	for _, f := range lp.Files {
+		if len(byFile[f]) == 0 && !usesRegexps(lp, f, rs) {
+			continue
+		}
+		name := lp.Fset.File(f.Pos()).Name()
+		src, err := ioutil.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		migrated, err := migrateFile(lp, f, src, byFile[f], rs)
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		if *migrateWrite {
+			err = ioutil.WriteFile(name, migrated, 0666)
+		} else {
+			err = diff(name, src, migrated)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findGetters returns the getters in the package, which can be migrated.
+func findGetters(lp *gen.Package) []*getter {
+	// Count the uses of package level variables, to only migrate those
+	// used by their getter alone.
+	uses := make(map[*types.Var]int)
+	for _, obj := range lp.Info.Uses {
+		if v, ok := obj.(*types.Var); ok && v.Parent() == lp.Types.Scope() {
+			uses[v]++
+		}
+	}
+
+	var gs []*getter
+	for _, f := range lp.Files {
+		for _, d := range f.Decls {
+			fn, ok := d.(*ast.FuncDecl)
+			if !ok {
+				continue
+			}
+			if g := matchGetter(lp, fn); g != nil && uses[g.x] == countUses(lp, fn, g.x) && uses[g.once] == 1 {
+				gs = append(gs, g)
+			}
+		}
+	}
+	return gs
+}
+
+// matchGetter returns the getter declared by fn, or nil.
+func matchGetter(lp *gen.Package, fn *ast.FuncDecl) *getter {
+	if fn.Recv != nil || fn.Type.TypeParams != nil || fn.Type.Params.NumFields() != 0 || fn.Type.Results.NumFields() != 1 || len(fn.Body.List) != 2 {
+		return nil
+	}
+	es, ok := fn.Body.List[0].(*ast.ExprStmt)
+	if !ok {
+		return nil
+	}
+	call, ok := es.X.(*ast.CallExpr)
+	if !ok || len(call.Args) != 1 {
+		return nil
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Do" {
+		return nil
+	}
+	do, ok := call.Args[0].(*ast.FuncLit)
+	if !ok {
+		return nil
+	}
+	ret, ok := fn.Body.List[1].(*ast.ReturnStmt)
+	if !ok || len(ret.Results) != 1 {
+		return nil
+	}
+	once := packageVar(lp, sel.X)
+	x := packageVar(lp, ret.Results[0])
+	if once == nil || x == nil || !isSyncOnce(once.Type()) {
+		return nil
+	}
+	if !types.Identical(x.Type(), lp.Info.TypeOf(fn.Type.Results.List[0].Type)) {
+		return nil
+	}
+	if declSpec(lp, once) == nil || declSpec(lp, x) == nil || returns(do.Body) {
+		return nil
+	}
+	return &getter{fn: fn, do: do, x: x, once: once, typ: types.ExprString(fn.Type.Results.List[0].Type)}
+}
+
+// findRegexps returns the package level regexps, which can be migrated. They
+// must be compiled from a constant pattern and never be assigned to or have
+// their address taken, as they are replaced by funcs.
+func findRegexps(lp *gen.Package) []*lazyRegexp {
+	var rs []*lazyRegexp
+	for _, f := range lp.Files {
+		for _, d := range f.Decls {
+			gd, ok := d.(*ast.GenDecl)
+			if !ok || gd.Tok != token.VAR {
+				continue
+			}
+			for _, s := range gd.Specs {
+				vs := s.(*ast.ValueSpec)
+				if len(vs.Names) != 1 || len(vs.Values) != 1 || vs.Type != nil {
+					continue
+				}
+				call, ok := vs.Values[0].(*ast.CallExpr)
+				if !ok || len(call.Args) != 1 || lp.Info.Types[call.Args[0]].Value == nil {
+					continue
+				}
+				fn, ok := lp.Info.Uses[calleeIdent(call)].(*types.Func)
+				if !ok || fn.Pkg() == nil || fn.Pkg().Path() != "regexp" || fn.Name() != "MustCompile" {
+					continue
+				}
+				if v, ok := lp.Info.Defs[vs.Names[0]].(*types.Var); ok && !modified(lp, v) {
+					rs = append(rs, &lazyRegexp{spec: vs, call: call, v: v})
+				}
+			}
+		}
+	}
+	return rs
+}
+
+// calleeIdent returns the identifier of the function called by call, or nil.
+func calleeIdent(call *ast.CallExpr) *ast.Ident {
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		return fun
+	case *ast.SelectorExpr:
+		return fun.Sel
+	}
+	return nil
+}
+
+// modified returns whether the package level variable v is assigned to or has
+// its address taken.
+func modified(lp *gen.Package, v *types.Var) bool {
+	is := func(e ast.Expr) bool {
+		id, ok := ast.Unparen(e).(*ast.Ident)
+		return ok && lp.Info.Uses[id] == v
+	}
+	found := false
+	for _, f := range lp.Files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.AssignStmt:
+				for _, l := range n.Lhs {
+					found = found || is(l)
+				}
+			case *ast.IncDecStmt:
+				found = found || is(n.X)
+			case *ast.UnaryExpr:
+				found = found || n.Op == token.AND && is(n.X)
+			}
+			return !found
+		})
+	}
+	return found
+}
+
+// usesRegexps returns whether f declares or uses one of rs.
+func usesRegexps(lp *gen.Package, f *ast.File, rs []*lazyRegexp) bool {
+	for _, r := range rs {
+		if fileOf(lp, r.spec) == f || countUses(lp, f, r.v) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// returns returns whether body contains a return statement, outside of func
+// literals.
+func returns(body *ast.BlockStmt) bool {
+	found := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.ReturnStmt:
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// packageVar returns the package level variable e refers to, or nil.
+func packageVar(lp *gen.Package, e ast.Expr) *types.Var {
+	id, ok := e.(*ast.Ident)
+	if !ok {
+		return nil
+	}
+	v, ok := lp.Info.Uses[id].(*types.Var)
+	if !ok || v.Parent() != lp.Types.Scope() {
+		return nil
+	}
+	return v
+}
+
+func isSyncOnce(t types.Type) bool {
+	n, ok := t.(*types.Named)
+	return ok && n.Obj().Pkg() != nil && n.Obj().Pkg().Path() == "sync" && n.Obj().Name() == "Once"
+}
+
+// countUses returns the number of uses of v in n.
+func countUses(lp *gen.Package, n ast.Node, v *types.Var) int {
+	c := 0
+	ast.Inspect(n, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && lp.Info.Uses[id] == v {
+			c++
+		}
+		return true
+	})
+	return c
+}
+
+// declSpec returns the declaration of the package level variable v, if it
+// declares v alone, without a value, or nil.
+func declSpec(lp *gen.Package, v *types.Var) *ast.ValueSpec {
+	for _, f := range lp.Files {
+		for _, d := range f.Decls {
+			gd, ok := d.(*ast.GenDecl)
+			if !ok || gd.Tok != token.VAR {
+				continue
+			}
+			for _, s := range gd.Specs {
+				vs := s.(*ast.ValueSpec)
+				if len(vs.Names) == 1 && len(vs.Values) == 0 && vs.Type != nil && lp.Info.Defs[vs.Names[0]] == v {
+					return vs
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func fileOf(lp *gen.Package, n ast.Node) *ast.File {
+	for _, f := range lp.Files {
+		if f.Pos() <= n.Pos() && n.Pos() <= f.End() {
+			return f
+		}
+	}
+	return nil
+}
+
+// edit replaces the source between two offsets.
+type edit struct {
+	start, end int
+	text       string
+}
+
+// migrateFile returns the source src of f, with the getters gs declared in it
+// replaced by lazy values. Their variables may be declared in other files of
+// the package, which are not edited, so they are only removed from f. The
+// regexps rs of the package declared in f are replaced by lazy.Regexp and
+// their uses in f by calls.
+func migrateFile(lp *gen.Package, f *ast.File, src []byte, gs []*getter, rs []*lazyRegexp) ([]byte, error) {
+	off := func(p token.Pos) int { return lp.Fset.Position(p).Offset }
+	var (
+		edits    []edit
+		builtins bool
+		typeArgs []string
+	)
+	// Remove the declarations of the variables in f, and their GenDecls,
+	// if they only declare them.
+	removed := make(map[ast.Spec]bool)
+	for _, g := range gs {
+		for _, v := range []*types.Var{g.x, g.once} {
+			if vs := declSpec(lp, v); fileOf(lp, vs) == f {
+				removed[vs] = true
+			}
+		}
+	}
+	for _, d := range f.Decls {
+		gd, ok := d.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		n := 0
+		for _, s := range gd.Specs {
+			if removed[s] {
+				n++
+			}
+		}
+		if n > 0 && n == len(gd.Specs) {
+			edits = append(edits, edit{off(docPos(gd.Doc, gd.Pos())), off(gd.End()), ""})
+			continue
+		}
+		for _, s := range gd.Specs {
+			if !removed[s] {
+				continue
+			}
+			vs := s.(*ast.ValueSpec)
+			end := vs.End()
+			if vs.Comment != nil {
+				end = vs.Comment.End()
+			}
+			edits = append(edits, edit{off(docPos(vs.Doc, vs.Pos())), off(end), ""})
+		}
+	}
+
+	for _, g := range gs {
+		ctor := ""
+		for _, t := range gen.BuiltinTypes {
+			if t.Type == g.typ {
+				ctor = "lazy." + t.Name
+				builtins = true
+			}
+		}
+		if ctor == "" {
+			ctor = "lazy" + upperFirst(g.x.Name())
+			typeArgs = append(typeArgs, ctor, quoteArg(g.typ))
+		}
+
+		body := g.do.Body.List
+		buf := new(bytes.Buffer)
+		fmt.Fprintf(buf, "var %s = %s(func() %s {\n", g.fn.Name.Name, ctor, g.typ)
+		if as, ok := body[0].(*ast.AssignStmt); ok && len(body) == 1 && as.Tok == token.ASSIGN && len(as.Lhs) == 1 && packageVar(lp, as.Lhs[0]) == g.x {
+			fmt.Fprintf(buf, "return %s\n", src[off(as.Rhs[0].Pos()):off(as.Rhs[0].End())])
+		} else {
+			fmt.Fprintf(buf, "var %s %s\n", g.x.Name(), g.typ)
+			fmt.Fprintf(buf, "%s\n", bytes.TrimSpace(src[off(g.do.Body.Lbrace)+1:off(g.do.Body.Rbrace)]))
+			fmt.Fprintf(buf, "return %s\n", g.x.Name())
+		}
+		buf.WriteString("})")
+		edits = append(edits, edit{off(g.fn.Pos()), off(g.fn.End()), buf.String()})
+	}
+
+	skip := make(map[ast.Node]bool)
+	for _, g := range gs {
+		skip[g.fn] = true
+		skip[declSpec(lp, g.once)] = true
+	}
+	for _, r := range rs {
+		if fileOf(lp, r.spec) == f {
+			edits = append(edits, edit{off(r.call.Fun.Pos()), off(r.call.Fun.End()), "lazy.Regexp"})
+			skip[r.call.Fun] = true
+			builtins = true
+		}
+	}
+	ast.Inspect(f, func(n ast.Node) bool {
+		id, ok := n.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		for _, r := range rs {
+			if lp.Info.Uses[id] == r.v {
+				edits = append(edits, edit{off(id.End()), off(id.End()), "()"})
+			}
+		}
+		return true
+	})
+
+	if len(typeArgs) > 0 {
+		directive := fmt.Sprintf("\n\n//go:generate go-lazy -package=%s -out %s %s", f.Name.Name, *migrateOut, strings.Join(typeArgs, " "))
+		edits = append(edits, edit{off(f.Name.End()), off(f.Name.End()), directive})
+	}
+	paths := []string{"sync"}
+	if len(rs) > 0 {
+		paths = append(paths, "regexp")
+	}
+	edits = append(edits, importEdits(lp, f, skip, paths, off, builtins)...)
+
+	return applyEdits(src, edits)
+}
+
+// applyEdits returns src with edits applied and formats it.
+func applyEdits(src []byte, edits []edit) ([]byte, error) {
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+	buf := new(bytes.Buffer)
+	last := 0
+	for _, e := range edits {
+		if e.start < last {
+			return nil, errors.New("overlapping edits")
+		}
+		buf.Write(src[last:e.start])
+		buf.WriteString(e.text)
+		last = e.end
+	}
+	buf.Write(src[last:])
+	return format.Source(buf.Bytes())
+}
+
+// importEdits returns the edits removing the imports of the packages paths
+// from f, if they are only used by the nodes in skip, and adding the import of
+// package lazy, if builtins is set.
+func importEdits(lp *gen.Package, f *ast.File, skip map[ast.Node]bool, paths []string, off func(token.Pos) int, builtins bool) []edit {
+	const lazyPath = `"merovius.de/go-misc/lazy"`
+	var (
+		first *ast.GenDecl
+		edits []edit
+		added bool
+	)
+	for _, d := range f.Decls {
+		gd, ok := d.(*ast.GenDecl)
+		if !ok || gd.Tok != token.IMPORT {
+			continue
+		}
+		if first == nil {
+			first = gd
+		}
+		for _, s := range gd.Specs {
+			is := s.(*ast.ImportSpec)
+			if is.Name != nil || !removable(is.Path.Value, paths) || usesPackage(lp, f, strings.Trim(is.Path.Value, `"`), skip) {
+				continue
+			}
+			switch {
+			case gd.Lparen.IsValid():
+				edits = append(edits, edit{off(is.Pos()), off(is.End()), ""})
+			case builtins && !added:
+				// The only spec of the declaration.
+				edits = append(edits, edit{off(is.Pos()), off(is.End()), lazyPath})
+				added = true
+			default:
+				edits = append(edits, edit{off(docPos(gd.Doc, gd.Pos())), off(gd.End()), ""})
+			}
+		}
+	}
+
+	switch {
+	case !builtins || added:
+	case first == nil:
+		edits = append(edits, edit{off(f.Name.End()), off(f.Name.End()), "\n\nimport " + lazyPath})
+	case first.Lparen.IsValid():
+		edits = append(edits, edit{off(first.Rparen), off(first.Rparen), "\n" + lazyPath + "\n"})
+	default:
+		edits = append(edits, edit{off(first.Pos()), off(first.Pos()), "import " + lazyPath + "\n"})
+	}
+	return edits
+}
+
+// removable returns whether the quoted import path is one of paths.
+func removable(quoted string, paths []string) bool {
+	for _, p := range paths {
+		if quoted == strconv.Quote(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// docPos returns the position of doc, if it is not nil, or pos.
+func docPos(doc *ast.CommentGroup, pos token.Pos) token.Pos {
+	if doc != nil {
+		return doc.Pos()
+	}
+	return pos
+}
+
+// usesPackage returns whether f uses the package with the given path, other
+// than in the nodes in skip.
+func usesPackage(lp *gen.Package, f *ast.File, path string, skip map[ast.Node]bool) bool {
+	used := false
+	ast.Inspect(f, func(n ast.Node) bool {
+		if skip[n] {
+			return false
+		}
+		if id, ok := n.(*ast.Ident); ok {
+			if pn, ok := lp.Info.Uses[id].(*types.PkgName); ok && pn.Imported().Path() == path {
+				used = true
+			}
+		}
+		return !used
+	})
+	return used
+}
+
+func upperFirst(s string) string {
+	r, n := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[n:]
+}
+
+// quoteArg quotes a type for use as an argument in a go:generate directive,
+// if necessary.
+func quoteArg(s string) string {
+	if strings.ContainsAny(s, " \t\"") {
+		return fmt.Sprintf("%q", s)
+	}
+	return s
+}
+
+// diff prints a unified diff of the file name from old to new, using the diff
+// command.
+func diff(name string, old, new []byte) error {
+	dir, err := ioutil.TempDir("", "go-lazy-migrate")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	a, b := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+	if err := ioutil.WriteFile(a, old, 0666); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(b, new, 0666); err != nil {
+		return err
+	}
+	cmd := exec.Command("diff", "-u", "-L", name, "-L", name, a, b)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		// diff exits with 1, if the files differ.
+		if e, ok := err.(*exec.ExitError); !ok || e.ExitCode() != 1 {
+			return err
+		}
+	}
+	return nil
+}
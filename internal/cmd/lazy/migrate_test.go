@@ -0,0 +1,21 @@
+package lazy
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMigrate(t *testing.T) {
+	dir := testPackage(t, "migrate")
+	goLazy(t, dir, "migrate", "-w", "-regexp")
+	checkGolden(t, dir, "server.go", "migrate/server.go.golden")
+
+	// The added directive generates the constructor of the non-builtin
+	// type, which the migrated package needs to build.
+	generate(t, dir)
+	out := goLazy(t, dir, "migrate", "-w", "-regexp")
+	if !strings.Contains(out, "no sync.Once getters or regexps found") {
+		t.Errorf("go-lazy migrate migrated the migrated package again:\n%s", out)
+	}
+	checkGolden(t, dir, "server.go", "migrate/server.go.golden")
+}
@@ -0,0 +1,157 @@
+package lazy
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+var propertiesTemplate = template.Must(template.New("lazy_properties_test.go").Parse(`
+// This file is automatically generated by merovius.de/go-misc/cmd/go-lazy.
+
+package {{ .Package }}
+
+import (
+{{- range .Imports }}
+	{{ . }}
+{{- end }}
+)
+{{ range .Types }}
+{{- if $.Versioned }}
+func TestVersioned{{ .Name }}Properties(t *testing.T) {
+	// f is not called before the value is used.
+	isLazy := func(x {{ .Type }}) bool {
+		n := 0
+		NewVersioned{{ .Name }}(func() {{ .Type }} {
+			n++
+			return x
+		})
+		return n == 0
+	}
+	// Repeated calls of Get return the same value and generation, without
+	// calling f again.
+	getIdempotent := func(x {{ .Type }}) bool {
+		n := 0
+		v := NewVersioned{{ .Name }}(func() {{ .Type }} {
+			n++
+			return x
+		})
+		a, ga := v.Get()
+		b, gb := v.Get()
+		return n == 1 && ga == gb && reflect.DeepEqual(a, x) && reflect.DeepEqual(b, x)
+	}
+	// Invalidating the current generation forces re-evaluation, which
+	// returns the new value in a later generation.
+	invalidateRecomputes := func(x, y {{ .Type }}) bool {
+		n := 0
+		v := NewVersioned{{ .Name }}(func() {{ .Type }} {
+			n++
+			if n == 1 {
+				return x
+			}
+			return y
+		})
+		a, ga := v.Get()
+		if !v.InvalidateIf(ga) {
+			return false
+		}
+		b, gb := v.Get()
+		return n == 2 && gb > ga && reflect.DeepEqual(a, x) && reflect.DeepEqual(b, y)
+	}
+	// Invalidating a past generation does nothing.
+	invalidateStale := func(x, y {{ .Type }}) bool {
+		n := 0
+		v := NewVersioned{{ .Name }}(func() {{ .Type }} {
+			n++
+			if n == 1 {
+				return x
+			}
+			return y
+		})
+		_, ga := v.Get()
+		v.InvalidateIf(ga)
+		_, gb := v.Get()
+		if v.InvalidateIf(ga) {
+			return false
+		}
+		b, gc := v.Get()
+		return n == 2 && gc == gb && reflect.DeepEqual(b, y)
+	}
+	for name, f := range map[string]interface{}{
+		"isLazy":               isLazy,
+		"getIdempotent":        getIdempotent,
+		"invalidateRecomputes": invalidateRecomputes,
+		"invalidateStale":      invalidateStale,
+	} {
+		if err := quick.Check(f, nil); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+{{- else }}
+func Test{{ .Name }}Properties(t *testing.T) {
+	// f is not called before the value is used.
+	isLazy := func(x {{ .Type }}) bool {
+		n := 0
+		{{ if .Value }}new({{ .Name }}).Init{{ else }}{{ .Name }}{{ end }}(func({{ .Params }}) {{ .Type }} {
+			n++
+			return x
+		})
+		return n == 0
+	}
+	// Repeated calls return the same value, without calling f again.
+	getIdempotent := func(x {{ .Type }}) bool {
+		n := 0
+		{{ if .Value }}v := new({{ .Name }})
+		v.Init(func({{ .Params }}) {{ .Type }} {
+			n++
+			return x
+		})
+		get := v.Get{{ else }}get := {{ .Name }}(func({{ .Params }}) {{ .Type }} {
+			n++
+			return x
+		}){{ end }}
+		a := get({{ if .Otel }}context.Background(){{ end }})
+		b := get({{ if .Otel }}context.Background(){{ end }})
+		return n == 1 && reflect.DeepEqual(a, x) && reflect.DeepEqual(b, x)
+	}
+	for name, f := range map[string]interface{}{
+		"isLazy":        isLazy,
+		"getIdempotent": getIdempotent,
+	} {
+		if err := quick.Check(f, nil); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+{{- end }}
+{{ end }}
+`))
+
+// properties is the data of propertiesTemplate.
+type properties struct {
+	pkg
+}
+
+// Imports returns the import specs of the packages used in the property
+// tests.
+func (p properties) Imports() []string {
+	paths := []string{"reflect", "testing", "testing/quick"}
+	if p.Otel {
+		paths = append(paths, "context")
+	}
+	return p.importSpecs(paths...)
+}
+
+// checkQuick returns an error if values of typ can never be generated by
+// testing/quick. Other types might still not be supported, e.g. structs with
+// unexported fields, unless they implement quick.Generator.
+func checkQuick(typ string) error {
+	switch {
+	case typ == "error", typ == "any", strings.HasPrefix(typ, "interface{"):
+		return fmt.Errorf("can not generate property tests for interface type %s", typ)
+	case strings.HasPrefix(typ, "func("), strings.HasPrefix(typ, "chan "), strings.HasPrefix(typ, "<-chan "):
+		return fmt.Errorf("can not generate property tests for %s", typ)
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package lazy
+
+import (
+	"errors"
+	"flag"
+	"go/types"
+	"strings"
+	"text/template"
+
+	"merovius.de/go-misc/internal/gen"
+)
+
+var proxyTemplate = template.Must(template.New("proxy.go").Parse(`
+// This file is automatically generated by merovius.de/go-misc/cmd/go-lazy.
+
+package {{ .Package }}
+
+import (
+{{- range .Imports }}
+	{{ . }}
+{{- end }}
+)
+
+{{ range $i := .Types }}
+// NewLazy{{ .Name }} returns a {{ .Name }}, which creates the implementation
+// by calling newImpl, when one of its methods is first called, and forwards
+// all calls to it. This allows handing out a {{ .Name }} right away, while
+// deferring its expensive construction until it is used.
+//
+{{- if $.Err }}
+// newImpl is called until it succeeds. While it fails, calls are forwarded to
+// fallback, if it is not nil. Otherwise, methods returning an error return the
+// error of newImpl as their first error result, with zero values for the other
+// results, and methods not returning an error panic with it. If newImpl
+// panics, the panic is propagated and newImpl is called again by the next
+// call.
+func NewLazy{{ .Name }}(newImpl func() ({{ .Name }}, error), fallback {{ .Name }}) {{ .Name }} {
+	return &lazy{{ .Name }}Proxy{f: newImpl, fallback: fallback}
+}
+{{- else }}
+// newImpl is called exactly once. If it panics, the panic is propagated and
+// newImpl is called again by the next call.
+func NewLazy{{ .Name }}(newImpl func() {{ .Name }}) {{ .Name }} {
+	return &lazy{{ .Name }}Proxy{f: newImpl}
+}
+{{- end }}
+
+// lazy{{ .Name }}Proxy is a {{ .Name }} with a lazily created implementation.
+type lazy{{ .Name }}Proxy struct {
+	v {{ .Name }}
+{{- if $.Err }}
+	f        func() ({{ .Name }}, error)
+	fallback {{ .Name }}
+{{- else }}
+	f func() {{ .Name }}
+{{- end }}
+	m sync.Mutex
+	o uint32
+}
+
+// impl returns the implementation, creating it if necessary.
+{{- if $.Err }} If that fails,
+// it returns the fallback, if any, or the error.
+func (p *lazy{{ .Name }}Proxy) impl() ({{ .Name }}, error) {
+	if atomic.LoadUint32(&p.o) == 1 {
+		return p.v, nil
+	}
+
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if p.o == 0 {
+		v, err := p.f()
+		if err != nil {
+			if p.fallback != nil {
+				return p.fallback, nil
+			}
+			return nil, err
+		}
+		p.v = v
+		atomic.StoreUint32(&p.o, 1)
+		p.f, p.fallback = nil, nil
+	}
+	return p.v, nil
+}
+{{- else }}
+func (p *lazy{{ .Name }}Proxy) impl() {{ .Name }} {
+	if atomic.LoadUint32(&p.o) == 1 {
+		return p.v
+	}
+
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if p.o == 0 {
+		p.v = p.f()
+		atomic.StoreUint32(&p.o, 1)
+		p.f = nil
+	}
+	return p.v
+}
+{{- end }}
+{{ range .Methods }}
+func (p *lazy{{ $i.Name }}Proxy) {{ .Name }}({{ .ParamDecl }}) {{ .ResultDecl }} {
+{{- if $.Err }}
+	impl, err := p.impl()
+	if err != nil {
+	{{- if .Err }}
+		{{ .Err }} = err
+		return
+	{{- else }}
+		panic(err)
+	{{- end }}
+	}
+	{{ if .Results }}return {{ end }}impl.{{ .Name }}({{ .Args }})
+{{- else }}
+	{{ if .Results }}return {{ end }}p.impl().{{ .Name }}({{ .Args }})
+{{- end }}
+}
+{{ end }}
+{{ end }}
+`))
+
+type proxyPkg struct {
+	Package string
+	Imports []string
+	Types   []proxyIface
+
+	// Err says whether implementations are created by a func returning an
+	// error.
+	Err bool
+}
+
+// proxyIface is an interface to generate a lazy proxy for.
+type proxyIface struct {
+	Name    string
+	Methods []proxyMethod
+}
+
+type proxyMethod struct {
+	*gen.Func
+	Name string
+	// Err is the name of the first result, which is an error, or "".
+	Err string
+}
+
+var (
+	proxyFlags = flag.NewFlagSet("go-lazy proxy", flag.ContinueOnError)
+	proxyTypes = proxyFlags.String("type", "", "Comma-separated list of interface type names")
+	proxyErr   = proxyFlags.Bool("err", false, "Create implementations with a func returning an error, which is returned by the methods")
+	proxyOut   = gen.OutputFlags(proxyFlags)
+)
+
+var errorType = types.Universe.Lookup("error").Type()
+
+// runProxy runs the proxy subcommand.
+func runProxy(args []string) error {
+	if err := gen.ParseFlags(proxyFlags, args); err != nil {
+		return err
+	}
+	if *proxyTypes == "" || proxyFlags.NArg() > 1 {
+		return errors.New("Usage: go-lazy proxy [-err] -type=<type>[,<type>...] [<dir>]")
+	}
+	dir := "."
+	if proxyFlags.NArg() == 1 {
+		dir = proxyFlags.Arg(0)
+	}
+
+	lp, err := gen.LoadPackage(dir)
+	if err != nil {
+		return err
+	}
+	im := gen.NewImports(lp.Types)
+	im.Add("sync", "sync")
+	im.Add("sync/atomic", "atomic")
+	p := proxyPkg{Package: lp.Types.Name(), Err: *proxyErr}
+	for _, name := range strings.Split(*proxyTypes, ",") {
+		_, it, err := lp.Interface(name)
+		if err != nil {
+			return err
+		}
+		i := proxyIface{Name: name}
+		for j := 0; j < it.NumMethods(); j++ {
+			fn := it.Method(j)
+			sig := fn.Type().(*types.Signature)
+			m := proxyMethod{Func: im.Func(sig), Name: fn.Name()}
+			for k := 0; k < sig.Results().Len(); k++ {
+				if types.Identical(sig.Results().At(k).Type(), errorType) {
+					m.Err = m.Results[k].Name
+					break
+				}
+			}
+			i.Methods = append(i.Methods, m)
+		}
+		p.Types = append(p.Types, i)
+	}
+	p.Imports = im.List()
+	return proxyOut.Write(proxyTemplate, p)
+}
@@ -0,0 +1,84 @@
+package lazy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProxy(t *testing.T) {
+	dir := testPackage(t, "proxy")
+	goLazy(t, dir, "proxy", "-type=Store", "-out=proxy.go")
+	checkGolden(t, dir, "proxy.go", "proxy/proxy.go.golden")
+}
+
+func TestProxyErr(t *testing.T) {
+	dir := testPackage(t, "proxy")
+	goLazy(t, dir, "proxy", "-err", "-type=Store", "-out=proxy.go")
+	checkGolden(t, dir, "proxy.go", "proxy/proxy_err.go.golden")
+}
+
+func TestProxyErrRun(t *testing.T) {
+	store, err := os.ReadFile(filepath.Join("testdata", "proxy", "store.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	goTest(t, map[string]string{"store.go": string(store), "proxy_test.go": `package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type mapStore map[string][]byte
+
+func (s mapStore) Get(ctx context.Context, key string) ([]byte, error) { return s[key], nil }
+func (s mapStore) Put(ctx context.Context, key string, v []byte) error { s[key] = v; return nil }
+func (s mapStore) Len() int                                          { return len(s) }
+func (s mapStore) Close()                                            {}
+
+func TestProxy(t *testing.T) {
+	ctx := context.Background()
+	errDown := errors.New("down")
+	calls := 0
+	s := NewLazyStore(func() (Store, error) {
+		if calls++; calls == 1 {
+			return nil, errDown
+		}
+		return mapStore{"a": []byte("b")}, nil
+	}, nil)
+
+	if _, err := s.Get(ctx, "a"); err != errDown {
+		t.Fatalf("Get() == _, %v, want %v", err, errDown)
+	}
+	if v, err := s.Get(ctx, "a"); string(v) != "b" || err != nil {
+		t.Fatalf("Get() == %q, %v after newImpl succeeded, want %q, <nil>", v, err, "b")
+	}
+	if calls != 2 {
+		t.Errorf("newImpl called %d times, want 2", calls)
+	}
+}
+
+func TestProxyPanics(t *testing.T) {
+	errDown := errors.New("down")
+	s := NewLazyStore(func() (Store, error) { return nil, errDown }, nil)
+	defer func() {
+		if r := recover(); r != errDown {
+			t.Errorf("Len() panicked with %v, want %v", r, errDown)
+		}
+	}()
+	s.Len()
+}
+
+func TestProxyFallback(t *testing.T) {
+	s := NewLazyStore(func() (Store, error) { return nil, errors.New("down") }, mapStore{})
+	if err := s.Put(context.Background(), "a", nil); err != nil {
+		t.Errorf("Put() == %v with fallback, want <nil>", err)
+	}
+	if n := s.Len(); n != 1 {
+		t.Errorf("Len() == %d after Put to fallback, want 1", n)
+	}
+}
+`}, "proxy", "-err", "-type=Store", "-out=proxy.go")
+}
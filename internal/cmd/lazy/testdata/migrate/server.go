@@ -0,0 +1,49 @@
+package server
+
+import (
+	"os"
+	"regexp"
+	"strconv"
+	"sync"
+)
+
+// Config is the configuration of the server.
+type Config struct {
+	Addr string
+}
+
+var (
+	port     int
+	portOnce sync.Once
+)
+
+// getPort returns the port to listen on.
+func getPort() int {
+	portOnce.Do(func() {
+		port, _ = strconv.Atoi(os.Getenv("PORT"))
+	})
+	return port
+}
+
+var config *Config
+var configOnce sync.Once
+
+func getConfig() *Config {
+	configOnce.Do(func() {
+		config = &Config{Addr: os.Getenv("ADDR")}
+	})
+	return config
+}
+
+var name = regexp.MustCompile(`^[a-z]+$`)
+
+// number is replaced by setNumber, so it is not migrated.
+var number = regexp.MustCompile(`^[0-9]+$`)
+
+func setNumber(expr string) {
+	number = regexp.MustCompile(expr)
+}
+
+func valid(s string) bool {
+	return name.MatchString(s) && !number.MatchString(s)
+}
@@ -0,0 +1,11 @@
+package store
+
+import "context"
+
+// Store is a key-value store.
+type Store interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Put(ctx context.Context, key string, value []byte) error
+	Len() int
+	Close()
+}
@@ -0,0 +1,19 @@
+package config
+
+//go:generate go-lazy -package=config -out lazy_gen.go lazyConfig *Config lazyNames []string
+
+// Config is the configuration of the server.
+type Config struct {
+	Addr string
+}
+
+var getConfig = lazyConfig(func() *Config {
+	return &Config{Addr: ":8080"}
+})
+
+// newNames is not called, so its type argument can not be inferred.
+var newNames func(func() []string) func() []string = lazyNames
+
+var getNames = newNames(func() []string {
+	return []string{"a", "b"}
+})
@@ -0,0 +1,130 @@
+package lazy
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+var testsTemplate = template.Must(template.New("lazy_test.go").Parse(`
+// This file is automatically generated by merovius.de/go-misc/cmd/go-lazy.
+
+package {{ .Package }}
+
+import (
+{{- range .Imports }}
+	{{ . }}
+{{- end }}
+)
+
+// lazyTestProcs are the values of GOMAXPROCS the tests run with.
+var lazyTestProcs = []int{ {{- .Procs -}} }
+
+// lazyStress calls the func returned by newGet from {{ .Goroutines }} goroutines at
+// once, {{ .Iterations }} times for every value in lazyTestProcs. newGet is passed a
+// counter, which must be incremented by every evaluation. The test fails
+// unless there is exactly one.
+func lazyStress(t *testing.T, newGet func(n *int32) func()) {
+	for _, procs := range lazyTestProcs {
+		prev := runtime.GOMAXPROCS(procs)
+		for i := 0; i < {{ .Iterations }}; i++ {
+			var n int32
+			get := newGet(&n)
+			start := make(chan struct{})
+			var wg sync.WaitGroup
+			for j := 0; j < {{ .Goroutines }}; j++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					<-start
+					get()
+				}()
+			}
+			close(start)
+			wg.Wait()
+			if n := atomic.LoadInt32(&n); n != 1 {
+				runtime.GOMAXPROCS(prev)
+				t.Fatalf("GOMAXPROCS=%d: evaluated %d times, want 1", procs, n)
+			}
+		}
+		runtime.GOMAXPROCS(prev)
+	}
+}
+// lazyNoAllocs fails the test, if get allocates after its first call.
+func lazyNoAllocs(t *testing.T, get func()) {
+	get()
+	if n := testing.AllocsPerRun(100, get); n != 0 {
+		t.Errorf("Get allocates %v times after evaluation, want 0", n)
+	}
+}
+{{ range .Types }}
+{{- if $.Versioned }}
+func TestVersioned{{ .Name }}Stress(t *testing.T) {
+	lazyStress(t, func(n *int32) func() {
+		l := NewVersioned{{ .Name }}(func() (v {{ .Type }}) {
+			atomic.AddInt32(n, 1)
+			return v
+		})
+		return func() { l.Get() }
+	})
+}
+
+func TestVersioned{{ .Name }}Allocs(t *testing.T) {
+	l := NewVersioned{{ .Name }}(func() (v {{ .Type }}) { return v })
+	lazyNoAllocs(t, func() { l.Get() })
+}
+{{- else }}
+func Test{{ .Name }}Stress(t *testing.T) {
+	lazyStress(t, func(n *int32) func() {
+		{{ if .Value }}f := new({{ .Name }})
+		f.Init{{ else }}f := {{ .Name }}{{ end }}(func({{ .Params }}) (v {{ .Type }}) {
+			atomic.AddInt32(n, 1)
+			return v
+		})
+		return func() { f{{ if .Value }}.Get{{ end }}({{ if .Otel }}context.Background(){{ end }}) }
+	})
+}
+
+func Test{{ .Name }}Allocs(t *testing.T) {
+	{{ if .Value }}f := new({{ .Name }})
+	f.Init{{ else }}f := {{ .Name }}{{ end }}(func({{ .Params }}) (v {{ .Type }}) { return v })
+	{{- if .Otel }}
+	ctx := context.Background()
+	{{- end }}
+	lazyNoAllocs(t, func() { f{{ if .Value }}.Get{{ end }}({{ if .Otel }}ctx{{ end }}) })
+}
+{{- end }}
+{{ end }}
+`))
+
+// tests is the data of testsTemplate.
+type tests struct {
+	pkg
+	Goroutines int
+	Iterations int
+	Procs      string
+}
+
+// Imports returns the import specs of the packages used in the tests.
+func (t tests) Imports() []string {
+	paths := []string{"runtime", "sync", "sync/atomic", "testing"}
+	if t.Otel {
+		paths = append(paths, "context")
+	}
+	return t.importSpecs(paths...)
+}
+
+// parseProcs parses a comma-separated list of GOMAXPROCS values and returns
+// it as the elements of a Go slice literal.
+func parseProcs(s string) (string, error) {
+	var l []string
+	for _, f := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(f))
+		if err != nil || n <= 0 {
+			return "", fmt.Errorf("invalid GOMAXPROCS value %q", f)
+		}
+		l = append(l, strconv.Itoa(n))
+	}
+	return strings.Join(l, ", "), nil
+}
@@ -0,0 +1,270 @@
+package lazy
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"merovius.de/go-misc/internal/gen"
+)
+
+var (
+	upgradeFlags = flag.NewFlagSet("go-lazy upgrade", flag.ContinueOnError)
+	upgradeWrite = upgradeFlags.Bool("w", false, "Write the upgraded files and regenerate, instead of printing a diff")
+	upgradeName  = upgradeFlags.String("name", "lazyValue", "Name of the generic constructor")
+)
+
+// directive is a //go:generate directive running go-lazy.
+type directive struct {
+	comment *ast.Comment
+	// cmd is the command, "go-lazy" or "gomisc lazy".
+	cmd  string
+	args []string
+}
+
+// upgrade is a directive, which can be upgraded to -generic.
+type upgrade struct {
+	directive
+	// out is the output file, relative to the directory of the package.
+	out string
+	// newArgs are the arguments of the upgraded directive.
+	newArgs []string
+	// types maps the names of the constructors to their types.
+	types map[string]string
+}
+
+// runUpgrade runs the upgrade subcommand.
+func runUpgrade(args []string) error {
+	if err := gen.ParseFlags(upgradeFlags, args); err != nil {
+		return err
+	}
+	if upgradeFlags.NArg() > 1 || !token.IsIdentifier(*upgradeName) {
+		return errors.New("Usage: go-lazy upgrade [-w] [-name=<name>] [<dir>]")
+	}
+	dir := "."
+	if upgradeFlags.NArg() == 1 {
+		dir = upgradeFlags.Arg(0)
+	}
+
+	lp, err := gen.LoadPackage(dir)
+	if err != nil {
+		return err
+	}
+	if len(lp.Errors) > 0 {
+		return lp.Errors[0]
+	}
+	var ups []*upgrade
+	for _, d := range directives(lp) {
+		up, err := upgradable(d)
+		if err == nil && len(ups) > 0 {
+			// The constructor would be declared twice.
+			err = errors.New("only one directive of a package can be upgraded")
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v: skipping %s: %v\n", lp.Fset.Position(d.comment.Pos()), d.comment.Text, err)
+			continue
+		}
+		ups = append(ups, up)
+	}
+	if len(ups) == 0 {
+		fmt.Fprintln(os.Stderr, "no go-lazy directives to upgrade found")
+		return nil
+	}
+
+	// Every file needs the absolute name of the generated file declaring a
+	// constructor, to tell it apart from other functions of the same name.
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	ctors := make(map[string]*upgrade)
+	for _, up := range ups {
+		for n := range up.types {
+			ctors[n] = up
+		}
+	}
+	isCtor := func(id *ast.Ident) bool {
+		fn, ok := lp.Info.Uses[id].(*types.Func)
+		if !ok || fn.Pkg() != lp.Types {
+			return false
+		}
+		up, ok := ctors[fn.Name()]
+		return ok && lp.Fset.Position(fn.Pos()).Filename == filepath.Join(absDir, up.out)
+	}
+
+	for _, f := range lp.Files {
+		name := lp.Fset.File(f.Pos()).Name()
+		if isGenerated(name, absDir, ups) {
+			continue
+		}
+		off := func(p token.Pos) int { return lp.Fset.Position(p).Offset }
+		var edits []edit
+		called := make(map[*ast.Ident]bool)
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.CallExpr:
+				if id, ok := ast.Unparen(n.Fun).(*ast.Ident); ok {
+					called[id] = true
+				}
+			case *ast.Ident:
+				if !isCtor(n) {
+					break
+				}
+				// The type argument is inferred from the argument of
+				// calls. Otherwise it is given explicitly, which only
+				// works if the file imports the packages it uses.
+				text := *upgradeName
+				if !called[n] {
+					text += "[" + ctors[n.Name].types[n.Name] + "]"
+				}
+				edits = append(edits, edit{off(n.Pos()), off(n.End()), text})
+			}
+			return true
+		})
+		for _, up := range ups {
+			if c := up.comment; lp.Fset.Position(c.Pos()).Filename == name {
+				text := "//go:generate " + up.cmd + " " + strings.Join(up.newArgs, " ")
+				edits = append(edits, edit{off(c.Pos()), off(c.End()), text})
+			}
+		}
+		if len(edits) == 0 {
+			continue
+		}
+
+		src, err := ioutil.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		upgraded, err := applyEdits(src, edits)
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		if *upgradeWrite {
+			err = ioutil.WriteFile(name, upgraded, 0666)
+		} else {
+			err = diff(name, src, upgraded)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if !*upgradeWrite {
+		for _, up := range ups {
+			fmt.Fprintf(os.Stderr, "%s would be generated again\n", filepath.Join(dir, up.out))
+		}
+		return nil
+	}
+	return regenerate(dir, ups)
+}
+
+// directives returns the //go:generate directives of the package running
+// go-lazy, other than its subcommands.
+func directives(lp *gen.Package) []directive {
+	var ds []directive
+	for _, f := range lp.Files {
+		for _, cg := range f.Comments {
+			for _, c := range cg.List {
+				if !strings.HasPrefix(c.Text, "//go:generate ") {
+					continue
+				}
+				args := strings.Fields(strings.TrimPrefix(c.Text, "//go:generate "))
+				d := directive{comment: c}
+				switch {
+				case len(args) > 0 && args[0] == "go-lazy":
+					d.cmd, d.args = "go-lazy", args[1:]
+				case len(args) > 1 && args[0] == "gomisc" && args[1] == "lazy":
+					d.cmd, d.args = "gomisc lazy", args[2:]
+				default:
+					continue
+				}
+				if len(d.args) > 0 && (d.args[0] == "bench" || d.args[0] == "migrate" || d.args[0] == "upgrade" || d.args[0] == "proxy") {
+					continue
+				}
+				ds = append(ds, d)
+			}
+		}
+	}
+	return ds
+}
+
+// upgradable returns the upgrade of d to -generic, or an error, if it can not
+// be upgraded.
+func upgradable(d directive) (*upgrade, error) {
+	resetFlags()
+	defer resetFlags()
+	if err := gen.ParseFlags(flags, d.args); err != nil {
+		return nil, err
+	}
+	switch {
+	case *generic:
+		return nil, errors.New("already generic")
+	case *versioned || *style != "func" || *slab || *pad || *testsFile != "" || *propsFile != "" || *configFile != "":
+		return nil, errors.New("-generic can not be used with -versioned, -style=value, -slab, -pad, -tests, -properties or -config")
+	case out.File == "":
+		return nil, errors.New("no -out")
+	case flags.NArg() == 0 || flags.NArg()%2 != 0:
+		// Without arguments, the builtin types are generated.
+		return nil, errors.New("no types given")
+	}
+
+	up := &upgrade{directive: d, out: out.File, types: make(map[string]string)}
+	var exported []string
+	for i := 0; i < flags.NArg(); i += 2 {
+		name := flags.Arg(i)
+		up.types[name] = flags.Arg(i + 1)
+		if ast.IsExported(name) {
+			exported = append(exported, name)
+		}
+	}
+	if len(exported) > 0 {
+		return nil, fmt.Errorf("the exported constructors %s may be used by other packages", strings.Join(exported, ", "))
+	}
+	up.newArgs = append(up.newArgs, d.args[:len(d.args)-flags.NArg()]...)
+	up.newArgs = append(up.newArgs, "-generic", *upgradeName)
+	return up, nil
+}
+
+// isGenerated returns whether the file name is generated by one of ups.
+func isGenerated(name, dir string, ups []*upgrade) bool {
+	for _, up := range ups {
+		if name == filepath.Join(dir, up.out) {
+			return true
+		}
+	}
+	return false
+}
+
+// regenerate runs the upgraded directives ups in dir.
+func regenerate(dir string, ups []*upgrade) error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if err := os.Chdir(dir); err != nil {
+		return err
+	}
+	defer os.Chdir(wd)
+	for _, up := range ups {
+		resetFlags()
+		if err := Run(up.newArgs); err != nil {
+			return fmt.Errorf("%s: %v", up.out, err)
+		}
+	}
+	return nil
+}
+
+// resetFlags resets the flags of go-lazy to their defaults, to parse them
+// again.
+func resetFlags() {
+	flags.VisitAll(func(f *flag.Flag) {
+		f.Value.Set(f.DefValue)
+	})
+}
@@ -0,0 +1,19 @@
+package lazy
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUpgrade(t *testing.T) {
+	dir := testPackage(t, "upgrade")
+	generate(t, dir)
+	goLazy(t, dir, "upgrade", "-w")
+	checkGolden(t, dir, "config.go", "upgrade/config.go.golden")
+
+	out := goLazy(t, dir, "upgrade", "-w")
+	if !strings.Contains(out, "already generic") {
+		t.Errorf("go-lazy upgrade upgraded the upgraded package again:\n%s", out)
+	}
+	checkGolden(t, dir, "config.go", "upgrade/config.go.golden")
+}
@@ -0,0 +1,249 @@
+package memoize
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"merovius.de/go-misc/internal/gen"
+)
+
+var benchTemplate = template.Must(template.New("bench_test.go").Parse(`
+package memobench
+
+import "testing"
+
+var sink int
+
+// keys is the number of distinct arguments the hit benchmarks cycle through.
+const keys = 1024
+
+func square(x int) int { return x * x }
+
+// BenchmarkHit measures calls with cached arguments.
+func BenchmarkHit(b *testing.B) {
+{{- range . }}
+	b.Run("{{ . }}", func(b *testing.B) {
+		f := {{ $.Func . }}(square)
+		for i := 0; i < keys; i++ {
+			f(i)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			sink = f(i % keys)
+		}
+	})
+{{- end }}
+}
+
+// BenchmarkHitParallel measures calls with cached arguments from many
+// goroutines, i.e. a read-mostly workload.
+func BenchmarkHitParallel(b *testing.B) {
+{{- range . }}
+	b.Run("{{ . }}", func(b *testing.B) {
+		f := {{ $.Func . }}(square)
+		for i := 0; i < keys; i++ {
+			f(i)
+		}
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			i := 0
+			for pb.Next() {
+				f(i % keys)
+				i++
+			}
+		})
+	})
+{{- end }}
+}
+
+// BenchmarkMiss measures calls with new arguments.
+func BenchmarkMiss(b *testing.B) {
+{{- range . }}
+	b.Run("{{ . }}", func(b *testing.B) {
+		f := {{ $.Func . }}(square)
+		for i := 0; i < b.N; i++ {
+			sink = f(i)
+		}
+	})
+{{- end }}
+}
+`))
+
+// backends are the values of -backend, in the order they are reported.
+var backends = []string{"mutex", "syncmap"}
+
+// benchBackends is the data of benchTemplate.
+type benchBackends []string
+
+// Func returns the name of the generated function for backend.
+func (benchBackends) Func(backend string) string {
+	return gen.Exported(backend)
+}
+
+// benchmarks are the benchmarks in benchTemplate, in the order they are
+// reported.
+var benchmarks = []string{"Hit", "HitParallel", "Miss"}
+
+// result is the result of a single benchmark run.
+type result struct {
+	ns     float64
+	bytes  string
+	allocs string
+}
+
+var (
+	benchFlags = flag.NewFlagSet("go-memoize bench", flag.ContinueOnError)
+	benchTime  = benchFlags.String("benchtime", "1s", "Run time of each benchmark, as accepted by go test")
+	benchCPU   = benchFlags.String("cpu", "", "Comma-separated list of GOMAXPROCS values, as accepted by go test")
+	keep       = benchFlags.Bool("keep", false, "Keep the generated benchmark package and print its location")
+)
+
+// runBench runs the bench subcommand.
+func runBench(args []string) error {
+	if err := gen.ParseFlags(benchFlags, args); err != nil {
+		return err
+	}
+	if benchFlags.NArg() != 0 {
+		return errors.New("Usage: go-memoize bench [-benchtime=<d>] [-cpu=<n>[,<n>...]] [-keep]")
+	}
+
+	dir, err := ioutil.TempDir("", "go-memoize-bench")
+	if err != nil {
+		return err
+	}
+	if *keep {
+		fmt.Fprintln(os.Stderr, "benchmark package in", dir)
+	} else {
+		defer os.RemoveAll(dir)
+	}
+
+	if err := writeBench(dir); err != nil {
+		return err
+	}
+
+	cmdArgs := []string{"test", "-run", "^$", "-bench", ".", "-benchmem", "-benchtime", *benchTime}
+	if *benchCPU != "" {
+		cmdArgs = append(cmdArgs, "-cpu", *benchCPU)
+	}
+	cmd := exec.Command("go", cmdArgs...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=")
+	cmd.Stderr = os.Stderr
+	output, err := cmd.Output()
+	if err != nil {
+		os.Stderr.Write(output)
+		return fmt.Errorf("running benchmarks: %v", err)
+	}
+
+	results, err := parseBench(output)
+	if err != nil {
+		return err
+	}
+	return report(results)
+}
+
+// writeBench writes a package benchmarking all backends to dir.
+func writeBench(dir string) error {
+	mod := []byte("module memobench\n")
+	if err := ioutil.WriteFile(filepath.Join(dir, "go.mod"), mod, 0666); err != nil {
+		return err
+	}
+	for _, backend := range backends {
+		f, err := parseFunc(benchBackends(nil).Func(backend), "func(int) int")
+		if err != nil {
+			return err
+		}
+		f.SyncMap = backend == "syncmap"
+		p := pkg{Package: "memobench", Funcs: []*fun{f}}
+		o := &gen.Output{File: filepath.Join(dir, backend+".go")}
+		if err := o.Write(implTemplate, p); err != nil {
+			return err
+		}
+	}
+	o := &gen.Output{File: filepath.Join(dir, "bench_test.go")}
+	return o.Write(benchTemplate, benchBackends(backends))
+}
+
+// parseBench parses the output of go test -benchmem into results, keyed by
+// benchmark, backend and GOMAXPROCS suffix, e.g. "HitParallel/syncmap-8".
+func parseBench(output []byte) (map[string]result, error) {
+	results := make(map[string]result)
+	s := bufio.NewScanner(bytes.NewReader(output))
+	for s.Scan() {
+		f := strings.Fields(s.Text())
+		if len(f) < 8 || !strings.HasPrefix(f[0], "Benchmark") || f[3] != "ns/op" {
+			continue
+		}
+		ns, err := strconv.ParseFloat(f[2], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid benchmark output %q", s.Text())
+		}
+		results[strings.TrimPrefix(f[0], "Benchmark")] = result{ns, f[4], f[6]}
+	}
+	if len(results) == 0 {
+		return nil, errors.New("no benchmark results found")
+	}
+	return results, s.Err()
+}
+
+// report prints a table of results and recommends the backend with the fastest
+// parallel hits at the highest GOMAXPROCS. Workloads with mostly new arguments
+// should look at Miss instead.
+func report(results map[string]result) error {
+	// Benchmark names have a -<GOMAXPROCS> suffix, unless it is 1.
+	var procs []int
+	for k := range results {
+		if !strings.HasPrefix(k, "Hit/"+backends[0]) {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimPrefix(k, "Hit/"+backends[0]+"-"))
+		if err != nil {
+			n = 1
+		}
+		procs = append(procs, n)
+	}
+	sort.Ints(procs)
+	suffix := func(n int) string {
+		if n == 1 {
+			return ""
+		}
+		return "-" + strconv.Itoa(n)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "backend\tGOMAXPROCS\tHit\tHitParallel\tMiss\tMiss B/op\tMiss allocs/op\t")
+	for _, backend := range backends {
+		for _, n := range procs {
+			fmt.Fprintf(w, "%s\t%d\t", backend, n)
+			for _, b := range benchmarks {
+				fmt.Fprintf(w, "%.2f ns\t", results[b+"/"+backend+suffix(n)].ns)
+			}
+			miss := results["Miss/"+backend+suffix(n)]
+			fmt.Fprintf(w, "%s\t%s\t\n", miss.bytes, miss.allocs)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	best, max := backends[0], suffix(procs[len(procs)-1])
+	for _, backend := range backends[1:] {
+		if results["HitParallel/"+backend+max].ns < results["HitParallel/"+best+max].ns {
+			best = backend
+		}
+	}
+	fmt.Printf("\nRecommendation: -backend=%s (fastest parallel hits on this machine)\n", best)
+	return nil
+}
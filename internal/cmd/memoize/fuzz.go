@@ -0,0 +1,141 @@
+package memoize
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+var fuzzTemplate = template.Must(template.New("memoize_test.go").Parse(`
+// This file is automatically generated by merovius.de/go-misc/cmd/go-memoize.
+
+package {{ .Package }}
+
+import (
+	"reflect"
+	"testing"
+)
+
+{{ range $f := .Funcs }}
+// Fuzz{{ .Name }} checks that the results of {{ .Name }}({{ .Ref }}) always equal
+// those of calling {{ .Ref }} directly. As the memoized function is shared by all
+// inputs, this detects results cached for the wrong arguments or returned
+// after being evicted.
+func Fuzz{{ .Name }}(f *testing.F) {
+	m := {{ .Name }}({{ .Ref }})
+	f.Fuzz(func(t *testing.T, {{ .ParamList }}) {
+		{{ .Got }} := m({{ .ArgNames }})
+		{{ .Want }} := {{ .Ref }}({{ .ArgNames }})
+{{- range $i, $_ := .Results }}
+		if !reflect.DeepEqual(got{{ $i }}, want{{ $i }}) {
+			t.Errorf("{{ $f.Name }}({{ $f.Ref }})({{ $f.Verbs }}) returned %v as result {{ $i }}, want %v", {{ $f.ArgNames }}, got{{ $i }}, want{{ $i }})
+		}
+{{- end }}
+	})
+}
+{{ end }}
+`))
+
+// fuzzPkg is the data of fuzzTemplate.
+type fuzzPkg struct {
+	Package string
+	Funcs   []fuzzFunc
+}
+
+// fuzzFunc is a memoized function to generate a fuzz target for.
+type fuzzFunc struct {
+	*fun
+	// Ref is the name of the function it is compared to.
+	Ref string
+}
+
+// ParamList returns the parameters of the fuzzed function.
+func (f fuzzFunc) ParamList() string {
+	var l []string
+	for _, p := range f.Params {
+		l = append(l, p.Name+" "+p.Type)
+	}
+	return strings.Join(l, ", ")
+}
+
+// Got returns the variables of the results of the memoized function.
+func (f fuzzFunc) Got() string {
+	return f.vars("got")
+}
+
+// Want returns the variables of the results of the reference function.
+func (f fuzzFunc) Want() string {
+	return f.vars("want")
+}
+
+func (f fuzzFunc) vars(prefix string) string {
+	var l []string
+	for i := range f.Results {
+		l = append(l, fmt.Sprintf("%s%d", prefix, i))
+	}
+	return strings.Join(l, ", ")
+}
+
+// Verbs returns format verbs for the arguments of f.
+func (f fuzzFunc) Verbs() string {
+	var l []string
+	for _, p := range f.Params {
+		if p.Type == "string" {
+			l = append(l, "%q")
+		} else {
+			l = append(l, "%v")
+		}
+	}
+	return strings.Join(l, ", ")
+}
+
+// fuzzable contains the types that can be arguments of fuzz targets and are
+// comparable.
+var fuzzable = map[string]bool{
+	"string":  true,
+	"bool":    true,
+	"byte":    true,
+	"rune":    true,
+	"int":     true,
+	"int8":    true,
+	"int16":   true,
+	"int32":   true,
+	"int64":   true,
+	"uint":    true,
+	"uint8":   true,
+	"uint16":  true,
+	"uint32":  true,
+	"uint64":  true,
+	"float32": true,
+	"float64": true,
+}
+
+// parseFuzzFuncs parses a comma-separated list of <name>=<func> pairs and
+// returns the fuzzFuncs for the corresponding functions in fs.
+func parseFuzzFuncs(s string, fs []*fun) ([]fuzzFunc, error) {
+	byName := make(map[string]*fun)
+	for _, f := range fs {
+		byName[f.Name] = f
+	}
+	var ffs []fuzzFunc
+	for _, pair := range strings.Split(s, ",") {
+		i := strings.Index(pair, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid fuzz function %q, want <name>=<func>", pair)
+		}
+		f := byName[pair[:i]]
+		if f == nil {
+			return nil, fmt.Errorf("no memoized function %s", pair[:i])
+		}
+		if len(f.Params) == 0 {
+			return nil, fmt.Errorf("%s has no arguments to fuzz", f.Name)
+		}
+		for _, p := range f.Params {
+			if !fuzzable[p.Type] {
+				return nil, fmt.Errorf("argument type %s of %s is not supported by fuzzing", p.Type, f.Name)
+			}
+		}
+		ffs = append(ffs, fuzzFunc{f, pair[i+1:]})
+	}
+	return ffs, nil
+}
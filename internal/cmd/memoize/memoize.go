@@ -0,0 +1,436 @@
+// Package memoize implements go-memoize. See merovius.de/go-misc/cmd/go-memoize for its
+// documentation.
+package memoize // import "merovius.de/go-misc/internal/cmd/memoize"
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"merovius.de/go-misc/internal/gen"
+)
+
+var implTemplate = template.Must(template.New("memoize.go").Parse(`
+// This file is automatically generated by merovius.de/go-misc/cmd/go-memoize.
+
+package {{ .Package }}
+
+import (
+{{- if .Max }}
+	"container/list"
+{{- end }}
+{{- if .Context }}
+	"context"
+{{- end }}
+	"sync"
+{{- if .Detached }}
+	"time"
+{{- end }}
+)
+{{- if .Detached }}
+
+// memoDetached is a context with the values of its parent, which is never
+// canceled.
+type memoDetached struct {
+	context.Context
+}
+
+func (memoDetached) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (memoDetached) Done() <-chan struct{}       { return nil }
+func (memoDetached) Err() error                  { return nil }
+{{- end }}
+
+{{ range .Funcs }}
+	{{ template "impl" . }}
+{{ end }}
+`))
+
+var _ = template.Must(implTemplate.New("ctx").Parse(`
+{{- if .Timeout -}}
+	context.WithTimeout(memoDetached{a0}, {{ .Timeout }})
+{{- else -}}
+	context.WithCancel(memoDetached{a0})
+{{- end -}}
+`))
+
+var _ = template.Must(implTemplate.New("impl").Parse(`
+// memo{{ .Name }} implements memoization for {{ .Func }}.
+type memo{{ .Name }} struct {
+	f {{ .Func }}
+{{- if .SyncMap }}
+	c sync.Map
+{{- else }}
+	m sync.Mutex
+	c map[{{ .Key }}]*memo{{ .Name }}Entry
+{{- end }}
+{{- if .Max }}
+	l *list.List
+{{- end }}
+}
+
+{{ if gt (len .KeyParams) 1 -}}
+// memo{{ .Name }}Key is the cache key for {{ .Name }}.
+type memo{{ .Name }}Key struct {
+{{- range .KeyParams }}
+	{{ .Name }} {{ .Type }}
+{{- end }}
+}
+{{- end }}
+
+// memo{{ .Name }}Entry is a cached result of {{ .Name }}.
+type memo{{ .Name }}Entry struct {
+{{- if eq .Ctx "merged" }}
+	// done is closed after the evaluation. n is the number of callers
+	// waiting for it and cancel cancels it.
+	done   chan struct{}
+	n      int
+	cancel context.CancelFunc
+{{- else }}
+	o sync.Once
+{{- end }}
+{{- if .Max }}
+	e *list.Element
+{{- end }}
+{{- range .Results }}
+	{{ .Name }} {{ .Type }}
+{{- end }}
+}
+
+func (m *memo{{ .Name }}) Call({{ range $i, $a := .Params }}{{ if $i }}, {{ end }}{{ $a.Name }} {{ $a.Type }}{{ end }}) {{ .ResultList }} {
+{{- if gt (len .KeyParams) 1 }}
+	k := memo{{ .Name }}Key{ {{- .KeyArgNames -}} }
+{{- else if .KeyParams }}
+	k := {{ .KeyArgNames }}
+{{- else }}
+	k := struct{}{}
+{{- end }}
+{{ if .SyncMap }}
+	v, ok := m.c.Load(k)
+	if !ok {
+		v, _ = m.c.LoadOrStore(k, new(memo{{ .Name }}Entry))
+	}
+	e := v.(*memo{{ .Name }}Entry)
+{{- else }}
+	m.m.Lock()
+	e := m.c[k]
+	if e == nil {
+		e = new(memo{{ .Name }}Entry)
+		m.c[k] = e
+{{- if .Max }}
+		e.e = m.l.PushFront(k)
+		if m.l.Len() > {{ .Max }} {
+			delete(m.c, m.l.Remove(m.l.Back()).({{ .Key }}))
+		}
+{{- end }}
+{{- if eq .Ctx "merged" }}
+		e.done = make(chan struct{})
+		ctx, cancel := {{ template "ctx" . }}
+		e.cancel = cancel
+		go func() {
+			defer close(e.done)
+			defer cancel()
+			{{ .RetNames }} = m.f({{ .CallArgs }})
+		}()
+{{- end }}
+	}
+{{- if and .Max .LRU }} else {
+		m.l.MoveToFront(e.e)
+	}
+{{- end }}
+{{- if eq .Ctx "merged" }}
+	e.n++
+{{- end }}
+	m.m.Unlock()
+{{- end }}
+{{ if eq .Ctx "merged" }}
+	select {
+	case <-e.done:
+		return {{ .RetNames }}
+	case <-a0.Done():
+	}
+	// The evaluation is canceled, once all callers waiting for it gave up.
+	m.m.Lock()
+	if e.n--; e.n == 0 {
+		e.cancel()
+		if m.c[k] == e {
+			delete(m.c, k)
+{{- if .Max }}
+			m.l.Remove(e.e)
+{{- end }}
+		}
+	}
+	m.m.Unlock()
+	{{ if gt (len .Results) 1 -}}
+	var z memo{{ .Name }}Entry
+	{{ end -}}
+	return {{ .ZeroRets }}
+{{- else }}
+	e.o.Do(func() {
+{{- if eq .Ctx "detached" }}
+		ctx, cancel := {{ template "ctx" . }}
+		defer cancel()
+{{- end }}
+		{{ .RetNames }} = m.f({{ .CallArgs }})
+	})
+	return {{ .RetNames }}
+{{- end }}
+}
+
+// {{ .Name }} provides memoization for {{ .Func }}. f is called at most
+// once for every distinct set of arguments{{ if .Max }}, as long as its
+// result is not evicted{{ end }}.
+{{- if eq .Ctx "first" }}
+//
+// The context is not part of the arguments. f is called with the context of
+// the first caller, so its cancellation also affects the others.
+{{- else if eq .Ctx "detached" }}
+//
+// The context is not part of the arguments. f is called with a context
+// carrying the values of the context of the first caller, which is not
+// canceled with it{{ if .Timeout }}, but after {{ .Timeout }}{{ end }}.
+{{- else if eq .Ctx "merged" }}
+//
+// The context is not part of the arguments. f is called in a new goroutine
+// with a context carrying the values of the context of the first caller,
+// which is canceled once the contexts of all callers waiting for the result
+// are done{{ if .Timeout }}, or after {{ .Timeout }}{{ end }}. A caller giving up returns the
+// error of its context.
+{{- end }}
+func {{ .Name }}(f {{ .Func }}) {{ .Func }} {
+	return (&memo{{ .Name }}{
+		f: f,
+{{- if not .SyncMap }}
+		c: make(map[{{ .Key }}]*memo{{ .Name }}Entry),
+{{- end }}
+{{- if .Max }}
+		l: list.New(),
+{{- end }}
+	}).Call
+}
+`))
+
+type pkg struct {
+	Package string
+	Max     int
+	Funcs   []*fun
+}
+
+// Context returns whether the generated code uses package context itself.
+func (p pkg) Context() bool {
+	for _, f := range p.Funcs {
+		if f.Ctx != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Detached returns whether the generated code needs memoDetached.
+func (p pkg) Detached() bool {
+	for _, f := range p.Funcs {
+		if f.Ctx == "detached" || f.Ctx == "merged" {
+			return true
+		}
+	}
+	return false
+}
+
+type fun struct {
+	*gen.Func
+	Name string
+
+	// Max is the maximum number of cached results and LRU says whether to
+	// evict by least recent use, instead of by age.
+	Max int
+	LRU bool
+
+	// Ctx is the policy for the context of shared evaluations, if the first
+	// argument of f is a context.Context, and Timeout the timeout of
+	// detached and merged contexts, if any.
+	Ctx     string
+	Timeout string
+
+	// SyncMap says whether results are cached in a sync.Map, instead of a
+	// map guarded by a mutex.
+	SyncMap bool
+}
+
+// KeyParams returns the parameters of f that are part of the cache key. The
+// context is not, if f has a context policy.
+func (f *fun) KeyParams() []gen.Field {
+	if f.Ctx != "" {
+		return f.Params[1:]
+	}
+	return f.Params
+}
+
+// Key returns the type used to key cached results of f.
+func (f *fun) Key() string {
+	switch ps := f.KeyParams(); len(ps) {
+	case 0:
+		return "struct{}"
+	case 1:
+		return ps[0].Type
+	default:
+		return "memo" + f.Name + "Key"
+	}
+}
+
+// KeyArgNames returns the names of the arguments of f that are part of the
+// cache key, separated by commas.
+func (f *fun) KeyArgNames() string {
+	return gen.JoinNames("", f.KeyParams())
+}
+
+// CallArgs returns the arguments f is called with, separated by commas.
+func (f *fun) CallArgs() string {
+	switch {
+	case f.Ctx == "" || f.Ctx == "first":
+		return f.ArgNames()
+	case len(f.Params) == 1:
+		return "ctx"
+	default:
+		return "ctx, " + gen.JoinNames("", f.Params[1:])
+	}
+}
+
+// ZeroRets returns the results returned by callers giving up on a merged
+// evaluation: the zero values of the entry z and the error of the context.
+func (f *fun) ZeroRets() string {
+	if len(f.Results) == 1 {
+		return "a0.Err()"
+	}
+	return gen.JoinNames("z.", f.Results[:len(f.Results)-1]) + ", a0.Err()"
+}
+
+// ArgNames returns the names of the arguments of f, separated by commas.
+func (f *fun) ArgNames() string {
+	return gen.JoinNames("", f.Params)
+}
+
+// RetNames returns the names of the result fields of f in its cache entry,
+// separated by commas.
+func (f *fun) RetNames() string {
+	return gen.JoinNames("e.", f.Results)
+}
+
+// parseFunc parses a function signature into a fun.
+func parseFunc(name, sig string) (*fun, error) {
+	f, err := gen.ParseFunc(sig)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	if f.Variadic {
+		return nil, fmt.Errorf("can not memoize variadic function %s", name)
+	}
+	for _, a := range f.Params {
+		if strings.HasPrefix(a.Type, "[]") || strings.HasPrefix(a.Type, "map[") || strings.HasPrefix(a.Type, "func(") {
+			return nil, fmt.Errorf("argument type %s of %s is not comparable", a.Type, name)
+		}
+	}
+	if len(f.Results) == 0 {
+		return nil, fmt.Errorf("can not memoize %s, as it does not return anything", name)
+	}
+	return &fun{Func: f, Name: name}, nil
+}
+
+var (
+	flags       = flag.NewFlagSet("go-memoize", flag.ContinueOnError)
+	pkgName     = flags.String("package", os.Getenv("GOPACKAGE"), "Package the file should be in")
+	out         = gen.OutputFlags(flags)
+	maxRes      = flags.Int("max", 0, "Maximum number of cached results per function (0 means unbounded)")
+	policy      = flags.String("policy", "lru", `Eviction policy, "lru" or "fifo"`)
+	fuzz        = flags.String("fuzz", "", "Output file for fuzz targets, e.g. memoize_fuzz_test.go")
+	allowUnsafe = flags.Bool("allow-unsafe", false, "Allow caching values of unsafe.Pointer and cgo types")
+	ctxPolicy   = flags.String("ctx", "", `Context of shared evaluations of functions taking a context.Context, "first", "detached" or "merged"`)
+	ctxTimeout  = flags.Duration("ctx-timeout", 0, "Timeout of detached and merged contexts (0 means none)")
+	backend     = flags.String("backend", "mutex", `Cache of the results, "mutex" or "syncmap"`)
+	fuzzFns     = flags.String("fuzz-funcs", "", "Comma-separated list of <name>=<func> pairs, giving the function to compare each memoized function to in fuzz targets")
+)
+
+// Run runs go-memoize with the command line arguments args, which do not include
+// the program name.
+func Run(args []string) error {
+	if len(args) > 0 && args[0] == "bench" {
+		return runBench(args[1:])
+	}
+	if err := gen.ParseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 || flags.NArg()%2 != 0 {
+		return errors.New("Usage: go-memoize [-package=<pkg>] [-max=<n>] [-policy=<policy>] [-ctx=<policy> [-ctx-timeout=<d>]] [-backend=<backend>] [-allow-unsafe] [-fuzz=<file> -fuzz-funcs=<name>=<func>[,...]] <name> <signature> [<name> <signature>]...")
+	}
+	if *pkgName == "" {
+		return errors.New("no package given")
+	}
+	if *maxRes < 0 {
+		return errors.New("-max must not be negative")
+	}
+	if *policy != "lru" && *policy != "fifo" {
+		return fmt.Errorf("unknown eviction policy %q", *policy)
+	}
+	switch *ctxPolicy {
+	case "", "first", "detached", "merged":
+	default:
+		return fmt.Errorf("unknown context policy %q", *ctxPolicy)
+	}
+	if *ctxTimeout < 0 {
+		return errors.New("-ctx-timeout must not be negative")
+	}
+	if *ctxTimeout != 0 && *ctxPolicy != "detached" && *ctxPolicy != "merged" {
+		return errors.New("-ctx-timeout requires -ctx=detached or -ctx=merged")
+	}
+	switch *backend {
+	case "mutex":
+	case "syncmap":
+		if *maxRes != 0 || *ctxPolicy == "merged" {
+			return errors.New("-backend=syncmap can not be used with -max or -ctx=merged")
+		}
+	default:
+		return fmt.Errorf("unknown backend %q", *backend)
+	}
+	if (*fuzz == "") != (*fuzzFns == "") {
+		return errors.New("-fuzz and -fuzz-funcs must be given together")
+	}
+
+	p := pkg{Package: *pkgName, Max: *maxRes}
+	for i := 0; i < flags.NArg(); i += 2 {
+		f, err := parseFunc(flags.Arg(i), flags.Arg(i+1))
+		if err != nil {
+			return err
+		}
+		if !*allowUnsafe {
+			if err := gen.CheckUnsafe(flags.Arg(i + 1)); err != nil {
+				return err
+			}
+		}
+		f.Max, f.LRU = *maxRes, *policy == "lru"
+		f.SyncMap = *backend == "syncmap"
+		if len(f.Params) > 0 && f.Params[0].Type == "context.Context" && *ctxPolicy != "" {
+			f.Ctx = *ctxPolicy
+			if *ctxTimeout != 0 {
+				f.Timeout = gen.DurationLiteral(*ctxTimeout)
+			}
+			if f.Ctx == "merged" && f.Results[len(f.Results)-1].Type != "error" {
+				return fmt.Errorf("%s must return an error to be used with -ctx=merged", f.Name)
+			}
+		}
+		p.Funcs = append(p.Funcs, f)
+	}
+
+	if err := out.Write(implTemplate, p); err != nil {
+		return err
+	}
+	if *fuzz == "" {
+		return nil
+	}
+	ffs, err := parseFuzzFuncs(*fuzzFns, p.Funcs)
+	if err != nil {
+		return err
+	}
+	o := &gen.Output{File: *fuzz, Check: out.Check}
+	return o.Write(fuzzTemplate, fuzzPkg{Package: p.Package, Funcs: ffs})
+}
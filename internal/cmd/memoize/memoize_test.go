@@ -0,0 +1,119 @@
+package memoize
+
+import (
+	"testing"
+
+	"merovius.de/go-misc/internal/gen/gentest"
+)
+
+func TestMain(m *testing.M) {
+	gentest.Main(m, Run)
+}
+
+// funcs are the memoized functions of the tests. The generated code only
+// imports context for -ctx, so fetch is only used with it.
+var (
+	funcs = []string{
+		"Lookup", "func(host string, port int) ([]string, error)",
+		"Square", "func(int) int",
+	}
+	fetch = []string{"Fetch", "func(ctx context.Context, key string) (string, error)"}
+)
+
+func TestGolden(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		args  []string
+		funcs []string
+	}{
+		{"default", nil, nil},
+		{"lru", []string{"-max=2"}, nil},
+		{"fifo", []string{"-max=2", "-policy=fifo"}, nil},
+		{"syncmap", []string{"-backend=syncmap"}, nil},
+		{"first", []string{"-ctx=first"}, fetch},
+		{"detached", []string{"-ctx=detached", "-ctx-timeout=1s"}, fetch},
+		{"merged", []string{"-ctx=merged", "-ctx-timeout=1s"}, fetch},
+		{"fuzz", []string{"-fuzz=memo_fuzz_test.go", "-fuzz-funcs=Square=square"}, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := gentest.Dir(t, map[string]string{
+				"square.go": "package memo\n\nfunc square(i int) int { return i * i }\n",
+			})
+			args := append([]string{"-package=memo", "-out=memo.go"}, tc.args...)
+			args = append(args, funcs...)
+			gentest.Generate(t, dir, append(args, tc.funcs...)...)
+			gentest.Golden(t, dir, "memo.go", tc.name+".go.golden")
+			if tc.name == "fuzz" {
+				gentest.Golden(t, dir, "memo_fuzz_test.go", "fuzz_test.go.golden")
+			}
+			gentest.Vet(t, dir)
+		})
+	}
+}
+
+func TestMerged(t *testing.T) {
+	dir := gentest.Dir(t, map[string]string{"merged_test.go": `package memo
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMerged(t *testing.T) {
+	started, calls := make(chan context.Context), 0
+	m := &memoFetch{
+		f: func(ctx context.Context, key string) (string, error) {
+			calls++
+			started <- ctx
+			<-ctx.Done()
+			return "", ctx.Err()
+		},
+		c: make(map[string]*memoFetchEntry),
+	}
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	errs := make(chan error)
+	go func() {
+		_, err := m.Call(ctx1, "a")
+		errs <- err
+	}()
+	evalCtx := <-started
+	go func() {
+		_, err := m.Call(ctx2, "a")
+		errs <- err
+	}()
+	// Wait for the second caller to join the evaluation. Its context is
+	// not canceled, so it keeps waiting until cancel2 is called.
+	for n := 0; n != 2; {
+		m.m.Lock()
+		n = m.c["a"].n
+		m.m.Unlock()
+	}
+
+	cancel1()
+	if err := <-errs; err != context.Canceled {
+		t.Fatalf("first caller returned %v, want %v", err, context.Canceled)
+	}
+	if evalCtx.Err() != nil {
+		t.Fatalf("evaluation canceled while the second caller waits")
+	}
+	cancel2()
+	if err := <-errs; err != context.Canceled {
+		t.Fatalf("second caller returned %v, want %v", err, context.Canceled)
+	}
+	<-evalCtx.Done()
+
+	// The canceled evaluation is not cached.
+	ctx3, cancel3 := context.WithCancel(context.Background())
+	cancel3()
+	m.Call(ctx3, "a")
+	<-started
+	if calls != 2 {
+		t.Errorf("f called %d times after the evaluation was canceled, want 2", calls)
+	}
+}
+`})
+	gentest.Generate(t, dir, "-package=memo", "-out=memo.go", "-ctx=merged", fetch[0], fetch[1])
+	gentest.Test(t, dir, "-race")
+}
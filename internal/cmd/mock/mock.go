@@ -0,0 +1,150 @@
+// Package mock implements go-mock. See merovius.de/go-misc/cmd/go-mock for its
+// documentation.
+package mock // import "merovius.de/go-misc/internal/cmd/mock"
+
+import (
+	"errors"
+	"flag"
+	"go/types"
+	"strings"
+	"text/template"
+
+	"merovius.de/go-misc/internal/gen"
+)
+
+var implTemplate = template.Must(template.New("mock.go").Parse(`
+// This file is automatically generated by merovius.de/go-misc/cmd/go-mock.
+
+package {{ .Package }}
+
+{{ if .Imports -}}
+import (
+{{- range .Imports }}
+	{{ . }}
+{{- end }}
+)
+{{- end }}
+
+{{ range .Types }}
+	{{ template "impl" . }}
+{{ end }}
+`))
+
+var _ = template.Must(implTemplate.New("impl").Parse(`
+// {{ .Name }}Mock is a mock implementation of {{ .Type }}. Every method calls
+// the field of the same name with the suffix Func, which must not be nil.
+type {{ .Name }}Mock struct {
+{{- range .Methods }}
+	{{ .Name }}Func {{ .Func }}
+{{- end }}
+}
+
+var _ {{ .Type }} = (*{{ .Name }}Mock)(nil)
+{{ range .Methods }}
+// {{ .Name }} calls {{ .Name }}Func.
+func (m *{{ $.Name }}Mock) {{ .Name }}({{ .ParamDecl }}) {{ .ResultList }} {
+	if m.{{ .Name }}Func == nil {
+		panic("{{ $.Name }}Mock.{{ .Name }} called, but {{ .Name }}Func is nil")
+	}
+	{{ if .Results }}return {{ end }}m.{{ .Name }}Func({{ .Args }})
+}
+{{ end }}
+`))
+
+type pkg struct {
+	Package string
+	Imports []string
+	Types   []mock
+}
+
+type mock struct {
+	Name    string
+	Type    string
+	Methods []method
+}
+
+type method struct {
+	*gen.Func
+	Name string
+}
+
+// ResultList returns the results of m, as used in a signature.
+func (m method) ResultList() string {
+	if len(m.Results) == 0 {
+		return ""
+	}
+	return m.Func.ResultList()
+}
+
+// interfaces returns the names of all exported interface types declared in p.
+func interfaces(p *gen.Package) []string {
+	var names []string
+	for _, name := range p.Types.Scope().Names() {
+		tn, ok := p.Types.Scope().Lookup(name).(*types.TypeName)
+		if !ok || !tn.Exported() || tn.IsAlias() {
+			continue
+		}
+		if it, ok := tn.Type().Underlying().(*types.Interface); ok && it.NumMethods() > 0 {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+var (
+	flags     = flag.NewFlagSet("go-mock", flag.ContinueOnError)
+	typeNames = flags.String("type", "", "Comma-separated list of interface type names (defaults to all)")
+	pkgName   = flags.String("package", "", "Package the file should be in (defaults to the package in dir)")
+	out       = gen.OutputFlags(flags)
+)
+
+// Run runs go-mock with the command line arguments args, which do not include
+// the program name.
+func Run(args []string) error {
+	if err := gen.ParseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		return errors.New("Usage: go-mock [-type=<type>[,<type>...]] [-package=<pkg>] [<dir>]")
+	}
+	dir := "."
+	if flags.NArg() == 1 {
+		dir = flags.Arg(0)
+	}
+
+	lp, err := gen.LoadPackage(dir)
+	if err != nil {
+		return err
+	}
+
+	dst := lp.Types
+	if *pkgName != "" && *pkgName != lp.Types.Name() {
+		dst = types.NewPackage(lp.Types.Path()+"_mock", *pkgName)
+	}
+	im := gen.NewImports(dst)
+
+	names := interfaces(lp)
+	if *typeNames != "" {
+		names = strings.Split(*typeNames, ",")
+	}
+	if len(names) == 0 {
+		return errors.New("no interface types found")
+	}
+
+	p := pkg{Package: dst.Name()}
+	for _, name := range names {
+		n, it, err := lp.Interface(name)
+		if err != nil {
+			return err
+		}
+		m := mock{Name: name, Type: im.TypeString(n)}
+		for i := 0; i < it.NumMethods(); i++ {
+			fn := it.Method(i)
+			m.Methods = append(m.Methods, method{im.Func(fn.Type().(*types.Signature)), fn.Name()})
+		}
+		p.Types = append(p.Types, m)
+	}
+	p.Imports = im.List()
+
+	return out.Write(implTemplate, p)
+}
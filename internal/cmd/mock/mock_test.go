@@ -0,0 +1,95 @@
+package mock
+
+import (
+	"testing"
+
+	"merovius.de/go-misc/internal/gen/gentest"
+)
+
+func TestMain(m *testing.M) {
+	gentest.Main(m, Run)
+}
+
+// src declares the interfaces of the tests, with an embedded interface of
+// another package.
+const src = `package store
+
+import (
+	"context"
+	"io"
+)
+
+type Store interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Put(key string, v []byte) error
+	io.Closer
+}
+
+type Counter interface {
+	Inc()
+	Count() int
+}
+
+type empty interface{}
+`
+
+func TestGolden(t *testing.T) {
+	tcs := []struct {
+		name string
+		args []string
+	}{
+		{"default", nil},
+		{"type", []string{"-type=Counter"}},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := gentest.Dir(t, map[string]string{"store.go": src})
+			gentest.Generate(t, dir, append(tc.args, "-out=mock.go")...)
+			gentest.Golden(t, dir, "mock.go", tc.name+".go.golden")
+			gentest.Vet(t, dir)
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	dir := gentest.Dir(t, map[string]string{"store.go": "package store\n\ntype empty interface{}\n"})
+	gentest.Fail(t, dir, "-out=mock.go")
+	gentest.Fail(t, dir, "-type=Missing", "-out=mock.go")
+	gentest.Fail(t, dir, "-out=mock.go", ".", ".")
+}
+
+// TestPackage tests mocks in another package, which is the external test
+// package. The generated code imports the package by its path, which depends
+// on the temporary directory, so there is no golden file.
+func TestPackage(t *testing.T) {
+	dir := gentest.Dir(t, map[string]string{"store.go": src, "store_test.go": `package store_test
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMock(t *testing.T) {
+	var got string
+	m := &StoreMock{
+		GetFunc: func(ctx context.Context, key string) ([]byte, error) {
+			got = key
+			return []byte("v"), nil
+		},
+	}
+	if v, err := m.Get(context.Background(), "k"); string(v) != "v" || err != nil || got != "k" {
+		t.Errorf("Get() == %q, %v, called with %q", v, err, got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Close did not panic without CloseFunc")
+		}
+	}()
+	m.Close()
+}
+`})
+	gentest.Generate(t, dir, "-type=Store", "-package=store_test", "-out=mock_test.go")
+	gentest.Test(t, dir)
+	gentest.Vet(t, dir)
+}
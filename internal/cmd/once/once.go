@@ -0,0 +1,126 @@
+// Package once implements go-once. See merovius.de/go-misc/cmd/go-once for its
+// documentation.
+package once // import "merovius.de/go-misc/internal/cmd/once"
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/template"
+
+	"merovius.de/go-misc/internal/gen"
+)
+
+var implTemplate = template.Must(template.New("once.go").Parse(`
+// This file is automatically generated by merovius.de/go-misc/cmd/go-once.
+
+package {{ .Package }}
+
+import "sync"
+
+{{ range .Funcs }}
+	{{ template "impl" . }}
+{{ end }}
+`))
+
+var _ = template.Must(implTemplate.New("impl").Parse(`
+// {{ .Name }} returns a function that invokes f only once{{ if .Results }} and returns
+// the values returned by f{{ end }}. If f panics, the returned function will
+// panic with the same value on every call.
+func {{ .Name }}(f {{ .Func }}) {{ .Func }} {
+	var (
+		once  sync.Once
+		valid bool
+		p     interface{}
+{{- range .Results }}
+		{{ .Name }} {{ .Type }}
+{{- end }}
+	)
+	g := func() {
+		defer func() {
+			p = recover()
+			if !valid {
+				panic(p)
+			}
+		}()
+		{{ if .Results }}{{ .RetNames }} = {{ end }}f()
+		f = nil
+		valid = true
+	}
+	return func() {{ if .Results }}{{ .ResultList }}{{ end }} {
+		once.Do(g)
+		if !valid {
+			panic(p)
+		}
+{{- if .Results }}
+		return {{ .RetNames }}
+{{- end }}
+	}
+}
+`))
+
+type pkg struct {
+	Package string
+	Funcs   []*fun
+}
+
+type fun struct {
+	*gen.Func
+	Name string
+}
+
+// RetNames returns the names of the variables holding the results of f,
+// separated by commas.
+func (f *fun) RetNames() string {
+	return gen.JoinNames("", f.Results)
+}
+
+// parseFunc parses a function signature into a fun.
+func parseFunc(name, sig string) (*fun, error) {
+	f, err := gen.ParseFunc(sig)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	if len(f.Params) != 0 {
+		return nil, fmt.Errorf("signature for %s must not have parameters", name)
+	}
+	return &fun{Func: f, Name: name}, nil
+}
+
+var (
+	flags       = flag.NewFlagSet("go-once", flag.ContinueOnError)
+	pkgName     = flags.String("package", os.Getenv("GOPACKAGE"), "Package the file should be in")
+	out         = gen.OutputFlags(flags)
+	allowUnsafe = flags.Bool("allow-unsafe", false, "Allow caching values of unsafe.Pointer and cgo types")
+)
+
+// Run runs go-once with the command line arguments args, which do not include
+// the program name.
+func Run(args []string) error {
+	if err := gen.ParseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 || flags.NArg()%2 != 0 {
+		return errors.New("Usage: go-once [-package=<pkg>] [-allow-unsafe] <name> <signature> [<name> <signature>]...")
+	}
+	if *pkgName == "" {
+		return errors.New("no package given")
+	}
+
+	p := pkg{Package: *pkgName}
+	for i := 0; i < flags.NArg(); i += 2 {
+		f, err := parseFunc(flags.Arg(i), flags.Arg(i+1))
+		if err != nil {
+			return err
+		}
+		if !*allowUnsafe {
+			if err := gen.CheckUnsafe(flags.Arg(i + 1)); err != nil {
+				return err
+			}
+		}
+		p.Funcs = append(p.Funcs, f)
+	}
+
+	return out.Write(implTemplate, p)
+}
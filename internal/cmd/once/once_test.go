@@ -0,0 +1,86 @@
+package once
+
+import (
+	"testing"
+
+	"merovius.de/go-misc/internal/gen/gentest"
+)
+
+func TestMain(m *testing.M) {
+	gentest.Main(m, Run)
+}
+
+// funcs are the functions of the tests, without results, with one and with
+// several.
+var funcs = []string{
+	"Init", "func()",
+	"Config", "func() string",
+	"Open", "func() (int, error)",
+}
+
+func TestGolden(t *testing.T) {
+	dir := gentest.Dir(t, nil)
+	gentest.Generate(t, dir, append([]string{"-package=once", "-out=once.go"}, funcs...)...)
+	gentest.Golden(t, dir, "once.go", "once.go.golden")
+	gentest.Vet(t, dir)
+}
+
+func TestParams(t *testing.T) {
+	gentest.Fail(t, gentest.Dir(t, nil), "-package=once", "F", "func(int) int")
+}
+
+func TestOnce(t *testing.T) {
+	dir := gentest.Dir(t, map[string]string{"once_test.go": `package once
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestOnce(t *testing.T) {
+	calls := 0
+	open := Open(func() (int, error) {
+		calls++
+		return 42, errors.New("closed")
+	})
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if n, err := open(); n != 42 || err == nil {
+				t.Errorf("open() == %v, %v, want 42, closed", n, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if calls != 1 {
+		t.Errorf("f called %d times, want 1", calls)
+	}
+}
+
+func TestPanic(t *testing.T) {
+	calls := 0
+	cfg := Config(func() string {
+		calls++
+		panic("boom")
+	})
+	for i := 0; i < 2; i++ {
+		func() {
+			defer func() {
+				if r := recover(); r != "boom" {
+					t.Errorf("cfg() panicked with %v, want boom", r)
+				}
+			}()
+			cfg()
+		}()
+	}
+	if calls != 1 {
+		t.Errorf("f called %d times, want 1", calls)
+	}
+}
+`})
+	gentest.Generate(t, dir, append([]string{"-package=once", "-out=once.go"}, funcs...)...)
+	gentest.Test(t, dir, "-race")
+}
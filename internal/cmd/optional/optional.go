@@ -0,0 +1,105 @@
+// Package optional implements go-optional. See merovius.de/go-misc/cmd/go-optional for its
+// documentation.
+package optional // import "merovius.de/go-misc/internal/cmd/optional"
+
+import (
+	"errors"
+	"flag"
+	"text/template"
+
+	"merovius.de/go-misc/internal/gen"
+)
+
+var implTemplate = template.Must(template.New("optional.go").Parse(`
+// This file is automatically generated by merovius.de/go-misc/cmd/go-optional.
+
+package {{ .Package }}
+
+{{ range .Types }}
+	{{ template "impl" . }}
+{{ end }}
+`))
+
+var _ = template.Must(implTemplate.New("impl").Parse(`
+// {{ .Name }} is an optional {{ .Type }}. The zero value holds no value.
+type {{ .Name }} struct {
+	v  {{ .Type }}
+	ok bool
+}
+
+// Some{{ .Name }} returns a {{ .Name }} holding v.
+func Some{{ .Name }}(v {{ .Type }}) {{ .Name }} {
+	return {{ .Name }}{v, true}
+}
+
+// None{{ .Name }} returns a {{ .Name }} holding no value.
+func None{{ .Name }}() {{ .Name }} {
+	return {{ .Name }}{}
+}
+
+// IsSome returns whether o holds a value.
+func (o {{ .Name }}) IsSome() bool {
+	return o.ok
+}
+
+// IsNone returns whether o holds no value.
+func (o {{ .Name }}) IsNone() bool {
+	return !o.ok
+}
+
+// Get returns the value held by o and whether it holds one.
+func (o {{ .Name }}) Get() ({{ .Type }}, bool) {
+	return o.v, o.ok
+}
+
+// Unwrap returns the value held by o. It panics, if o holds no value.
+func (o {{ .Name }}) Unwrap() {{ .Type }} {
+	if !o.ok {
+		panic("Unwrap called on empty {{ .Name }}")
+	}
+	return o.v
+}
+
+// UnwrapOr returns the value held by o, or def, if it holds no value.
+func (o {{ .Name }}) UnwrapOr(def {{ .Type }}) {{ .Type }} {
+	if !o.ok {
+		return def
+	}
+	return o.v
+}
+
+// Map returns a {{ .Name }} holding f applied to the value held by o. If o
+// holds no value, f is not called and the result holds no value either.
+func (o {{ .Name }}) Map(f func({{ .Type }}) {{ .Type }}) {{ .Name }} {
+	if !o.ok {
+		return o
+	}
+	return {{ .Name }}{f(o.v), true}
+}
+`))
+
+type pkg struct {
+	Package string
+	Types   []gen.Type
+}
+
+var (
+	flags   = flag.NewFlagSet("go-optional", flag.ContinueOnError)
+	pkgName = flags.String("package", "optional", "Package the file should be in")
+	out     = gen.OutputFlags(flags)
+)
+
+// Run runs go-optional with the command line arguments args, which do not include
+// the program name.
+func Run(args []string) error {
+	if err := gen.ParseFlags(flags, args); err != nil {
+		return err
+	}
+
+	types, err := gen.ParseTypes(flags.Args())
+	if err != nil {
+		return errors.New("Usage: go-optional [-package=<pkg>] [<name> <type>]...")
+	}
+
+	return out.Write(implTemplate, pkg{Package: *pkgName, Types: types})
+}
@@ -0,0 +1,29 @@
+package optional
+
+import (
+	"testing"
+
+	"merovius.de/go-misc/internal/gen/gentest"
+)
+
+func TestMain(m *testing.M) {
+	gentest.Main(m, Run)
+}
+
+func TestGolden(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		args []string
+	}{
+		// Without types, the builtin types are generated.
+		{"builtin", nil},
+		{"types", []string{"Port", "uint16", "Names", "[]string"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := gentest.Dir(t, nil)
+			gentest.Generate(t, dir, append([]string{"-out=optional.go"}, tc.args...)...)
+			gentest.Golden(t, dir, "optional.go", tc.name+".go.golden")
+			gentest.Vet(t, dir)
+		})
+	}
+}
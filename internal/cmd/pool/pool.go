@@ -0,0 +1,93 @@
+// Package pool implements go-pool. See merovius.de/go-misc/cmd/go-pool for its
+// documentation.
+package pool // import "merovius.de/go-misc/internal/cmd/pool"
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"text/template"
+
+	"merovius.de/go-misc/internal/gen"
+)
+
+var implTemplate = template.Must(template.New("pool.go").Parse(`
+// This file is automatically generated by merovius.de/go-misc/cmd/go-pool.
+
+package {{ .Package }}
+
+import "sync"
+
+{{ range .Types }}
+	{{ template "impl" . }}
+{{ end }}
+`))
+
+var _ = template.Must(implTemplate.New("impl").Parse(`
+// {{ .Name }} is a pool of *{{ .Type }}. The zero value is an empty pool,
+// which resets values with their Reset method, if they have one. A {{ .Name }}
+// must not be copied after first use.
+type {{ .Name }} struct {
+	p     sync.Pool
+	reset func(*{{ .Type }})
+}
+
+// New{{ .Name }} returns a new {{ .Name }}. If reset is not nil, it is called
+// on every value passed to Put. Otherwise, values are reset with their Reset
+// method, if they have one.
+func New{{ .Name }}(reset func(*{{ .Type }})) *{{ .Name }} {
+	return &{{ .Name }}{reset: reset}
+}
+
+// Get returns a value from the pool. If the pool is empty, a newly allocated
+// zero value is returned.
+func (p *{{ .Name }}) Get() *{{ .Type }} {
+	if v, ok := p.p.Get().(*{{ .Type }}); ok {
+		return v
+	}
+	return new({{ .Type }})
+}
+
+// Put resets v and returns it to the pool. Put(nil) is a no-op. v must not
+// be used after calling Put.
+func (p *{{ .Name }}) Put(v *{{ .Type }}) {
+	if v == nil {
+		return
+	}
+	if p.reset != nil {
+		p.reset(v)
+	} else if r, ok := interface{}(v).(interface{ Reset() }); ok {
+		r.Reset()
+	}
+	p.p.Put(v)
+}
+`))
+
+type pkg struct {
+	Package string
+	Types   []gen.Type
+}
+
+var (
+	flags   = flag.NewFlagSet("go-pool", flag.ContinueOnError)
+	pkgName = flags.String("package", os.Getenv("GOPACKAGE"), "Package the file should be in")
+	out     = gen.OutputFlags(flags)
+)
+
+// Run runs go-pool with the command line arguments args, which do not include
+// the program name.
+func Run(args []string) error {
+	if err := gen.ParseFlags(flags, args); err != nil {
+		return err
+	}
+
+	types, err := gen.ParseTypes(flags.Args())
+	if err != nil || flags.NArg() == 0 {
+		return errors.New("Usage: go-pool [-package=<pkg>] <name> <type> [<name> <type>]...")
+	}
+	if *pkgName == "" {
+		return errors.New("no package given")
+	}
+
+	return out.Write(implTemplate, pkg{Package: *pkgName, Types: types})
+}
@@ -0,0 +1,67 @@
+package pool
+
+import (
+	"testing"
+
+	"merovius.de/go-misc/internal/gen/gentest"
+)
+
+func TestMain(m *testing.M) {
+	gentest.Main(m, Run)
+}
+
+// src declares the pooled types of the tests. Buffer has a Reset method, Msg
+// does not.
+const src = `package buf
+
+import "bytes"
+
+type Buffer = bytes.Buffer
+
+type Msg struct{ Body []byte }
+`
+
+func TestGolden(t *testing.T) {
+	dir := gentest.Dir(t, map[string]string{"buf.go": src})
+	gentest.Generate(t, dir, "-package=buf", "-out=pool.go", "BufferPool", "Buffer", "MsgPool", "Msg")
+	gentest.Golden(t, dir, "pool.go", "pool.go.golden")
+	gentest.Vet(t, dir)
+}
+
+func TestUsage(t *testing.T) {
+	dir := gentest.Dir(t, nil)
+	gentest.Fail(t, dir, "-package=buf", "-out=pool.go")
+	gentest.Fail(t, dir, "-package=buf", "-out=pool.go", "BufferPool")
+	gentest.Fail(t, dir, "-package=", "-out=pool.go", "BufferPool", "Buffer")
+}
+
+func TestReset(t *testing.T) {
+	dir := gentest.Dir(t, map[string]string{"buf.go": src, "pool_test.go": `package buf
+
+import "testing"
+
+func TestReset(t *testing.T) {
+	var bp BufferPool
+	b := bp.Get()
+	b.WriteString("x")
+	bp.Put(b)
+	bp.Put(nil)
+	if b.Len() != 0 {
+		t.Errorf("Put did not call Reset")
+	}
+
+	mp := NewMsgPool(func(m *Msg) { m.Body = m.Body[:0] })
+	m := mp.Get()
+	m.Body = append(m.Body, 'x')
+	mp.Put(m)
+	if len(m.Body) != 0 {
+		t.Errorf("Put did not call reset")
+	}
+	if m := mp.Get(); m == nil {
+		t.Errorf("Get() == nil")
+	}
+}
+`})
+	gentest.Generate(t, dir, "-package=buf", "-out=pool.go", "BufferPool", "Buffer", "MsgPool", "Msg")
+	gentest.Test(t, dir)
+}
@@ -0,0 +1,135 @@
+// Package result implements go-result. See merovius.de/go-misc/cmd/go-result for its
+// documentation.
+package result // import "merovius.de/go-misc/internal/cmd/result"
+
+import (
+	"errors"
+	"flag"
+	"text/template"
+
+	"merovius.de/go-misc/internal/gen"
+)
+
+var implTemplate = template.Must(template.New("result.go").Parse(`
+// This file is automatically generated by merovius.de/go-misc/cmd/go-result.
+
+package {{ .Package }}
+
+{{ range .Types }}
+	{{ template "impl" . }}
+{{ end }}
+`))
+
+var _ = template.Must(implTemplate.New("impl").Parse(`
+// {{ .Name }} is the result of a computation returning a {{ .Type }}. It either
+// holds a value or a non-nil error.
+type {{ .Name }} struct {
+	v   {{ .Type }}
+	err error
+}
+
+// Ok{{ .Name }} returns a successful {{ .Name }} holding v.
+func Ok{{ .Name }}(v {{ .Type }}) {{ .Name }} {
+	return {{ .Name }}{v: v}
+}
+
+// Err{{ .Name }} returns a failed {{ .Name }} holding err. If err is nil, the
+// result is successful and holds the zero value.
+func Err{{ .Name }}(err error) {{ .Name }} {
+	return {{ .Name }}{err: err}
+}
+
+// {{ .Name }}Of returns a {{ .Name }} from the results of a function call.
+// If err is not nil, v is discarded.
+func {{ .Name }}Of(v {{ .Type }}, err error) {{ .Name }} {
+	if err != nil {
+		return {{ .Name }}{err: err}
+	}
+	return {{ .Name }}{v: v}
+}
+
+// IsOk returns whether r is successful.
+func (r {{ .Name }}) IsOk() bool {
+	return r.err == nil
+}
+
+// Get returns the value and error held by r.
+func (r {{ .Name }}) Get() ({{ .Type }}, error) {
+	return r.v, r.err
+}
+
+// Err returns the error held by r, or nil if it is successful.
+func (r {{ .Name }}) Err() error {
+	return r.err
+}
+
+// Unwrap returns the value held by r. It panics with the error held by r,
+// if it failed.
+func (r {{ .Name }}) Unwrap() {{ .Type }} {
+	if r.err != nil {
+		panic(r.err)
+	}
+	return r.v
+}
+
+// UnwrapOr returns the value held by r, or def, if it failed.
+func (r {{ .Name }}) UnwrapOr(def {{ .Type }}) {{ .Type }} {
+	if r.err != nil {
+		return def
+	}
+	return r.v
+}
+
+// Map returns a {{ .Name }} holding f applied to the value held by r. If r
+// failed, f is not called and r is returned.
+func (r {{ .Name }}) Map(f func({{ .Type }}) {{ .Type }}) {{ .Name }} {
+	if r.err != nil {
+		return r
+	}
+	return {{ .Name }}{v: f(r.v)}
+}
+
+// AndThen returns the result of calling f with the value held by r. If r
+// failed, f is not called and r is returned.
+func (r {{ .Name }}) AndThen(f func({{ .Type }}) ({{ .Type }}, error)) {{ .Name }} {
+	if r.err != nil {
+		return r
+	}
+	return {{ .Name }}Of(f(r.v))
+}
+
+// OrElse returns the result of calling f with the error held by r. If r is
+// successful, f is not called and r is returned.
+func (r {{ .Name }}) OrElse(f func(error) ({{ .Type }}, error)) {{ .Name }} {
+	if r.err == nil {
+		return r
+	}
+	return {{ .Name }}Of(f(r.err))
+}
+`))
+
+type pkg struct {
+	Package string
+	Types   []gen.Type
+}
+
+var (
+	flags   = flag.NewFlagSet("go-result", flag.ContinueOnError)
+	pkgName = flags.String("package", "result", "Package the file should be in")
+	out     = gen.OutputFlags(flags)
+)
+
+// Run runs go-result with the command line arguments args, which do not include
+// the program name.
+func Run(args []string) error {
+	if err := gen.ParseFlags(flags, args); err != nil {
+		return err
+	}
+
+	types, err := gen.ParseTypes(flags.Args())
+	if err != nil {
+		return errors.New("Usage: go-result [-package=<pkg>] [<name> <type>]...")
+	}
+
+	return out.Write(implTemplate, pkg{Package: *pkgName, Types: types})
+}
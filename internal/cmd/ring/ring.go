@@ -0,0 +1,201 @@
+// Package ring implements go-ring. See merovius.de/go-misc/cmd/go-ring for its
+// documentation.
+package ring // import "merovius.de/go-misc/internal/cmd/ring"
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"text/template"
+
+	"merovius.de/go-misc/internal/gen"
+)
+
+var implTemplate = template.Must(template.New("ring.go").Parse(`
+// This file is automatically generated by merovius.de/go-misc/cmd/go-ring.
+
+package {{ .Package }}
+
+import (
+	"sync/atomic"
+	"unsafe"
+)
+
+{{ range .Types }}
+	{{ template "impl" . }}
+{{ end }}
+`))
+
+var _ = template.Must(implTemplate.New("impl").Parse(`
+// {{ .Name }} is a bounded single-producer, single-consumer queue of
+// {{ .Type }} values. At any time, at most one goroutine may push and at most
+// one goroutine may pop values.
+type {{ .Name }} struct {
+	// head and tail are accessed atomically and must come first, to be
+	// 64-bit aligned on 32-bit platforms. This is asserted below.
+	head uint64 // index of the next value to pop, written by the consumer
+	tail uint64 // index of the next value to push, written by the producer
+
+	closed int32
+	waitP  int32 // set, if the producer waits for space
+	waitC  int32 // set, if the consumer waits for values
+
+	notFull  chan struct{}
+	notEmpty chan struct{}
+	buf      []{{ .Type }}
+	mask     uint64
+}
+
+// The index expressions are out of range and fail to compile, if head or tail
+// are not 64-bit aligned.
+var (
+	_ = [1]struct{}{}[unsafe.Offsetof({{ .Name }}{}.head)%8]
+	_ = [1]struct{}{}[unsafe.Offsetof({{ .Name }}{}.tail)%8]
+)
+
+// New{{ .Name }} returns a new {{ .Name }} holding at least size values. size
+// is rounded up to the next power of two.
+func New{{ .Name }}(size int) *{{ .Name }} {
+	n := 1
+	for n < size {
+		n <<= 1
+	}
+	return &{{ .Name }}{
+		notFull:  make(chan struct{}, 1),
+		notEmpty: make(chan struct{}, 1),
+		buf:      make([]{{ .Type }}, n),
+		mask:     uint64(n - 1),
+	}
+}
+
+// TryPush pushes v to r, if there is space. It returns whether v was pushed.
+func (r *{{ .Name }}) TryPush(v {{ .Type }}) bool {
+	t := atomic.LoadUint64(&r.tail)
+	if t-atomic.LoadUint64(&r.head) == uint64(len(r.buf)) {
+		return false
+	}
+	r.buf[t&r.mask] = v
+	atomic.StoreUint64(&r.tail, t+1)
+	r.wake(&r.waitC, r.notEmpty)
+	return true
+}
+
+// TryPop pops a value from r, if there is one. It returns whether a value
+// was popped.
+func (r *{{ .Name }}) TryPop() ({{ .Type }}, bool) {
+	var zero {{ .Type }}
+	h := atomic.LoadUint64(&r.head)
+	if h == atomic.LoadUint64(&r.tail) {
+		return zero, false
+	}
+	v := r.buf[h&r.mask]
+	// Clear the slot, so the popped value can be garbage collected.
+	r.buf[h&r.mask] = zero
+	atomic.StoreUint64(&r.head, h+1)
+	r.wake(&r.waitP, r.notFull)
+	return v, true
+}
+
+// Push pushes v to r, waiting for space if r is full. It returns false, if r
+// is closed.
+func (r *{{ .Name }}) Push(v {{ .Type }}) bool {
+	for {
+		if atomic.LoadInt32(&r.closed) != 0 {
+			return false
+		}
+		if r.TryPush(v) {
+			return true
+		}
+		r.wait(&r.waitP, r.notFull, func() bool {
+			return atomic.LoadUint64(&r.tail)-atomic.LoadUint64(&r.head) < uint64(len(r.buf)) || atomic.LoadInt32(&r.closed) != 0
+		})
+	}
+}
+
+// Pop pops a value from r, waiting for one if r is empty. It returns false,
+// if r is closed and all values have been popped.
+func (r *{{ .Name }}) Pop() ({{ .Type }}, bool) {
+	for {
+		if v, ok := r.TryPop(); ok {
+			return v, true
+		}
+		if atomic.LoadInt32(&r.closed) != 0 {
+			// All values have been pushed before r was closed.
+			return r.TryPop()
+		}
+		r.wait(&r.waitC, r.notEmpty, func() bool {
+			return atomic.LoadUint64(&r.head) != atomic.LoadUint64(&r.tail) || atomic.LoadInt32(&r.closed) != 0
+		})
+	}
+}
+
+// Close closes r. Subsequent calls to Push fail and Pop fails once all
+// remaining values have been popped. Close must only be called by the
+// producer.
+func (r *{{ .Name }}) Close() {
+	atomic.StoreInt32(&r.closed, 1)
+	r.wake(&r.waitC, r.notEmpty)
+	r.wake(&r.waitP, r.notFull)
+}
+
+// Len returns the number of values in r.
+func (r *{{ .Name }}) Len() int {
+	return int(atomic.LoadUint64(&r.tail) - atomic.LoadUint64(&r.head))
+}
+
+// Cap returns the capacity of r.
+func (r *{{ .Name }}) Cap() int {
+	return len(r.buf)
+}
+
+// wait blocks on ch, unless ready returns true after announcing the wait in
+// flag. Spurious wakeups are possible.
+func (r *{{ .Name }}) wait(flag *int32, ch chan struct{}, ready func() bool) {
+	atomic.StoreInt32(flag, 1)
+	if ready() {
+		atomic.StoreInt32(flag, 0)
+		return
+	}
+	<-ch
+}
+
+// wake wakes up a goroutine announced in flag.
+func (r *{{ .Name }}) wake(flag *int32, ch chan struct{}) {
+	if atomic.LoadInt32(flag) == 0 || !atomic.CompareAndSwapInt32(flag, 1, 0) {
+		return
+	}
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+`))
+
+type pkg struct {
+	Package string
+	Types   []gen.Type
+}
+
+var (
+	flags   = flag.NewFlagSet("go-ring", flag.ContinueOnError)
+	pkgName = flags.String("package", os.Getenv("GOPACKAGE"), "Package the file should be in")
+	out     = gen.OutputFlags(flags)
+)
+
+// Run runs go-ring with the command line arguments args, which do not include
+// the program name.
+func Run(args []string) error {
+	if err := gen.ParseFlags(flags, args); err != nil {
+		return err
+	}
+
+	types, err := gen.ParseTypes(flags.Args())
+	if err != nil || flags.NArg() == 0 {
+		return errors.New("Usage: go-ring [-package=<pkg>] <name> <type> [<name> <type>]...")
+	}
+	if *pkgName == "" {
+		return errors.New("no package given")
+	}
+
+	return out.Write(implTemplate, pkg{Package: *pkgName, Types: types})
+}
@@ -0,0 +1,127 @@
+package ring
+
+import (
+	"testing"
+
+	"merovius.de/go-misc/internal/gen/gentest"
+)
+
+func TestMain(m *testing.M) {
+	gentest.Main(m, Run)
+}
+
+func TestGolden(t *testing.T) {
+	dir := gentest.Dir(t, nil)
+	gentest.Generate(t, dir, "-package=ring", "-out=ring.go", "Ints", "int", "Strings", "[]string")
+	gentest.Golden(t, dir, "ring.go", "ring.go.golden")
+	gentest.Vet(t, dir)
+}
+
+func TestAlign(t *testing.T) {
+	dir := gentest.Dir(t, nil)
+	gentest.Generate(t, dir, "-package=ring", "-out=ring.go", "Ints", "int", "Bytes", "byte")
+	for _, arch := range []string{"386", "arm", "mips"} {
+		gentest.Vet(t, dir, "GOARCH="+arch)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	dir := gentest.Dir(t, nil)
+	gentest.Fail(t, dir, "-package=ring", "-out=ring.go")
+	gentest.Fail(t, dir, "-package=ring", "-out=ring.go", "Ints")
+	gentest.Fail(t, dir, "-package=", "-out=ring.go", "Ints", "int")
+}
+
+// ringTest tests, that a producer and a consumer waiting on a ring Ints of
+// ints wake each other up. It is run with the race detector.
+const ringTest = `package ring
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSize(t *testing.T) {
+	if c := NewInts(5).Cap(); c != 8 {
+		t.Errorf("Cap() == %d, want 8", c)
+	}
+	r := NewInts(2)
+	if !r.TryPush(1) || !r.TryPush(2) || r.TryPush(3) {
+		t.Fatal("TryPush did not respect the capacity")
+	}
+	if v, ok := r.TryPop(); v != 1 || !ok || r.Len() != 1 {
+		t.Fatalf("TryPop() == %v, %v, Len() == %d, want 1, true, 1", v, ok, r.Len())
+	}
+}
+
+func TestSPSC(t *testing.T) {
+	const N = 10000
+	r := NewInts(4)
+	go func() {
+		for i := 0; i < N; i++ {
+			if !r.Push(i) {
+				t.Errorf("Push(%d) failed", i)
+			}
+		}
+		r.Close()
+	}()
+	for i := 0; i < N; i++ {
+		if v, ok := r.Pop(); v != i || !ok {
+			t.Fatalf("Pop() == %v, %v, want %v, true", v, ok, i)
+		}
+	}
+	if v, ok := r.Pop(); ok {
+		t.Fatalf("Pop() == %v, true after Close", v)
+	}
+}
+
+func TestWake(t *testing.T) {
+	r := NewInts(1)
+	done := make(chan int)
+	go func() {
+		v, _ := r.Pop()
+		done <- v
+	}()
+	// Give Pop time to wait.
+	time.Sleep(10 * time.Millisecond)
+	r.Push(1)
+	if v := <-done; v != 1 {
+		t.Fatalf("Pop() == %v, want 1", v)
+	}
+
+	r.Push(2)
+	go func() {
+		r.Push(3)
+		close(done)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	if v, _ := r.Pop(); v != 2 {
+		t.Fatalf("Pop() == %v, want 2", v)
+	}
+	<-done
+	if v, _ := r.Pop(); v != 3 {
+		t.Fatalf("Pop() == %v, want 3", v)
+	}
+}
+
+func TestClose(t *testing.T) {
+	r := NewInts(1)
+	r.Push(1)
+	done := make(chan bool)
+	go func() { done <- r.Push(2) }()
+	time.Sleep(10 * time.Millisecond)
+	r.Close()
+	if <-done {
+		t.Error("blocked Push succeeded after Close")
+	}
+	if v, ok := r.Pop(); v != 1 || !ok {
+		t.Errorf("Pop() == %v, %v after Close, want 1, true", v, ok)
+	}
+}
+`
+
+func TestRing(t *testing.T) {
+	dir := gentest.Dir(t, map[string]string{"ring_test.go": ringTest})
+	gentest.Generate(t, dir, "-package=ring", "-out=ring.go", "Ints", "int")
+	gentest.Test(t, dir, "-race")
+}
@@ -0,0 +1,273 @@
+// Package set implements go-set. See merovius.de/go-misc/cmd/go-set for its
+// documentation.
+package set // import "merovius.de/go-misc/internal/cmd/set"
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"text/template"
+
+	"merovius.de/go-misc/internal/gen"
+)
+
+var implTemplate = template.Must(template.New("set.go").Parse(`
+// This file is automatically generated by merovius.de/go-misc/cmd/go-set.
+
+package {{ .Package }}
+
+{{ if or .Sync .Ordered -}}
+import (
+{{- if .Ordered }}
+	"sort"
+{{- end }}
+{{- if .Sync }}
+	"sync"
+{{- end }}
+)
+{{- end }}
+
+{{ if and .Generic .Ordered -}}
+// setOrdered is the constraint of the element types of sets that can be
+// sorted. Only sorting requires it, so sets of other comparable types can
+// still be used.
+type setOrdered interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64 | ~string
+}
+{{- end }}
+
+{{ range .Types }}
+	{{ template "impl" . }}
+{{ end }}
+`))
+
+var _ = template.Must(implTemplate.New("impl").Parse(`
+// {{ .Name }} is a set of {{ .Type }} values. The zero value is an empty set.
+{{- if .Sync }}
+// It is safe for concurrent use and must not be copied after first use.
+{{- end }}
+type {{ .Name }}{{ .TypeParams }} struct {
+{{- if .Sync }}
+	mu sync.RWMutex
+{{- end }}
+	m  map[{{ .Type }}]struct{}
+}
+
+// New{{ .Name }} returns a new {{ .Name }} containing vs.
+func New{{ .Name }}{{ .TypeParams }}(vs ...{{ .Type }}) *{{ .Ref }} {
+	s := &{{ .Ref }}{m: make(map[{{ .Type }}]struct{}, len(vs))}
+	for _, v := range vs {
+		s.m[v] = struct{}{}
+	}
+	return s
+}
+
+// Add adds vs to s.
+func (s *{{ .Ref }}) Add(vs ...{{ .Type }}) {
+{{- if .Sync }}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+{{- end }}
+	if s.m == nil {
+		s.m = make(map[{{ .Type }}]struct{}, len(vs))
+	}
+	for _, v := range vs {
+		s.m[v] = struct{}{}
+	}
+}
+
+// Has returns whether v is in s.
+func (s *{{ .Ref }}) Has(v {{ .Type }}) bool {
+{{- if .Sync }}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+{{- end }}
+	_, ok := s.m[v]
+	return ok
+}
+
+// Delete removes vs from s.
+func (s *{{ .Ref }}) Delete(vs ...{{ .Type }}) {
+{{- if .Sync }}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+{{- end }}
+	for _, v := range vs {
+		delete(s.m, v)
+	}
+}
+
+// Len returns the number of elements in s.
+func (s *{{ .Ref }}) Len() int {
+{{- if .Sync }}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+{{- end }}
+	return len(s.m)
+}
+
+// Slice returns the elements of s in unspecified order.
+func (s *{{ .Ref }}) Slice() []{{ .Type }} {
+{{- if .Sync }}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+{{- end }}
+	l := make([]{{ .Type }}, 0, len(s.m))
+	for v := range s.m {
+		l = append(l, v)
+	}
+	return l
+}
+{{ if and .Ordered .Generic }}
+// Sorted{{ .Name }} returns the elements of s in ascending order.
+func Sorted{{ .Name }}[T setOrdered](s *{{ .Ref }}) []T {
+	l := s.Slice()
+	sort.Slice(l, func(i, j int) bool { return l[i] < l[j] })
+	return l
+}
+{{ else if .Ordered }}
+// Sorted returns the elements of s in ascending order.
+func (s *{{ .Ref }}) Sorted() []{{ .Type }} {
+	l := s.Slice()
+	sort.Slice(l, func(i, j int) bool { return l[i] < l[j] })
+	return l
+}
+{{ end }}
+// Range calls f for every element of s in unspecified order, until f returns
+// false.{{ if .Sync }} f must not modify s.{{ end }}
+func (s *{{ .Ref }}) Range(f func({{ .Type }}) bool) {
+{{- if .Sync }}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+{{- end }}
+	for v := range s.m {
+		if !f(v) {
+			return
+		}
+	}
+}
+
+// Union returns a new set containing the elements that are in s or in o.
+func (s *{{ .Ref }}) Union(o *{{ .Ref }}) *{{ .Ref }} {
+{{- if .Sync }}
+	// Taking a snapshot of o, instead of holding both locks, avoids
+	// deadlocks when two sets are combined concurrently in both orders.
+	r := New{{ .Name }}(o.Slice()...)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for v := range s.m {
+		r.m[v] = struct{}{}
+	}
+{{- else }}
+	r := &{{ .Ref }}{m: make(map[{{ .Type }}]struct{}, len(s.m)+len(o.m))}
+	for v := range s.m {
+		r.m[v] = struct{}{}
+	}
+	for v := range o.m {
+		r.m[v] = struct{}{}
+	}
+{{- end }}
+	return r
+}
+
+// Intersect returns a new set containing the elements that are in s and in o.
+func (s *{{ .Ref }}) Intersect(o *{{ .Ref }}) *{{ .Ref }} {
+	r := &{{ .Ref }}{m: make(map[{{ .Type }}]struct{})}
+{{- if .Sync }}
+	l := o.Slice()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, v := range l {
+		if _, ok := s.m[v]; ok {
+			r.m[v] = struct{}{}
+		}
+	}
+{{- else }}
+	a, b := s.m, o.m
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	for v := range a {
+		if _, ok := b[v]; ok {
+			r.m[v] = struct{}{}
+		}
+	}
+{{- end }}
+	return r
+}
+`))
+
+type pkg struct {
+	Package string
+	Sync    bool
+	Ordered bool
+	Generic bool
+	Types   []set
+}
+
+type set struct {
+	Name    string
+	Type    string
+	Sync    bool
+	Ordered bool
+	Generic bool
+}
+
+// TypeParams returns the type parameter list of s.
+func (s set) TypeParams() string {
+	if s.Generic {
+		return "[" + s.Type + " comparable]"
+	}
+	return ""
+}
+
+// Ref returns the type of s, as used in the generated code.
+func (s set) Ref() string {
+	if s.Generic {
+		return s.Name + "[" + s.Type + "]"
+	}
+	return s.Name
+}
+
+var (
+	flags     = flag.NewFlagSet("go-set", flag.ContinueOnError)
+	pkgName   = flags.String("package", os.Getenv("GOPACKAGE"), "Package the file should be in")
+	syncSafe  = flags.Bool("sync", false, "Generate sets that are safe for concurrent use")
+	unordered = flags.Bool("unordered", false, "Do not generate the Sorted method")
+	generic   = flags.Bool("generic", false, "Generate generic sets, taking only names as arguments")
+	out       = gen.OutputFlags(flags)
+)
+
+// Run runs go-set with the command line arguments args, which do not include
+// the program name.
+func Run(args []string) error {
+	if err := gen.ParseFlags(flags, args); err != nil {
+		return err
+	}
+
+	var (
+		types []gen.Type
+		err   error
+	)
+	if *generic {
+		for _, n := range flags.Args() {
+			types = append(types, gen.Type{Name: n, Type: "T"})
+		}
+	} else {
+		types, err = gen.ParseTypes(flags.Args())
+	}
+	if err != nil || flags.NArg() == 0 {
+		return errors.New("Usage: go-set [-package=<pkg>] [-sync] [-unordered] (<name> <type> [<name> <type>]... | -generic <name>...)")
+	}
+	if *pkgName == "" {
+		return errors.New("no package given")
+	}
+
+	p := pkg{Package: *pkgName, Sync: *syncSafe, Ordered: !*unordered, Generic: *generic}
+	for _, t := range types {
+		p.Types = append(p.Types, set{t.Name, t.Type, p.Sync, p.Ordered, p.Generic})
+	}
+	return out.Write(implTemplate, p)
+}
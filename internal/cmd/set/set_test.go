@@ -0,0 +1,105 @@
+package set
+
+import (
+	"testing"
+
+	"merovius.de/go-misc/internal/gen/gentest"
+)
+
+func TestMain(m *testing.M) {
+	gentest.Main(m, Run)
+}
+
+func TestGolden(t *testing.T) {
+	tcs := []struct {
+		name string
+		args []string
+	}{
+		{"default", []string{"Ints", "int", "Strings", "string"}},
+		{"sync", []string{"-sync", "Ints", "int"}},
+		{"unordered", []string{"-unordered", "Points", "[2]int"}},
+		{"generic", []string{"-generic", "Set"}},
+		{"generic_sync", []string{"-generic", "-sync", "-unordered", "Set"}},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := gentest.Dir(t, nil)
+			gentest.Generate(t, dir, append([]string{"-package=set", "-out=set.go"}, tc.args...)...)
+			gentest.Golden(t, dir, "set.go", tc.name+".go.golden")
+			gentest.Vet(t, dir)
+		})
+	}
+}
+
+func TestUsage(t *testing.T) {
+	dir := gentest.Dir(t, nil)
+	gentest.Fail(t, dir, "-package=set", "-out=set.go")
+	gentest.Fail(t, dir, "-package=set", "-out=set.go", "Ints")
+	gentest.Fail(t, dir, "-package=", "-out=set.go", "Ints", "int")
+}
+
+// setTest tests the operations of the sets Ints and Set[int].
+const setTest = `package set
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestInts(t *testing.T) {
+	s := NewInts(3, 1, 2)
+	s.Add(2, 4)
+	s.Delete(1, 5)
+	if !s.Has(4) || s.Has(1) || s.Len() != 3 {
+		t.Errorf("Has/Len of %v are wrong", s.Sorted())
+	}
+	if got, want := s.Sorted(), []int{2, 3, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Sorted() == %v, want %v", got, want)
+	}
+	if got, want := s.Union(NewInts(1)).Sorted(), []int{1, 2, 3, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Union() == %v, want %v", got, want)
+	}
+	if got, want := s.Intersect(NewInts(1, 3)).Sorted(), []int{3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Intersect() == %v, want %v", got, want)
+	}
+	n := 0
+	s.Range(func(int) bool { n++; return false })
+	if n != 1 {
+		t.Errorf("Range called f %d times after it returned false", n)
+	}
+
+	var g Set[int]
+	g.Add(2, 1)
+	if got, want := SortedSet(&g), []int{1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SortedSet() == %v, want %v", got, want)
+	}
+}
+
+func TestConcurrent(t *testing.T) {
+	s := NewInts()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				s.Add(i*100 + j)
+				s.Has(j)
+				s.Len()
+			}
+		}(i)
+	}
+	wg.Wait()
+	if s.Len() != 400 {
+		t.Errorf("Len() == %d, want 400", s.Len())
+	}
+}
+`
+
+func TestSet(t *testing.T) {
+	dir := gentest.Dir(t, map[string]string{"set_test.go": setTest})
+	gentest.Generate(t, dir, "-package=set", "-sync", "-out=ints.go", "Ints", "int")
+	gentest.Generate(t, dir, "-package=set", "-generic", "-out=set.go", "Set")
+	gentest.Test(t, dir, "-race")
+}
@@ -0,0 +1,201 @@
+// Package sorted implements go-sorted. See merovius.de/go-misc/cmd/go-sorted for its
+// documentation.
+package sorted // import "merovius.de/go-misc/internal/cmd/sorted"
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"go/token"
+	"go/types"
+	"strings"
+	"text/template"
+
+	"merovius.de/go-misc/internal/gen"
+)
+
+var implTemplate = template.Must(template.New("sorted.go").Parse(`
+// This file is automatically generated by merovius.de/go-misc/cmd/go-sorted.
+
+package {{ .Package }}
+
+{{ if .Imports -}}
+import (
+{{- range .Imports }}
+	{{ . }}
+{{- end }}
+)
+{{- end }}
+
+{{ range .Types }}
+	{{ template "impl" . }}
+{{ end }}
+`))
+
+var _ = template.Must(implTemplate.New("impl").Parse(`
+// {{ .Name }} attaches the methods of sort.Interface to {{ .Slice }}, sorting
+// by {{ .Order }}.
+type {{ .Name }} {{ .Slice }}
+
+func (s {{ .Name }}) Len() int      { return len(s) }
+func (s {{ .Name }}) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+
+func (s {{ .Name }}) Less(i, j int) bool {
+{{- range $i, $k := .Keys }}
+{{- if $.Last $i }}
+	return s[i].{{ $k.Field }} < s[j].{{ $k.Field }}
+{{- else }}
+	if s[i].{{ $k.Field }} != s[j].{{ $k.Field }} {
+		return s[i].{{ $k.Field }} < s[j].{{ $k.Field }}
+	}
+{{- end }}
+{{- end }}
+}
+
+// Search returns the index of the first element of the sorted slice s, which
+// is not less than the given values, or len(s), if there is none.
+func (s {{ .Name }}) Search({{ range $i, $k := .Keys }}{{ if $i }}, {{ end }}{{ $k.Param }} {{ $k.Type }}{{ end }}) int {
+	lo, hi := 0, len(s)
+	for lo < hi {
+		h := int(uint(lo+hi) >> 1)
+		e := {{ if not .Ptr }}&{{ end }}s[h]
+		var below bool
+{{- if eq (len .Keys) 1 }}
+		below = e.{{ (index .Keys 0).Field }} < {{ (index .Keys 0).Param }}
+{{- else }}
+		switch {
+{{- range $i, $k := .Keys }}
+{{- if $.Last $i }}
+		default:
+{{- else }}
+		case e.{{ $k.Field }} != {{ $k.Param }}:
+{{- end }}
+			below = e.{{ $k.Field }} < {{ $k.Param }}
+{{- end }}
+		}
+{{- end }}
+		if below {
+			lo = h + 1
+		} else {
+			hi = h
+		}
+	}
+	return lo
+}
+`))
+
+type pkg struct {
+	Package string
+	Imports []string
+	Types   []sorted
+}
+
+type sorted struct {
+	Name  string
+	Slice string
+	Order string
+	Ptr   bool
+	Keys  []key
+}
+
+type key struct {
+	Field string
+	Param string
+	Type  string
+}
+
+// Last returns whether i is the index of the last key.
+func (s sorted) Last(i int) bool {
+	return i == len(s.Keys)-1
+}
+
+// reserved contains the identifiers used by the generated Search methods.
+var reserved = map[string]bool{
+	"s":     true,
+	"lo":    true,
+	"hi":    true,
+	"h":     true,
+	"e":     true,
+	"below": true,
+}
+
+// collect returns the sorted slice type for the struct type name in p, ordered
+// by the given fields.
+func collect(p *gen.Package, im *gen.Imports, name string, fields []string, ptr bool) (sorted, error) {
+	_, st, err := p.Struct(name)
+	if err != nil {
+		return sorted{}, err
+	}
+	s := sorted{
+		Name:  name + "By" + strings.Join(fields, ""),
+		Slice: "[]" + name,
+		Order: strings.Join(fields, ", "),
+		Ptr:   ptr,
+	}
+	if ptr {
+		s.Slice = "[]*" + name
+	}
+	for _, f := range fields {
+		var v *types.Var
+		for i := 0; i < st.NumFields(); i++ {
+			if st.Field(i).Name() == f {
+				v = st.Field(i)
+			}
+		}
+		if v == nil {
+			return sorted{}, fmt.Errorf("%s has no field %s", name, f)
+		}
+		if b, ok := v.Type().Underlying().(*types.Basic); !ok || b.Info()&types.IsOrdered == 0 {
+			return sorted{}, fmt.Errorf("field %s.%s of type %s is not ordered", name, f, v.Type())
+		}
+		param := gen.Unexported(f)
+		if token.IsKeyword(param) || reserved[param] {
+			param += "_"
+		}
+		s.Keys = append(s.Keys, key{Field: f, Param: param, Type: im.TypeString(v.Type())})
+	}
+	return s, nil
+}
+
+var (
+	flags     = flag.NewFlagSet("go-sorted", flag.ContinueOnError)
+	typeNames = flags.String("type", "", "Comma-separated list of struct type names")
+	orders    = flags.String("by", "", "Comma-separated list of orders, each a +-separated list of fields")
+	ptr       = flags.Bool("ptr", false, "Generate slices of pointers")
+	out       = gen.OutputFlags(flags)
+)
+
+// Run runs go-sorted with the command line arguments args, which do not include
+// the program name.
+func Run(args []string) error {
+	if err := gen.ParseFlags(flags, args); err != nil {
+		return err
+	}
+	if *typeNames == "" || *orders == "" || flags.NArg() > 1 {
+		return errors.New("Usage: go-sorted [-ptr] -type=<type>[,<type>...] -by=<order>[,<order>...] [<dir>]")
+	}
+	dir := "."
+	if flags.NArg() == 1 {
+		dir = flags.Arg(0)
+	}
+
+	lp, err := gen.LoadPackage(dir)
+	if err != nil {
+		return err
+	}
+
+	im := gen.NewImports(lp.Types)
+	p := pkg{Package: lp.Types.Name()}
+	for _, name := range strings.Split(*typeNames, ",") {
+		for _, order := range strings.Split(*orders, ",") {
+			s, err := collect(lp, im, name, strings.Split(order, "+"), *ptr)
+			if err != nil {
+				return err
+			}
+			p.Types = append(p.Types, s)
+		}
+	}
+	p.Imports = im.List()
+
+	return out.Write(implTemplate, p)
+}
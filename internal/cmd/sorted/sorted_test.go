@@ -0,0 +1,102 @@
+package sorted
+
+import (
+	"testing"
+
+	"merovius.de/go-misc/internal/gen/gentest"
+)
+
+func TestMain(m *testing.M) {
+	gentest.Main(m, Run)
+}
+
+// src declares the type of the tests. The field Type is a keyword, when
+// unexported.
+const src = `package people
+
+import "time"
+
+type Person struct {
+	Last, First string
+	Age         int
+	Type        uint8
+	Born        time.Duration
+	Tags        []string
+	Admin       bool
+}
+`
+
+func TestGolden(t *testing.T) {
+	tcs := []struct {
+		name string
+		args []string
+	}{
+		{"default", []string{"-by=Age,Last+First+Type"}},
+		{"ptr", []string{"-ptr", "-by=Born"}},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := gentest.Dir(t, map[string]string{"person.go": src})
+			gentest.Generate(t, dir, append(tc.args, "-type=Person", "-out=sorted.go")...)
+			gentest.Golden(t, dir, "sorted.go", tc.name+".go.golden")
+			gentest.Vet(t, dir)
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	dir := gentest.Dir(t, map[string]string{"person.go": src})
+	for _, by := range []string{"-by=Missing", "-by=Tags", "-by=Age+Admin", "-by="} {
+		gentest.Fail(t, dir, by, "-type=Person", "-out=sorted.go")
+	}
+}
+
+func TestSorted(t *testing.T) {
+	dir := gentest.Dir(t, map[string]string{"person.go": src, "sorted_test.go": `package people
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestSorted(t *testing.T) {
+	ps := []Person{
+		{Last: "b", First: "x", Age: 30},
+		{Last: "a", First: "z", Age: 20},
+		{Last: "b", First: "a", Age: 40},
+		{Last: "a", First: "y", Age: 10},
+	}
+
+	sort.Sort(PersonByAge(ps))
+	for i, want := range []int{10, 20, 30, 40} {
+		if ps[i].Age != want {
+			t.Fatalf("sorted by age: %v", ps)
+		}
+	}
+	if i := PersonByAge(ps).Search(25); i != 2 {
+		t.Errorf("Search(25) == %d, want 2", i)
+	}
+	if i := PersonByAge(ps).Search(50); i != 4 {
+		t.Errorf("Search(50) == %d, want 4", i)
+	}
+
+	s := PersonByLastFirstType(ps)
+	sort.Sort(s)
+	var got []string
+	for _, p := range ps {
+		got = append(got, p.Last+p.First)
+	}
+	if !sort.StringsAreSorted(got) {
+		t.Fatalf("sorted by name: %v", got)
+	}
+	if i := s.Search("b", "a", 0); i != 2 {
+		t.Errorf("Search(\"b\", \"a\", 0) == %d, want 2", i)
+	}
+	if i := s.Search("a", "zz", 0); i != 2 {
+		t.Errorf("Search(\"a\", \"zz\", 0) == %d, want 2", i)
+	}
+}
+`})
+	gentest.Generate(t, dir, "-by=Age,Last+First+Type", "-type=Person", "-out=sorted.go")
+	gentest.Test(t, dir)
+}
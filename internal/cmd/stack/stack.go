@@ -0,0 +1,229 @@
+// Package stack implements go-stack. See merovius.de/go-misc/cmd/go-stack for its
+// documentation.
+package stack // import "merovius.de/go-misc/internal/cmd/stack"
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"text/template"
+
+	"merovius.de/go-misc/internal/gen"
+)
+
+var implTemplate = template.Must(template.New("stack.go").Parse(`
+// This file is automatically generated by merovius.de/go-misc/cmd/go-stack.
+
+package {{ .Package }}
+
+import (
+{{- if .LockFree }}
+	"sync/atomic"
+	"unsafe"
+{{- else }}
+	"sync"
+{{- end }}
+)
+
+{{ range .Types }}
+	{{- if .LockFree }}
+		{{ template "lockfree" . }}
+	{{- else }}
+		{{ template "impl" . }}
+	{{- end }}
+{{ end }}
+`))
+
+var _ = template.Must(implTemplate.New("impl").Parse(`
+// {{ .Name }} is a LIFO stack of {{ .Type }} values. The zero value is an
+// empty, unbounded stack. It is safe for concurrent use and must not be copied
+// after first use.
+type {{ .Name }} struct {
+	mu  sync.Mutex
+	s   []{{ .Type }}
+	max int
+}
+
+// New{{ .Name }} returns a new {{ .Name }}, holding at most max values. If max
+// is 0, the stack is unbounded.
+func New{{ .Name }}(max int) *{{ .Name }} {
+	return &{{ .Name }}{max: max}
+}
+
+// Push pushes v onto s. It returns false, if s is full.
+func (s *{{ .Name }}) Push(v {{ .Type }}) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.max > 0 && len(s.s) >= s.max {
+		return false
+	}
+	s.s = append(s.s, v)
+	return true
+}
+
+// Pop removes and returns the top value of s. It returns false, if s is empty.
+func (s *{{ .Name }}) Pop() ({{ .Type }}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var zero {{ .Type }}
+	if len(s.s) == 0 {
+		return zero, false
+	}
+	v := s.s[len(s.s)-1]
+	// Clear the slot, so the popped value can be garbage collected.
+	s.s[len(s.s)-1] = zero
+	s.s = s.s[:len(s.s)-1]
+	return v, true
+}
+
+// Peek returns the top value of s without removing it. It returns false, if
+// s is empty.
+func (s *{{ .Name }}) Peek() ({{ .Type }}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.s) == 0 {
+		var zero {{ .Type }}
+		return zero, false
+	}
+	return s.s[len(s.s)-1], true
+}
+
+// Len returns the number of values in s.
+func (s *{{ .Name }}) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.s)
+}
+`))
+
+var _ = template.Must(implTemplate.New("lockfree").Parse(`
+// {{ .Name }} is a lock-free LIFO stack of {{ .Type }} values. The zero value
+// is an empty, unbounded stack. It is safe for concurrent use and must not be
+// copied after first use.
+type {{ .Name }} struct {
+	// n is accessed atomically and must come first, to be 64-bit aligned on
+	// 32-bit platforms. This is asserted below.
+	n    int64
+	max  int64
+	head unsafe.Pointer // *{{ .Node }}
+}
+
+// The index expression is out of range and fails to compile, if n is not
+// 64-bit aligned.
+var _ = [1]struct{}{}[unsafe.Offsetof({{ .Name }}{}.n)%8]
+
+type {{ .Node }} struct {
+	v    {{ .Type }}
+	next unsafe.Pointer // *{{ .Node }}
+}
+
+// New{{ .Name }} returns a new {{ .Name }}, holding at most max values. If max
+// is 0, the stack is unbounded.
+func New{{ .Name }}(max int) *{{ .Name }} {
+	return &{{ .Name }}{max: int64(max)}
+}
+
+// Push pushes v onto s. It returns false, if s is full.
+func (s *{{ .Name }}) Push(v {{ .Type }}) bool {
+	if s.max > 0 {
+		for {
+			n := atomic.LoadInt64(&s.n)
+			if n >= s.max {
+				return false
+			}
+			if atomic.CompareAndSwapInt64(&s.n, n, n+1) {
+				break
+			}
+		}
+	} else {
+		atomic.AddInt64(&s.n, 1)
+	}
+	nd := &{{ .Node }}{v: v}
+	for {
+		h := atomic.LoadPointer(&s.head)
+		nd.next = h
+		if atomic.CompareAndSwapPointer(&s.head, h, unsafe.Pointer(nd)) {
+			return true
+		}
+	}
+}
+
+// Pop removes and returns the top value of s. It returns false, if s is empty.
+func (s *{{ .Name }}) Pop() ({{ .Type }}, bool) {
+	for {
+		h := atomic.LoadPointer(&s.head)
+		if h == nil {
+			var zero {{ .Type }}
+			return zero, false
+		}
+		nd := (*{{ .Node }})(h)
+		if atomic.CompareAndSwapPointer(&s.head, h, nd.next) {
+			atomic.AddInt64(&s.n, -1)
+			return nd.v, true
+		}
+	}
+}
+
+// Peek returns the top value of s without removing it. It returns false, if
+// s is empty.
+func (s *{{ .Name }}) Peek() ({{ .Type }}, bool) {
+	h := atomic.LoadPointer(&s.head)
+	if h == nil {
+		var zero {{ .Type }}
+		return zero, false
+	}
+	return (*{{ .Node }})(h).v, true
+}
+
+// Len returns the number of values in s. Concurrent Push operations might
+// be counted before their values are visible to Pop.
+func (s *{{ .Name }}) Len() int {
+	return int(atomic.LoadInt64(&s.n))
+}
+`))
+
+type pkg struct {
+	Package  string
+	LockFree bool
+	Types    []stack
+}
+
+type stack struct {
+	Name     string
+	Type     string
+	LockFree bool
+}
+
+// Node returns the name of the node type of a lock-free stack.
+func (s stack) Node() string {
+	return gen.Unexported(s.Name) + "Node"
+}
+
+var (
+	flags    = flag.NewFlagSet("go-stack", flag.ContinueOnError)
+	pkgName  = flags.String("package", os.Getenv("GOPACKAGE"), "Package the file should be in")
+	lockFree = flags.Bool("lockfree", false, "Generate lock-free stacks")
+	out      = gen.OutputFlags(flags)
+)
+
+// Run runs go-stack with the command line arguments args, which do not include
+// the program name.
+func Run(args []string) error {
+	if err := gen.ParseFlags(flags, args); err != nil {
+		return err
+	}
+
+	types, err := gen.ParseTypes(flags.Args())
+	if err != nil || flags.NArg() == 0 {
+		return errors.New("Usage: go-stack [-package=<pkg>] [-lockfree] <name> <type> [<name> <type>]...")
+	}
+	if *pkgName == "" {
+		return errors.New("no package given")
+	}
+
+	p := pkg{Package: *pkgName, LockFree: *lockFree}
+	for _, t := range types {
+		p.Types = append(p.Types, stack{t.Name, t.Type, p.LockFree})
+	}
+	return out.Write(implTemplate, p)
+}
@@ -0,0 +1,147 @@
+package stack
+
+import (
+	"strconv"
+	"testing"
+
+	"merovius.de/go-misc/internal/gen/gentest"
+)
+
+func TestMain(m *testing.M) {
+	gentest.Main(m, Run)
+}
+
+func TestGolden(t *testing.T) {
+	tcs := []struct {
+		name string
+		args []string
+	}{
+		{"default", nil},
+		{"lockfree", []string{"-lockfree"}},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := gentest.Dir(t, nil)
+			gentest.Generate(t, dir, append(tc.args, "-package=stack", "-out=stack.go", "Ints", "int", "Strings", "[]string")...)
+			gentest.Golden(t, dir, "stack.go", tc.name+".go.golden")
+			gentest.Vet(t, dir)
+		})
+	}
+}
+
+func TestAlign(t *testing.T) {
+	dir := gentest.Dir(t, nil)
+	gentest.Generate(t, dir, "-package=stack", "-lockfree", "-out=stack.go", "Ints", "int", "Bytes", "byte")
+	for _, arch := range []string{"386", "arm", "mips"} {
+		gentest.Vet(t, dir, "GOARCH="+arch)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	dir := gentest.Dir(t, nil)
+	gentest.Fail(t, dir, "-package=stack", "-out=stack.go")
+	gentest.Fail(t, dir, "-package=stack", "-out=stack.go", "Ints")
+	gentest.Fail(t, dir, "-package=", "-out=stack.go", "Ints", "int")
+}
+
+// stackTest tests a stack Ints of ints. It is run with the race detector, for
+// both implementations.
+const stackTest = `package stack
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestLIFO(t *testing.T) {
+	var s Ints
+	if _, ok := s.Pop(); ok {
+		t.Fatal("Pop() on empty stack succeeded")
+	}
+	for i := 0; i < 3; i++ {
+		s.Push(i)
+	}
+	if v, ok := s.Peek(); v != 2 || !ok || s.Len() != 3 {
+		t.Fatalf("Peek() == %v, %v, Len() == %d, want 2, true, 3", v, ok, s.Len())
+	}
+	for i := 2; i >= 0; i-- {
+		if v, ok := s.Pop(); v != i || !ok {
+			t.Fatalf("Pop() == %v, %v, want %v, true", v, ok, i)
+		}
+	}
+}
+
+func TestMax(t *testing.T) {
+	s := NewInts(2)
+	if !s.Push(1) || !s.Push(2) || s.Push(3) {
+		t.Fatal("Push did not respect max")
+	}
+	s.Pop()
+	if !s.Push(3) {
+		t.Fatal("Push failed after Pop")
+	}
+}
+
+func TestConcurrent(t *testing.T) {
+	const N, M = 8, 1000
+	s := NewInts(N * M / 2)
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		pushed int
+		seen   = make(map[int]bool)
+	)
+	for i := 0; i < N; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			n := 0
+			for j := 0; j < M; j++ {
+				if s.Push(i*M + j) {
+					n++
+				}
+			}
+			mu.Lock()
+			pushed += n
+			mu.Unlock()
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < M; j++ {
+				v, ok := s.Pop()
+				if !ok {
+					continue
+				}
+				mu.Lock()
+				if seen[v] {
+					t.Errorf("popped %d twice", v)
+				}
+				seen[v] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	for {
+		v, ok := s.Pop()
+		if !ok {
+			break
+		}
+		if seen[v] {
+			t.Errorf("popped %d twice", v)
+		}
+		seen[v] = true
+	}
+	if len(seen) != pushed || s.Len() != 0 {
+		t.Errorf("popped %d values, pushed %d, Len() == %d", len(seen), pushed, s.Len())
+	}
+}
+`
+
+func TestStack(t *testing.T) {
+	for _, lockFree := range []bool{false, true} {
+		dir := gentest.Dir(t, map[string]string{"stack_test.go": stackTest})
+		gentest.Generate(t, dir, "-package=stack", "-lockfree="+strconv.FormatBool(lockFree), "-out=stack.go", "Ints", "int")
+		gentest.Test(t, dir, "-race")
+	}
+}
@@ -0,0 +1,187 @@
+// Package visitor implements go-visitor. See merovius.de/go-misc/cmd/go-visitor for its
+// documentation.
+package visitor // import "merovius.de/go-misc/internal/cmd/visitor"
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"go/token"
+	"go/types"
+	"strings"
+	"text/template"
+
+	"merovius.de/go-misc/internal/gen"
+)
+
+var implTemplate = template.Must(template.New("visitor.go").Parse(`
+// This file is automatically generated by merovius.de/go-misc/cmd/go-visitor.
+
+package {{ .Package }}
+
+{{ range .Types }}
+	{{ template "impl" . }}
+{{ end }}
+`))
+
+var _ = template.Must(implTemplate.New("impl").Parse(`
+// {{ .Name }}Visitor visits the variants of {{ .Name }}.
+type {{ .Name }}Visitor interface {
+{{- range .Variants }}
+	Visit{{ .Name }}({{ .Type }})
+{{- end }}
+}
+{{ range .Variants }}
+// Accept calls v.Visit{{ .Name }}(x).
+func (x {{ .Type }}) Accept(v {{ $.Name }}Visitor) {
+	v.Visit{{ .Name }}(x)
+}
+{{ end }}
+// Match{{ .Name }} calls the func for the dynamic type of x. It panics, if x is
+// nil or of an unknown type.
+func Match{{ .Name }}(x {{ .Name }}{{ range .Variants }}, {{ .Param }} func({{ .Type }}){{ end }}) {
+	switch x := x.(type) {
+{{- range .Variants }}
+	case {{ .Type }}:
+		{{ .Param }}(x)
+{{- end }}
+	default:
+		panic("unknown {{ .Name }} variant")
+	}
+}
+`))
+
+type pkg struct {
+	Package string
+	Types   []visitor
+}
+
+type visitor struct {
+	Name     string
+	Variants []variant
+}
+
+type variant struct {
+	Name  string
+	Type  string
+	Param string
+}
+
+// implements returns whether t has all methods of it, except Accept.
+func implements(t types.Type, it *types.Interface) bool {
+	for i := 0; i < it.NumMethods(); i++ {
+		m := it.Method(i)
+		if m.Name() == "Accept" {
+			continue
+		}
+		obj, _, _ := types.LookupFieldOrMethod(t, false, m.Pkg(), m.Name())
+		fn, ok := obj.(*types.Func)
+		if !ok || !types.Identical(fn.Type(), m.Type()) {
+			return false
+		}
+	}
+	return true
+}
+
+// variantOf returns the variant for the named type n, if n or *n implements
+// it.
+func variantOf(n *types.Named, it *types.Interface) (variant, bool) {
+	name := n.Obj().Name()
+	v := variant{Name: name, Type: name, Param: gen.Unexported(name)}
+	if token.IsKeyword(v.Param) || v.Param == "x" {
+		v.Param += "_"
+	}
+	if implements(n, it) {
+		return v, true
+	}
+	if implements(types.NewPointer(n), it) {
+		v.Type = "*" + name
+		return v, true
+	}
+	return v, false
+}
+
+// collect returns the visitor for the interface type name in p.
+func collect(p *gen.Package, name string, variants []string) (visitor, error) {
+	_, it, err := p.Interface(name)
+	if err != nil {
+		return visitor{}, err
+	}
+	vis := visitor{Name: name}
+	if variants == nil {
+		for _, vn := range p.Types.Scope().Names() {
+			tn, ok := p.Types.Scope().Lookup(vn).(*types.TypeName)
+			if !ok || tn.IsAlias() {
+				continue
+			}
+			n, ok := tn.Type().(*types.Named)
+			if !ok || types.IsInterface(n) {
+				continue
+			}
+			if v, ok := variantOf(n, it); ok {
+				vis.Variants = append(vis.Variants, v)
+			}
+		}
+	}
+	for _, vn := range variants {
+		n, err := p.Named(vn)
+		if err != nil {
+			return visitor{}, err
+		}
+		v, ok := variantOf(n, it)
+		if !ok {
+			return visitor{}, fmt.Errorf("%s does not implement %s", vn, name)
+		}
+		vis.Variants = append(vis.Variants, v)
+	}
+	if len(vis.Variants) == 0 {
+		return visitor{}, fmt.Errorf("no variants of %s found", name)
+	}
+	return vis, nil
+}
+
+var (
+	flags     = flag.NewFlagSet("go-visitor", flag.ContinueOnError)
+	typeNames = flags.String("type", "", "Comma-separated list of interface type names")
+	variants  = flags.String("variants", "", "Comma-separated list of variants (defaults to all implementations)")
+	out       = gen.OutputFlags(flags)
+)
+
+// Run runs go-visitor with the command line arguments args, which do not include
+// the program name.
+func Run(args []string) error {
+	if err := gen.ParseFlags(flags, args); err != nil {
+		return err
+	}
+	if *typeNames == "" || flags.NArg() > 1 {
+		return errors.New("Usage: go-visitor [-variants=<type>[,<type>...]] -type=<type>[,<type>...] [<dir>]")
+	}
+	names := strings.Split(*typeNames, ",")
+	var vs []string
+	if *variants != "" {
+		if len(names) > 1 {
+			return errors.New("-variants can only be used with a single type")
+		}
+		vs = strings.Split(*variants, ",")
+	}
+	dir := "."
+	if flags.NArg() == 1 {
+		dir = flags.Arg(0)
+	}
+
+	lp, err := gen.LoadPackage(dir)
+	if err != nil {
+		return err
+	}
+
+	p := pkg{Package: lp.Types.Name()}
+	for _, name := range names {
+		v, err := collect(lp, name, vs)
+		if err != nil {
+			return err
+		}
+		p.Types = append(p.Types, v)
+	}
+
+	return out.Write(implTemplate, p)
+}
@@ -0,0 +1,121 @@
+package visitor
+
+import (
+	"testing"
+
+	"merovius.de/go-misc/internal/gen/gentest"
+)
+
+func TestMain(m *testing.M) {
+	gentest.Main(m, Run)
+}
+
+// src declares the interface of the tests, with variants implementing it with
+// value and pointer receivers.
+const src = `package expr
+
+type Expr interface {
+	isExpr()
+}
+
+type Num int
+
+func (Num) isExpr() {}
+
+type Add struct{ L, R Expr }
+
+func (*Add) isExpr() {}
+
+type Neg struct{ X Expr }
+
+func (Neg) isExpr() {}
+
+type Other struct{}
+
+type Stmt interface {
+	isStmt()
+}
+`
+
+func TestGolden(t *testing.T) {
+	tcs := []struct {
+		name string
+		args []string
+	}{
+		{"default", nil},
+		{"variants", []string{"-variants=Num,Add"}},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := gentest.Dir(t, map[string]string{"expr.go": src})
+			gentest.Generate(t, dir, append(tc.args, "-type=Expr", "-out=visitor.go")...)
+			gentest.Golden(t, dir, "visitor.go", tc.name+".go.golden")
+			gentest.Vet(t, dir)
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	dir := gentest.Dir(t, map[string]string{"expr.go": src})
+	for _, args := range [][]string{
+		{"-type=Expr", "-variants=Other"},
+		{"-type=Expr,Stmt", "-variants=Num"},
+		{"-type=Stmt"},
+		{"-type=Num"},
+		{},
+	} {
+		gentest.Fail(t, dir, append(args, "-out=visitor.go")...)
+	}
+}
+
+func TestVisitor(t *testing.T) {
+	dir := gentest.Dir(t, map[string]string{"expr.go": src, "visitor_test.go": `package expr
+
+import "testing"
+
+type eval struct{ v int }
+
+func (e *eval) VisitAdd(x *Add) {
+	e.v = Eval(x.L) + Eval(x.R)
+}
+
+func (e *eval) VisitNeg(x Neg) {
+	e.v = -Eval(x.X)
+}
+
+func (e *eval) VisitNum(x Num) {
+	e.v = int(x)
+}
+
+func Eval(x Expr) int {
+	e := new(eval)
+	x.(interface{ Accept(ExprVisitor) }).Accept(e)
+	return e.v
+}
+
+func TestVisitor(t *testing.T) {
+	x := &Add{Num(1), Neg{Num(3)}}
+	if v := Eval(x); v != -2 {
+		t.Errorf("Eval() == %d, want -2", v)
+	}
+
+	var got string
+	MatchExpr(x,
+		func(*Add) { got = "add" },
+		func(Neg) { got = "neg" },
+		func(Num) { got = "num" },
+	)
+	if got != "add" {
+		t.Errorf("MatchExpr called the func for %s, want add", got)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("MatchExpr(nil) did not panic")
+		}
+	}()
+	MatchExpr(nil, nil, nil, nil)
+}
+`})
+	gentest.Generate(t, dir, "-type=Expr", "-out=visitor.go")
+	gentest.Test(t, dir)
+}
@@ -0,0 +1,286 @@
+// Package wrap implements go-wrap. See merovius.de/go-misc/cmd/go-wrap for its
+// documentation.
+package wrap // import "merovius.de/go-misc/internal/cmd/wrap"
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"go/types"
+	"strings"
+	"text/template"
+
+	"merovius.de/go-misc/internal/gen"
+)
+
+var implTemplate = template.Must(template.New("wrap.go").Parse(`
+// This file is automatically generated by merovius.de/go-misc/cmd/go-wrap.
+
+package {{ .Package }}
+
+{{ if .Imports -}}
+import (
+{{- range .Imports }}
+	{{ . }}
+{{- end }}
+)
+{{- end }}
+
+{{ range $i := .Types }}
+	{{- if $.Log }}{{ template "log" $i }}{{ end }}
+	{{- if $.Metrics }}{{ template "metrics" $i }}{{ end }}
+	{{- if $.Retry }}{{ template "retry" $i }}{{ end }}
+	{{- if $.Trace }}{{ template "trace" $i }}{{ end }}
+{{ end }}
+`))
+
+var _ = template.Must(implTemplate.New("log").Parse(`
+// NewLogging{{ .Name }} returns a {{ .Name }} wrapping next, which logs every
+// call with logf.
+func NewLogging{{ .Name }}(next {{ .Name }}, logf func(format string, v ...interface{})) {{ .Name }} {
+	return &logging{{ .Name }}{next, logf}
+}
+
+type logging{{ .Name }} struct {
+	next {{ .Name }}
+	logf func(string, ...interface{})
+}
+{{ range .Methods }}
+func (w *logging{{ $.Name }}) {{ .Name }}({{ .ParamDecl }}) {{ .ResultDecl }} {
+	defer func(start time.Time) {
+		w.logf({{ .LogFormat $.Name }}, {{ .LogArgs }}time.Since(start))
+	}(time.Now())
+	{{ if .Results }}return {{ end }}w.next.{{ .Name }}({{ .Args }})
+}
+{{ end }}
+`))
+
+var _ = template.Must(implTemplate.New("metrics").Parse(`
+// NewMetrics{{ .Name }} returns a {{ .Name }} wrapping next, which calls
+// observe with the method name, duration and error of every call.
+func NewMetrics{{ .Name }}(next {{ .Name }}, observe func(method string, d time.Duration, err error)) {{ .Name }} {
+	return &metrics{{ .Name }}{next, observe}
+}
+
+type metrics{{ .Name }} struct {
+	next    {{ .Name }}
+	observe func(string, time.Duration, error)
+}
+{{ range .Methods }}
+func (w *metrics{{ $.Name }}) {{ .Name }}({{ .ParamDecl }}) {{ .ResultDecl }} {
+	defer func(start time.Time) {
+		w.observe("{{ .Name }}", time.Since(start), {{ or .Err "nil" }})
+	}(time.Now())
+	{{ if .Results }}return {{ end }}w.next.{{ .Name }}({{ .Args }})
+}
+{{ end }}
+`))
+
+var _ = template.Must(implTemplate.New("retry").Parse(`
+// NewRetrying{{ .Name }} returns a {{ .Name }} wrapping next, which calls
+// methods returning an error up to attempts times, until they succeed. If
+// backoff is not nil, it sleeps backoff(i) after the i'th failed attempt.
+func NewRetrying{{ .Name }}(next {{ .Name }}, attempts int, backoff func(attempt int) time.Duration) {{ .Name }} {
+	return &retrying{{ .Name }}{next, attempts, backoff}
+}
+
+type retrying{{ .Name }} struct {
+	next     {{ .Name }}
+	attempts int
+	backoff  func(int) time.Duration
+}
+{{ range .Methods }}
+func (w *retrying{{ $.Name }}) {{ .Name }}({{ .ParamDecl }}) {{ .ResultDecl }} {
+{{- if .Err }}
+	for i := 0; ; i++ {
+		{{ .ResultNames }} = w.next.{{ .Name }}({{ .Args }})
+		if {{ .Err }} == nil || i+1 >= w.attempts {
+			return {{ .ResultNames }}
+		}
+		if w.backoff == nil {
+			continue
+		}
+	{{- if .Ctx }}
+		select {
+		case <-a0.Done():
+			return {{ .ResultNames }}
+		case <-time.After(w.backoff(i)):
+		}
+	{{- else }}
+		time.Sleep(w.backoff(i))
+	{{- end }}
+	}
+{{- else }}
+	{{ if .Results }}return {{ end }}w.next.{{ .Name }}({{ .Args }})
+{{- end }}
+}
+{{ end }}
+`))
+
+var _ = template.Must(implTemplate.New("trace").Parse(`
+// NewTracing{{ .Name }} returns a {{ .Name }} wrapping next, which calls start
+// before and the returned func after every call.
+func NewTracing{{ .Name }}(next {{ .Name }}, start func(ctx context.Context, method string) (context.Context, func(err error))) {{ .Name }} {
+	return &tracing{{ .Name }}{next, start}
+}
+
+type tracing{{ .Name }} struct {
+	next  {{ .Name }}
+	start func(context.Context, string) (context.Context, func(error))
+}
+{{ range .Methods }}
+func (w *tracing{{ $.Name }}) {{ .Name }}({{ .ParamDecl }}) {{ .ResultDecl }} {
+{{- if .Ctx }}
+	a0, end := w.start(a0, "{{ $.Name }}.{{ .Name }}")
+{{- else }}
+	_, end := w.start(context.Background(), "{{ $.Name }}.{{ .Name }}")
+{{- end }}
+	defer func() { end({{ or .Err "nil" }}) }()
+	{{ if .Results }}return {{ end }}w.next.{{ .Name }}({{ .Args }})
+}
+{{ end }}
+`))
+
+type pkg struct {
+	Package string
+	Imports []string
+	Types   []iface
+
+	Log     bool
+	Metrics bool
+	Retry   bool
+	Trace   bool
+}
+
+type iface struct {
+	Name    string
+	Methods []method
+}
+
+type method struct {
+	*gen.Func
+	Name string
+	// Ctx is set, if the first parameter is a context.Context.
+	Ctx bool
+	// Err is the name of the last result, if it is an error.
+	Err string
+}
+
+// ResultNames returns the names of the results of m, separated by commas.
+func (m method) ResultNames() string {
+	return gen.JoinNames("", m.Results)
+}
+
+// LogFormat returns the quoted format string used to log calls to m of
+// interface i.
+func (m method) LogFormat(i string) string {
+	verbs := func(n int) string {
+		return strings.TrimSuffix(strings.Repeat("%v, ", n), ", ")
+	}
+	f := i + "." + m.Name + "(" + verbs(len(m.Params)) + ")"
+	switch len(m.Results) {
+	case 0:
+	case 1:
+		f += " = %v"
+	default:
+		f += " = (" + verbs(len(m.Results)) + ")"
+	}
+	return fmt.Sprintf("%q", f+" [%v]")
+}
+
+// LogArgs returns the arguments to log calls to m, followed by a comma, if
+// there are any.
+func (m method) LogArgs() string {
+	s := gen.JoinNames("", append(append([]gen.Field(nil), m.Params...), m.Results...))
+	if s != "" {
+		s += ", "
+	}
+	return s
+}
+
+var errorType = types.Universe.Lookup("error").Type()
+
+// collect returns the methods of the interface type name in p.
+func collect(p *gen.Package, im *gen.Imports, name string) (iface, error) {
+	_, it, err := p.Interface(name)
+	if err != nil {
+		return iface{}, err
+	}
+	i := iface{Name: name}
+	for j := 0; j < it.NumMethods(); j++ {
+		fn := it.Method(j)
+		sig := fn.Type().(*types.Signature)
+		m := method{Func: im.Func(sig), Name: fn.Name()}
+		if ps := sig.Params(); ps.Len() > 0 && isContext(ps.At(0).Type()) {
+			m.Ctx = true
+		}
+		if rs := sig.Results(); rs.Len() > 0 && types.Identical(rs.At(rs.Len()-1).Type(), errorType) {
+			m.Err = m.Results[rs.Len()-1].Name
+		}
+		i.Methods = append(i.Methods, m)
+	}
+	return i, nil
+}
+
+func isContext(t types.Type) bool {
+	n, ok := t.(*types.Named)
+	return ok && n.Obj().Pkg() != nil && n.Obj().Pkg().Path() == "context" && n.Obj().Name() == "Context"
+}
+
+var (
+	flags     = flag.NewFlagSet("go-wrap", flag.ContinueOnError)
+	typeNames = flags.String("type", "", "Comma-separated list of interface type names")
+	logging   = flags.Bool("log", false, "Generate logging decorators")
+	metrics   = flags.Bool("metrics", false, "Generate metrics decorators")
+	retry     = flags.Bool("retry", false, "Generate retrying decorators")
+	trace     = flags.Bool("trace", false, "Generate tracing decorators")
+	out       = gen.OutputFlags(flags)
+)
+
+// Run runs go-wrap with the command line arguments args, which do not include
+// the program name.
+func Run(args []string) error {
+	if err := gen.ParseFlags(flags, args); err != nil {
+		return err
+	}
+	if *typeNames == "" || flags.NArg() > 1 {
+		return errors.New("Usage: go-wrap [-log] [-metrics] [-retry] [-trace] -type=<type>[,<type>...] [<dir>]")
+	}
+	if !*logging && !*metrics && !*retry && !*trace {
+		return errors.New("at least one of -log, -metrics, -retry and -trace must be given")
+	}
+	dir := "."
+	if flags.NArg() == 1 {
+		dir = flags.Arg(0)
+	}
+
+	lp, err := gen.LoadPackage(dir)
+	if err != nil {
+		return err
+	}
+
+	im := gen.NewImports(lp.Types)
+	p := pkg{
+		Package: lp.Types.Name(),
+		Log:     *logging,
+		Metrics: *metrics,
+		Retry:   *retry,
+		Trace:   *trace,
+	}
+	for _, name := range strings.Split(*typeNames, ",") {
+		i, err := collect(lp, im, name)
+		if err != nil {
+			return err
+		}
+		p.Types = append(p.Types, i)
+	}
+	if p.Log || p.Metrics || p.Retry {
+		im.Add("time", "time")
+	}
+	if p.Trace {
+		im.Add("context", "context")
+	}
+	p.Imports = im.List()
+
+	return out.Write(implTemplate, p)
+}
@@ -0,0 +1,174 @@
+package wrap
+
+import (
+	"testing"
+
+	"merovius.de/go-misc/internal/gen/gentest"
+)
+
+func TestMain(m *testing.M) {
+	gentest.Main(m, Run)
+}
+
+// src declares the interface of the tests, with methods with and without a
+// context and an error.
+const src = `package store
+
+import "context"
+
+type Store interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Put(key string, v []byte) error
+	Len() int
+	Close()
+}
+`
+
+func TestGolden(t *testing.T) {
+	tcs := []struct {
+		name string
+		args []string
+	}{
+		{"log", []string{"-log"}},
+		{"metrics", []string{"-metrics"}},
+		{"retry", []string{"-retry"}},
+		{"trace", []string{"-trace"}},
+		{"all", []string{"-log", "-metrics", "-retry", "-trace"}},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := gentest.Dir(t, map[string]string{"store.go": src})
+			gentest.Generate(t, dir, append(tc.args, "-type=Store", "-out=wrap.go")...)
+			gentest.Golden(t, dir, "wrap.go", tc.name+".go.golden")
+			gentest.Vet(t, dir)
+		})
+	}
+}
+
+func TestUsage(t *testing.T) {
+	dir := gentest.Dir(t, map[string]string{"store.go": src})
+	gentest.Fail(t, dir, "-log", "-out=wrap.go")
+	gentest.Fail(t, dir, "-type=Store", "-out=wrap.go")
+	gentest.Fail(t, dir, "-log", "-type=Missing", "-out=wrap.go")
+}
+
+// wrapTest tests the decorators of Store with a fake implementation.
+const wrapTest = `package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+type fake struct {
+	fails int
+	calls int
+}
+
+func (f *fake) Get(ctx context.Context, key string) ([]byte, error) {
+	f.calls++
+	if f.calls <= f.fails {
+		return nil, errors.New("unavailable")
+	}
+	return []byte(key), nil
+}
+
+func (f *fake) Put(key string, v []byte) error {
+	f.calls++
+	if f.calls <= f.fails {
+		return errors.New("unavailable")
+	}
+	return nil
+}
+
+func (f *fake) Len() int { f.calls++; return 1 }
+func (f *fake) Close()   {}
+
+func TestLog(t *testing.T) {
+	var lines []string
+	s := NewLoggingStore(&fake{}, func(format string, v ...interface{}) {
+		// Replace the duration, to make the output deterministic.
+		v[len(v)-1] = time.Duration(0)
+		lines = append(lines, fmt.Sprintf(format, v...))
+	})
+	s.Len()
+	s.Put("a", nil)
+	want := []string{"Store.Len() = 1 [0s]", "Store.Put(a, []) = <nil> [0s]"}
+	if fmt.Sprint(lines) != fmt.Sprint(want) {
+		t.Errorf("logged %q, want %q", lines, want)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	var methods []string
+	var errs []error
+	s := NewMetricsStore(&fake{fails: 1}, func(method string, d time.Duration, err error) {
+		methods, errs = append(methods, method), append(errs, err)
+	})
+	s.Put("a", nil)
+	s.Close()
+	if len(methods) != 2 || methods[0] != "Put" || errs[0] == nil || methods[1] != "Close" || errs[1] != nil {
+		t.Errorf("observed %v, %v", methods, errs)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	f := &fake{fails: 2}
+	var backoffs []int
+	s := NewRetryingStore(f, 3, func(i int) time.Duration {
+		backoffs = append(backoffs, i)
+		return 0
+	})
+	if v, err := s.Get(context.Background(), "a"); err != nil || string(v) != "a" || f.calls != 3 {
+		t.Errorf("Get() == %q, %v after %d calls, want \"a\", nil after 3", v, err, f.calls)
+	}
+	if len(backoffs) != 2 || backoffs[1] != 1 {
+		t.Errorf("backoff called with %v, want [0 1]", backoffs)
+	}
+
+	f = &fake{fails: 5}
+	s = NewRetryingStore(f, 3, nil)
+	if err := s.Put("a", nil); err == nil || f.calls != 3 {
+		t.Errorf("Put() == %v after %d calls, want error after 3", err, f.calls)
+	}
+	s.Len()
+	if f.calls != 4 {
+		t.Errorf("Len was retried")
+	}
+
+	// A canceled context stops the backoff.
+	f = &fake{fails: 5}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s = NewRetryingStore(f, 3, func(int) time.Duration { return time.Hour })
+	if _, err := s.Get(ctx, "a"); err == nil || f.calls != 1 {
+		t.Errorf("Get() == %v after %d calls, want error after 1", err, f.calls)
+	}
+}
+
+type key struct{}
+
+func TestTrace(t *testing.T) {
+	var spans []string
+	s := NewTracingStore(&fake{fails: 1}, func(ctx context.Context, method string) (context.Context, func(error)) {
+		return context.WithValue(ctx, key{}, method), func(err error) {
+			spans = append(spans, fmt.Sprint(method, " ", err))
+		}
+	})
+	s.Get(context.Background(), "a")
+	s.Len()
+	want := "[Store.Get unavailable Store.Len <nil>]"
+	if fmt.Sprint(spans) != want {
+		t.Errorf("traced %v, want %v", spans, want)
+	}
+}
+`
+
+func TestWrap(t *testing.T) {
+	dir := gentest.Dir(t, map[string]string{"store.go": src, "wrap_test.go": wrapTest})
+	gentest.Generate(t, dir, "-log", "-metrics", "-retry", "-trace", "-type=Store", "-out=wrap.go")
+	gentest.Test(t, dir)
+}
@@ -0,0 +1,133 @@
+package gen
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"os"
+	"text/template"
+)
+
+// errFlags is returned by ParseFlags. The error itself has already been
+// reported by the flag package.
+var errFlags = errors.New("invalid flags")
+
+// ParseFlags parses args with fs, which must use flag.ContinueOnError.
+func ParseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return errFlags
+	}
+	return nil
+}
+
+// Main runs a generator with the command line arguments args, which do not
+// include the program name, and exits the program if it fails.
+func Main(run func(args []string) error, args []string) {
+	log.SetFlags(0)
+
+	if err := run(args); err == errFlags {
+		os.Exit(2)
+	} else if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Output is where a generator writes the generated code to.
+type Output struct {
+	// File is the name of the output file. If it is empty, the output is
+	// written to stdout.
+	File string
+
+	// Check makes Write report an error if File does not contain the output
+	// already, instead of writing it.
+	Check bool
+
+	// Template is the name of a file containing a template to use instead
+	// of the built-in one. The built-in associated templates can be used by
+	// it and are overridden by templates it defines.
+	Template string
+
+	// Lint makes Write check the code generated by Template for errors,
+	// instead of writing it. Errors are reported at the line of the template
+	// that generated the code.
+	Lint bool
+}
+
+// OutputFlags returns an Output configured by the -out, -check, -template
+// and -lint flags, which it defines in fs.
+func OutputFlags(fs *flag.FlagSet) *Output {
+	o := new(Output)
+	fs.StringVar(&o.File, "out", "", "Where to write the output (defaults to stdout)")
+	fs.BoolVar(&o.Check, "check", false, "Check that the output file is up to date, instead of writing it")
+	fs.StringVar(&o.Template, "template", "", "File containing a template to use instead of the built-in one")
+	fs.BoolVar(&o.Lint, "lint", false, "Check the code generated by -template for errors, instead of writing it")
+	return o
+}
+
+// Generate executes t with data and returns the formatted result.
+func Generate(t *template.Template, data interface{}) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := t.Execute(buf, data); err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
+
+// Write executes t with data, formats the result and writes it to o. The
+// output file is only created if the code could be generated successfully.
+func (o *Output) Write(t *template.Template, data interface{}) error {
+	if o.Lint && o.Template == "" {
+		return errors.New("-lint requires a template")
+	}
+	if o.Template != "" {
+		if o.Lint {
+			return o.lint(t, data)
+		}
+		src, err := ioutil.ReadFile(o.Template)
+		if err != nil {
+			return err
+		}
+		if t, err = o.custom(t, src); err != nil {
+			return err
+		}
+	}
+
+	output, err := Generate(t, data)
+	if err != nil && o.Template != "" {
+		// Report syntax errors at the line of the template, if possible.
+		if lerr := o.lint(t, data); lerr != nil {
+			return lerr
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return o.WriteBytes(output)
+}
+
+// WriteBytes writes output to o, as is.
+func (o *Output) WriteBytes(output []byte) error {
+	if o.Check {
+		if o.File == "" {
+			return errors.New("-check requires an output file")
+		}
+		old, err := ioutil.ReadFile(o.File)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(old, output) {
+			return fmt.Errorf("%s is not up to date", o.File)
+		}
+		return nil
+	}
+
+	if o.File == "" {
+		_, err := os.Stdout.Write(output)
+		return err
+	}
+	return ioutil.WriteFile(o.File, output, 0666)
+}
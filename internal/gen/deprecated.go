@@ -0,0 +1,124 @@
+package gen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"strings"
+)
+
+// Deprecations looks up the named types referred to by the type expression
+// typ and returns the deprecation notices of those that are deprecated, keyed
+// by their qualified names. A notice is the paragraph of the doc comment of a
+// type starting with "Deprecated: ", without that prefix.
+//
+// Unqualified types are looked up in the package in dir. Qualified ones are
+// looked up in the package with the import path paths[qualifier], or the
+// qualifier itself, if it is not in paths. The doc comments are read from the
+// source of the packages, as export data does not contain them.
+func Deprecations(dir, typ string, paths map[string]string) (map[string]string, error) {
+	e, err := parser.ParseExpr(typ)
+	if err != nil {
+		return nil, fmt.Errorf("invalid type %q: %v", typ, err)
+	}
+	type ref struct{ qual, name string }
+	var refs []ref
+	var visit func(n ast.Node) bool
+	visit = func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Field:
+			// Skip the names of fields and parameters.
+			ast.Inspect(n.Type, visit)
+			return false
+		case *ast.SelectorExpr:
+			if x, ok := n.X.(*ast.Ident); ok {
+				refs = append(refs, ref{x.Name, n.Sel.Name})
+			}
+			return false
+		case *ast.Ident:
+			if types.Universe.Lookup(n.Name) == nil {
+				refs = append(refs, ref{"", n.Name})
+			}
+		}
+		return true
+	}
+	ast.Inspect(e, visit)
+
+	m := make(map[string]string)
+	for _, r := range refs {
+		pdir := dir
+		if r.qual != "" {
+			path := r.qual
+			if p, ok := paths[r.qual]; ok {
+				path = p
+			}
+			bp, err := build.Import(path, dir, build.FindOnly)
+			if err != nil {
+				return nil, err
+			}
+			pdir = bp.Dir
+		}
+		doc, err := typeDoc(pdir, r.name)
+		if err != nil {
+			return nil, err
+		}
+		if notice := deprecation(doc); notice != "" {
+			name := r.name
+			if r.qual != "" {
+				name = r.qual + "." + name
+			}
+			m[name] = notice
+		}
+	}
+	return m, nil
+}
+
+// typeDoc returns the doc comment of the type name declared in the package in
+// dir, or "" if there is none.
+func typeDoc(dir, name string) (string, error) {
+	bp, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return "", err
+	}
+	fset := token.NewFileSet()
+	for _, fn := range bp.GoFiles {
+		f, err := parser.ParseFile(fset, filepath.Join(dir, fn), nil, parser.ParseComments)
+		if err != nil {
+			return "", err
+		}
+		for _, d := range f.Decls {
+			gd, ok := d.(*ast.GenDecl)
+			if !ok || gd.Tok != token.TYPE {
+				continue
+			}
+			for _, s := range gd.Specs {
+				ts := s.(*ast.TypeSpec)
+				if ts.Name.Name != name {
+					continue
+				}
+				if ts.Doc != nil {
+					return ts.Doc.Text(), nil
+				}
+				if len(gd.Specs) == 1 && gd.Doc != nil {
+					return gd.Doc.Text(), nil
+				}
+				return "", nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// deprecation returns the deprecation notice in the doc comment doc, or "".
+func deprecation(doc string) string {
+	for _, par := range strings.Split(doc, "\n\n") {
+		if strings.HasPrefix(par, "Deprecated: ") {
+			return strings.Join(strings.Fields(strings.TrimPrefix(par, "Deprecated: ")), " ")
+		}
+	}
+	return ""
+}
@@ -0,0 +1,318 @@
+// Package gen contains infrastructure shared by the code generators in
+// merovius.de/go-misc/cmd.
+package gen // import "merovius.de/go-misc/internal/gen"
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/types"
+	"strings"
+	"time"
+)
+
+// Type is a type to generate code for.
+type Type struct {
+	// Name is used to derive the names of generated identifiers.
+	Name string
+	// Type is the Go type expression.
+	Type string
+}
+
+// BuiltinTypes contains all builtin types and interface{}.
+var BuiltinTypes = []Type{
+	{Name: "Bool", Type: "bool"},
+	{Name: "Byte", Type: "byte"},
+	{Name: "Complex64", Type: "complex64"},
+	{Name: "Complex128", Type: "complex128"},
+	{Name: "Float32", Type: "float32"},
+	{Name: "Float64", Type: "float64"},
+	{Name: "Error", Type: "error"},
+	{Name: "Int", Type: "int"},
+	{Name: "Int8", Type: "int8"},
+	{Name: "Int16", Type: "int16"},
+	{Name: "Int32", Type: "int32"},
+	{Name: "Int64", Type: "int64"},
+	{Name: "Interface", Type: "interface{}"},
+	{Name: "Rune", Type: "rune"},
+	{Name: "String", Type: "string"},
+	{Name: "Uint", Type: "uint"},
+	{Name: "Uint8", Type: "uint8"},
+	{Name: "Uint16", Type: "uint16"},
+	{Name: "Uint32", Type: "uint32"},
+	{Name: "Uint64", Type: "uint64"},
+	{Name: "Uintptr", Type: "uintptr"},
+}
+
+// ParseTypes parses a list of alternating names and types, as given on the
+// command line. If args is empty, it returns BuiltinTypes.
+func ParseTypes(args []string) ([]Type, error) {
+	if len(args)%2 != 0 {
+		return nil, fmt.Errorf("odd number of arguments")
+	}
+	if len(args) == 0 {
+		return BuiltinTypes, nil
+	}
+
+	var ts []Type
+	for i := 0; i < len(args); i += 2 {
+		ts = append(ts, Type{Name: args[i], Type: args[i+1]})
+	}
+	return ts, nil
+}
+
+// Field is a parameter or result of a function.
+type Field struct {
+	// Name is a generated name for the field, a0, a1, … for parameters and
+	// r0, r1, … for results.
+	Name string
+	Type string
+}
+
+// Func is a parsed function signature.
+type Func struct {
+	Params   []Field
+	Results  []Field
+	Variadic bool
+}
+
+// ParseFunc parses a function signature, like "func(int, string) error".
+func ParseFunc(sig string) (*Func, error) {
+	e, err := parser.ParseExpr(sig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature %q: %v", sig, err)
+	}
+	ft, ok := e.(*ast.FuncType)
+	if !ok {
+		return nil, fmt.Errorf("not a func type: %s", sig)
+	}
+
+	f := new(Func)
+	for _, t := range expand(ft.Params) {
+		if el, ok := t.(*ast.Ellipsis); ok {
+			f.Variadic = true
+			t = &ast.ArrayType{Elt: el.Elt}
+		}
+		f.Params = append(f.Params, Field{fmt.Sprintf("a%d", len(f.Params)), types.ExprString(t)})
+	}
+	for _, t := range expand(ft.Results) {
+		f.Results = append(f.Results, Field{fmt.Sprintf("r%d", len(f.Results)), types.ExprString(t)})
+	}
+	return f, nil
+}
+
+// CheckUnsafe returns an error if the type expression typ refers to
+// unsafe.Pointer or to a cgo type. Caching values of such types is hazardous,
+// as they often point to memory that is not managed by the garbage collector
+// and might be freed while the value is still cached. Generators caching
+// values only allow them with the -allow-unsafe flag.
+func CheckUnsafe(typ string) error {
+	e, err := parser.ParseExpr(typ)
+	if err != nil {
+		return fmt.Errorf("invalid type %q: %v", typ, err)
+	}
+	var found ast.Expr
+	ast.Inspect(e, func(n ast.Node) bool {
+		if found != nil {
+			return false
+		}
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if x, ok := sel.X.(*ast.Ident); ok && (x.Name == "C" || x.Name == "unsafe" && sel.Sel.Name == "Pointer") {
+			found = sel
+		}
+		return false
+	})
+	if found != nil {
+		return fmt.Errorf("%s refers to %s, which might point to memory freed while the value is cached; pass -allow-unsafe to generate code anyway", typ, types.ExprString(found))
+	}
+	return nil
+}
+
+// Qualifiers returns the names of the packages referred to by the type
+// expression typ, in the order they appear.
+func Qualifiers(typ string) ([]string, error) {
+	e, err := parser.ParseExpr(typ)
+	if err != nil {
+		return nil, fmt.Errorf("invalid type %q: %v", typ, err)
+	}
+	var names []string
+	seen := make(map[string]bool)
+	ast.Inspect(e, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if x, ok := sel.X.(*ast.Ident); ok && !seen[x.Name] {
+			seen[x.Name] = true
+			names = append(names, x.Name)
+		}
+		return false
+	})
+	return names, nil
+}
+
+// expand returns the type of every single entry of l.
+func expand(l *ast.FieldList) []ast.Expr {
+	if l == nil {
+		return nil
+	}
+	var ts []ast.Expr
+	for _, f := range l.List {
+		n := len(f.Names)
+		if n == 0 {
+			n = 1
+		}
+		for i := 0; i < n; i++ {
+			ts = append(ts, f.Type)
+		}
+	}
+	return ts
+}
+
+// String returns f as a func type.
+func (f *Func) String() string {
+	if len(f.Results) == 0 {
+		return "func(" + f.ParamList() + ")"
+	}
+	return "func(" + f.ParamList() + ") " + f.ResultList()
+}
+
+// ParamList returns the parameter types of f, separated by commas.
+func (f *Func) ParamList() string {
+	buf := new(bytes.Buffer)
+	for i, p := range f.Params {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		if f.Variadic && i == len(f.Params)-1 {
+			buf.WriteString("..." + p.Type[2:])
+		} else {
+			buf.WriteString(p.Type)
+		}
+	}
+	return buf.String()
+}
+
+// ParamDecl returns the parameters of f, with their names, as used in a
+// function declaration.
+func (f *Func) ParamDecl() string {
+	buf := new(bytes.Buffer)
+	for i, p := range f.Params {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		if f.Variadic && i == len(f.Params)-1 {
+			buf.WriteString(p.Name + " ..." + p.Type[2:])
+		} else {
+			buf.WriteString(p.Name + " " + p.Type)
+		}
+	}
+	return buf.String()
+}
+
+// ResultDecl returns the results of f, with their names, as used in a
+// function declaration. It returns the empty string, if f has no results.
+func (f *Func) ResultDecl() string {
+	if len(f.Results) == 0 {
+		return ""
+	}
+	buf := new(bytes.Buffer)
+	buf.WriteString("(")
+	for i, r := range f.Results {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(r.Name + " " + r.Type)
+	}
+	buf.WriteString(")")
+	return buf.String()
+}
+
+// Args returns the names of the parameters of f, as used to pass them on to
+// a function of the same signature.
+func (f *Func) Args() string {
+	s := JoinNames("", f.Params)
+	if f.Variadic {
+		s += "..."
+	}
+	return s
+}
+
+// ResultList returns the result types of f, as used in a signature.
+func (f *Func) ResultList() string {
+	if len(f.Results) == 1 {
+		return f.Results[0].Type
+	}
+	return "(" + JoinTypes(f.Results) + ")"
+}
+
+// JoinTypes returns the types of fs, separated by commas.
+func JoinTypes(fs []Field) string {
+	buf := new(bytes.Buffer)
+	for i, f := range fs {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(f.Type)
+	}
+	return buf.String()
+}
+
+// JoinNames returns the names of fs, each with the given prefix, separated
+// by commas.
+func JoinNames(prefix string, fs []Field) string {
+	buf := new(bytes.Buffer)
+	for i, f := range fs {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(prefix + f.Name)
+	}
+	return buf.String()
+}
+
+// DurationLiteral returns d as a multiple of the largest unit dividing it,
+// e.g. "1500 * time.Millisecond".
+func DurationLiteral(d time.Duration) string {
+	units := []struct {
+		d    time.Duration
+		name string
+	}{
+		{time.Hour, "Hour"},
+		{time.Minute, "Minute"},
+		{time.Second, "Second"},
+		{time.Millisecond, "Millisecond"},
+		{time.Microsecond, "Microsecond"},
+	}
+	for _, u := range units {
+		if d%u.d == 0 {
+			return fmt.Sprintf("%d * time.%s", d/u.d, u.name)
+		}
+	}
+	return fmt.Sprintf("%d * time.Nanosecond", d)
+}
+
+// Comment returns text as a line comment, wrapped at 80 columns. Every
+// paragraph of text is a line.
+func Comment(text string) string {
+	var lines []string
+	for i, par := range strings.Split(text, "\n") {
+		if i > 0 {
+			lines = append(lines, "//")
+		}
+		line := "//"
+		for _, w := range strings.Fields(par) {
+			if len(line)+1+len(w) > 80 && line != "//" {
+				lines = append(lines, line)
+				line = "//"
+			}
+			line += " " + w
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,137 @@
+// Package gentest provides helpers for testing the generators.
+//
+// The generators keep their flags in package variables, so every run needs a
+// fresh process. The test binary of a generator runs it instead of the tests,
+// if started by Generate, which requires calling Main from TestMain:
+//
+//	func TestMain(m *testing.M) {
+//		gentest.Main(m, Run)
+//	}
+package gentest // import "merovius.de/go-misc/internal/gen/gentest"
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"merovius.de/go-misc/internal/gen"
+)
+
+var update = flag.Bool("update", false, "Update the golden files in testdata")
+
+// argsEnv is the environment variable, which makes the test binary run the
+// generator with its newline-separated arguments, instead of the tests.
+const argsEnv = "GENTEST_ARGS"
+
+// Main runs the tests of m, or the generator run, if the test binary was
+// started by Generate.
+func Main(m *testing.M, run func(args []string) error) {
+	if args, ok := os.LookupEnv(argsEnv); ok {
+		gen.Main(run, strings.Split(args, "\n"))
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// Dir returns a new directory containing files, which maps file names to
+// their contents. It is created in the testdata directory of the package
+// under test, so that the code in it can import packages of this module, and
+// removed when the test ends.
+func Dir(t *testing.T, files map[string]string) string {
+	t.Helper()
+	if err := os.MkdirAll("testdata", 0777); err != nil {
+		t.Fatal(err)
+	}
+	dir, err := os.MkdirTemp("testdata", "tmp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// Generate runs the generator with args in dir and returns its output. It
+// fails the test, if the generator fails.
+func Generate(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := run(dir, args)
+	if err != nil {
+		t.Fatalf("%s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return out
+}
+
+// Fail runs the generator with args in dir and returns its output. It fails
+// the test, unless the generator fails.
+func Fail(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := run(dir, args)
+	if err == nil {
+		t.Fatalf("%s succeeded, want error\n%s", strings.Join(args, " "), out)
+	}
+	return out
+}
+
+func run(dir string, args []string) (string, error) {
+	cmd := exec.Command(os.Args[0])
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), argsEnv+"="+strings.Join(args, "\n"))
+	out, err := cmd.CombinedOutput()
+	return string(out), err
+}
+
+// Golden fails the test, if the file name in dir differs from the golden file
+// testdata/golden. With -update, it writes the golden file instead.
+func Golden(t *testing.T, dir, name, golden string) {
+	t.Helper()
+	got, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden = filepath.Join("testdata", golden)
+	if *update {
+		if err := os.WriteFile(golden, got, 0666); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs from %s:\n%s", name, golden, got)
+	}
+}
+
+// Vet runs go vet on the package in dir, with the additional environment
+// variables env, e.g. GOARCH=386.
+func Vet(t *testing.T, dir string, env ...string) {
+	t.Helper()
+	goCmd(t, dir, env, "vet", ".")
+}
+
+// Test runs go test with the flags args on the package in dir.
+func Test(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	goCmd(t, dir, nil, append([]string{"test"}, append(args, ".")...)...)
+}
+
+func goCmd(t *testing.T, dir string, env []string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("go %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+}
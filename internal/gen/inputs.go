@@ -0,0 +1,217 @@
+package gen
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// InputsDirective is the prefix of the comment recording the Inputs of a
+// generated file.
+const InputsDirective = "//gen:inputs "
+
+// Inputs are the inputs a file was generated from. They are recorded in the
+// file, so that it can be detected when it is out of date, without running the
+// generator.
+type Inputs struct {
+	// Args are the command line arguments of the generator, without
+	// -check.
+	Args []string `json:"args"`
+
+	// Config is the name of a config file, relative to the directory of the
+	// generated file, and ConfigSHA256 the hash of its contents.
+	Config       string `json:"config,omitempty"`
+	ConfigSHA256 string `json:"configSHA256,omitempty"`
+
+	// Types are the type expressions code was generated for and
+	// TypesSHA256 the hash of the declarations of the types of the
+	// package they refer to.
+	Types       []string `json:"types,omitempty"`
+	TypesSHA256 string   `json:"typesSHA256,omitempty"`
+}
+
+// RecordInputs returns the Inputs of a file generated into dir. config is the
+// name of the config file, relative to the working directory, or "".
+func RecordInputs(dir string, args []string, config string, typs []string) (*Inputs, error) {
+	in := &Inputs{Types: typs}
+	for _, a := range args {
+		if a != "-check" && a != "--check" && a != "-check=true" && a != "--check=true" {
+			in.Args = append(in.Args, a)
+		}
+	}
+	if config != "" {
+		abs, err := filepath.Abs(config)
+		if err != nil {
+			return nil, err
+		}
+		absDir, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, err
+		}
+		if in.Config, err = filepath.Rel(absDir, abs); err != nil {
+			return nil, err
+		}
+		in.Config = filepath.ToSlash(in.Config)
+		if in.ConfigSHA256, err = FileHash(config); err != nil {
+			return nil, err
+		}
+	}
+	if len(typs) > 0 {
+		files, err := parseDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		if in.TypesSHA256, err = TypesHash(files, typs); err != nil {
+			return nil, err
+		}
+	}
+	return in, nil
+}
+
+// Comment returns the comment recording in.
+func (in *Inputs) Comment() string {
+	b, err := json.Marshal(in)
+	if err != nil {
+		panic(err)
+	}
+	return InputsDirective + string(b)
+}
+
+// ParseInputs parses the Inputs recorded in the comment c. It returns nil, if c
+// does not record Inputs.
+func ParseInputs(c string) (*Inputs, error) {
+	if !strings.HasPrefix(c, InputsDirective) {
+		return nil, nil
+	}
+	in := new(Inputs)
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(c, InputsDirective)), in); err != nil {
+		return nil, fmt.Errorf("invalid inputs %q: %v", c, err)
+	}
+	return in, nil
+}
+
+// Stale returns the reasons why a file generated into dir from in is out of
+// date, given the parsed files of its package.
+func (in *Inputs) Stale(dir string, files []*ast.File) ([]string, error) {
+	var reasons []string
+	if in.Config != "" {
+		h, err := FileHash(filepath.Join(dir, filepath.FromSlash(in.Config)))
+		if err != nil {
+			return nil, err
+		}
+		if h != in.ConfigSHA256 {
+			reasons = append(reasons, in.Config+" changed")
+		}
+	}
+	if len(in.Types) > 0 {
+		h, err := TypesHash(files, in.Types)
+		if err != nil {
+			return nil, err
+		}
+		if h != in.TypesSHA256 {
+			reasons = append(reasons, "declarations of the types changed")
+		}
+	}
+	return reasons, nil
+}
+
+// FileHash returns the hex encoded SHA-256 hash of the contents of the named
+// file.
+func FileHash(name string) (string, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:]), nil
+}
+
+// TypesHash returns the hex encoded SHA-256 hash of the declarations in files
+// of the types referred to by the type expressions types, directly or through
+// other such declarations. Only the types are part of the hash, not comments
+// or struct tags.
+func TypesHash(files []*ast.File, typs []string) (string, error) {
+	decls := make(map[string]*ast.TypeSpec)
+	for _, f := range files {
+		for _, d := range f.Decls {
+			gd, ok := d.(*ast.GenDecl)
+			if !ok || gd.Tok != token.TYPE {
+				continue
+			}
+			for _, s := range gd.Specs {
+				ts := s.(*ast.TypeSpec)
+				decls[ts.Name.Name] = ts
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	var visit func(n ast.Node) bool
+	visit = func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			// Types of other packages are not covered.
+			return false
+		case *ast.Ident:
+			if ts, ok := decls[n.Name]; ok && !seen[n.Name] {
+				seen[n.Name] = true
+				ast.Inspect(ts.Type, visit)
+			}
+		}
+		return true
+	}
+	for _, typ := range typs {
+		e, err := parser.ParseExpr(typ)
+		if err != nil {
+			return "", fmt.Errorf("invalid type %q: %v", typ, err)
+		}
+		ast.Inspect(e, visit)
+	}
+
+	names := make([]string, 0, len(seen))
+	for n := range seen {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	h := sha256.New()
+	for _, n := range names {
+		ts := decls[n]
+		if ts.Assign.IsValid() {
+			n += " ="
+		}
+		fmt.Fprintf(h, "%s %s\n", n, types.ExprString(ts.Type))
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// parseDir parses the Go files of the package in dir, for the current
+// platform.
+func parseDir(dir string) ([]*ast.File, error) {
+	bp, err := build.ImportDir(dir, 0)
+	if err != nil {
+		if _, ok := err.(*build.NoGoError); ok {
+			return nil, nil
+		}
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, name := range bp.GoFiles {
+		f, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
@@ -0,0 +1,139 @@
+package gen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// maxLintErrors is the maximum number of errors reported by Lint.
+const maxLintErrors = 10
+
+// custom returns t with its root template replaced by the one in o.Template.
+// The built-in associated templates are still available to the custom one
+// and are overridden by any it defines.
+func (o *Output) custom(t *template.Template, src []byte) (*template.Template, error) {
+	c, err := t.Clone()
+	if err != nil {
+		return nil, err
+	}
+	return c.New(filepath.Base(o.Template)).Parse(string(src))
+}
+
+// lint executes the custom template o.Template with data and parses and
+// type-checks the result, together with the rest of the package of the
+// output file. Errors in the generated code are reported at the line of the
+// template that produced it.
+func (o *Output) lint(t *template.Template, data interface{}) error {
+	src, err := ioutil.ReadFile(o.Template)
+	if err != nil {
+		return err
+	}
+	lt, err := o.custom(t, lineDirectives(o.Template, src))
+	if err != nil {
+		return err
+	}
+	buf := new(bytes.Buffer)
+	if err := lt.Execute(buf, data); err != nil {
+		return err
+	}
+
+	fset := token.NewFileSet()
+	name := o.File
+	if name == "" {
+		name = "generated.go"
+	}
+	f, err := parser.ParseFile(fset, name, buf, parser.AllErrors)
+	if err != nil {
+		if l, ok := err.(scanner.ErrorList); ok {
+			var errs []error
+			for _, e := range l {
+				errs = append(errs, e)
+			}
+			return lintErrors(errs)
+		}
+		return err
+	}
+
+	files, err := otherFiles(fset, name)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	cfg := &types.Config{
+		Importer: importer.ForCompiler(fset, "source", nil),
+		Error:    func(err error) { errs = append(errs, err) },
+	}
+	cfg.Check(f.Name.Name, fset, append(files, f), nil)
+	return lintErrors(errs)
+}
+
+// otherFiles parses the Go files, other than tests, in the directory of the
+// output file name, except name itself.
+func otherFiles(fset *token.FileSet, name string) ([]*ast.File, error) {
+	dir := filepath.Dir(name)
+	matches, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	var files []*ast.File
+	for _, m := range matches {
+		if filepath.Base(m) == filepath.Base(name) || strings.HasSuffix(m, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, m, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// lintErrors combines errs into a single error, or returns nil if errs is
+// empty.
+func lintErrors(errs []error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	var msgs []string
+	for _, e := range errs {
+		msgs = append(msgs, e.Error())
+	}
+	if len(msgs) > maxLintErrors {
+		msgs = append(msgs[:maxLintErrors], fmt.Sprintf("(and %d more errors)", len(msgs)-maxLintErrors))
+	}
+	return errors.New(strings.Join(msgs, "\n"))
+}
+
+// lineDirectives returns the template src with a /*line*/ comment at the
+// start of every line, so that positions in the generated code refer to the
+// template file name. Lines starting in an action or with a trim marker are
+// skipped, as a comment would change their meaning. The positions of code
+// generated by them are approximated by the preceding line.
+func lineDirectives(name string, src []byte) []byte {
+	var (
+		out      bytes.Buffer
+		inAction bool
+	)
+	for i, line := range strings.SplitAfter(string(src), "\n") {
+		if !inAction && !strings.HasPrefix(strings.TrimSpace(line), "{{-") {
+			fmt.Fprintf(&out, "/*line %s:%d:1*/", name, i+1)
+		}
+		out.WriteString(line)
+		if open, close := strings.LastIndex(line, "{{"), strings.LastIndex(line, "}}"); open >= 0 || close >= 0 {
+			inAction = open > close
+		}
+	}
+	return out.Bytes()
+}
@@ -0,0 +1,290 @@
+package gen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Package is a parsed and type-checked package.
+type Package struct {
+	Fset  *token.FileSet
+	Files []*ast.File
+	Types *types.Package
+	Info  *types.Info
+
+	// Errors contains the errors encountered while type-checking. They are
+	// not fatal, as generated code is often missing when generating.
+	Errors []error
+}
+
+// LoadPackage parses and type-checks the package in dir. Its dependencies are
+// resolved by the go command, run in dir, so they are found according to the
+// module or workspace dir belongs to, independent of the working directory.
+func LoadPackage(dir string) (*Package, error) {
+	// go/build can only determine the import path of absolute directories.
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	bp, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Package{
+		Fset: token.NewFileSet(),
+		Info: &types.Info{
+			Types: make(map[ast.Expr]types.TypeAndValue),
+			Defs:  make(map[*ast.Ident]types.Object),
+			Uses:  make(map[*ast.Ident]types.Object),
+		},
+	}
+	for _, name := range bp.GoFiles {
+		f, err := parser.ParseFile(p.Fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		p.Files = append(p.Files, f)
+	}
+
+	path, exports, err := listDeps(dir)
+	if err != nil {
+		return nil, err
+	}
+	lookup := func(path string) (io.ReadCloser, error) {
+		f, ok := exports[path]
+		if !ok {
+			return nil, fmt.Errorf("could not find export data of %q", path)
+		}
+		return os.Open(f)
+	}
+	cfg := &types.Config{
+		Importer: importer.ForCompiler(p.Fset, "gc", lookup),
+		Error:    func(err error) { p.Errors = append(p.Errors, err) },
+	}
+	p.Types, _ = cfg.Check(path, p.Fset, p.Files, p.Info)
+	return p, nil
+}
+
+// listDeps runs go list in dir and returns the import path of the package in
+// dir and the export data files of its dependencies, keyed by the import paths
+// they are imported as.
+func listDeps(dir string) (path string, exports map[string]string, err error) {
+	args := []string{"list", "-e", "-export", "-deps", "-json"}
+	if vendored(dir) && !strings.Contains(os.Getenv("GOFLAGS"), "-mod=") {
+		args = append(args, "-mod=vendor")
+	}
+	cmd := exec.Command("go", append(args, ".")...)
+	cmd.Dir = dir
+	stderr := new(bytes.Buffer)
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", nil, fmt.Errorf("go list: %v\n%s", err, stderr.Bytes())
+	}
+
+	type listed struct {
+		ImportPath string
+		Export     string
+		ImportMap  map[string]string
+	}
+	var pkgs []listed
+	dec := json.NewDecoder(bytes.NewReader(out))
+	for dec.More() {
+		var l listed
+		if err := dec.Decode(&l); err != nil {
+			return "", nil, fmt.Errorf("go list: %v", err)
+		}
+		pkgs = append(pkgs, l)
+	}
+	if len(pkgs) == 0 {
+		return "", nil, fmt.Errorf("go list: no package in %s", dir)
+	}
+
+	// With -deps, the package itself is listed last.
+	exports = make(map[string]string)
+	for _, l := range pkgs {
+		if l.Export != "" {
+			exports[l.ImportPath] = l.Export
+		}
+	}
+	root := pkgs[len(pkgs)-1]
+	for from, to := range root.ImportMap {
+		if f, ok := exports[to]; ok {
+			exports[from] = f
+		}
+	}
+	return root.ImportPath, exports, nil
+}
+
+// vendored returns whether the module or workspace containing dir has a
+// vendor directory. The go command only uses it by default if the go
+// version of the module is at least 1.14, but it is always preferred during
+// generation, as it works without network access.
+func vendored(dir string) bool {
+	if os.Getenv("GO111MODULE") == "off" {
+		return false
+	}
+	exists := func(name ...string) bool {
+		_, err := os.Stat(filepath.Join(name...))
+		return err == nil
+	}
+	switch w := os.Getenv("GOWORK"); w {
+	case "", "off":
+	default:
+		return exists(filepath.Dir(w), "vendor", "modules.txt")
+	}
+	mod := ""
+	for d := dir; ; d = filepath.Dir(d) {
+		if mod == "" && exists(d, "go.mod") {
+			mod = d
+		}
+		// A workspace is vendored at its root, ignoring the vendor
+		// directories of its modules.
+		if os.Getenv("GOWORK") != "off" && exists(d, "go.work") {
+			return exists(d, "vendor", "modules.txt")
+		}
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+	return mod != "" && exists(mod, "vendor", "modules.txt")
+}
+
+// Named returns the named type declared in p.
+func (p *Package) Named(name string) (*types.Named, error) {
+	tn, ok := p.Types.Scope().Lookup(name).(*types.TypeName)
+	if !ok {
+		return nil, fmt.Errorf("no type %s in package %s", name, p.Types.Name())
+	}
+	n, ok := tn.Type().(*types.Named)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a named type", name)
+	}
+	return n, nil
+}
+
+// Struct returns the named struct type declared in p. It returns an error, if
+// the types of any of its fields could not be resolved.
+func (p *Package) Struct(name string) (*types.Named, *types.Struct, error) {
+	n, err := p.Named(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	s, ok := n.Underlying().(*types.Struct)
+	if !ok {
+		return nil, nil, fmt.Errorf("%s is not a struct type", name)
+	}
+	for i := 0; i < s.NumFields(); i++ {
+		if f := s.Field(i); strings.Contains(f.Type().String(), "invalid type") {
+			return nil, nil, fmt.Errorf("could not resolve type of %s.%s: %v", name, f.Name(), p.firstError())
+		}
+	}
+	return n, s, nil
+}
+
+// Interface returns the named interface type declared in p.
+func (p *Package) Interface(name string) (*types.Named, *types.Interface, error) {
+	n, err := p.Named(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	it, ok := n.Underlying().(*types.Interface)
+	if !ok {
+		return nil, nil, fmt.Errorf("%s is not an interface type", name)
+	}
+	return n, it, nil
+}
+
+func (p *Package) firstError() error {
+	if len(p.Errors) == 0 {
+		return nil
+	}
+	return p.Errors[0]
+}
+
+// Imports tracks the packages referenced by generated code.
+type Imports struct {
+	pkg   *types.Package
+	paths map[string]string
+}
+
+// NewImports returns Imports for code generated into pkg.
+func NewImports(pkg *types.Package) *Imports {
+	return &Imports{pkg: pkg, paths: make(map[string]string)}
+}
+
+// Add records that the generated code uses the package with the given path
+// and name.
+func (im *Imports) Add(path, name string) {
+	im.paths[path] = name
+}
+
+// TypeString returns the representation of t in the generated code and
+// records all packages it references.
+func (im *Imports) TypeString(t types.Type) string {
+	return types.TypeString(t, im.qualify)
+}
+
+func (im *Imports) qualify(p *types.Package) string {
+	if p == im.pkg {
+		return ""
+	}
+	im.paths[p.Path()] = p.Name()
+	return p.Name()
+}
+
+// Func returns sig as a Func, recording all packages it references.
+func (im *Imports) Func(sig *types.Signature) *Func {
+	f := &Func{Variadic: sig.Variadic()}
+	for i := 0; i < sig.Params().Len(); i++ {
+		f.Params = append(f.Params, Field{fmt.Sprintf("a%d", i), im.TypeString(sig.Params().At(i).Type())})
+	}
+	for i := 0; i < sig.Results().Len(); i++ {
+		f.Results = append(f.Results, Field{fmt.Sprintf("r%d", i), im.TypeString(sig.Results().At(i).Type())})
+	}
+	return f
+}
+
+// List returns the sorted import specs of all recorded packages.
+func (im *Imports) List() []string {
+	var l []string
+	for path, name := range im.paths {
+		if name == filepath.Base(path) {
+			l = append(l, fmt.Sprintf("%q", path))
+		} else {
+			l = append(l, fmt.Sprintf("%s %q", name, path))
+		}
+	}
+	sort.Strings(l)
+	return l
+}
+
+// Exported returns name with its first letter in upper case.
+func Exported(name string) string {
+	if name == "" {
+		return ""
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// Unexported returns name with its first letter in lower case.
+func Unexported(name string) string {
+	if name == "" {
+		return ""
+	}
+	return strings.ToLower(name[:1]) + name[1:]
+}
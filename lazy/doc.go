@@ -1,9 +1,24 @@
 // Package lazy provides lazy evaluation for builtin types.
 //
+// The Versioned types additionally allow invalidating a value, to have it
+// re-evaluated on next use. Every evaluation is tagged with a generation, so
+// that concurrent refreshers only invalidate the value they actually saw.
+// Their TryGet method returns a value only if it is already evaluated. The
+// funcs returned by Int and the other constructors have no equivalent.
+//
+// The Env functions read and parse environment variables once, when they are
+// first used. File and ReloadingFile read files, e.g. certificates or
+// templates, and the Template functions parse text/template and html/template
+// templates. Regexp compiles regular expressions. Certificate and
+// GetCertificate load TLS certificates, reloading them when they are renewed.
+// DB opens a database, when it is first used, and HTTPClients creates HTTP
+// clients by key.
+//
 // Most code in this package is automatically generated with
 // merovius.de/go-misc/cmd/go-lazy.
 //
 // The API is still not finalized, I reserve the right to change things for now.
 package lazy // import "merovius.de/go-misc/lazy"
 
-//go:generate go-lazy -out lazy.go
+//go:generate go-lazy -tests=lazy_stress_test.go -out lazy.go
+//go:generate go-lazy -versioned -out versioned.go
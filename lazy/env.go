@@ -0,0 +1,77 @@
+package lazy
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// EnvString returns a lazily read environment variable. If it is unset or
+// empty, def is used.
+func EnvString(name, def string) func() string {
+	return String(func() string {
+		if s := os.Getenv(name); s != "" {
+			return s
+		}
+		return def
+	})
+}
+
+// EnvInt returns a lazily parsed integer environment variable, as accepted by
+// strconv.ParseInt with base 0. If it is unset or empty, def is used. If it
+// can not be parsed, using it panics, as for a program that is misconfigured.
+func EnvInt(name string, def int) func() int {
+	return Int(func() int {
+		s := os.Getenv(name)
+		if s == "" {
+			return def
+		}
+		i, err := strconv.ParseInt(s, 0, strconv.IntSize)
+		if err != nil {
+			panic(envError(name, err))
+		}
+		return int(i)
+	})
+}
+
+// EnvBool returns a lazily parsed boolean environment variable, as accepted by
+// strconv.ParseBool. If it is unset or empty, def is used. If it can not be
+// parsed, using it panics.
+func EnvBool(name string, def bool) func() bool {
+	return Bool(func() bool {
+		s := os.Getenv(name)
+		if s == "" {
+			return def
+		}
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			panic(envError(name, err))
+		}
+		return b
+	})
+}
+
+// EnvDuration returns a lazily parsed duration environment variable, as
+// accepted by time.ParseDuration. If it is unset or empty, def is used. If it
+// can not be parsed, using it panics.
+func EnvDuration(name string, def time.Duration) func() time.Duration {
+	d := Int64(func() int64 {
+		s := os.Getenv(name)
+		if s == "" {
+			return int64(def)
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			panic(envError(name, err))
+		}
+		return int64(d)
+	})
+	return func() time.Duration {
+		return time.Duration(d())
+	}
+}
+
+func envError(name string, err error) error {
+	return fmt.Errorf("invalid environment variable %s: %w", name, err)
+}
@@ -0,0 +1,48 @@
+package lazy
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEnv(t *testing.T) {
+	t.Setenv("LAZY_TEST_PORT", "0x50")
+	t.Setenv("LAZY_TEST_DEBUG", "true")
+	t.Setenv("LAZY_TEST_TIMEOUT", "")
+
+	port := EnvInt("LAZY_TEST_PORT", 8080)
+	debug := EnvBool("LAZY_TEST_DEBUG", false)
+	timeout := EnvDuration("LAZY_TEST_TIMEOUT", time.Second)
+	host := EnvString("LAZY_TEST_HOST", "localhost")
+
+	if got := port(); got != 80 {
+		t.Errorf("port() == %v, expected 80", got)
+	}
+	if got := debug(); !got {
+		t.Errorf("debug() == %v, expected true", got)
+	}
+	if got := timeout(); got != time.Second {
+		t.Errorf("timeout() == %v, expected %v", got, time.Second)
+	}
+	if got := host(); got != "localhost" {
+		t.Errorf("host() == %q, expected %q", got, "localhost")
+	}
+
+	// The values are only parsed once.
+	t.Setenv("LAZY_TEST_PORT", "81")
+	if got := port(); got != 80 {
+		t.Errorf("port() == %v after changing the environment, expected 80", got)
+	}
+}
+
+func TestEnvInvalid(t *testing.T) {
+	t.Setenv("LAZY_TEST_PORT", "http")
+	port := EnvInt("LAZY_TEST_PORT", 8080)
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("port() did not panic for invalid value")
+		}
+	}()
+	port()
+}
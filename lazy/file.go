@@ -0,0 +1,81 @@
+package lazy
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// File returns the lazily read contents of the named file. It is read once,
+// when first used. An error reading it is cached like the contents.
+func File(name string) func() ([]byte, error) {
+	return memo(func() ([]byte, error) {
+		return os.ReadFile(name)
+	})
+}
+
+// ReloadingFile is like File, but reads the file again, when its modification
+// time or size changed since it was last read. Every use stats the file. Errors
+// are not cached, so the file is read again by the next use.
+func ReloadingFile(name string) func() ([]byte, error) {
+	r := &reloader[[]byte]{
+		names: []string{name},
+		load: func() ([]byte, error) {
+			return os.ReadFile(name)
+		},
+	}
+	return r.get
+}
+
+// reloader calls load when first used and again, whenever the modification
+// time or size of one of the named files changed.
+type reloader[T any] struct {
+	names []string
+	load  func() (T, error)
+
+	mu     sync.Mutex
+	stamps []fileStamp
+	v      T
+	ok     bool
+}
+
+// fileStamp identifies a version of a file.
+type fileStamp struct {
+	mod  time.Time
+	size int64
+}
+
+func (r *reloader[T]) get() (T, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// The files are checked before loading them, so that a change while
+	// they are loaded is picked up by the next use.
+	var zero T
+	stamps := make([]fileStamp, len(r.names))
+	for i, n := range r.names {
+		fi, err := os.Stat(n)
+		if err != nil {
+			return zero, err
+		}
+		stamps[i] = fileStamp{fi.ModTime(), fi.Size()}
+	}
+	if r.ok && equalStamps(stamps, r.stamps) {
+		return r.v, nil
+	}
+	v, err := r.load()
+	if err != nil {
+		return zero, err
+	}
+	r.v, r.stamps, r.ok = v, stamps, true
+	return v, nil
+}
+
+func equalStamps(a, b []fileStamp) bool {
+	for i := range a {
+		if !a[i].mod.Equal(b[i].mod) || a[i].size != b[i].size {
+			return false
+		}
+	}
+	return true
+}
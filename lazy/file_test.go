@@ -0,0 +1,45 @@
+package lazy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "f")
+	f := File(name)
+	r := ReloadingFile(name)
+
+	if _, err := r(); err == nil {
+		t.Fatalf("r() succeeded for missing file")
+	}
+	write(t, name, "a", time.Unix(1, 0))
+	if b, err := r(); err != nil || string(b) != "a" {
+		t.Fatalf("r() == %q, %v, expected %q, <nil>", b, err, "a")
+	}
+	if b, err := f(); err != nil || string(b) != "a" {
+		t.Fatalf("f() == %q, %v, expected %q, <nil>", b, err, "a")
+	}
+
+	write(t, name, "b", time.Unix(2, 0))
+	if b, err := r(); err != nil || string(b) != "b" {
+		t.Errorf("r() == %q, %v after change, expected %q, <nil>", b, err, "b")
+	}
+	if b, err := f(); err != nil || string(b) != "a" {
+		t.Errorf("f() == %q, %v after change, expected %q, <nil>", b, err, "a")
+	}
+}
+
+// write writes s to the named file and sets its modification time to mod, so
+// that changes are seen regardless of the resolution of the file system.
+func write(t *testing.T, name, s string, mod time.Time) {
+	t.Helper()
+	if err := os.WriteFile(name, []byte(s), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(name, mod, mod); err != nil {
+		t.Fatal(err)
+	}
+}
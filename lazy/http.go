@@ -0,0 +1,81 @@
+package lazy
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HTTPClients are HTTP clients created lazily by key, e.g. per host or per
+// profile of settings, and shared by all uses of the key. It is safe for
+// concurrent use.
+type HTTPClients[K comparable] struct {
+	new  func(K) *http.Client
+	idle time.Duration
+
+	mu      sync.Mutex
+	clients map[K]*httpClient
+}
+
+type httpClient struct {
+	c     *http.Client
+	used  time.Time
+	timer *time.Timer
+}
+
+// NewHTTPClients returns HTTPClients, which are created by calling newClient
+// with the key, when it is first used. If idle is positive, clients not used
+// for that long are dropped and their idle connections closed. They are
+// created again, when the key is used again.
+func NewHTTPClients[K comparable](newClient func(K) *http.Client, idle time.Duration) *HTTPClients[K] {
+	return &HTTPClients[K]{new: newClient, idle: idle, clients: make(map[K]*httpClient)}
+}
+
+// Get returns the client for k, creating it if necessary.
+func (cs *HTTPClients[K]) Get(k K) *http.Client {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	c := cs.clients[k]
+	if c == nil {
+		// The client is created under the lock, so it is only created once.
+		// This should be cheap, as connections are only made when it is
+		// used.
+		c = &httpClient{c: cs.new(k)}
+		if cs.idle > 0 {
+			c.timer = time.AfterFunc(cs.idle, func() { cs.expire(k, c) })
+		}
+		cs.clients[k] = c
+	}
+	c.used = time.Now()
+	return c.c
+}
+
+// expire drops c, the client of k, if it was not used for the idle timeout.
+// Otherwise, it checks again, when it would time out.
+func (cs *HTTPClients[K]) expire(k K, c *httpClient) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if cs.clients[k] != c {
+		return
+	}
+	if d := cs.idle - time.Since(c.used); d > 0 {
+		c.timer.Reset(d)
+		return
+	}
+	delete(cs.clients, k)
+	c.c.CloseIdleConnections()
+}
+
+// Close drops all clients and closes their idle connections. Clients returned
+// by Get can still be used, but HTTPClients creates new ones afterwards.
+func (cs *HTTPClients[K]) Close() {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	for k, c := range cs.clients {
+		if c.timer != nil {
+			c.timer.Stop()
+		}
+		c.c.CloseIdleConnections()
+		delete(cs.clients, k)
+	}
+}
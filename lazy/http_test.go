@@ -0,0 +1,32 @@
+package lazy
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestHTTPClients(t *testing.T) {
+	created := make(map[string]int)
+	cs := NewHTTPClients(func(host string) *http.Client {
+		created[host]++
+		return &http.Client{Timeout: time.Second}
+	}, 10*time.Millisecond)
+	defer cs.Close()
+
+	a := cs.Get("a")
+	if cs.Get("a") != a {
+		t.Errorf("Get returned different clients for the same key")
+	}
+	if cs.Get("b") == a {
+		t.Errorf("Get returned the same client for different keys")
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if cs.Get("a") == a {
+		t.Errorf("Get returned the same client after the idle timeout")
+	}
+	if created["a"] != 2 || created["b"] != 1 {
+		t.Errorf("created %v clients, expected map[a:2 b:1]", created)
+	}
+}
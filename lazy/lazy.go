@@ -25,7 +25,7 @@ func (v *lazyBool) Get() bool {
 
 	if v.o == 0 {
 		v.v = v.f()
-		v.o = 1
+		atomic.StoreUint32(&v.o, 1)
 		v.f = nil
 	}
 	return v.v
@@ -33,6 +33,11 @@ func (v *lazyBool) Get() bool {
 
 // Bool provides lazy evaluation for bool. f is called exactly
 // once, when the result is first used.
+//
+// It is safe for concurrent use. Once it is evaluated, using it only needs an
+// atomic load. If f panics, the panic is propagated and f is called again by
+// the next use. The result is never recomputed. Lazy values can not fail, so
+// errors returned as part of it are cached like any other value.
 func Bool(f func() bool) func() bool {
 	return (&lazyBool{f: f}).Get
 }
@@ -55,7 +60,7 @@ func (v *lazyByte) Get() byte {
 
 	if v.o == 0 {
 		v.v = v.f()
-		v.o = 1
+		atomic.StoreUint32(&v.o, 1)
 		v.f = nil
 	}
 	return v.v
@@ -63,6 +68,11 @@ func (v *lazyByte) Get() byte {
 
 // Byte provides lazy evaluation for byte. f is called exactly
 // once, when the result is first used.
+//
+// It is safe for concurrent use. Once it is evaluated, using it only needs an
+// atomic load. If f panics, the panic is propagated and f is called again by
+// the next use. The result is never recomputed. Lazy values can not fail, so
+// errors returned as part of it are cached like any other value.
 func Byte(f func() byte) func() byte {
 	return (&lazyByte{f: f}).Get
 }
@@ -85,7 +95,7 @@ func (v *lazyComplex64) Get() complex64 {
 
 	if v.o == 0 {
 		v.v = v.f()
-		v.o = 1
+		atomic.StoreUint32(&v.o, 1)
 		v.f = nil
 	}
 	return v.v
@@ -93,6 +103,11 @@ func (v *lazyComplex64) Get() complex64 {
 
 // Complex64 provides lazy evaluation for complex64. f is called exactly
 // once, when the result is first used.
+//
+// It is safe for concurrent use. Once it is evaluated, using it only needs an
+// atomic load. If f panics, the panic is propagated and f is called again by
+// the next use. The result is never recomputed. Lazy values can not fail, so
+// errors returned as part of it are cached like any other value.
 func Complex64(f func() complex64) func() complex64 {
 	return (&lazyComplex64{f: f}).Get
 }
@@ -115,7 +130,7 @@ func (v *lazyComplex128) Get() complex128 {
 
 	if v.o == 0 {
 		v.v = v.f()
-		v.o = 1
+		atomic.StoreUint32(&v.o, 1)
 		v.f = nil
 	}
 	return v.v
@@ -123,6 +138,11 @@ func (v *lazyComplex128) Get() complex128 {
 
 // Complex128 provides lazy evaluation for complex128. f is called exactly
 // once, when the result is first used.
+//
+// It is safe for concurrent use. Once it is evaluated, using it only needs an
+// atomic load. If f panics, the panic is propagated and f is called again by
+// the next use. The result is never recomputed. Lazy values can not fail, so
+// errors returned as part of it are cached like any other value.
 func Complex128(f func() complex128) func() complex128 {
 	return (&lazyComplex128{f: f}).Get
 }
@@ -145,7 +165,7 @@ func (v *lazyFloat32) Get() float32 {
 
 	if v.o == 0 {
 		v.v = v.f()
-		v.o = 1
+		atomic.StoreUint32(&v.o, 1)
 		v.f = nil
 	}
 	return v.v
@@ -153,6 +173,11 @@ func (v *lazyFloat32) Get() float32 {
 
 // Float32 provides lazy evaluation for float32. f is called exactly
 // once, when the result is first used.
+//
+// It is safe for concurrent use. Once it is evaluated, using it only needs an
+// atomic load. If f panics, the panic is propagated and f is called again by
+// the next use. The result is never recomputed. Lazy values can not fail, so
+// errors returned as part of it are cached like any other value.
 func Float32(f func() float32) func() float32 {
 	return (&lazyFloat32{f: f}).Get
 }
@@ -175,7 +200,7 @@ func (v *lazyFloat64) Get() float64 {
 
 	if v.o == 0 {
 		v.v = v.f()
-		v.o = 1
+		atomic.StoreUint32(&v.o, 1)
 		v.f = nil
 	}
 	return v.v
@@ -183,6 +208,11 @@ func (v *lazyFloat64) Get() float64 {
 
 // Float64 provides lazy evaluation for float64. f is called exactly
 // once, when the result is first used.
+//
+// It is safe for concurrent use. Once it is evaluated, using it only needs an
+// atomic load. If f panics, the panic is propagated and f is called again by
+// the next use. The result is never recomputed. Lazy values can not fail, so
+// errors returned as part of it are cached like any other value.
 func Float64(f func() float64) func() float64 {
 	return (&lazyFloat64{f: f}).Get
 }
@@ -205,7 +235,7 @@ func (v *lazyError) Get() error {
 
 	if v.o == 0 {
 		v.v = v.f()
-		v.o = 1
+		atomic.StoreUint32(&v.o, 1)
 		v.f = nil
 	}
 	return v.v
@@ -213,6 +243,11 @@ func (v *lazyError) Get() error {
 
 // Error provides lazy evaluation for error. f is called exactly
 // once, when the result is first used.
+//
+// It is safe for concurrent use. Once it is evaluated, using it only needs an
+// atomic load. If f panics, the panic is propagated and f is called again by
+// the next use. The result is never recomputed. Lazy values can not fail, so
+// errors returned as part of it are cached like any other value.
 func Error(f func() error) func() error {
 	return (&lazyError{f: f}).Get
 }
@@ -235,7 +270,7 @@ func (v *lazyInt) Get() int {
 
 	if v.o == 0 {
 		v.v = v.f()
-		v.o = 1
+		atomic.StoreUint32(&v.o, 1)
 		v.f = nil
 	}
 	return v.v
@@ -243,6 +278,11 @@ func (v *lazyInt) Get() int {
 
 // Int provides lazy evaluation for int. f is called exactly
 // once, when the result is first used.
+//
+// It is safe for concurrent use. Once it is evaluated, using it only needs an
+// atomic load. If f panics, the panic is propagated and f is called again by
+// the next use. The result is never recomputed. Lazy values can not fail, so
+// errors returned as part of it are cached like any other value.
 func Int(f func() int) func() int {
 	return (&lazyInt{f: f}).Get
 }
@@ -265,7 +305,7 @@ func (v *lazyInt8) Get() int8 {
 
 	if v.o == 0 {
 		v.v = v.f()
-		v.o = 1
+		atomic.StoreUint32(&v.o, 1)
 		v.f = nil
 	}
 	return v.v
@@ -273,6 +313,11 @@ func (v *lazyInt8) Get() int8 {
 
 // Int8 provides lazy evaluation for int8. f is called exactly
 // once, when the result is first used.
+//
+// It is safe for concurrent use. Once it is evaluated, using it only needs an
+// atomic load. If f panics, the panic is propagated and f is called again by
+// the next use. The result is never recomputed. Lazy values can not fail, so
+// errors returned as part of it are cached like any other value.
 func Int8(f func() int8) func() int8 {
 	return (&lazyInt8{f: f}).Get
 }
@@ -295,7 +340,7 @@ func (v *lazyInt16) Get() int16 {
 
 	if v.o == 0 {
 		v.v = v.f()
-		v.o = 1
+		atomic.StoreUint32(&v.o, 1)
 		v.f = nil
 	}
 	return v.v
@@ -303,6 +348,11 @@ func (v *lazyInt16) Get() int16 {
 
 // Int16 provides lazy evaluation for int16. f is called exactly
 // once, when the result is first used.
+//
+// It is safe for concurrent use. Once it is evaluated, using it only needs an
+// atomic load. If f panics, the panic is propagated and f is called again by
+// the next use. The result is never recomputed. Lazy values can not fail, so
+// errors returned as part of it are cached like any other value.
 func Int16(f func() int16) func() int16 {
 	return (&lazyInt16{f: f}).Get
 }
@@ -325,7 +375,7 @@ func (v *lazyInt32) Get() int32 {
 
 	if v.o == 0 {
 		v.v = v.f()
-		v.o = 1
+		atomic.StoreUint32(&v.o, 1)
 		v.f = nil
 	}
 	return v.v
@@ -333,6 +383,11 @@ func (v *lazyInt32) Get() int32 {
 
 // Int32 provides lazy evaluation for int32. f is called exactly
 // once, when the result is first used.
+//
+// It is safe for concurrent use. Once it is evaluated, using it only needs an
+// atomic load. If f panics, the panic is propagated and f is called again by
+// the next use. The result is never recomputed. Lazy values can not fail, so
+// errors returned as part of it are cached like any other value.
 func Int32(f func() int32) func() int32 {
 	return (&lazyInt32{f: f}).Get
 }
@@ -355,7 +410,7 @@ func (v *lazyInt64) Get() int64 {
 
 	if v.o == 0 {
 		v.v = v.f()
-		v.o = 1
+		atomic.StoreUint32(&v.o, 1)
 		v.f = nil
 	}
 	return v.v
@@ -363,6 +418,11 @@ func (v *lazyInt64) Get() int64 {
 
 // Int64 provides lazy evaluation for int64. f is called exactly
 // once, when the result is first used.
+//
+// It is safe for concurrent use. Once it is evaluated, using it only needs an
+// atomic load. If f panics, the panic is propagated and f is called again by
+// the next use. The result is never recomputed. Lazy values can not fail, so
+// errors returned as part of it are cached like any other value.
 func Int64(f func() int64) func() int64 {
 	return (&lazyInt64{f: f}).Get
 }
@@ -385,7 +445,7 @@ func (v *lazyInterface) Get() interface{} {
 
 	if v.o == 0 {
 		v.v = v.f()
-		v.o = 1
+		atomic.StoreUint32(&v.o, 1)
 		v.f = nil
 	}
 	return v.v
@@ -393,6 +453,11 @@ func (v *lazyInterface) Get() interface{} {
 
 // Interface provides lazy evaluation for interface{}. f is called exactly
 // once, when the result is first used.
+//
+// It is safe for concurrent use. Once it is evaluated, using it only needs an
+// atomic load. If f panics, the panic is propagated and f is called again by
+// the next use. The result is never recomputed. Lazy values can not fail, so
+// errors returned as part of it are cached like any other value.
 func Interface(f func() interface{}) func() interface{} {
 	return (&lazyInterface{f: f}).Get
 }
@@ -415,7 +480,7 @@ func (v *lazyRune) Get() rune {
 
 	if v.o == 0 {
 		v.v = v.f()
-		v.o = 1
+		atomic.StoreUint32(&v.o, 1)
 		v.f = nil
 	}
 	return v.v
@@ -423,6 +488,11 @@ func (v *lazyRune) Get() rune {
 
 // Rune provides lazy evaluation for rune. f is called exactly
 // once, when the result is first used.
+//
+// It is safe for concurrent use. Once it is evaluated, using it only needs an
+// atomic load. If f panics, the panic is propagated and f is called again by
+// the next use. The result is never recomputed. Lazy values can not fail, so
+// errors returned as part of it are cached like any other value.
 func Rune(f func() rune) func() rune {
 	return (&lazyRune{f: f}).Get
 }
@@ -445,7 +515,7 @@ func (v *lazyString) Get() string {
 
 	if v.o == 0 {
 		v.v = v.f()
-		v.o = 1
+		atomic.StoreUint32(&v.o, 1)
 		v.f = nil
 	}
 	return v.v
@@ -453,6 +523,11 @@ func (v *lazyString) Get() string {
 
 // String provides lazy evaluation for string. f is called exactly
 // once, when the result is first used.
+//
+// It is safe for concurrent use. Once it is evaluated, using it only needs an
+// atomic load. If f panics, the panic is propagated and f is called again by
+// the next use. The result is never recomputed. Lazy values can not fail, so
+// errors returned as part of it are cached like any other value.
 func String(f func() string) func() string {
 	return (&lazyString{f: f}).Get
 }
@@ -475,7 +550,7 @@ func (v *lazyUint) Get() uint {
 
 	if v.o == 0 {
 		v.v = v.f()
-		v.o = 1
+		atomic.StoreUint32(&v.o, 1)
 		v.f = nil
 	}
 	return v.v
@@ -483,6 +558,11 @@ func (v *lazyUint) Get() uint {
 
 // Uint provides lazy evaluation for uint. f is called exactly
 // once, when the result is first used.
+//
+// It is safe for concurrent use. Once it is evaluated, using it only needs an
+// atomic load. If f panics, the panic is propagated and f is called again by
+// the next use. The result is never recomputed. Lazy values can not fail, so
+// errors returned as part of it are cached like any other value.
 func Uint(f func() uint) func() uint {
 	return (&lazyUint{f: f}).Get
 }
@@ -505,7 +585,7 @@ func (v *lazyUint8) Get() uint8 {
 
 	if v.o == 0 {
 		v.v = v.f()
-		v.o = 1
+		atomic.StoreUint32(&v.o, 1)
 		v.f = nil
 	}
 	return v.v
@@ -513,6 +593,11 @@ func (v *lazyUint8) Get() uint8 {
 
 // Uint8 provides lazy evaluation for uint8. f is called exactly
 // once, when the result is first used.
+//
+// It is safe for concurrent use. Once it is evaluated, using it only needs an
+// atomic load. If f panics, the panic is propagated and f is called again by
+// the next use. The result is never recomputed. Lazy values can not fail, so
+// errors returned as part of it are cached like any other value.
 func Uint8(f func() uint8) func() uint8 {
 	return (&lazyUint8{f: f}).Get
 }
@@ -535,7 +620,7 @@ func (v *lazyUint16) Get() uint16 {
 
 	if v.o == 0 {
 		v.v = v.f()
-		v.o = 1
+		atomic.StoreUint32(&v.o, 1)
 		v.f = nil
 	}
 	return v.v
@@ -543,6 +628,11 @@ func (v *lazyUint16) Get() uint16 {
 
 // Uint16 provides lazy evaluation for uint16. f is called exactly
 // once, when the result is first used.
+//
+// It is safe for concurrent use. Once it is evaluated, using it only needs an
+// atomic load. If f panics, the panic is propagated and f is called again by
+// the next use. The result is never recomputed. Lazy values can not fail, so
+// errors returned as part of it are cached like any other value.
 func Uint16(f func() uint16) func() uint16 {
 	return (&lazyUint16{f: f}).Get
 }
@@ -565,7 +655,7 @@ func (v *lazyUint32) Get() uint32 {
 
 	if v.o == 0 {
 		v.v = v.f()
-		v.o = 1
+		atomic.StoreUint32(&v.o, 1)
 		v.f = nil
 	}
 	return v.v
@@ -573,6 +663,11 @@ func (v *lazyUint32) Get() uint32 {
 
 // Uint32 provides lazy evaluation for uint32. f is called exactly
 // once, when the result is first used.
+//
+// It is safe for concurrent use. Once it is evaluated, using it only needs an
+// atomic load. If f panics, the panic is propagated and f is called again by
+// the next use. The result is never recomputed. Lazy values can not fail, so
+// errors returned as part of it are cached like any other value.
 func Uint32(f func() uint32) func() uint32 {
 	return (&lazyUint32{f: f}).Get
 }
@@ -595,7 +690,7 @@ func (v *lazyUint64) Get() uint64 {
 
 	if v.o == 0 {
 		v.v = v.f()
-		v.o = 1
+		atomic.StoreUint32(&v.o, 1)
 		v.f = nil
 	}
 	return v.v
@@ -603,6 +698,11 @@ func (v *lazyUint64) Get() uint64 {
 
 // Uint64 provides lazy evaluation for uint64. f is called exactly
 // once, when the result is first used.
+//
+// It is safe for concurrent use. Once it is evaluated, using it only needs an
+// atomic load. If f panics, the panic is propagated and f is called again by
+// the next use. The result is never recomputed. Lazy values can not fail, so
+// errors returned as part of it are cached like any other value.
 func Uint64(f func() uint64) func() uint64 {
 	return (&lazyUint64{f: f}).Get
 }
@@ -625,7 +725,7 @@ func (v *lazyUintptr) Get() uintptr {
 
 	if v.o == 0 {
 		v.v = v.f()
-		v.o = 1
+		atomic.StoreUint32(&v.o, 1)
 		v.f = nil
 	}
 	return v.v
@@ -633,6 +733,11 @@ func (v *lazyUintptr) Get() uintptr {
 
 // Uintptr provides lazy evaluation for uintptr. f is called exactly
 // once, when the result is first used.
+//
+// It is safe for concurrent use. Once it is evaluated, using it only needs an
+// atomic load. If f panics, the panic is propagated and f is called again by
+// the next use. The result is never recomputed. Lazy values can not fail, so
+// errors returned as part of it are cached like any other value.
 func Uintptr(f func() uintptr) func() uintptr {
 	return (&lazyUintptr{f: f}).Get
 }
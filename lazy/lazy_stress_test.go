@@ -0,0 +1,367 @@
+// This file is automatically generated by merovius.de/go-misc/cmd/go-lazy.
+
+package lazy
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// lazyTestProcs are the values of GOMAXPROCS the tests run with.
+var lazyTestProcs = []int{1, 2, 4, 8}
+
+// lazyStress calls the func returned by newGet from 8 goroutines at
+// once, 100 times for every value in lazyTestProcs. newGet is passed a
+// counter, which must be incremented by every evaluation. The test fails
+// unless there is exactly one.
+func lazyStress(t *testing.T, newGet func(n *int32) func()) {
+	for _, procs := range lazyTestProcs {
+		prev := runtime.GOMAXPROCS(procs)
+		for i := 0; i < 100; i++ {
+			var n int32
+			get := newGet(&n)
+			start := make(chan struct{})
+			var wg sync.WaitGroup
+			for j := 0; j < 8; j++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					<-start
+					get()
+				}()
+			}
+			close(start)
+			wg.Wait()
+			if n := atomic.LoadInt32(&n); n != 1 {
+				runtime.GOMAXPROCS(prev)
+				t.Fatalf("GOMAXPROCS=%d: evaluated %d times, want 1", procs, n)
+			}
+		}
+		runtime.GOMAXPROCS(prev)
+	}
+}
+
+// lazyNoAllocs fails the test, if get allocates after its first call.
+func lazyNoAllocs(t *testing.T, get func()) {
+	get()
+	if n := testing.AllocsPerRun(100, get); n != 0 {
+		t.Errorf("Get allocates %v times after evaluation, want 0", n)
+	}
+}
+
+func TestBoolStress(t *testing.T) {
+	lazyStress(t, func(n *int32) func() {
+		f := Bool(func() (v bool) {
+			atomic.AddInt32(n, 1)
+			return v
+		})
+		return func() { f() }
+	})
+}
+
+func TestBoolAllocs(t *testing.T) {
+	f := Bool(func() (v bool) { return v })
+	lazyNoAllocs(t, func() { f() })
+}
+
+func TestByteStress(t *testing.T) {
+	lazyStress(t, func(n *int32) func() {
+		f := Byte(func() (v byte) {
+			atomic.AddInt32(n, 1)
+			return v
+		})
+		return func() { f() }
+	})
+}
+
+func TestByteAllocs(t *testing.T) {
+	f := Byte(func() (v byte) { return v })
+	lazyNoAllocs(t, func() { f() })
+}
+
+func TestComplex64Stress(t *testing.T) {
+	lazyStress(t, func(n *int32) func() {
+		f := Complex64(func() (v complex64) {
+			atomic.AddInt32(n, 1)
+			return v
+		})
+		return func() { f() }
+	})
+}
+
+func TestComplex64Allocs(t *testing.T) {
+	f := Complex64(func() (v complex64) { return v })
+	lazyNoAllocs(t, func() { f() })
+}
+
+func TestComplex128Stress(t *testing.T) {
+	lazyStress(t, func(n *int32) func() {
+		f := Complex128(func() (v complex128) {
+			atomic.AddInt32(n, 1)
+			return v
+		})
+		return func() { f() }
+	})
+}
+
+func TestComplex128Allocs(t *testing.T) {
+	f := Complex128(func() (v complex128) { return v })
+	lazyNoAllocs(t, func() { f() })
+}
+
+func TestFloat32Stress(t *testing.T) {
+	lazyStress(t, func(n *int32) func() {
+		f := Float32(func() (v float32) {
+			atomic.AddInt32(n, 1)
+			return v
+		})
+		return func() { f() }
+	})
+}
+
+func TestFloat32Allocs(t *testing.T) {
+	f := Float32(func() (v float32) { return v })
+	lazyNoAllocs(t, func() { f() })
+}
+
+func TestFloat64Stress(t *testing.T) {
+	lazyStress(t, func(n *int32) func() {
+		f := Float64(func() (v float64) {
+			atomic.AddInt32(n, 1)
+			return v
+		})
+		return func() { f() }
+	})
+}
+
+func TestFloat64Allocs(t *testing.T) {
+	f := Float64(func() (v float64) { return v })
+	lazyNoAllocs(t, func() { f() })
+}
+
+func TestErrorStress(t *testing.T) {
+	lazyStress(t, func(n *int32) func() {
+		f := Error(func() (v error) {
+			atomic.AddInt32(n, 1)
+			return v
+		})
+		return func() { f() }
+	})
+}
+
+func TestErrorAllocs(t *testing.T) {
+	f := Error(func() (v error) { return v })
+	lazyNoAllocs(t, func() { f() })
+}
+
+func TestIntStress(t *testing.T) {
+	lazyStress(t, func(n *int32) func() {
+		f := Int(func() (v int) {
+			atomic.AddInt32(n, 1)
+			return v
+		})
+		return func() { f() }
+	})
+}
+
+func TestIntAllocs(t *testing.T) {
+	f := Int(func() (v int) { return v })
+	lazyNoAllocs(t, func() { f() })
+}
+
+func TestInt8Stress(t *testing.T) {
+	lazyStress(t, func(n *int32) func() {
+		f := Int8(func() (v int8) {
+			atomic.AddInt32(n, 1)
+			return v
+		})
+		return func() { f() }
+	})
+}
+
+func TestInt8Allocs(t *testing.T) {
+	f := Int8(func() (v int8) { return v })
+	lazyNoAllocs(t, func() { f() })
+}
+
+func TestInt16Stress(t *testing.T) {
+	lazyStress(t, func(n *int32) func() {
+		f := Int16(func() (v int16) {
+			atomic.AddInt32(n, 1)
+			return v
+		})
+		return func() { f() }
+	})
+}
+
+func TestInt16Allocs(t *testing.T) {
+	f := Int16(func() (v int16) { return v })
+	lazyNoAllocs(t, func() { f() })
+}
+
+func TestInt32Stress(t *testing.T) {
+	lazyStress(t, func(n *int32) func() {
+		f := Int32(func() (v int32) {
+			atomic.AddInt32(n, 1)
+			return v
+		})
+		return func() { f() }
+	})
+}
+
+func TestInt32Allocs(t *testing.T) {
+	f := Int32(func() (v int32) { return v })
+	lazyNoAllocs(t, func() { f() })
+}
+
+func TestInt64Stress(t *testing.T) {
+	lazyStress(t, func(n *int32) func() {
+		f := Int64(func() (v int64) {
+			atomic.AddInt32(n, 1)
+			return v
+		})
+		return func() { f() }
+	})
+}
+
+func TestInt64Allocs(t *testing.T) {
+	f := Int64(func() (v int64) { return v })
+	lazyNoAllocs(t, func() { f() })
+}
+
+func TestInterfaceStress(t *testing.T) {
+	lazyStress(t, func(n *int32) func() {
+		f := Interface(func() (v interface{}) {
+			atomic.AddInt32(n, 1)
+			return v
+		})
+		return func() { f() }
+	})
+}
+
+func TestInterfaceAllocs(t *testing.T) {
+	f := Interface(func() (v interface{}) { return v })
+	lazyNoAllocs(t, func() { f() })
+}
+
+func TestRuneStress(t *testing.T) {
+	lazyStress(t, func(n *int32) func() {
+		f := Rune(func() (v rune) {
+			atomic.AddInt32(n, 1)
+			return v
+		})
+		return func() { f() }
+	})
+}
+
+func TestRuneAllocs(t *testing.T) {
+	f := Rune(func() (v rune) { return v })
+	lazyNoAllocs(t, func() { f() })
+}
+
+func TestStringStress(t *testing.T) {
+	lazyStress(t, func(n *int32) func() {
+		f := String(func() (v string) {
+			atomic.AddInt32(n, 1)
+			return v
+		})
+		return func() { f() }
+	})
+}
+
+func TestStringAllocs(t *testing.T) {
+	f := String(func() (v string) { return v })
+	lazyNoAllocs(t, func() { f() })
+}
+
+func TestUintStress(t *testing.T) {
+	lazyStress(t, func(n *int32) func() {
+		f := Uint(func() (v uint) {
+			atomic.AddInt32(n, 1)
+			return v
+		})
+		return func() { f() }
+	})
+}
+
+func TestUintAllocs(t *testing.T) {
+	f := Uint(func() (v uint) { return v })
+	lazyNoAllocs(t, func() { f() })
+}
+
+func TestUint8Stress(t *testing.T) {
+	lazyStress(t, func(n *int32) func() {
+		f := Uint8(func() (v uint8) {
+			atomic.AddInt32(n, 1)
+			return v
+		})
+		return func() { f() }
+	})
+}
+
+func TestUint8Allocs(t *testing.T) {
+	f := Uint8(func() (v uint8) { return v })
+	lazyNoAllocs(t, func() { f() })
+}
+
+func TestUint16Stress(t *testing.T) {
+	lazyStress(t, func(n *int32) func() {
+		f := Uint16(func() (v uint16) {
+			atomic.AddInt32(n, 1)
+			return v
+		})
+		return func() { f() }
+	})
+}
+
+func TestUint16Allocs(t *testing.T) {
+	f := Uint16(func() (v uint16) { return v })
+	lazyNoAllocs(t, func() { f() })
+}
+
+func TestUint32Stress(t *testing.T) {
+	lazyStress(t, func(n *int32) func() {
+		f := Uint32(func() (v uint32) {
+			atomic.AddInt32(n, 1)
+			return v
+		})
+		return func() { f() }
+	})
+}
+
+func TestUint32Allocs(t *testing.T) {
+	f := Uint32(func() (v uint32) { return v })
+	lazyNoAllocs(t, func() { f() })
+}
+
+func TestUint64Stress(t *testing.T) {
+	lazyStress(t, func(n *int32) func() {
+		f := Uint64(func() (v uint64) {
+			atomic.AddInt32(n, 1)
+			return v
+		})
+		return func() { f() }
+	})
+}
+
+func TestUint64Allocs(t *testing.T) {
+	f := Uint64(func() (v uint64) { return v })
+	lazyNoAllocs(t, func() { f() })
+}
+
+func TestUintptrStress(t *testing.T) {
+	lazyStress(t, func(n *int32) func() {
+		f := Uintptr(func() (v uintptr) {
+			atomic.AddInt32(n, 1)
+			return v
+		})
+		return func() { f() }
+	})
+}
+
+func TestUintptrAllocs(t *testing.T) {
+	f := Uintptr(func() (v uintptr) { return v })
+	lazyNoAllocs(t, func() { f() })
+}
@@ -21,3 +21,51 @@ func TestBool(t *testing.T) {
 		}
 	}
 }
+
+func TestVersionedInt(t *testing.T) {
+	calls := 0
+	v := NewVersionedInt(func() int {
+		calls++
+		return calls
+	})
+
+	x, g := v.Get()
+	if x != 1 || g != 1 {
+		t.Fatalf("v.Get() == %v, %v, expected 1, 1", x, g)
+	}
+	if x, g = v.Get(); x != 1 || g != 1 {
+		t.Fatalf("v.Get() == %v, %v, expected 1, 1", x, g)
+	}
+
+	if !v.InvalidateIf(1) {
+		t.Fatalf("v.InvalidateIf(1) == false, expected true")
+	}
+	if v.InvalidateIf(1) {
+		t.Fatalf("v.InvalidateIf(1) == true after invalidation, expected false")
+	}
+	if x, g = v.Get(); x != 2 || g != 2 {
+		t.Fatalf("v.Get() == %v, %v, expected 2, 2", x, g)
+	}
+	if v.InvalidateIf(1) {
+		t.Errorf("v.InvalidateIf(1) == true for stale generation, expected false")
+	}
+	if x, g = v.Get(); x != 2 || g != 2 {
+		t.Errorf("v.Get() == %v, %v, expected 2, 2", x, g)
+	}
+}
+
+func TestVersionedTryGet(t *testing.T) {
+	v := NewVersionedInt(func() int { return 42 })
+
+	if x, g, ok := v.TryGet(); ok {
+		t.Fatalf("v.TryGet() == %v, %v, true before Get, expected false", x, g)
+	}
+	v.Get()
+	if x, g, ok := v.TryGet(); !ok || x != 42 || g != 1 {
+		t.Fatalf("v.TryGet() == %v, %v, %v, expected 42, 1, true", x, g, ok)
+	}
+	v.InvalidateIf(1)
+	if x, g, ok := v.TryGet(); ok {
+		t.Errorf("v.TryGet() == %v, %v, true after InvalidateIf, expected false", x, g)
+	}
+}
@@ -0,0 +1,205 @@
+package lazycheck
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// Copy reports copies of lazy values, which evaluate independently of the
+// original, and lazy values of the func style, which are evaluated right after
+// they are created and thus on every execution.
+var Copy = &analysis.Analyzer{
+	Name: "lazycopy",
+	Doc: `report copied lazy values
+
+Copying a lazy value after Init duplicates its state, so that the copy
+and the original evaluate independently. This reports assignments,
+arguments, parameters, results, receivers and range variables copying lazy values,
+or structs and arrays containing them, with a fix taking the address,
+where possible.
+
+It also reports lazy values of the func style, which are called right
+after they are created, e.g. LazyConfig(load)(), as they are evaluated
+every time the expression is.`,
+	Run: runCopy,
+}
+
+func runCopy(pass *analysis.Pass) (interface{}, error) {
+	c := &copier{pass}
+	for _, f := range pass.Files {
+		ast.Inspect(f, c.visit)
+	}
+	return nil, nil
+}
+
+type copier struct {
+	pass *analysis.Pass
+}
+
+func (c *copier) visit(n ast.Node) bool {
+	switch n := n.(type) {
+	case *ast.AssignStmt:
+		if len(n.Lhs) != len(n.Rhs) {
+			break
+		}
+		for i, x := range n.Rhs {
+			if id, ok := n.Lhs[i].(*ast.Ident); ok && id.Name == "_" {
+				continue
+			}
+			c.checkCopy(x, "assignment")
+		}
+	case *ast.ValueSpec:
+		for _, x := range n.Values {
+			c.checkCopy(x, "variable declaration")
+		}
+	case *ast.ReturnStmt:
+		for _, x := range n.Results {
+			c.checkCopy(x, "return")
+		}
+	case *ast.CompositeLit:
+		for _, x := range n.Elts {
+			if kv, ok := x.(*ast.KeyValueExpr); ok {
+				x = kv.Value
+			}
+			c.checkCopy(x, "composite literal")
+		}
+	case *ast.CallExpr:
+		c.checkCall(n)
+	case *ast.FuncDecl:
+		if n.Recv != nil {
+			c.checkFields(n.Recv, "receiver")
+		}
+	case *ast.FuncType:
+		c.checkFields(n.Params, "parameter")
+	case *ast.RangeStmt:
+		if n.Value == nil {
+			break
+		}
+		if t := c.pass.TypesInfo.TypeOf(n.Value); t != nil {
+			if l := lazyIn(t); l != nil {
+				c.pass.Reportf(n.Value.Pos(), "range variable copies lazy value %s; range over the indices instead", types.TypeString(l, types.RelativeTo(c.pass.Pkg)))
+			}
+		}
+	}
+	return true
+}
+
+// checkCopy reports x, if it copies an existing lazy value.
+func (c *copier) checkCopy(x ast.Expr, what string) {
+	x = ast.Unparen(x)
+	if !existing(x) {
+		return
+	}
+	t := c.pass.TypesInfo.TypeOf(x)
+	if t == nil {
+		return
+	}
+	l := lazyIn(t)
+	if l == nil {
+		return
+	}
+	d := analysis.Diagnostic{
+		Pos:     x.Pos(),
+		End:     x.End(),
+		Message: what + " copies lazy value " + types.TypeString(l, types.RelativeTo(c.pass.Pkg)) + "; use a pointer",
+	}
+	if addressable(x) {
+		d.SuggestedFixes = []analysis.SuggestedFix{{
+			Message:   "Take the address",
+			TextEdits: []analysis.TextEdit{{Pos: x.Pos(), End: x.Pos(), NewText: []byte("&")}},
+		}}
+	}
+	c.pass.Report(d)
+}
+
+// checkCall reports arguments copying lazy values and lazy values of the func
+// style, which are called right after their creation.
+func (c *copier) checkCall(call *ast.CallExpr) {
+	if tv, ok := c.pass.TypesInfo.Types[call.Fun]; ok && tv.IsType() {
+		// A conversion.
+		return
+	}
+	for _, x := range call.Args {
+		c.checkCopy(x, "argument")
+	}
+	inner, ok := ast.Unparen(call.Fun).(*ast.CallExpr)
+	if !ok {
+		return
+	}
+	if f := callee(c.pass.TypesInfo, inner); f != nil && isConstructor(f) {
+		c.pass.Reportf(call.Pos(), "lazy value created by %s is evaluated right away, on every execution; create it once and store the returned func", f.Name())
+	}
+}
+
+// checkFields reports fields of a function signature, which are passed by
+// value and contain lazy values.
+func (c *copier) checkFields(fl *ast.FieldList, what string) {
+	if fl == nil {
+		return
+	}
+	for _, f := range fl.List {
+		t := c.pass.TypesInfo.TypeOf(f.Type)
+		if t == nil {
+			continue
+		}
+		if l := lazyIn(t); l != nil {
+			c.pass.Report(analysis.Diagnostic{
+				Pos:     f.Type.Pos(),
+				End:     f.Type.End(),
+				Message: what + " passes lazy value " + types.TypeString(l, types.RelativeTo(c.pass.Pkg)) + " by value; use a pointer",
+				SuggestedFixes: []analysis.SuggestedFix{{
+					Message:   "Use a pointer",
+					TextEdits: []analysis.TextEdit{{Pos: f.Type.Pos(), End: f.Type.Pos(), NewText: []byte("*")}},
+				}},
+			})
+		}
+	}
+}
+
+// existing returns whether x refers to an existing value, as opposed to a new
+// one, like a composite literal or the result of a call.
+func existing(x ast.Expr) bool {
+	switch x := x.(type) {
+	case *ast.Ident:
+		return x.Name != "nil"
+	case *ast.SelectorExpr, *ast.IndexExpr, *ast.StarExpr:
+		return true
+	}
+	return false
+}
+
+// addressable returns whether the address of x can be taken.
+func addressable(x ast.Expr) bool {
+	switch x := x.(type) {
+	case *ast.Ident, *ast.StarExpr:
+		return true
+	case *ast.SelectorExpr:
+		return addressable(x.X) || isPointerExpr(x.X)
+	case *ast.IndexExpr:
+		return addressable(x.X)
+	}
+	return false
+}
+
+func isPointerExpr(x ast.Expr) bool {
+	_, ok := x.(*ast.StarExpr)
+	return ok
+}
+
+// callee returns the function called by call, if it is a statically known
+// function.
+func callee(info *types.Info, call *ast.CallExpr) *types.Func {
+	var id *ast.Ident
+	switch fun := ast.Unparen(call.Fun).(type) {
+	case *ast.Ident:
+		id = fun
+	case *ast.SelectorExpr:
+		id = fun.Sel
+	default:
+		return nil
+	}
+	f, _ := info.Uses[id].(*types.Func)
+	return f
+}
@@ -0,0 +1,269 @@
+package lazycheck
+
+import (
+	"go/ast"
+	"go/types"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// Cycle reports package level lazy values, whose evaluation depends on their
+// own value.
+var Cycle = &analysis.Analyzer{
+	Name: "lazycycle",
+	Doc: `report lazy values depending on themselves
+
+A lazy value, whose func calls its own Get, directly or through other
+lazy values, deadlocks when it is evaluated. This builds the graph of
+package level lazy values, with an edge from A to B if the func A is
+initialized with calls B or B.Get, directly or through functions
+declared in the package, and reports its cycles.
+
+Lazy values are initialized by their constructor in the declaration of
+the variable, or by calling Init anywhere in the package. Cycles among
+values initialized in their declarations only are initialization
+cycles, which the compiler rejects already. Funcs created
+but not called while evaluating are not followed, so no cycles through
+them are reported.`,
+	Run: runCycle,
+}
+
+func runCycle(pass *analysis.Pass) (interface{}, error) {
+	c := &cycler{
+		pass:  pass,
+		decls: make(map[*types.Func]*ast.FuncDecl),
+		deps:  make(map[*types.Func][]*types.Var),
+		inits: make(map[*types.Var][]ast.Expr),
+	}
+	var order []*types.Var
+	addInit := func(v *types.Var, f ast.Expr) {
+		if _, ok := c.inits[v]; !ok {
+			order = append(order, v)
+		}
+		c.inits[v] = append(c.inits[v], f)
+	}
+	for _, f := range pass.Files {
+		for _, d := range f.Decls {
+			switch d := d.(type) {
+			case *ast.FuncDecl:
+				if fn, ok := pass.TypesInfo.Defs[d.Name].(*types.Func); ok && d.Body != nil {
+					c.decls[fn] = d
+				}
+			case *ast.GenDecl:
+				for _, s := range d.Specs {
+					vs, ok := s.(*ast.ValueSpec)
+					if !ok || len(vs.Names) != len(vs.Values) {
+						continue
+					}
+					for i, x := range vs.Values {
+						call, ok := ast.Unparen(x).(*ast.CallExpr)
+						if !ok || len(call.Args) != 1 {
+							continue
+						}
+						fn := callee(pass.TypesInfo, call)
+						if fn == nil || !isConstructor(fn) && !returnsLazy(fn) {
+							continue
+						}
+						if v, ok := pass.TypesInfo.Defs[vs.Names[i]].(*types.Var); ok {
+							addInit(v, call.Args[0])
+						}
+					}
+				}
+			}
+		}
+	}
+	for _, f := range pass.Files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) != 1 {
+				return true
+			}
+			if v := c.method(call, "Init"); v != nil {
+				addInit(v, call.Args[0])
+			}
+			return true
+		})
+	}
+
+	sort.Slice(order, func(i, j int) bool { return order[i].Pos() < order[j].Pos() })
+
+	graph := make(map[*types.Var][]*types.Var)
+	for _, v := range order {
+		for _, f := range c.inits[v] {
+			graph[v] = append(graph[v], c.funcDeps(f)...)
+		}
+	}
+	for _, v := range order {
+		path := cyclePath(graph, v)
+		if path == nil || !first(path, order) {
+			continue
+		}
+		names := make([]string, len(path)+1)
+		for i, w := range path {
+			names[i] = w.Name()
+		}
+		names[len(path)] = v.Name()
+		pass.Reportf(v.Pos(), "evaluating lazy value %s depends on itself (%s), which deadlocks", v.Name(), strings.Join(names, " -> "))
+	}
+	return nil, nil
+}
+
+type cycler struct {
+	pass *analysis.Pass
+	// decls are the functions declared in the package.
+	decls map[*types.Func]*ast.FuncDecl
+	// deps caches the lazy values evaluated by calls of functions. An
+	// entry is added before the body is inspected, to terminate recursion.
+	deps map[*types.Func][]*types.Var
+	// inits are the funcs passed to the constructors and Init methods of
+	// package level lazy values.
+	inits map[*types.Var][]ast.Expr
+}
+
+// funcDeps returns the lazy values evaluated by calling the func x.
+func (c *cycler) funcDeps(x ast.Expr) []*types.Var {
+	switch x := ast.Unparen(x).(type) {
+	case *ast.FuncLit:
+		return c.bodyDeps(x.Body)
+	case *ast.Ident, *ast.SelectorExpr:
+		var id *ast.Ident
+		if s, ok := x.(*ast.SelectorExpr); ok {
+			id = s.Sel
+		} else {
+			id = x.(*ast.Ident)
+		}
+		if fn, ok := c.pass.TypesInfo.Uses[id].(*types.Func); ok {
+			return c.callDeps(fn)
+		}
+	}
+	return nil
+}
+
+// callDeps returns the lazy values evaluated by calling fn, if it is declared
+// in the package.
+func (c *cycler) callDeps(fn *types.Func) []*types.Var {
+	if deps, ok := c.deps[fn]; ok {
+		return deps
+	}
+	d, ok := c.decls[fn]
+	if !ok {
+		return nil
+	}
+	c.deps[fn] = nil
+	deps := c.bodyDeps(d.Body)
+	c.deps[fn] = deps
+	return deps
+}
+
+// bodyDeps returns the lazy values evaluated by executing body.
+func (c *cycler) bodyDeps(body *ast.BlockStmt) []*types.Var {
+	var deps []*types.Var
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			// Inspected if called, below.
+			return false
+		case *ast.CallExpr:
+			if lit, ok := ast.Unparen(n.Fun).(*ast.FuncLit); ok {
+				deps = append(deps, c.bodyDeps(lit.Body)...)
+			}
+			if id, ok := ast.Unparen(n.Fun).(*ast.Ident); ok {
+				if v, ok := c.pass.TypesInfo.Uses[id].(*types.Var); ok {
+					if _, ok := c.inits[v]; ok {
+						deps = append(deps, v)
+					}
+				}
+			}
+			if v := c.method(n, "Get"); v != nil {
+				deps = append(deps, v)
+			}
+			if fn := callee(c.pass.TypesInfo, n); fn != nil {
+				deps = append(deps, c.callDeps(fn)...)
+			}
+		}
+		return true
+	})
+	return deps
+}
+
+// method returns the package level lazy value, whose method name is called by
+// call, or nil.
+func (c *cycler) method(call *ast.CallExpr, name string) *types.Var {
+	s, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok || s.Sel.Name != name {
+		return nil
+	}
+	id, ok := ast.Unparen(s.X).(*ast.Ident)
+	if !ok {
+		return nil
+	}
+	v, ok := c.pass.TypesInfo.Uses[id].(*types.Var)
+	if !ok || v.Parent() != c.pass.Pkg.Scope() {
+		return nil
+	}
+	t := v.Type()
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	if !isLazy(t) {
+		return nil
+	}
+	return v
+}
+
+// returnsLazy returns whether fn returns a pointer to a lazy value, like the
+// constructors of versioned lazy values.
+func returnsLazy(fn *types.Func) bool {
+	sig := fn.Type().(*types.Signature)
+	if sig.Results().Len() != 1 {
+		return false
+	}
+	p, ok := sig.Results().At(0).Type().(*types.Pointer)
+	return ok && isLazy(p.Elem())
+}
+
+// cyclePath returns a shortest path in graph from v back to v, starting with
+// v, or nil if there is none.
+func cyclePath(graph map[*types.Var][]*types.Var, v *types.Var) []*types.Var {
+	prev := make(map[*types.Var]*types.Var)
+	queue := []*types.Var{v}
+	for len(queue) > 0 {
+		w := queue[0]
+		queue = queue[1:]
+		for _, u := range graph[w] {
+			if u == v {
+				path := []*types.Var{w}
+				for w != v {
+					w = prev[w]
+					path = append(path, w)
+				}
+				for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+					path[i], path[j] = path[j], path[i]
+				}
+				return path
+			}
+			if _, ok := prev[u]; !ok {
+				prev[u] = w
+				queue = append(queue, u)
+			}
+		}
+	}
+	return nil
+}
+
+// first returns whether path[0] is the first of the values in path, in order,
+// so that every cycle is reported once, at its first declaration.
+func first(path, order []*types.Var) bool {
+	in := make(map[*types.Var]bool)
+	for _, v := range path {
+		in[v] = true
+	}
+	for _, v := range order {
+		if in[v] {
+			return v == path[0]
+		}
+	}
+	return false
+}
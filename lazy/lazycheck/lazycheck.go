@@ -0,0 +1,79 @@
+// Package lazycheck provides analyzers for code using lazy values generated by
+// merovius.de/go-misc/cmd/go-lazy, for use with go vet -vettool or
+// merovius.de/go-misc/cmd/lazycheck.
+//
+// Lazy value types are recognized by their methods: a named struct type is
+// one, if its pointer has a method Get and a method Init or InvalidateIf.
+// Constructors of lazy values of the func style are recognized by their
+// signature: they take a func and return a func of the same type, which takes
+// no arguments or a context.Context and has a single result.
+package lazycheck // import "merovius.de/go-misc/lazy/lazycheck"
+
+import (
+	"go/types"
+)
+
+// isLazy returns whether t is a lazy value type.
+func isLazy(t types.Type) bool {
+	n, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	if _, ok := n.Underlying().(*types.Struct); !ok {
+		return false
+	}
+	ms := types.NewMethodSet(types.NewPointer(n))
+	return ms.Lookup(nil, "Get") != nil && (ms.Lookup(nil, "Init") != nil || ms.Lookup(nil, "InvalidateIf") != nil)
+}
+
+// lazyIn returns the lazy value type contained in t, if values of t contain
+// one directly, i.e. not behind a pointer, or nil.
+func lazyIn(t types.Type) types.Type {
+	return lazyInSeen(t, make(map[types.Type]bool))
+}
+
+func lazyInSeen(t types.Type, seen map[types.Type]bool) types.Type {
+	if seen[t] {
+		return nil
+	}
+	seen[t] = true
+	if isLazy(t) {
+		return t
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Struct:
+		for i := 0; i < u.NumFields(); i++ {
+			if l := lazyInSeen(u.Field(i).Type(), seen); l != nil {
+				return l
+			}
+		}
+	case *types.Array:
+		return lazyInSeen(u.Elem(), seen)
+	}
+	return nil
+}
+
+// isConstructor returns whether f is a constructor of lazy values of the
+// func style.
+func isConstructor(f *types.Func) bool {
+	sig := f.Type().(*types.Signature)
+	if sig.Recv() != nil || sig.Params().Len() != 1 || sig.Results().Len() != 1 {
+		return false
+	}
+	t := sig.Params().At(0).Type()
+	if !types.Identical(t, sig.Results().At(0).Type()) {
+		return false
+	}
+	get, ok := t.Underlying().(*types.Signature)
+	if !ok || get.Results().Len() != 1 {
+		return false
+	}
+	switch get.Params().Len() {
+	case 0:
+		return true
+	case 1:
+		n, ok := get.Params().At(0).Type().(*types.Named)
+		return ok && n.Obj().Pkg() != nil && n.Obj().Pkg().Path() == "context" && n.Obj().Name() == "Context"
+	}
+	return false
+}
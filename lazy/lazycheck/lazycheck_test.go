@@ -0,0 +1,238 @@
+package lazycheck
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/analysis"
+	"merovius.de/go-misc/internal/gen"
+)
+
+// run runs a on the package consisting of the file src and checks that the
+// reported diagnostics match the comments of the form
+//
+//	// want "regexp"
+//	/* want "regexp" */
+//
+// in src, which expect a diagnostic on their line.
+func run(t *testing.T, a *analysis.Analyzer, src string) {
+	t.Helper()
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "x.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &types.Info{
+		Types: make(map[ast.Expr]types.TypeAndValue),
+		Defs:  make(map[*ast.Ident]types.Object),
+		Uses:  make(map[*ast.Ident]types.Object),
+	}
+	cfg := &types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	pkg, err := cfg.Check("x", fset, []*ast.File{f}, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := make(map[int]*regexp.Regexp)
+	for _, cg := range f.Comments {
+		for _, c := range cg.List {
+			s := strings.TrimPrefix(c.Text, "// want ")
+			if s == c.Text {
+				s = strings.TrimSuffix(strings.TrimPrefix(c.Text, "/* want "), " */")
+			}
+			if s != c.Text {
+				want[fset.Position(c.Pos()).Line] = regexp.MustCompile(strings.Trim(s, `"`))
+			}
+		}
+	}
+
+	var got []analysis.Diagnostic
+	pass := &analysis.Pass{
+		Analyzer:  a,
+		Fset:      fset,
+		Files:     []*ast.File{f},
+		Pkg:       pkg,
+		TypesInfo: info,
+		Report:    func(d analysis.Diagnostic) { got = append(got, d) },
+		ResultOf:  make(map[*analysis.Analyzer]interface{}),
+	}
+	if _, err := a.Run(pass); err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(got, func(i, j int) bool { return got[i].Pos < got[j].Pos })
+	for _, d := range got {
+		line := fset.Position(d.Pos).Line
+		re, ok := want[line]
+		if !ok {
+			t.Errorf("%d: unexpected diagnostic %q", line, d.Message)
+			continue
+		}
+		if !re.MatchString(d.Message) {
+			t.Errorf("%d: diagnostic %q does not match %q", line, d.Message, re)
+		}
+		delete(want, line)
+	}
+	for line, re := range want {
+		t.Errorf("%d: no diagnostic matching %q", line, re)
+	}
+}
+
+const lazyDecl = `
+type LazyInt struct {
+	f func() int
+	x int
+}
+
+func (v *LazyInt) Init(f func() int) { v.f = f }
+func (v *LazyInt) Get() int          { return v.x }
+
+func Int(f func() int) func() int { return f }
+`
+
+func TestCopy(t *testing.T) {
+	run(t, Copy, `package x
+
+import "context"
+`+lazyDecl+`
+func Ctx(f func(context.Context) string) func(context.Context) string { return f }
+
+type config struct {
+	port LazyInt
+}
+
+func (c config) port2() int { return 0 } // want "receiver passes lazy value LazyInt by value"
+
+func use(v LazyInt) {} // want "parameter passes lazy value LazyInt by value"
+
+func usePtr(v *LazyInt) {}
+
+func fine() {
+	var a LazyInt
+	a.Init(func() int { return 42 })
+	b := &a
+	usePtr(b)
+	c := *b // want "assignment copies lazy value LazyInt"
+	_ = c
+	_ = a
+	var d = config{}
+	e := d // want "assignment copies lazy value LazyInt"
+	_ = e
+	p := d.port // want "assignment copies lazy value LazyInt"
+	_ = p
+	q := &d.port
+	_ = q
+	vs := make([]LazyInt, 3)
+	for i := range vs {
+		usePtr(&vs[i])
+	}
+	for _, v := range vs { // want "range variable copies lazy value LazyInt"
+		_ = v
+	}
+	_ = config{port: a} // want "composite literal copies lazy value LazyInt"
+
+	n := Int(func() int { return 42 })() // want "created by Int is evaluated right away"
+	_ = n
+	get := Int(func() int { return 42 })
+	_ = get()
+	_ = Ctx(func(context.Context) string { return "" })(context.Background()) // want "created by Ctx"
+}
+`)
+}
+
+func TestCycle(t *testing.T) {
+	// Cycles among lazy values initialized in their declaration are
+	// already rejected by the compiler.
+	run(t, Cycle, `package x
+`+lazyDecl+`
+var A LazyInt // want "lazy value A depends on itself \(A -> B -> A\)"
+
+var B = Int(func() int {
+	return viaC()
+})
+
+func viaC() int {
+	return C.Get() + A.Get()
+}
+
+var C LazyInt
+
+var D LazyInt // want "lazy value D depends on itself \(D -> D\)"
+
+var E LazyInt
+
+func init() {
+	A.Init(func() int { return B() + 1 })
+	C.Init(func() int { return 1 })
+	D.Init(func() int {
+		return func() int { return D.Get() }()
+	})
+	E.Init(func() int {
+		f := func() int { return E.Get() }
+		_ = f
+		return C.Get()
+	})
+	F.Init(g)
+}
+
+var F LazyInt // want "lazy value F depends on itself \(F -> F\)"
+
+func g() int {
+	if F.Get() > 0 {
+		return g()
+	}
+	return 0
+}
+`)
+}
+
+func TestStale(t *testing.T) {
+	const decls = `
+type config struct {
+	A int
+	B map[string]sub
+}
+
+type sub struct{ X []byte }
+`
+	f, err := parser.ParseFile(token.NewFileSet(), "x.go", "package x\n"+decls, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	typesHash, err := gen.TypesHash([]*ast.File{f}, []string{"config"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	inputs := (&gen.Inputs{
+		Args:        []string{"-style=value", "-record-inputs", "-out", "x.go", "Config", "config"},
+		Types:       []string{"config"},
+		TypesSHA256: typesHash,
+	}).Comment()
+
+	run(t, Stale, inputs+`
+
+package x
+
+//go:generate go-lazy -style=value -record-inputs -out x.go Config config
+`+decls)
+
+	run(t, Stale, `/* want "x.go is out of date \(declarations of the types changed\)" */ `+inputs+`
+
+package x
+
+//go:generate go-lazy -style=value -record-inputs -out x.go Config config
+`+strings.Replace(decls, "[]byte", "string", 1))
+
+	run(t, Stale, `/* want "x.go is out of date \(the arguments of go-lazy changed\)" */ `+inputs+`
+
+package x
+
+//go:generate go-lazy -style=value -record-inputs -hash -out x.go Config config
+`+decls)
+}
@@ -0,0 +1,105 @@
+package lazycheck
+
+import (
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"merovius.de/go-misc/internal/gen"
+)
+
+// Stale reports files generated by go-lazy with -record-inputs, which are out
+// of date.
+var Stale = &analysis.Analyzer{
+	Name: "lazystale",
+	Doc: `report out of date files generated by go-lazy
+
+Files generated with -record-inputs record the arguments of go-lazy, a
+hash of its config file and one of the declarations of the types of the
+package their lazy values refer to. This reports such files, if the
+config file or one of the types changed, or if the arguments differ
+from those of a //go:generate directive of the package writing the
+file, so they need to be generated again.
+
+Unlike go-lazy -check, it does not run go-lazy, so it does not detect
+changes to go-lazy itself.`,
+	Run: runStale,
+}
+
+func runStale(pass *analysis.Pass) (interface{}, error) {
+	directives := generateDirectives(pass)
+	for _, f := range pass.Files {
+		name := pass.Fset.File(f.Pos()).Name()
+		for _, cg := range f.Comments {
+			if cg.Pos() > f.Package {
+				break
+			}
+			for _, c := range cg.List {
+				in, err := gen.ParseInputs(c.Text)
+				if err != nil {
+					return nil, err
+				}
+				if in == nil {
+					continue
+				}
+				reasons, err := in.Stale(filepath.Dir(name), pass.Files)
+				if err != nil {
+					return nil, err
+				}
+				if args, ok := directives[name]; ok && strings.Join(args, "\x00") != strings.Join(in.Args, "\x00") {
+					reasons = append(reasons, "the arguments of go-lazy changed")
+				}
+				if len(reasons) > 0 {
+					pass.Reportf(c.Pos(), "%s is out of date (%s); run go generate", filepath.Base(name), strings.Join(reasons, ", "))
+				}
+			}
+		}
+	}
+	return nil, nil
+}
+
+// generateDirectives returns the arguments of the //go:generate directives of
+// the package running go-lazy, keyed by the file they write.
+func generateDirectives(pass *analysis.Pass) map[string][]string {
+	m := make(map[string][]string)
+	for _, f := range pass.Files {
+		dir := filepath.Dir(pass.Fset.File(f.Pos()).Name())
+		for _, cg := range f.Comments {
+			for _, c := range cg.List {
+				if !strings.HasPrefix(c.Text, "//go:generate ") {
+					continue
+				}
+				args := strings.Fields(strings.TrimPrefix(c.Text, "//go:generate "))
+				if len(args) == 0 {
+					continue
+				}
+				switch {
+				case args[0] == "go-lazy":
+					args = args[1:]
+				case args[0] == "gomisc" && len(args) > 1 && args[1] == "lazy":
+					args = args[2:]
+				default:
+					continue
+				}
+				var rec []string
+				out := ""
+				for i, a := range args {
+					a = "-" + strings.TrimLeft(a, "-")
+					switch {
+					case a == "-check" || a == "-check=true":
+						continue
+					case a == "-out" && i+1 < len(args):
+						out = args[i+1]
+					case strings.HasPrefix(a, "-out="):
+						out = strings.TrimPrefix(a, "-out=")
+					}
+					rec = append(rec, args[i])
+				}
+				if out != "" {
+					m[filepath.Join(dir, out)] = rec
+				}
+			}
+		}
+	}
+	return m
+}
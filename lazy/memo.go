@@ -0,0 +1,20 @@
+package lazy
+
+import "sync"
+
+// memo returns a func calling f once, when first called, and returning its
+// results from then on.
+func memo[T any](f func() (T, error)) func() (T, error) {
+	var (
+		once sync.Once
+		v    T
+		err  error
+	)
+	return func() (T, error) {
+		once.Do(func() {
+			v, err = f()
+			f = nil
+		})
+		return v, err
+	}
+}
@@ -0,0 +1,24 @@
+package lazy
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Regexp returns a lazily compiled regular expression. It is compiled once,
+// when first used, instead of when the package is initialized, like with
+// regexp.MustCompile. If the expression can not be parsed, using it panics.
+//
+// go-lazy migrate -regexp rewrites package level regexps to use it.
+func Regexp(expr string) func() *regexp.Regexp {
+	re := memo(func() (*regexp.Regexp, error) {
+		return regexp.Compile(expr)
+	})
+	return func() *regexp.Regexp {
+		r, err := re()
+		if err != nil {
+			panic(fmt.Sprintf("regexp: Compile(%q): %v", expr, err))
+		}
+		return r
+	}
+}
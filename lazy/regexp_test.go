@@ -0,0 +1,21 @@
+package lazy
+
+import "testing"
+
+func TestRegexp(t *testing.T) {
+	re := Regexp(`^a+$`)
+	if !re().MatchString("aaa") {
+		t.Errorf("re().MatchString(%q) == false, expected true", "aaa")
+	}
+	if re() != re() {
+		t.Errorf("re() compiled the regexp twice")
+	}
+
+	bad := Regexp(`(`)
+	defer func() {
+		if recover() == nil {
+			t.Errorf("bad() did not panic for invalid regexp")
+		}
+	}()
+	bad()
+}
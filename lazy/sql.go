@@ -0,0 +1,64 @@
+package lazy
+
+import (
+	"context"
+	"database/sql"
+	"sync/atomic"
+	"time"
+)
+
+// DB returns a lazily opened database handle. When first used, the database
+// is opened with sql.Open and pinged with the context of the use, so that
+// processes never using it do not connect to it. Uses waiting for another one
+// opening the database return early, if their context is done.
+//
+// If opening the database fails, the error is returned by all uses for the
+// duration retry, after which the next use tries again. If retry is negative,
+// the error is returned from then on. Errors caused by the context of a use
+// are only returned to it.
+func DB(driverName, dataSourceName string, retry time.Duration) func(context.Context) (*sql.DB, error) {
+	var (
+		db     atomic.Pointer[sql.DB]
+		sem    = make(chan struct{}, 1)
+		err    error
+		failed time.Time
+	)
+	return func(ctx context.Context) (*sql.DB, error) {
+		if d := db.Load(); d != nil {
+			return d, nil
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		defer func() { <-sem }()
+
+		if d := db.Load(); d != nil {
+			return d, nil
+		}
+		if err != nil && (retry < 0 || time.Since(failed) < retry) {
+			return nil, err
+		}
+		d, e := openDB(ctx, driverName, dataSourceName)
+		switch {
+		case e == nil:
+			db.Store(d)
+		case ctx.Err() == nil:
+			err, failed = e, time.Now()
+		}
+		return d, e
+	}
+}
+
+func openDB(ctx context.Context, driverName, dataSourceName string) (*sql.DB, error) {
+	db, err := sql.Open(driverName, dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
@@ -0,0 +1,78 @@
+package lazy
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+)
+
+// testDriver is a database/sql driver, whose connections fail to ping while
+// down is set.
+type testDriver struct {
+	down  bool
+	opens int
+}
+
+type testConn struct{ d *testDriver }
+
+func (d *testDriver) Open(string) (driver.Conn, error) {
+	d.opens++
+	return testConn{d}, nil
+}
+
+func (c testConn) Ping(context.Context) error {
+	if c.d.down {
+		return errors.New("down")
+	}
+	return nil
+}
+
+func (testConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not implemented") }
+func (testConn) Close() error                        { return nil }
+func (testConn) Begin() (driver.Tx, error)           { return nil, errors.New("not implemented") }
+
+// testDB is registered as "lazytest", once, as drivers can not be registered
+// again.
+var testDB = new(testDriver)
+
+func init() {
+	sql.Register("lazytest", testDB)
+}
+
+func TestDB(t *testing.T) {
+	d := testDB
+	d.down = true
+	ctx := context.Background()
+
+	get := DB("lazytest", "", time.Hour)
+	if _, err := get(ctx); err == nil {
+		t.Fatalf("get succeeded while the database is down")
+	}
+	d.down = false
+	if _, err := get(ctx); err == nil {
+		t.Errorf("get did not return the cached error")
+	}
+
+	get = DB("lazytest", "", 0)
+	db, err := get(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opens := d.opens
+	if db2, err := get(ctx); err != nil || db2 != db || d.opens != opens {
+		t.Errorf("get == %p, %v after %d opens, expected %p, <nil> after %d", db2, err, d.opens, db, opens)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	get = DB("lazytest", "", -1)
+	if _, err := get(canceled); err == nil {
+		t.Errorf("get succeeded with a canceled context")
+	}
+	if _, err := get(ctx); err != nil {
+		t.Errorf("get == %v after the error of a canceled context, expected <nil>", err)
+	}
+}
@@ -0,0 +1,64 @@
+package lazy
+
+import (
+	htmltemplate "html/template"
+	"io/fs"
+	texttemplate "text/template"
+)
+
+// TextTemplate returns a lazily parsed text/template with the given name.
+// It is parsed once, when first used. An error parsing it is cached like the
+// template.
+func TextTemplate(name, text string) func() (*texttemplate.Template, error) {
+	return memo(func() (*texttemplate.Template, error) {
+		return texttemplate.New(name).Parse(text)
+	})
+}
+
+// TextTemplateFiles is like TextTemplate, but parses the named files, like
+// text/template.ParseFiles.
+func TextTemplateFiles(filenames ...string) func() (*texttemplate.Template, error) {
+	return memo(func() (*texttemplate.Template, error) {
+		return texttemplate.ParseFiles(filenames...)
+	})
+}
+
+// TextTemplateFS is like TextTemplate, but parses the files of fsys matching
+// patterns, like text/template.ParseFS.
+func TextTemplateFS(fsys fs.FS, patterns ...string) func() (*texttemplate.Template, error) {
+	return memo(func() (*texttemplate.Template, error) {
+		return texttemplate.ParseFS(fsys, patterns...)
+	})
+}
+
+// HTMLTemplate is like TextTemplate, for html/template.
+func HTMLTemplate(name, text string) func() (*htmltemplate.Template, error) {
+	return memo(func() (*htmltemplate.Template, error) {
+		return htmltemplate.New(name).Parse(text)
+	})
+}
+
+// HTMLTemplateFiles is like TextTemplateFiles, for html/template.
+func HTMLTemplateFiles(filenames ...string) func() (*htmltemplate.Template, error) {
+	return memo(func() (*htmltemplate.Template, error) {
+		return htmltemplate.ParseFiles(filenames...)
+	})
+}
+
+// HTMLTemplateFS is like TextTemplateFS, for html/template.
+func HTMLTemplateFS(fsys fs.FS, patterns ...string) func() (*htmltemplate.Template, error) {
+	return memo(func() (*htmltemplate.Template, error) {
+		return htmltemplate.ParseFS(fsys, patterns...)
+	})
+}
+
+// Template returns a func calling parse once, when first used, and returning
+// its results from then on. It can be used for templates needing functions or
+// options, which must be set before parsing, e.g.
+//
+//	var page = lazy.Template(func() (*template.Template, error) {
+//		return template.New("page").Funcs(funcs).Parse(pageText)
+//	})
+func Template[T any](parse func() (T, error)) func() (T, error) {
+	return memo(parse)
+}
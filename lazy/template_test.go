@@ -0,0 +1,40 @@
+package lazy
+
+import (
+	htmltemplate "html/template"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestTemplate(t *testing.T) {
+	var b strings.Builder
+	tt, err := TextTemplate("t", "{{ . }}")()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tt.Execute(&b, "<x>"); err != nil || b.String() != "<x>" {
+		t.Errorf("executing text template == %q, %v, expected %q, <nil>", b.String(), err, "<x>")
+	}
+
+	fsys := fstest.MapFS{"a.html": {Data: []byte("{{ . }}")}}
+	ht, err := HTMLTemplateFS(fsys, "*.html")()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Reset()
+	if err := ht.ExecuteTemplate(&b, "a.html", "<x>"); err != nil || b.String() != "&lt;x&gt;" {
+		t.Errorf("executing html template == %q, %v, expected %q, <nil>", b.String(), err, "&lt;x&gt;")
+	}
+
+	calls := 0
+	bad := Template(func() (*htmltemplate.Template, error) {
+		calls++
+		return htmltemplate.New("bad").Parse("{{")
+	})
+	_, err1 := bad()
+	_, err2 := bad()
+	if err1 == nil || err1 != err2 || calls != 1 {
+		t.Errorf("invalid template returned %v, %v after %d calls, expected the same error after 1 call", err1, err2, calls)
+	}
+}
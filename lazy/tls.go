@@ -0,0 +1,50 @@
+package lazy
+
+import (
+	"crypto/tls"
+	"sync"
+)
+
+// Certificate returns a lazily loaded TLS certificate, like
+// tls.LoadX509KeyPair. It is loaded again, when the modification time or size
+// of one of the files changed since it was last loaded, so certificates can be
+// renewed without restarting. Every use stats both files. Errors are not
+// cached, so the files are loaded again by the next use.
+func Certificate(certFile, keyFile string) func() (*tls.Certificate, error) {
+	r := &reloader[*tls.Certificate]{
+		names: []string{certFile, keyFile},
+		load: func() (*tls.Certificate, error) {
+			c, err := tls.LoadX509KeyPair(certFile, keyFile)
+			if err != nil {
+				return nil, err
+			}
+			return &c, nil
+		},
+	}
+	return r.get
+}
+
+// GetCertificate returns a func for tls.Config.GetCertificate, using the
+// certificate loaded by Certificate. If loading it again fails, e.g. because
+// only one of the files was replaced yet, the last loaded certificate is used
+// until it succeeds.
+func GetCertificate(certFile, keyFile string) func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	get := Certificate(certFile, keyFile)
+	var (
+		mu   sync.Mutex
+		last *tls.Certificate
+	)
+	return func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+		c, err := get()
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			if last != nil {
+				return last, nil
+			}
+			return nil, err
+		}
+		last = c
+		return c, nil
+	}
+}
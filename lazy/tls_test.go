@@ -0,0 +1,72 @@
+package lazy
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGetCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	get := GetCertificate(certFile, keyFile)
+
+	if _, err := get(nil); err == nil {
+		t.Fatalf("get(nil) succeeded without files")
+	}
+	cert1, key1 := selfSigned(t, "a")
+	write(t, certFile, cert1, time.Unix(1, 0))
+	write(t, keyFile, key1, time.Unix(1, 0))
+	c1, err := get(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, err := get(nil); err != nil || c != c1 {
+		t.Errorf("get(nil) == %p, %v, expected %p, <nil>", c, err, c1)
+	}
+
+	// Only the certificate is renewed yet, so the key does not match.
+	cert2, key2 := selfSigned(t, "b")
+	write(t, certFile, cert2, time.Unix(2, 0))
+	if c, err := get(nil); err != nil || c != c1 {
+		t.Errorf("get(nil) == %p, %v during renewal, expected %p, <nil>", c, err, c1)
+	}
+	write(t, keyFile, key2, time.Unix(2, 0))
+	c2, err := get(nil)
+	if err != nil || c2 == c1 {
+		t.Errorf("get(nil) == %p, %v after renewal, expected a new certificate", c2, err)
+	}
+}
+
+// selfSigned returns a PEM encoded self-signed certificate and its key.
+func selfSigned(t *testing.T, name string) (cert, key string) {
+	t.Helper()
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &k.PublicKey, k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kder, err := x509.MarshalECPrivateKey(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	key = string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: kder}))
+	return cert, key
+}
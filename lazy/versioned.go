@@ -0,0 +1,1499 @@
+// This file is automatically generated by merovius.de/go-misc/cmdgo-lazy.
+
+package lazy
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// VersionedBool provides lazy evaluation for bool, that can be
+// invalidated to force re-evaluation. Every evaluation is tagged with a
+// generation, which increases with every call of f.
+//
+// It is safe for concurrent use. Once it is evaluated, Get only needs an
+// atomic load. If f panics, the panic is propagated and f is called again by
+// the next Get. The result is only recomputed after InvalidateIf. Lazy values
+// can not fail, so errors returned as part of it are cached like any other
+// value.
+type VersionedBool struct {
+	f func() bool
+	m sync.Mutex
+	n uint64
+	r atomic.Value
+}
+
+type versionedBoolResult struct {
+	v bool
+	g uint64
+}
+
+// NewVersionedBool returns a VersionedBool, which calls f when the
+// result is first used and again after every successful invalidation.
+func NewVersionedBool(f func() bool) *VersionedBool {
+	return &VersionedBool{f: f}
+}
+
+// Get returns the value and the generation it was computed in.
+func (v *VersionedBool) Get() (bool, uint64) {
+	if r, _ := v.r.Load().(*versionedBoolResult); r != nil {
+		return r.v, r.g
+	}
+
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	r, _ := v.r.Load().(*versionedBoolResult)
+	if r == nil {
+		v.n++
+		r = &versionedBoolResult{v.f(), v.n}
+		v.r.Store(r)
+	}
+	return r.v, r.g
+}
+
+// TryGet returns the value and the generation it was computed in and true, if
+// it is evaluated. Otherwise, it returns false, without evaluating the value
+// or waiting for an evaluation in progress.
+func (v *VersionedBool) TryGet() (bool, uint64, bool) {
+	if r, _ := v.r.Load().(*versionedBoolResult); r != nil {
+		return r.v, r.g, true
+	}
+	var zero bool
+	return zero, 0, false
+}
+
+// InvalidateIf invalidates the value, if it was computed in generation gen.
+// If the value was already invalidated or re-evaluated since, it does
+// nothing. It returns whether the value was invalidated.
+func (v *VersionedBool) InvalidateIf(gen uint64) bool {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	r, _ := v.r.Load().(*versionedBoolResult)
+	if r == nil || r.g != gen {
+		return false
+	}
+	v.r.Store((*versionedBoolResult)(nil))
+	return true
+}
+
+// VersionedByte provides lazy evaluation for byte, that can be
+// invalidated to force re-evaluation. Every evaluation is tagged with a
+// generation, which increases with every call of f.
+//
+// It is safe for concurrent use. Once it is evaluated, Get only needs an
+// atomic load. If f panics, the panic is propagated and f is called again by
+// the next Get. The result is only recomputed after InvalidateIf. Lazy values
+// can not fail, so errors returned as part of it are cached like any other
+// value.
+type VersionedByte struct {
+	f func() byte
+	m sync.Mutex
+	n uint64
+	r atomic.Value
+}
+
+type versionedByteResult struct {
+	v byte
+	g uint64
+}
+
+// NewVersionedByte returns a VersionedByte, which calls f when the
+// result is first used and again after every successful invalidation.
+func NewVersionedByte(f func() byte) *VersionedByte {
+	return &VersionedByte{f: f}
+}
+
+// Get returns the value and the generation it was computed in.
+func (v *VersionedByte) Get() (byte, uint64) {
+	if r, _ := v.r.Load().(*versionedByteResult); r != nil {
+		return r.v, r.g
+	}
+
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	r, _ := v.r.Load().(*versionedByteResult)
+	if r == nil {
+		v.n++
+		r = &versionedByteResult{v.f(), v.n}
+		v.r.Store(r)
+	}
+	return r.v, r.g
+}
+
+// TryGet returns the value and the generation it was computed in and true, if
+// it is evaluated. Otherwise, it returns false, without evaluating the value
+// or waiting for an evaluation in progress.
+func (v *VersionedByte) TryGet() (byte, uint64, bool) {
+	if r, _ := v.r.Load().(*versionedByteResult); r != nil {
+		return r.v, r.g, true
+	}
+	var zero byte
+	return zero, 0, false
+}
+
+// InvalidateIf invalidates the value, if it was computed in generation gen.
+// If the value was already invalidated or re-evaluated since, it does
+// nothing. It returns whether the value was invalidated.
+func (v *VersionedByte) InvalidateIf(gen uint64) bool {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	r, _ := v.r.Load().(*versionedByteResult)
+	if r == nil || r.g != gen {
+		return false
+	}
+	v.r.Store((*versionedByteResult)(nil))
+	return true
+}
+
+// VersionedComplex64 provides lazy evaluation for complex64, that can be
+// invalidated to force re-evaluation. Every evaluation is tagged with a
+// generation, which increases with every call of f.
+//
+// It is safe for concurrent use. Once it is evaluated, Get only needs an
+// atomic load. If f panics, the panic is propagated and f is called again by
+// the next Get. The result is only recomputed after InvalidateIf. Lazy values
+// can not fail, so errors returned as part of it are cached like any other
+// value.
+type VersionedComplex64 struct {
+	f func() complex64
+	m sync.Mutex
+	n uint64
+	r atomic.Value
+}
+
+type versionedComplex64Result struct {
+	v complex64
+	g uint64
+}
+
+// NewVersionedComplex64 returns a VersionedComplex64, which calls f when the
+// result is first used and again after every successful invalidation.
+func NewVersionedComplex64(f func() complex64) *VersionedComplex64 {
+	return &VersionedComplex64{f: f}
+}
+
+// Get returns the value and the generation it was computed in.
+func (v *VersionedComplex64) Get() (complex64, uint64) {
+	if r, _ := v.r.Load().(*versionedComplex64Result); r != nil {
+		return r.v, r.g
+	}
+
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	r, _ := v.r.Load().(*versionedComplex64Result)
+	if r == nil {
+		v.n++
+		r = &versionedComplex64Result{v.f(), v.n}
+		v.r.Store(r)
+	}
+	return r.v, r.g
+}
+
+// TryGet returns the value and the generation it was computed in and true, if
+// it is evaluated. Otherwise, it returns false, without evaluating the value
+// or waiting for an evaluation in progress.
+func (v *VersionedComplex64) TryGet() (complex64, uint64, bool) {
+	if r, _ := v.r.Load().(*versionedComplex64Result); r != nil {
+		return r.v, r.g, true
+	}
+	var zero complex64
+	return zero, 0, false
+}
+
+// InvalidateIf invalidates the value, if it was computed in generation gen.
+// If the value was already invalidated or re-evaluated since, it does
+// nothing. It returns whether the value was invalidated.
+func (v *VersionedComplex64) InvalidateIf(gen uint64) bool {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	r, _ := v.r.Load().(*versionedComplex64Result)
+	if r == nil || r.g != gen {
+		return false
+	}
+	v.r.Store((*versionedComplex64Result)(nil))
+	return true
+}
+
+// VersionedComplex128 provides lazy evaluation for complex128, that can be
+// invalidated to force re-evaluation. Every evaluation is tagged with a
+// generation, which increases with every call of f.
+//
+// It is safe for concurrent use. Once it is evaluated, Get only needs an
+// atomic load. If f panics, the panic is propagated and f is called again by
+// the next Get. The result is only recomputed after InvalidateIf. Lazy values
+// can not fail, so errors returned as part of it are cached like any other
+// value.
+type VersionedComplex128 struct {
+	f func() complex128
+	m sync.Mutex
+	n uint64
+	r atomic.Value
+}
+
+type versionedComplex128Result struct {
+	v complex128
+	g uint64
+}
+
+// NewVersionedComplex128 returns a VersionedComplex128, which calls f when the
+// result is first used and again after every successful invalidation.
+func NewVersionedComplex128(f func() complex128) *VersionedComplex128 {
+	return &VersionedComplex128{f: f}
+}
+
+// Get returns the value and the generation it was computed in.
+func (v *VersionedComplex128) Get() (complex128, uint64) {
+	if r, _ := v.r.Load().(*versionedComplex128Result); r != nil {
+		return r.v, r.g
+	}
+
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	r, _ := v.r.Load().(*versionedComplex128Result)
+	if r == nil {
+		v.n++
+		r = &versionedComplex128Result{v.f(), v.n}
+		v.r.Store(r)
+	}
+	return r.v, r.g
+}
+
+// TryGet returns the value and the generation it was computed in and true, if
+// it is evaluated. Otherwise, it returns false, without evaluating the value
+// or waiting for an evaluation in progress.
+func (v *VersionedComplex128) TryGet() (complex128, uint64, bool) {
+	if r, _ := v.r.Load().(*versionedComplex128Result); r != nil {
+		return r.v, r.g, true
+	}
+	var zero complex128
+	return zero, 0, false
+}
+
+// InvalidateIf invalidates the value, if it was computed in generation gen.
+// If the value was already invalidated or re-evaluated since, it does
+// nothing. It returns whether the value was invalidated.
+func (v *VersionedComplex128) InvalidateIf(gen uint64) bool {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	r, _ := v.r.Load().(*versionedComplex128Result)
+	if r == nil || r.g != gen {
+		return false
+	}
+	v.r.Store((*versionedComplex128Result)(nil))
+	return true
+}
+
+// VersionedFloat32 provides lazy evaluation for float32, that can be
+// invalidated to force re-evaluation. Every evaluation is tagged with a
+// generation, which increases with every call of f.
+//
+// It is safe for concurrent use. Once it is evaluated, Get only needs an
+// atomic load. If f panics, the panic is propagated and f is called again by
+// the next Get. The result is only recomputed after InvalidateIf. Lazy values
+// can not fail, so errors returned as part of it are cached like any other
+// value.
+type VersionedFloat32 struct {
+	f func() float32
+	m sync.Mutex
+	n uint64
+	r atomic.Value
+}
+
+type versionedFloat32Result struct {
+	v float32
+	g uint64
+}
+
+// NewVersionedFloat32 returns a VersionedFloat32, which calls f when the
+// result is first used and again after every successful invalidation.
+func NewVersionedFloat32(f func() float32) *VersionedFloat32 {
+	return &VersionedFloat32{f: f}
+}
+
+// Get returns the value and the generation it was computed in.
+func (v *VersionedFloat32) Get() (float32, uint64) {
+	if r, _ := v.r.Load().(*versionedFloat32Result); r != nil {
+		return r.v, r.g
+	}
+
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	r, _ := v.r.Load().(*versionedFloat32Result)
+	if r == nil {
+		v.n++
+		r = &versionedFloat32Result{v.f(), v.n}
+		v.r.Store(r)
+	}
+	return r.v, r.g
+}
+
+// TryGet returns the value and the generation it was computed in and true, if
+// it is evaluated. Otherwise, it returns false, without evaluating the value
+// or waiting for an evaluation in progress.
+func (v *VersionedFloat32) TryGet() (float32, uint64, bool) {
+	if r, _ := v.r.Load().(*versionedFloat32Result); r != nil {
+		return r.v, r.g, true
+	}
+	var zero float32
+	return zero, 0, false
+}
+
+// InvalidateIf invalidates the value, if it was computed in generation gen.
+// If the value was already invalidated or re-evaluated since, it does
+// nothing. It returns whether the value was invalidated.
+func (v *VersionedFloat32) InvalidateIf(gen uint64) bool {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	r, _ := v.r.Load().(*versionedFloat32Result)
+	if r == nil || r.g != gen {
+		return false
+	}
+	v.r.Store((*versionedFloat32Result)(nil))
+	return true
+}
+
+// VersionedFloat64 provides lazy evaluation for float64, that can be
+// invalidated to force re-evaluation. Every evaluation is tagged with a
+// generation, which increases with every call of f.
+//
+// It is safe for concurrent use. Once it is evaluated, Get only needs an
+// atomic load. If f panics, the panic is propagated and f is called again by
+// the next Get. The result is only recomputed after InvalidateIf. Lazy values
+// can not fail, so errors returned as part of it are cached like any other
+// value.
+type VersionedFloat64 struct {
+	f func() float64
+	m sync.Mutex
+	n uint64
+	r atomic.Value
+}
+
+type versionedFloat64Result struct {
+	v float64
+	g uint64
+}
+
+// NewVersionedFloat64 returns a VersionedFloat64, which calls f when the
+// result is first used and again after every successful invalidation.
+func NewVersionedFloat64(f func() float64) *VersionedFloat64 {
+	return &VersionedFloat64{f: f}
+}
+
+// Get returns the value and the generation it was computed in.
+func (v *VersionedFloat64) Get() (float64, uint64) {
+	if r, _ := v.r.Load().(*versionedFloat64Result); r != nil {
+		return r.v, r.g
+	}
+
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	r, _ := v.r.Load().(*versionedFloat64Result)
+	if r == nil {
+		v.n++
+		r = &versionedFloat64Result{v.f(), v.n}
+		v.r.Store(r)
+	}
+	return r.v, r.g
+}
+
+// TryGet returns the value and the generation it was computed in and true, if
+// it is evaluated. Otherwise, it returns false, without evaluating the value
+// or waiting for an evaluation in progress.
+func (v *VersionedFloat64) TryGet() (float64, uint64, bool) {
+	if r, _ := v.r.Load().(*versionedFloat64Result); r != nil {
+		return r.v, r.g, true
+	}
+	var zero float64
+	return zero, 0, false
+}
+
+// InvalidateIf invalidates the value, if it was computed in generation gen.
+// If the value was already invalidated or re-evaluated since, it does
+// nothing. It returns whether the value was invalidated.
+func (v *VersionedFloat64) InvalidateIf(gen uint64) bool {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	r, _ := v.r.Load().(*versionedFloat64Result)
+	if r == nil || r.g != gen {
+		return false
+	}
+	v.r.Store((*versionedFloat64Result)(nil))
+	return true
+}
+
+// VersionedError provides lazy evaluation for error, that can be
+// invalidated to force re-evaluation. Every evaluation is tagged with a
+// generation, which increases with every call of f.
+//
+// It is safe for concurrent use. Once it is evaluated, Get only needs an
+// atomic load. If f panics, the panic is propagated and f is called again by
+// the next Get. The result is only recomputed after InvalidateIf. Lazy values
+// can not fail, so errors returned as part of it are cached like any other
+// value.
+type VersionedError struct {
+	f func() error
+	m sync.Mutex
+	n uint64
+	r atomic.Value
+}
+
+type versionedErrorResult struct {
+	v error
+	g uint64
+}
+
+// NewVersionedError returns a VersionedError, which calls f when the
+// result is first used and again after every successful invalidation.
+func NewVersionedError(f func() error) *VersionedError {
+	return &VersionedError{f: f}
+}
+
+// Get returns the value and the generation it was computed in.
+func (v *VersionedError) Get() (error, uint64) {
+	if r, _ := v.r.Load().(*versionedErrorResult); r != nil {
+		return r.v, r.g
+	}
+
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	r, _ := v.r.Load().(*versionedErrorResult)
+	if r == nil {
+		v.n++
+		r = &versionedErrorResult{v.f(), v.n}
+		v.r.Store(r)
+	}
+	return r.v, r.g
+}
+
+// TryGet returns the value and the generation it was computed in and true, if
+// it is evaluated. Otherwise, it returns false, without evaluating the value
+// or waiting for an evaluation in progress.
+func (v *VersionedError) TryGet() (error, uint64, bool) {
+	if r, _ := v.r.Load().(*versionedErrorResult); r != nil {
+		return r.v, r.g, true
+	}
+	var zero error
+	return zero, 0, false
+}
+
+// InvalidateIf invalidates the value, if it was computed in generation gen.
+// If the value was already invalidated or re-evaluated since, it does
+// nothing. It returns whether the value was invalidated.
+func (v *VersionedError) InvalidateIf(gen uint64) bool {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	r, _ := v.r.Load().(*versionedErrorResult)
+	if r == nil || r.g != gen {
+		return false
+	}
+	v.r.Store((*versionedErrorResult)(nil))
+	return true
+}
+
+// VersionedInt provides lazy evaluation for int, that can be
+// invalidated to force re-evaluation. Every evaluation is tagged with a
+// generation, which increases with every call of f.
+//
+// It is safe for concurrent use. Once it is evaluated, Get only needs an
+// atomic load. If f panics, the panic is propagated and f is called again by
+// the next Get. The result is only recomputed after InvalidateIf. Lazy values
+// can not fail, so errors returned as part of it are cached like any other
+// value.
+type VersionedInt struct {
+	f func() int
+	m sync.Mutex
+	n uint64
+	r atomic.Value
+}
+
+type versionedIntResult struct {
+	v int
+	g uint64
+}
+
+// NewVersionedInt returns a VersionedInt, which calls f when the
+// result is first used and again after every successful invalidation.
+func NewVersionedInt(f func() int) *VersionedInt {
+	return &VersionedInt{f: f}
+}
+
+// Get returns the value and the generation it was computed in.
+func (v *VersionedInt) Get() (int, uint64) {
+	if r, _ := v.r.Load().(*versionedIntResult); r != nil {
+		return r.v, r.g
+	}
+
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	r, _ := v.r.Load().(*versionedIntResult)
+	if r == nil {
+		v.n++
+		r = &versionedIntResult{v.f(), v.n}
+		v.r.Store(r)
+	}
+	return r.v, r.g
+}
+
+// TryGet returns the value and the generation it was computed in and true, if
+// it is evaluated. Otherwise, it returns false, without evaluating the value
+// or waiting for an evaluation in progress.
+func (v *VersionedInt) TryGet() (int, uint64, bool) {
+	if r, _ := v.r.Load().(*versionedIntResult); r != nil {
+		return r.v, r.g, true
+	}
+	var zero int
+	return zero, 0, false
+}
+
+// InvalidateIf invalidates the value, if it was computed in generation gen.
+// If the value was already invalidated or re-evaluated since, it does
+// nothing. It returns whether the value was invalidated.
+func (v *VersionedInt) InvalidateIf(gen uint64) bool {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	r, _ := v.r.Load().(*versionedIntResult)
+	if r == nil || r.g != gen {
+		return false
+	}
+	v.r.Store((*versionedIntResult)(nil))
+	return true
+}
+
+// VersionedInt8 provides lazy evaluation for int8, that can be
+// invalidated to force re-evaluation. Every evaluation is tagged with a
+// generation, which increases with every call of f.
+//
+// It is safe for concurrent use. Once it is evaluated, Get only needs an
+// atomic load. If f panics, the panic is propagated and f is called again by
+// the next Get. The result is only recomputed after InvalidateIf. Lazy values
+// can not fail, so errors returned as part of it are cached like any other
+// value.
+type VersionedInt8 struct {
+	f func() int8
+	m sync.Mutex
+	n uint64
+	r atomic.Value
+}
+
+type versionedInt8Result struct {
+	v int8
+	g uint64
+}
+
+// NewVersionedInt8 returns a VersionedInt8, which calls f when the
+// result is first used and again after every successful invalidation.
+func NewVersionedInt8(f func() int8) *VersionedInt8 {
+	return &VersionedInt8{f: f}
+}
+
+// Get returns the value and the generation it was computed in.
+func (v *VersionedInt8) Get() (int8, uint64) {
+	if r, _ := v.r.Load().(*versionedInt8Result); r != nil {
+		return r.v, r.g
+	}
+
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	r, _ := v.r.Load().(*versionedInt8Result)
+	if r == nil {
+		v.n++
+		r = &versionedInt8Result{v.f(), v.n}
+		v.r.Store(r)
+	}
+	return r.v, r.g
+}
+
+// TryGet returns the value and the generation it was computed in and true, if
+// it is evaluated. Otherwise, it returns false, without evaluating the value
+// or waiting for an evaluation in progress.
+func (v *VersionedInt8) TryGet() (int8, uint64, bool) {
+	if r, _ := v.r.Load().(*versionedInt8Result); r != nil {
+		return r.v, r.g, true
+	}
+	var zero int8
+	return zero, 0, false
+}
+
+// InvalidateIf invalidates the value, if it was computed in generation gen.
+// If the value was already invalidated or re-evaluated since, it does
+// nothing. It returns whether the value was invalidated.
+func (v *VersionedInt8) InvalidateIf(gen uint64) bool {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	r, _ := v.r.Load().(*versionedInt8Result)
+	if r == nil || r.g != gen {
+		return false
+	}
+	v.r.Store((*versionedInt8Result)(nil))
+	return true
+}
+
+// VersionedInt16 provides lazy evaluation for int16, that can be
+// invalidated to force re-evaluation. Every evaluation is tagged with a
+// generation, which increases with every call of f.
+//
+// It is safe for concurrent use. Once it is evaluated, Get only needs an
+// atomic load. If f panics, the panic is propagated and f is called again by
+// the next Get. The result is only recomputed after InvalidateIf. Lazy values
+// can not fail, so errors returned as part of it are cached like any other
+// value.
+type VersionedInt16 struct {
+	f func() int16
+	m sync.Mutex
+	n uint64
+	r atomic.Value
+}
+
+type versionedInt16Result struct {
+	v int16
+	g uint64
+}
+
+// NewVersionedInt16 returns a VersionedInt16, which calls f when the
+// result is first used and again after every successful invalidation.
+func NewVersionedInt16(f func() int16) *VersionedInt16 {
+	return &VersionedInt16{f: f}
+}
+
+// Get returns the value and the generation it was computed in.
+func (v *VersionedInt16) Get() (int16, uint64) {
+	if r, _ := v.r.Load().(*versionedInt16Result); r != nil {
+		return r.v, r.g
+	}
+
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	r, _ := v.r.Load().(*versionedInt16Result)
+	if r == nil {
+		v.n++
+		r = &versionedInt16Result{v.f(), v.n}
+		v.r.Store(r)
+	}
+	return r.v, r.g
+}
+
+// TryGet returns the value and the generation it was computed in and true, if
+// it is evaluated. Otherwise, it returns false, without evaluating the value
+// or waiting for an evaluation in progress.
+func (v *VersionedInt16) TryGet() (int16, uint64, bool) {
+	if r, _ := v.r.Load().(*versionedInt16Result); r != nil {
+		return r.v, r.g, true
+	}
+	var zero int16
+	return zero, 0, false
+}
+
+// InvalidateIf invalidates the value, if it was computed in generation gen.
+// If the value was already invalidated or re-evaluated since, it does
+// nothing. It returns whether the value was invalidated.
+func (v *VersionedInt16) InvalidateIf(gen uint64) bool {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	r, _ := v.r.Load().(*versionedInt16Result)
+	if r == nil || r.g != gen {
+		return false
+	}
+	v.r.Store((*versionedInt16Result)(nil))
+	return true
+}
+
+// VersionedInt32 provides lazy evaluation for int32, that can be
+// invalidated to force re-evaluation. Every evaluation is tagged with a
+// generation, which increases with every call of f.
+//
+// It is safe for concurrent use. Once it is evaluated, Get only needs an
+// atomic load. If f panics, the panic is propagated and f is called again by
+// the next Get. The result is only recomputed after InvalidateIf. Lazy values
+// can not fail, so errors returned as part of it are cached like any other
+// value.
+type VersionedInt32 struct {
+	f func() int32
+	m sync.Mutex
+	n uint64
+	r atomic.Value
+}
+
+type versionedInt32Result struct {
+	v int32
+	g uint64
+}
+
+// NewVersionedInt32 returns a VersionedInt32, which calls f when the
+// result is first used and again after every successful invalidation.
+func NewVersionedInt32(f func() int32) *VersionedInt32 {
+	return &VersionedInt32{f: f}
+}
+
+// Get returns the value and the generation it was computed in.
+func (v *VersionedInt32) Get() (int32, uint64) {
+	if r, _ := v.r.Load().(*versionedInt32Result); r != nil {
+		return r.v, r.g
+	}
+
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	r, _ := v.r.Load().(*versionedInt32Result)
+	if r == nil {
+		v.n++
+		r = &versionedInt32Result{v.f(), v.n}
+		v.r.Store(r)
+	}
+	return r.v, r.g
+}
+
+// TryGet returns the value and the generation it was computed in and true, if
+// it is evaluated. Otherwise, it returns false, without evaluating the value
+// or waiting for an evaluation in progress.
+func (v *VersionedInt32) TryGet() (int32, uint64, bool) {
+	if r, _ := v.r.Load().(*versionedInt32Result); r != nil {
+		return r.v, r.g, true
+	}
+	var zero int32
+	return zero, 0, false
+}
+
+// InvalidateIf invalidates the value, if it was computed in generation gen.
+// If the value was already invalidated or re-evaluated since, it does
+// nothing. It returns whether the value was invalidated.
+func (v *VersionedInt32) InvalidateIf(gen uint64) bool {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	r, _ := v.r.Load().(*versionedInt32Result)
+	if r == nil || r.g != gen {
+		return false
+	}
+	v.r.Store((*versionedInt32Result)(nil))
+	return true
+}
+
+// VersionedInt64 provides lazy evaluation for int64, that can be
+// invalidated to force re-evaluation. Every evaluation is tagged with a
+// generation, which increases with every call of f.
+//
+// It is safe for concurrent use. Once it is evaluated, Get only needs an
+// atomic load. If f panics, the panic is propagated and f is called again by
+// the next Get. The result is only recomputed after InvalidateIf. Lazy values
+// can not fail, so errors returned as part of it are cached like any other
+// value.
+type VersionedInt64 struct {
+	f func() int64
+	m sync.Mutex
+	n uint64
+	r atomic.Value
+}
+
+type versionedInt64Result struct {
+	v int64
+	g uint64
+}
+
+// NewVersionedInt64 returns a VersionedInt64, which calls f when the
+// result is first used and again after every successful invalidation.
+func NewVersionedInt64(f func() int64) *VersionedInt64 {
+	return &VersionedInt64{f: f}
+}
+
+// Get returns the value and the generation it was computed in.
+func (v *VersionedInt64) Get() (int64, uint64) {
+	if r, _ := v.r.Load().(*versionedInt64Result); r != nil {
+		return r.v, r.g
+	}
+
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	r, _ := v.r.Load().(*versionedInt64Result)
+	if r == nil {
+		v.n++
+		r = &versionedInt64Result{v.f(), v.n}
+		v.r.Store(r)
+	}
+	return r.v, r.g
+}
+
+// TryGet returns the value and the generation it was computed in and true, if
+// it is evaluated. Otherwise, it returns false, without evaluating the value
+// or waiting for an evaluation in progress.
+func (v *VersionedInt64) TryGet() (int64, uint64, bool) {
+	if r, _ := v.r.Load().(*versionedInt64Result); r != nil {
+		return r.v, r.g, true
+	}
+	var zero int64
+	return zero, 0, false
+}
+
+// InvalidateIf invalidates the value, if it was computed in generation gen.
+// If the value was already invalidated or re-evaluated since, it does
+// nothing. It returns whether the value was invalidated.
+func (v *VersionedInt64) InvalidateIf(gen uint64) bool {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	r, _ := v.r.Load().(*versionedInt64Result)
+	if r == nil || r.g != gen {
+		return false
+	}
+	v.r.Store((*versionedInt64Result)(nil))
+	return true
+}
+
+// VersionedInterface provides lazy evaluation for interface{}, that can be
+// invalidated to force re-evaluation. Every evaluation is tagged with a
+// generation, which increases with every call of f.
+//
+// It is safe for concurrent use. Once it is evaluated, Get only needs an
+// atomic load. If f panics, the panic is propagated and f is called again by
+// the next Get. The result is only recomputed after InvalidateIf. Lazy values
+// can not fail, so errors returned as part of it are cached like any other
+// value.
+type VersionedInterface struct {
+	f func() interface{}
+	m sync.Mutex
+	n uint64
+	r atomic.Value
+}
+
+type versionedInterfaceResult struct {
+	v interface{}
+	g uint64
+}
+
+// NewVersionedInterface returns a VersionedInterface, which calls f when the
+// result is first used and again after every successful invalidation.
+func NewVersionedInterface(f func() interface{}) *VersionedInterface {
+	return &VersionedInterface{f: f}
+}
+
+// Get returns the value and the generation it was computed in.
+func (v *VersionedInterface) Get() (interface{}, uint64) {
+	if r, _ := v.r.Load().(*versionedInterfaceResult); r != nil {
+		return r.v, r.g
+	}
+
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	r, _ := v.r.Load().(*versionedInterfaceResult)
+	if r == nil {
+		v.n++
+		r = &versionedInterfaceResult{v.f(), v.n}
+		v.r.Store(r)
+	}
+	return r.v, r.g
+}
+
+// TryGet returns the value and the generation it was computed in and true, if
+// it is evaluated. Otherwise, it returns false, without evaluating the value
+// or waiting for an evaluation in progress.
+func (v *VersionedInterface) TryGet() (interface{}, uint64, bool) {
+	if r, _ := v.r.Load().(*versionedInterfaceResult); r != nil {
+		return r.v, r.g, true
+	}
+	var zero interface{}
+	return zero, 0, false
+}
+
+// InvalidateIf invalidates the value, if it was computed in generation gen.
+// If the value was already invalidated or re-evaluated since, it does
+// nothing. It returns whether the value was invalidated.
+func (v *VersionedInterface) InvalidateIf(gen uint64) bool {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	r, _ := v.r.Load().(*versionedInterfaceResult)
+	if r == nil || r.g != gen {
+		return false
+	}
+	v.r.Store((*versionedInterfaceResult)(nil))
+	return true
+}
+
+// VersionedRune provides lazy evaluation for rune, that can be
+// invalidated to force re-evaluation. Every evaluation is tagged with a
+// generation, which increases with every call of f.
+//
+// It is safe for concurrent use. Once it is evaluated, Get only needs an
+// atomic load. If f panics, the panic is propagated and f is called again by
+// the next Get. The result is only recomputed after InvalidateIf. Lazy values
+// can not fail, so errors returned as part of it are cached like any other
+// value.
+type VersionedRune struct {
+	f func() rune
+	m sync.Mutex
+	n uint64
+	r atomic.Value
+}
+
+type versionedRuneResult struct {
+	v rune
+	g uint64
+}
+
+// NewVersionedRune returns a VersionedRune, which calls f when the
+// result is first used and again after every successful invalidation.
+func NewVersionedRune(f func() rune) *VersionedRune {
+	return &VersionedRune{f: f}
+}
+
+// Get returns the value and the generation it was computed in.
+func (v *VersionedRune) Get() (rune, uint64) {
+	if r, _ := v.r.Load().(*versionedRuneResult); r != nil {
+		return r.v, r.g
+	}
+
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	r, _ := v.r.Load().(*versionedRuneResult)
+	if r == nil {
+		v.n++
+		r = &versionedRuneResult{v.f(), v.n}
+		v.r.Store(r)
+	}
+	return r.v, r.g
+}
+
+// TryGet returns the value and the generation it was computed in and true, if
+// it is evaluated. Otherwise, it returns false, without evaluating the value
+// or waiting for an evaluation in progress.
+func (v *VersionedRune) TryGet() (rune, uint64, bool) {
+	if r, _ := v.r.Load().(*versionedRuneResult); r != nil {
+		return r.v, r.g, true
+	}
+	var zero rune
+	return zero, 0, false
+}
+
+// InvalidateIf invalidates the value, if it was computed in generation gen.
+// If the value was already invalidated or re-evaluated since, it does
+// nothing. It returns whether the value was invalidated.
+func (v *VersionedRune) InvalidateIf(gen uint64) bool {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	r, _ := v.r.Load().(*versionedRuneResult)
+	if r == nil || r.g != gen {
+		return false
+	}
+	v.r.Store((*versionedRuneResult)(nil))
+	return true
+}
+
+// VersionedString provides lazy evaluation for string, that can be
+// invalidated to force re-evaluation. Every evaluation is tagged with a
+// generation, which increases with every call of f.
+//
+// It is safe for concurrent use. Once it is evaluated, Get only needs an
+// atomic load. If f panics, the panic is propagated and f is called again by
+// the next Get. The result is only recomputed after InvalidateIf. Lazy values
+// can not fail, so errors returned as part of it are cached like any other
+// value.
+type VersionedString struct {
+	f func() string
+	m sync.Mutex
+	n uint64
+	r atomic.Value
+}
+
+type versionedStringResult struct {
+	v string
+	g uint64
+}
+
+// NewVersionedString returns a VersionedString, which calls f when the
+// result is first used and again after every successful invalidation.
+func NewVersionedString(f func() string) *VersionedString {
+	return &VersionedString{f: f}
+}
+
+// Get returns the value and the generation it was computed in.
+func (v *VersionedString) Get() (string, uint64) {
+	if r, _ := v.r.Load().(*versionedStringResult); r != nil {
+		return r.v, r.g
+	}
+
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	r, _ := v.r.Load().(*versionedStringResult)
+	if r == nil {
+		v.n++
+		r = &versionedStringResult{v.f(), v.n}
+		v.r.Store(r)
+	}
+	return r.v, r.g
+}
+
+// TryGet returns the value and the generation it was computed in and true, if
+// it is evaluated. Otherwise, it returns false, without evaluating the value
+// or waiting for an evaluation in progress.
+func (v *VersionedString) TryGet() (string, uint64, bool) {
+	if r, _ := v.r.Load().(*versionedStringResult); r != nil {
+		return r.v, r.g, true
+	}
+	var zero string
+	return zero, 0, false
+}
+
+// InvalidateIf invalidates the value, if it was computed in generation gen.
+// If the value was already invalidated or re-evaluated since, it does
+// nothing. It returns whether the value was invalidated.
+func (v *VersionedString) InvalidateIf(gen uint64) bool {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	r, _ := v.r.Load().(*versionedStringResult)
+	if r == nil || r.g != gen {
+		return false
+	}
+	v.r.Store((*versionedStringResult)(nil))
+	return true
+}
+
+// VersionedUint provides lazy evaluation for uint, that can be
+// invalidated to force re-evaluation. Every evaluation is tagged with a
+// generation, which increases with every call of f.
+//
+// It is safe for concurrent use. Once it is evaluated, Get only needs an
+// atomic load. If f panics, the panic is propagated and f is called again by
+// the next Get. The result is only recomputed after InvalidateIf. Lazy values
+// can not fail, so errors returned as part of it are cached like any other
+// value.
+type VersionedUint struct {
+	f func() uint
+	m sync.Mutex
+	n uint64
+	r atomic.Value
+}
+
+type versionedUintResult struct {
+	v uint
+	g uint64
+}
+
+// NewVersionedUint returns a VersionedUint, which calls f when the
+// result is first used and again after every successful invalidation.
+func NewVersionedUint(f func() uint) *VersionedUint {
+	return &VersionedUint{f: f}
+}
+
+// Get returns the value and the generation it was computed in.
+func (v *VersionedUint) Get() (uint, uint64) {
+	if r, _ := v.r.Load().(*versionedUintResult); r != nil {
+		return r.v, r.g
+	}
+
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	r, _ := v.r.Load().(*versionedUintResult)
+	if r == nil {
+		v.n++
+		r = &versionedUintResult{v.f(), v.n}
+		v.r.Store(r)
+	}
+	return r.v, r.g
+}
+
+// TryGet returns the value and the generation it was computed in and true, if
+// it is evaluated. Otherwise, it returns false, without evaluating the value
+// or waiting for an evaluation in progress.
+func (v *VersionedUint) TryGet() (uint, uint64, bool) {
+	if r, _ := v.r.Load().(*versionedUintResult); r != nil {
+		return r.v, r.g, true
+	}
+	var zero uint
+	return zero, 0, false
+}
+
+// InvalidateIf invalidates the value, if it was computed in generation gen.
+// If the value was already invalidated or re-evaluated since, it does
+// nothing. It returns whether the value was invalidated.
+func (v *VersionedUint) InvalidateIf(gen uint64) bool {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	r, _ := v.r.Load().(*versionedUintResult)
+	if r == nil || r.g != gen {
+		return false
+	}
+	v.r.Store((*versionedUintResult)(nil))
+	return true
+}
+
+// VersionedUint8 provides lazy evaluation for uint8, that can be
+// invalidated to force re-evaluation. Every evaluation is tagged with a
+// generation, which increases with every call of f.
+//
+// It is safe for concurrent use. Once it is evaluated, Get only needs an
+// atomic load. If f panics, the panic is propagated and f is called again by
+// the next Get. The result is only recomputed after InvalidateIf. Lazy values
+// can not fail, so errors returned as part of it are cached like any other
+// value.
+type VersionedUint8 struct {
+	f func() uint8
+	m sync.Mutex
+	n uint64
+	r atomic.Value
+}
+
+type versionedUint8Result struct {
+	v uint8
+	g uint64
+}
+
+// NewVersionedUint8 returns a VersionedUint8, which calls f when the
+// result is first used and again after every successful invalidation.
+func NewVersionedUint8(f func() uint8) *VersionedUint8 {
+	return &VersionedUint8{f: f}
+}
+
+// Get returns the value and the generation it was computed in.
+func (v *VersionedUint8) Get() (uint8, uint64) {
+	if r, _ := v.r.Load().(*versionedUint8Result); r != nil {
+		return r.v, r.g
+	}
+
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	r, _ := v.r.Load().(*versionedUint8Result)
+	if r == nil {
+		v.n++
+		r = &versionedUint8Result{v.f(), v.n}
+		v.r.Store(r)
+	}
+	return r.v, r.g
+}
+
+// TryGet returns the value and the generation it was computed in and true, if
+// it is evaluated. Otherwise, it returns false, without evaluating the value
+// or waiting for an evaluation in progress.
+func (v *VersionedUint8) TryGet() (uint8, uint64, bool) {
+	if r, _ := v.r.Load().(*versionedUint8Result); r != nil {
+		return r.v, r.g, true
+	}
+	var zero uint8
+	return zero, 0, false
+}
+
+// InvalidateIf invalidates the value, if it was computed in generation gen.
+// If the value was already invalidated or re-evaluated since, it does
+// nothing. It returns whether the value was invalidated.
+func (v *VersionedUint8) InvalidateIf(gen uint64) bool {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	r, _ := v.r.Load().(*versionedUint8Result)
+	if r == nil || r.g != gen {
+		return false
+	}
+	v.r.Store((*versionedUint8Result)(nil))
+	return true
+}
+
+// VersionedUint16 provides lazy evaluation for uint16, that can be
+// invalidated to force re-evaluation. Every evaluation is tagged with a
+// generation, which increases with every call of f.
+//
+// It is safe for concurrent use. Once it is evaluated, Get only needs an
+// atomic load. If f panics, the panic is propagated and f is called again by
+// the next Get. The result is only recomputed after InvalidateIf. Lazy values
+// can not fail, so errors returned as part of it are cached like any other
+// value.
+type VersionedUint16 struct {
+	f func() uint16
+	m sync.Mutex
+	n uint64
+	r atomic.Value
+}
+
+type versionedUint16Result struct {
+	v uint16
+	g uint64
+}
+
+// NewVersionedUint16 returns a VersionedUint16, which calls f when the
+// result is first used and again after every successful invalidation.
+func NewVersionedUint16(f func() uint16) *VersionedUint16 {
+	return &VersionedUint16{f: f}
+}
+
+// Get returns the value and the generation it was computed in.
+func (v *VersionedUint16) Get() (uint16, uint64) {
+	if r, _ := v.r.Load().(*versionedUint16Result); r != nil {
+		return r.v, r.g
+	}
+
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	r, _ := v.r.Load().(*versionedUint16Result)
+	if r == nil {
+		v.n++
+		r = &versionedUint16Result{v.f(), v.n}
+		v.r.Store(r)
+	}
+	return r.v, r.g
+}
+
+// TryGet returns the value and the generation it was computed in and true, if
+// it is evaluated. Otherwise, it returns false, without evaluating the value
+// or waiting for an evaluation in progress.
+func (v *VersionedUint16) TryGet() (uint16, uint64, bool) {
+	if r, _ := v.r.Load().(*versionedUint16Result); r != nil {
+		return r.v, r.g, true
+	}
+	var zero uint16
+	return zero, 0, false
+}
+
+// InvalidateIf invalidates the value, if it was computed in generation gen.
+// If the value was already invalidated or re-evaluated since, it does
+// nothing. It returns whether the value was invalidated.
+func (v *VersionedUint16) InvalidateIf(gen uint64) bool {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	r, _ := v.r.Load().(*versionedUint16Result)
+	if r == nil || r.g != gen {
+		return false
+	}
+	v.r.Store((*versionedUint16Result)(nil))
+	return true
+}
+
+// VersionedUint32 provides lazy evaluation for uint32, that can be
+// invalidated to force re-evaluation. Every evaluation is tagged with a
+// generation, which increases with every call of f.
+//
+// It is safe for concurrent use. Once it is evaluated, Get only needs an
+// atomic load. If f panics, the panic is propagated and f is called again by
+// the next Get. The result is only recomputed after InvalidateIf. Lazy values
+// can not fail, so errors returned as part of it are cached like any other
+// value.
+type VersionedUint32 struct {
+	f func() uint32
+	m sync.Mutex
+	n uint64
+	r atomic.Value
+}
+
+type versionedUint32Result struct {
+	v uint32
+	g uint64
+}
+
+// NewVersionedUint32 returns a VersionedUint32, which calls f when the
+// result is first used and again after every successful invalidation.
+func NewVersionedUint32(f func() uint32) *VersionedUint32 {
+	return &VersionedUint32{f: f}
+}
+
+// Get returns the value and the generation it was computed in.
+func (v *VersionedUint32) Get() (uint32, uint64) {
+	if r, _ := v.r.Load().(*versionedUint32Result); r != nil {
+		return r.v, r.g
+	}
+
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	r, _ := v.r.Load().(*versionedUint32Result)
+	if r == nil {
+		v.n++
+		r = &versionedUint32Result{v.f(), v.n}
+		v.r.Store(r)
+	}
+	return r.v, r.g
+}
+
+// TryGet returns the value and the generation it was computed in and true, if
+// it is evaluated. Otherwise, it returns false, without evaluating the value
+// or waiting for an evaluation in progress.
+func (v *VersionedUint32) TryGet() (uint32, uint64, bool) {
+	if r, _ := v.r.Load().(*versionedUint32Result); r != nil {
+		return r.v, r.g, true
+	}
+	var zero uint32
+	return zero, 0, false
+}
+
+// InvalidateIf invalidates the value, if it was computed in generation gen.
+// If the value was already invalidated or re-evaluated since, it does
+// nothing. It returns whether the value was invalidated.
+func (v *VersionedUint32) InvalidateIf(gen uint64) bool {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	r, _ := v.r.Load().(*versionedUint32Result)
+	if r == nil || r.g != gen {
+		return false
+	}
+	v.r.Store((*versionedUint32Result)(nil))
+	return true
+}
+
+// VersionedUint64 provides lazy evaluation for uint64, that can be
+// invalidated to force re-evaluation. Every evaluation is tagged with a
+// generation, which increases with every call of f.
+//
+// It is safe for concurrent use. Once it is evaluated, Get only needs an
+// atomic load. If f panics, the panic is propagated and f is called again by
+// the next Get. The result is only recomputed after InvalidateIf. Lazy values
+// can not fail, so errors returned as part of it are cached like any other
+// value.
+type VersionedUint64 struct {
+	f func() uint64
+	m sync.Mutex
+	n uint64
+	r atomic.Value
+}
+
+type versionedUint64Result struct {
+	v uint64
+	g uint64
+}
+
+// NewVersionedUint64 returns a VersionedUint64, which calls f when the
+// result is first used and again after every successful invalidation.
+func NewVersionedUint64(f func() uint64) *VersionedUint64 {
+	return &VersionedUint64{f: f}
+}
+
+// Get returns the value and the generation it was computed in.
+func (v *VersionedUint64) Get() (uint64, uint64) {
+	if r, _ := v.r.Load().(*versionedUint64Result); r != nil {
+		return r.v, r.g
+	}
+
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	r, _ := v.r.Load().(*versionedUint64Result)
+	if r == nil {
+		v.n++
+		r = &versionedUint64Result{v.f(), v.n}
+		v.r.Store(r)
+	}
+	return r.v, r.g
+}
+
+// TryGet returns the value and the generation it was computed in and true, if
+// it is evaluated. Otherwise, it returns false, without evaluating the value
+// or waiting for an evaluation in progress.
+func (v *VersionedUint64) TryGet() (uint64, uint64, bool) {
+	if r, _ := v.r.Load().(*versionedUint64Result); r != nil {
+		return r.v, r.g, true
+	}
+	var zero uint64
+	return zero, 0, false
+}
+
+// InvalidateIf invalidates the value, if it was computed in generation gen.
+// If the value was already invalidated or re-evaluated since, it does
+// nothing. It returns whether the value was invalidated.
+func (v *VersionedUint64) InvalidateIf(gen uint64) bool {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	r, _ := v.r.Load().(*versionedUint64Result)
+	if r == nil || r.g != gen {
+		return false
+	}
+	v.r.Store((*versionedUint64Result)(nil))
+	return true
+}
+
+// VersionedUintptr provides lazy evaluation for uintptr, that can be
+// invalidated to force re-evaluation. Every evaluation is tagged with a
+// generation, which increases with every call of f.
+//
+// It is safe for concurrent use. Once it is evaluated, Get only needs an
+// atomic load. If f panics, the panic is propagated and f is called again by
+// the next Get. The result is only recomputed after InvalidateIf. Lazy values
+// can not fail, so errors returned as part of it are cached like any other
+// value.
+type VersionedUintptr struct {
+	f func() uintptr
+	m sync.Mutex
+	n uint64
+	r atomic.Value
+}
+
+type versionedUintptrResult struct {
+	v uintptr
+	g uint64
+}
+
+// NewVersionedUintptr returns a VersionedUintptr, which calls f when the
+// result is first used and again after every successful invalidation.
+func NewVersionedUintptr(f func() uintptr) *VersionedUintptr {
+	return &VersionedUintptr{f: f}
+}
+
+// Get returns the value and the generation it was computed in.
+func (v *VersionedUintptr) Get() (uintptr, uint64) {
+	if r, _ := v.r.Load().(*versionedUintptrResult); r != nil {
+		return r.v, r.g
+	}
+
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	r, _ := v.r.Load().(*versionedUintptrResult)
+	if r == nil {
+		v.n++
+		r = &versionedUintptrResult{v.f(), v.n}
+		v.r.Store(r)
+	}
+	return r.v, r.g
+}
+
+// TryGet returns the value and the generation it was computed in and true, if
+// it is evaluated. Otherwise, it returns false, without evaluating the value
+// or waiting for an evaluation in progress.
+func (v *VersionedUintptr) TryGet() (uintptr, uint64, bool) {
+	if r, _ := v.r.Load().(*versionedUintptrResult); r != nil {
+		return r.v, r.g, true
+	}
+	var zero uintptr
+	return zero, 0, false
+}
+
+// InvalidateIf invalidates the value, if it was computed in generation gen.
+// If the value was already invalidated or re-evaluated since, it does
+// nothing. It returns whether the value was invalidated.
+func (v *VersionedUintptr) InvalidateIf(gen uint64) bool {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	r, _ := v.r.Load().(*versionedUintptrResult)
+	if r == nil || r.g != gen {
+		return false
+	}
+	v.r.Store((*versionedUintptrResult)(nil))
+	return true
+}